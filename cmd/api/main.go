@@ -3,25 +3,25 @@ package main
 import (
 	"flag"
 	"log"
-	"net/http"
 
-	"digiemu-core/internal/httpapi"
-	fsrepo "digiemu-core/internal/kernel/adapters/fs"
-	"digiemu-core/internal/kernel/usecases"
+	"digiemu-core/internal/server"
 )
 
+// The HTTP API on its own; it serves the same routes, tenants and policy as
+// `digiemu serve`.
 func main() {
 	addr := flag.String("addr", ":8080", "listen address")
 	data := flag.String("data", "./data", "data directory")
+	authFile := flag.String("auth", "", "keys file with API keys and HS256 secrets (writes need a key or a JWT)")
+	issuer := flag.String("jwt-issuer", "", "accept only JWTs with this iss")
+	audience := flag.String("jwt-audience", "", "accept only JWTs with this aud")
+	signingKey := flag.String("signing-key", "", "key file whose actor's events are signed (see digiemu key generate)")
 	flag.Parse()
 
-	repo := fsrepo.NewUnitRepo(*data)
-	createUnit := usecases.CreateUnit{Repo: repo}
-	createVersion := usecases.CreateVersion{Repo: repo}
-
-	r := httpapi.NewRouter(httpapi.API{Units: createUnit, Vers: createVersion})
-
-	srv := &http.Server{Addr: *addr, Handler: r}
+	srv, err := server.New(server.Options{Addr: *addr, Data: *data, AuthFile: *authFile, JWTIssuer: *issuer, JWTAudience: *audience, SigningKey: *signingKey})
+	if err != nil {
+		log.Fatal(err)
+	}
 	log.Printf("api listening on %s (data=%s)", *addr, *data)
 	log.Fatal(srv.ListenAndServe())
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"digiemu-core/internal/auditexport"
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// runAuditExport converts the audit log for external log systems. Without
+// --forward it writes the events (after the checkpoint, if one is named) to
+// stdout or --out and exits; with --forward it keeps shipping new events to
+// the destination until interrupted, resuming from its checkpoint.
+func runAuditExport(args []string) {
+	fs := flag.NewFlagSet("audit export", flag.ExitOnError)
+	data := dataFlag(fs)
+	format := fs.String("format", "", "output format: "+strings.Join(auditexport.Formats, " | ")+" (required)")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	forward := fs.String("forward", "", "follow the log and ship events to udp://host:port, tcp://host:port or file:///path")
+	checkpoint := fs.String("checkpoint", "", "checkpoint name under <data>/exports (default for --forward: the format)")
+	source := fs.String("source", "digiemu", "event source (CloudEvents source, OTLP service.instance.id)")
+	hostname := fs.String("hostname", "", "syslog HOSTNAME (default: this host)")
+	interval := fs.Duration("interval", ports.DefaultAuditFollowInterval, "poll interval for --forward")
+	parseFlags(fs, args)
+
+	if *format == "" {
+		fmt.Fprintln(os.Stderr, "--format is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *forward != "" && *out != "" {
+		fmt.Fprintln(os.Stderr, "--out and --forward are exclusive")
+		os.Exit(2)
+	}
+	if *hostname == "" {
+		*hostname, _ = os.Hostname()
+	}
+	enc, err := auditexport.NewEncoder(*format, auditexport.Options{Source: *source, Hostname: *hostname})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var sink auditexport.Sink = auditexport.WriterSink{W: os.Stdout}
+	switch {
+	case *forward != "":
+		if sink, err = auditexport.NewSink(*forward); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if *checkpoint == "" {
+			*checkpoint = *format
+		}
+	case *out != "":
+		if sink, err = auditexport.OpenFileSink(*out); err != nil {
+			log.Fatalf("audit export: %v", err)
+		}
+	}
+	defer sink.Close()
+
+	uc := usecases.ForwardAudit{
+		Audit:    fsrepo.NewAuditReader(*data),
+		Encoder:  enc,
+		Sink:     sink,
+		Clock:    mem.RealClock{},
+		Interval: *interval,
+	}
+	if *checkpoint != "" {
+		uc.Checkpoint = fsrepo.NewAuditExportCheckpoint(*data, *checkpoint)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	res, err := uc.ForwardAudit(ctx, ports.ForwardAuditRequest{Format: *format, Follow: *forward != ""})
+	if err != nil {
+		log.Fatalf("audit export: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d events (last=%s)\n", res.Forwarded, res.LastEventID)
+}
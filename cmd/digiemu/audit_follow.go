@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// followAudit prints events appended to the audit log of data (by this or
+// any other process) until interrupted.
+func followAudit(data string, interval time.Duration, in ports.AuditFollowRequest, emit func(ev domain.AuditEvent) error) {
+	uc := usecases.FollowAudit{Audit: fsrepo.NewAuditReader(data), Interval: interval}
+	cur, err := uc.StartFollow(in)
+	if err != nil {
+		log.Fatalf("audit tail --follow: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := uc.FollowAudit(ctx, in, cur, emit); err != nil {
+		log.Fatalf("audit tail --follow: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// openAuditMerkle returns the Merkle log of the data dir; tree heads are
+// signed when $DIGIEMU_SIGNING_KEY is a registered key of the "system" actor.
+func openAuditMerkle(data string) usecases.AuditMerkle {
+	uc := usecases.AuditMerkle{
+		Audit:  fsrepo.NewAuditReader(data),
+		Merkle: fsrepo.NewAuditMerkleStore(data),
+		Clock:  mem.RealClock{},
+	}
+	if kf := cliSigningKey(); kf != nil {
+		uc.Signer = fsrepo.KeySigner{Key: *kf, Keys: fsrepo.NewKeyRegistry(data)}
+	}
+	return uc
+}
+
+func runAuditProof(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "audit proof subcommands: inclusion | consistency | head | check")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "inclusion":
+		fs := flag.NewFlagSet("audit proof inclusion", flag.ExitOnError)
+		data := dataFlag(fs)
+		treeSize := fs.Int64("tree-size", 0, "prove against this tree size (default: latest tree head)")
+		rem := parseInterspersed(fs, args[1:])
+
+		if len(rem) != 1 {
+			fmt.Fprintln(os.Stderr, "event id is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		out, err := openAuditMerkle(*data).InclusionProof(ports.InclusionProofRequest{EventID: rem[0], TreeSize: *treeSize})
+		if err != nil {
+			log.Fatalf("audit proof inclusion: %v", err)
+		}
+		printProofJSON(out)
+
+	case "consistency":
+		fs := flag.NewFlagSet("audit proof consistency", flag.ExitOnError)
+		data := dataFlag(fs)
+		rem := parseInterspersed(fs, args[1:])
+
+		if len(rem) < 1 || len(rem) > 2 {
+			fmt.Fprintln(os.Stderr, "usage: audit proof consistency <oldSize> [<newSize>]")
+			os.Exit(2)
+		}
+		oldSize, err := strconv.ParseInt(rem[0], 10, 64)
+		if err != nil {
+			log.Fatalf("invalid old size: %v", err)
+		}
+		var newSize int64
+		if len(rem) == 2 {
+			if newSize, err = strconv.ParseInt(rem[1], 10, 64); err != nil {
+				log.Fatalf("invalid new size: %v", err)
+			}
+		}
+
+		out, err := openAuditMerkle(*data).ConsistencyProof(ports.ConsistencyProofRequest{OldSize: oldSize, NewSize: newSize})
+		if err != nil {
+			log.Fatalf("audit proof consistency: %v", err)
+		}
+		printProofJSON(out)
+
+	case "head":
+		fs := flag.NewFlagSet("audit proof head", flag.ExitOnError)
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		out, err := openAuditMerkle(*data).PublishTreeHead()
+		if err != nil {
+			log.Fatalf("audit proof head: %v", err)
+		}
+		printProofJSON(out.TreeHead)
+
+	case "check":
+		fs := flag.NewFlagSet("audit proof check", flag.ExitOnError)
+		pubKey := fs.String("public-key", "", "base64 ed25519 public key of the log (checks tree head signatures)")
+		rem := parseInterspersed(fs, args[1:])
+
+		if len(rem) != 1 {
+			fmt.Fprintln(os.Stderr, "proof file is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+		b, err := os.ReadFile(rem[0])
+		if err != nil {
+			log.Fatalf("read proof: %v", err)
+		}
+		if err := checkProof(b, *pubKey); err != nil {
+			fmt.Printf("INVALID: %v\n", err)
+			os.Exit(1)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, "audit proof subcommands: inclusion | consistency | head | check")
+		os.Exit(2)
+	}
+}
+
+// checkProof verifies a proof file offline; it needs nothing but the file
+// and (optionally) the log's public key.
+func checkProof(b []byte, pubKeyB64 string) error {
+	var probe struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return err
+	}
+
+	var heads []*domain.TreeHead
+	switch probe.Schema {
+	case domain.InclusionProofSchema:
+		var p domain.InclusionProof
+		if err := json.Unmarshal(b, &p); err != nil {
+			return err
+		}
+		if err := p.Verify(); err != nil {
+			return err
+		}
+		heads = append(heads, &p.TreeHead)
+		fmt.Printf("OK: event %s is leaf %d of tree size=%d root=%s\n", p.EventID, p.LeafIndex, p.TreeHead.TreeSize, p.TreeHead.RootHash)
+
+	case domain.ConsistencyProofSchema:
+		var p domain.ConsistencyProof
+		if err := json.Unmarshal(b, &p); err != nil {
+			return err
+		}
+		if err := p.Verify(); err != nil {
+			return err
+		}
+		heads = append(heads, p.OldTreeHead, p.NewTreeHead)
+		fmt.Printf("OK: tree size=%d root=%s is a prefix of size=%d root=%s\n", p.OldSize, p.OldRoot, p.NewSize, p.NewRoot)
+
+	default:
+		return fmt.Errorf("unknown proof schema %q", probe.Schema)
+	}
+
+	for _, th := range heads {
+		if th == nil {
+			continue
+		}
+		switch {
+		case th.Sig == "":
+			fmt.Printf("WARN: tree head size=%d is unsigned\n", th.TreeSize)
+		case pubKeyB64 == "":
+			fmt.Printf("WARN: tree head size=%d signed by %s, not checked (no --public-key)\n", th.TreeSize, th.KeyID)
+		default:
+			pub, err := domain.DecodePublicKey(pubKeyB64)
+			if err != nil {
+				return err
+			}
+			if err := domain.VerifyTreeHeadSignature(*th, pub); err != nil {
+				return fmt.Errorf("tree head size=%d: %w", th.TreeSize, err)
+			}
+			fmt.Printf("OK: tree head size=%d signature valid (key %s)\n", th.TreeSize, th.KeyID)
+		}
+	}
+	return nil
+}
+
+func printProofJSON(v any) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("proof marshal: %v", err)
+	}
+	fmt.Println(string(b))
+}
+
+// parseInterspersed parses flags that may follow positional arguments
+// (e.g. "inclusion <eventId> --data ./data") and returns the positionals.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var rem []string
+	for {
+		parseFlags(fs, args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return rem
+		}
+		rem = append(rem, args[0])
+		args = args[1:]
+	}
+}
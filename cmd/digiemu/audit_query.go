@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func runAuditQuery(args []string) {
+	fs := flag.NewFlagSet("audit query", flag.ExitOnError)
+	data := dataFlag(fs)
+	from := fs.String("from", "", "only events at or after this time (unix seconds or RFC 3339)")
+	to := fs.String("to", "", "only events at or before this time (unix seconds or RFC 3339)")
+	actors := fs.String("actor", "", "filter by actor ids (comma separated)")
+	types := fs.String("type", "", "filter by event types (comma separated)")
+	unitIDs := fs.String("unit-id", "", "filter by unit ids (comma separated)")
+	unitPrefix := fs.String("unit-prefix", "", "filter by unit key prefix")
+	versionID := fs.String("version-id", "", "filter by version id")
+	limit := fs.Int("limit", ports.DefaultAuditQueryLimit, "events per page")
+	cursor := fs.String("cursor", "", "continue with the next cursor of a previous page")
+	all := fs.Bool("all", false, "fetch all pages")
+	format := fs.String("format", "text", "output format: text|json")
+	parseFlags(fs, args)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "invalid --format %q (text|json)\n", *format)
+		os.Exit(2)
+	}
+	in := ports.AuditQueryRequest{
+		AuditQueryFilter: ports.AuditQueryFilter{
+			ActorIDs:  splitList(*actors),
+			Types:     splitList(*types),
+			UnitIDs:   splitList(*unitIDs),
+			VersionID: *versionID,
+		},
+		UnitKeyPrefix: *unitPrefix,
+		Limit:         *limit,
+		Cursor:        *cursor,
+	}
+	for name, v := range map[string]string{"from": *from, "to": *to} {
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeFlag(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --%s %q (unix seconds or RFC 3339)\n", name, v)
+			os.Exit(2)
+		}
+		if name == "from" {
+			in.FromUnix = t
+		} else {
+			in.ToUnix = t
+		}
+	}
+
+	uc := usecases.QueryAudit{Audit: fsrepo.NewAuditReader(*data), Repo: fsrepo.NewUnitRepo(*data)}
+	for {
+		out, err := uc.QueryAudit(in)
+		if errors.Is(err, domain.ErrInvalidAuditQuery) || errors.Is(err, domain.ErrInvalidAuditCursor) {
+			fmt.Fprintf(os.Stderr, "audit query: %v\n", err)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalf("audit query: %v", err)
+		}
+
+		if *format == "json" {
+			b, err := json.Marshal(out)
+			if err != nil {
+				log.Fatalf("audit query json: %v", err)
+			}
+			fmt.Println(string(b))
+		} else {
+			for _, ev := range out.Events {
+				fmt.Printf("%s at=%d actor=%s unit=%s ver=%s id=%s\n", ev.Type, ev.AtUnix, ev.ActorID, ev.UnitID, ev.VersionID, ev.ID)
+			}
+			if out.NextCursor != "" && !*all {
+				fmt.Printf("next cursor: %s\n", out.NextCursor)
+			}
+		}
+
+		if !*all || out.NextCursor == "" {
+			return
+		}
+		in.Cursor = out.NextCursor
+	}
+}
+
+// splitList splits a comma separated flag value.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseTimeFlag accepts unix seconds or an RFC 3339 timestamp.
+func parseTimeFlag(v string) (int64, error) {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+)
+
+// runAuditRotate shows or changes the audit segment rotation policy and
+// seals the active segment on request (--now). The policy is stored in the
+// data dir, so every later writer (CLI or serve) applies it.
+func runAuditRotate(args []string) {
+	fs := flag.NewFlagSet("audit rotate", flag.ExitOnError)
+	data := dataFlag(fs)
+	maxBytes := fs.Int64("max-bytes", 0, "seal the active segment once it holds this many bytes (0 = no size limit)")
+	maxAge := fs.Duration("max-age", 0, "seal the active segment once its first event is this old (0 = no age limit)")
+	now := fs.Bool("now", false, "seal the active segment now")
+	parseFlags(fs, args)
+
+	rot, _, err := fsrepo.ReadAuditSegments(*data)
+	if err != nil {
+		log.Fatalf("audit rotate: %v", err)
+	}
+	changed := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-bytes":
+			rot.MaxBytes, changed = *maxBytes, true
+		case "max-age":
+			rot.MaxAgeSeconds, changed = int64(maxAge.Seconds()), true
+		}
+	})
+	if changed {
+		if err := fsrepo.SetAuditRotation(*data, rot); err != nil {
+			log.Fatalf("audit rotate: %v", err)
+		}
+	}
+
+	if *now {
+		seg, ok, err := fsrepo.NewAuditLog(*data).Rotate()
+		if err != nil {
+			log.Fatalf("audit rotate: %v", err)
+		}
+		if ok {
+			fmt.Printf("OK: sealed %s (events=%d seq=%d..%d)\n", seg.File, seg.Events, seg.FirstSeq, seg.LastSeq)
+		} else {
+			fmt.Println("OK: active segment is empty, nothing sealed")
+		}
+	}
+
+	rot, segs, err := fsrepo.ReadAuditSegments(*data)
+	if err != nil {
+		log.Fatalf("audit rotate: %v", err)
+	}
+	fmt.Printf("rotation: max-bytes=%d max-age=%s\n", rot.MaxBytes, time.Duration(rot.MaxAgeSeconds)*time.Second)
+	for _, s := range segs {
+		fmt.Printf("%s events=%d seq=%d..%d size=%d sha256=%s\n", s.File, s.Events, s.FirstSeq, s.LastSeq, s.Size, s.SHA256)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"digiemu-core/internal/httpapi"
+)
+
+func runAuth(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "auth subcommands: apikey")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "apikey":
+		// generates an API key for actor of the selected tenant; the keys file
+		// (digiemu serve --auth) keeps only its hash, so the key is shown once
+		fs := flag.NewFlagSet("auth apikey", flag.ExitOnError)
+		actor := fs.String("actor", "", "actor id the key authenticates as (required)")
+		file := fs.String("file", "", "keys file (required, created if missing)")
+		parseFlags(fs, args[1:])
+
+		if *actor == "" || *file == "" {
+			fmt.Fprintln(os.Stderr, "--actor and --file are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		f, err := httpapi.LoadAuthFile(*file)
+		if err != nil {
+			log.Fatalf("auth apikey: %v", err)
+		}
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatalf("auth apikey: %v", err)
+		}
+		key := "dk_" + base64.RawURLEncoding.EncodeToString(b)
+		f.APIKeys = append(f.APIKeys, httpapi.APIKey{ActorID: *actor, KeySHA256: httpapi.APIKeyHash(key), CreatedAtUnix: time.Now().Unix(), TenantID: cliTenant})
+		if err := httpapi.SaveAuthFile(*file, f); err != nil {
+			log.Fatalf("auth apikey: %v", err)
+		}
+		fmt.Printf("OK: api key for %s added to %s (send as X-API-Key or Bearer)\n", *actor, *file)
+		fmt.Println(key)
+
+	default:
+		fmt.Fprintln(os.Stderr, "auth subcommands: apikey")
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func runBranch(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "branch subcommands: create | list | delete | merge")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("branch create", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		name := fs.String("name", "", "branch name (required)")
+		from := fs.String("from", "", "branch to start from (default: main)")
+		version := fs.String("version", "", "version to start from (default: the head of --from)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" || *name == "" {
+			fmt.Fprintln(os.Stderr, "--unit and --name are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		repo := fsrepo.NewUnitRepo(*data)
+		audit := openAuditLog(*data)
+
+		g := policyGuard(*data, repo, audit)
+		g.Branches = usecases.CreateBranch{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: openJournal(*data, repo, audit)}
+
+		out, err := g.CreateBranch(ports.CreateBranchRequest{UnitKey: *unit, Name: *name, FromBranch: *from, FromVersionID: *version, ActorID: cliActor()})
+		if err != nil {
+			log.Fatalf("create branch: %v", err)
+		}
+		fmt.Printf("OK: branch created name=%s unit=%s base=%s\n", out.Branch.Name, out.UnitID, out.Branch.BaseVersionID)
+
+	case "list":
+		fs := flag.NewFlagSet("branch list", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" {
+			fmt.Fprintln(os.Stderr, "--unit is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		uc := usecases.ListBranches{Repo: fsrepo.NewUnitRepo(*data)}
+		out, err := uc.ListBranches(ports.ListBranchesRequest{UnitKey: *unit})
+		if errors.Is(err, domain.ErrUnitNotFound) {
+			fmt.Fprintf(os.Stderr, "branch list: %v\n", err)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalf("branch list: %v", err)
+		}
+		for _, b := range out.Branches {
+			if b.BaseVersionID == "" {
+				fmt.Printf("%s head=%s\n", b.Name, b.HeadVersionID)
+				continue
+			}
+			fmt.Printf("%s head=%s base=%s actor=%s\n", b.Name, b.HeadVersionID, b.BaseVersionID, b.ActorID)
+		}
+
+	case "delete":
+		fs := flag.NewFlagSet("branch delete", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		name := fs.String("name", "", "branch name (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" || *name == "" {
+			fmt.Fprintln(os.Stderr, "--unit and --name are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		repo := fsrepo.NewUnitRepo(*data)
+		audit := openAuditLog(*data)
+
+		g := policyGuard(*data, repo, audit)
+		g.DelBranch = usecases.DeleteBranch{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: openJournal(*data, repo, audit)}
+
+		out, err := g.DeleteBranch(ports.DeleteBranchRequest{UnitKey: *unit, Name: *name, ActorID: cliActor()})
+		if err != nil {
+			log.Fatalf("delete branch: %v", err)
+		}
+		fmt.Printf("OK: branch deleted name=%s unit=%s head=%s\n", out.Branch.Name, out.UnitID, out.Branch.HeadVersionID)
+
+	case "merge":
+		fs := flag.NewFlagSet("branch merge", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		source := fs.String("source", "", "branch to merge (required)")
+		target := fs.String("into", "", "branch to merge into (default: main)")
+		label := fs.String("label", "", "label of the merge version (default: merge <source>)")
+		contentFile := fs.String("content-file", "", "merged content resolving the conflicts of an earlier attempt")
+		base := fs.String("base", "", "expected head version id of the target (optimistic locking)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" || *source == "" {
+			fmt.Fprintln(os.Stderr, "--unit and --source are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+		var content string
+		if *contentFile != "" {
+			b, err := os.ReadFile(*contentFile)
+			if err != nil {
+				log.Fatalf("read content file: %v", err)
+			}
+			content = string(b)
+		}
+
+		repo := fsrepo.NewUnitRepo(*data)
+		audit := openAuditLog(*data)
+
+		g := policyGuard(*data, repo, audit)
+		g.Merge = usecases.MergeBranch{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: openJournal(*data, repo, audit)}
+
+		in := ports.MergeBranchRequest{UnitKey: *unit, Source: *source, Target: *target, Label: *label, Content: content, BaseVersionID: *base, ActorID: cliActor()}
+		out, err := g.MergeBranch(in)
+		var conflict *domain.MergeConflictError
+		if errors.As(err, &conflict) {
+			// the marked-up text can be edited and passed back as --content-file
+			fmt.Println(conflict.Text)
+			fmt.Fprintf(os.Stderr, "merge branch: %v\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatalf("merge branch: %v", err)
+		}
+		fmt.Printf("OK: branch merged id=%s unit=%s source=%s into=%s base=%s\n", out.VersionID, out.UnitID, out.SourceBranch, out.Branch, out.MergeBaseVersionID)
+
+	default:
+		fmt.Fprintln(os.Stderr, "branch subcommands: create | list | delete | merge")
+		os.Exit(2)
+	}
+}
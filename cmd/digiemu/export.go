@@ -22,10 +22,12 @@ func runExport(args []string) {
 	case "unit":
 		fs := flag.NewFlagSet("export unit", flag.ExitOnError)
 		unitKey := fs.String("unit", "", "unit key (required)")
-		data := fs.String("data", "./data", "data directory")
+		data := dataFlag(fs)
 		withAudit := fs.Bool("audit", false, "include audit events for this unit")
 		pretty := fs.Bool("pretty", false, "pretty-print JSON")
-		fs.Parse(args[1:])
+		version := fs.String("version", "", "export the unit as of this version id or tag (default: head)")
+		revision := fs.Int("revision", 0, "take title and description from this metadata revision (default: as of --version)")
+		parseFlags(fs, args[1:])
 
 		if *unitKey == "" {
 			fmt.Fprintln(os.Stderr, "--unit is required")
@@ -45,6 +47,8 @@ func runExport(args []string) {
 		out, err := uc.ExportUnitSnapshot(ports.ExportUnitSnapshotRequest{
 			UnitKey:      *unitKey,
 			IncludeAudit: *withAudit,
+			VersionID:    *version,
+			Revision:     *revision,
 		})
 		if err != nil {
 			log.Fatalf("export unit: %v", err)
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// runFsck checks the data dir and prints a JSON report; it exits 1 if
+// findings remain. The check runs before any adapter is opened, because
+// opening a data dir cleans up after crashes. --repair opens the dir
+// normally (with that cleanup), rebuilds indexes with findings and audits
+// each rebuild.
+func runFsck(args []string) {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	data := dataFlag(fs)
+	repair := fs.Bool("repair", false, "rebuild derived indexes (primary history is never modified)")
+	actor := fs.String("actor", "system", "actor id of the fsck.repaired audit events")
+	parseFlags(fs, args)
+
+	uc := usecases.Fsck{Storage: fsrepo.NewDataDirChecker(*data)}
+	if *repair {
+		uc.Audit = openAuditLog(*data)
+		uc.Clock = mem.RealClock{}
+	}
+	out, err := uc.Fsck(ports.FsckRequest{Repair: *repair, ActorID: *actor})
+	if err != nil {
+		log.Fatalf("fsck: %v", err)
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		log.Fatalf("fsck marshal: %v", err)
+	}
+	fmt.Println(string(b))
+	if !out.Ok {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log"
+
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/server"
+)
+
+// openJournal returns the intent journal of the data dir after recovering
+// operations a previous run left unfinished. Recovery actions are audited
+// and reported on stderr.
+func openJournal(data string, repo ports.UnitRepository, audit ports.AuditLog) ports.IntentJournal {
+	journal, err := server.RecoverJournal(data, repo, audit)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return journal
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+	"digiemu-core/internal/server"
+)
+
+// openAuditLog returns the data dir audit log; events of the actor of
+// $DIGIEMU_SIGNING_KEY are signed with that key while it is registered.
+func openAuditLog(data string) ports.AuditLog {
+	return server.OpenAuditLog(data, cliSigningKey())
+}
+
+// cliSigningKey loads the key file of $DIGIEMU_SIGNING_KEY (nil when unset).
+// The file is the acting user's own (see key generate --out); the data dir
+// only holds public keys.
+func cliSigningKey() *fsrepo.KeyFile {
+	p := os.Getenv("DIGIEMU_SIGNING_KEY")
+	if p == "" {
+		return nil
+	}
+	kf, err := fsrepo.ReadKeyFile(p)
+	if err != nil {
+		log.Fatalf("DIGIEMU_SIGNING_KEY: %v", err)
+	}
+	return &kf
+}
+
+// insideDir reports whether p is dir or lies below it.
+func insideDir(dir, p string) bool {
+	d, err1 := filepath.Abs(dir)
+	q, err2 := filepath.Abs(p)
+	if err1 != nil || err2 != nil {
+		return true // cannot tell; refuse
+	}
+	rel, err := filepath.Rel(d, q)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func runKey(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "key subcommands: generate | register | revoke | list")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "generate":
+		fs := flag.NewFlagSet("key generate", flag.ExitOnError)
+		actor := fs.String("actor", "", "actor id the key signs for (required)")
+		out := fs.String("out", "", "file to write the private key to, outside the data dir (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *actor == "" || *out == "" {
+			fmt.Fprintln(os.Stderr, "--actor and --out are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+		// anyone who can read the data dir must not be able to sign
+		if insideDir(fs.Lookup("data").Value.String(), *out) {
+			fmt.Fprintln(os.Stderr, "--out must be outside the data dir")
+			os.Exit(2)
+		}
+
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			log.Fatalf("generate key: %v", err)
+		}
+
+		// write the private key first so the actor signs its own
+		// registration; an unregistered key file is never used for signing
+		kf, err := fsrepo.WriteKeyFile(*out, *actor, priv)
+		if err != nil {
+			log.Fatalf("write private key: %v", err)
+		}
+		uc := usecases.RegisterKey{Keys: fsrepo.NewKeyRegistry(*data), Audit: server.OpenAuditLog(*data, &kf), Clock: mem.RealClock{}}
+		reg, err := uc.RegisterKey(ports.RegisterKeyRequest{
+			ActorID:   *actor,
+			PublicKey: base64.StdEncoding.EncodeToString(pub),
+			ByActorID: *actor,
+		})
+		if err != nil {
+			log.Fatalf("register key: %v", err)
+		}
+		fmt.Printf("OK: key generated key_id=%s actor=%s public_key=%s private_key_file=%s\n", reg.KeyID, reg.ActorID, base64.StdEncoding.EncodeToString(pub), *out)
+
+	case "register":
+		fs := flag.NewFlagSet("key register", flag.ExitOnError)
+		actor := fs.String("actor", "", "actor id the key signs for (required)")
+		pub := fs.String("public-key", "", "base64 ed25519 public key (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *actor == "" || *pub == "" {
+			fmt.Fprintln(os.Stderr, "--actor and --public-key are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		uc := usecases.RegisterKey{Keys: fsrepo.NewKeyRegistry(*data), Audit: openAuditLog(*data), Clock: mem.RealClock{}}
+		out, err := uc.RegisterKey(ports.RegisterKeyRequest{ActorID: *actor, PublicKey: *pub, ByActorID: "cli"})
+		if err != nil {
+			log.Fatalf("register key: %v", err)
+		}
+		fmt.Printf("OK: key registered key_id=%s actor=%s\n", out.KeyID, out.ActorID)
+
+	case "revoke":
+		fs := flag.NewFlagSet("key revoke", flag.ExitOnError)
+		keyID := fs.String("key-id", "", "key id to revoke (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *keyID == "" {
+			fmt.Fprintln(os.Stderr, "--key-id is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		uc := usecases.RevokeKey{Keys: fsrepo.NewKeyRegistry(*data), Audit: openAuditLog(*data), Clock: mem.RealClock{}}
+		out, err := uc.RevokeKey(ports.RevokeKeyRequest{KeyID: *keyID, ByActorID: "cli"})
+		if err != nil {
+			log.Fatalf("revoke key: %v", err)
+		}
+		fmt.Printf("OK: key revoked key_id=%s at=%d\n", out.KeyID, out.RevokedAtUnix)
+
+	case "list":
+		fs := flag.NewFlagSet("key list", flag.ExitOnError)
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		keys, err := fsrepo.NewKeyRegistry(*data).ListKeys()
+		if err != nil {
+			log.Fatalf("list keys: %v", err)
+		}
+		for _, k := range keys {
+			status := "active"
+			if k.RevokedAtUnix != 0 {
+				status = fmt.Sprintf("revoked@%d", k.RevokedAtUnix)
+			}
+			fmt.Printf("%s actor=%s created=%d %s\n", k.KeyID, k.ActorID, k.CreatedAtUnix, status)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, "key subcommands: generate | register | revoke | list")
+		os.Exit(2)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -12,41 +13,58 @@ import (
 	"strings"
 	"time"
 
-	"digiemu-core/internal/httpapi"
 	fsrepo "digiemu-core/internal/kernel/adapters/fs"
 	mem "digiemu-core/internal/kernel/adapters/memory"
 	"digiemu-core/internal/kernel/domain"
 	"digiemu-core/internal/kernel/ports"
 	usecases "digiemu-core/internal/kernel/usecases"
+	"digiemu-core/internal/server"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	args := takeTenantFlag(os.Args[1:])
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(2)
 	}
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "uncertainty":
-		runUncertainty(os.Args[2:])
+		runUncertainty(args[1:])
 	case "meaning":
-		runMeaning(os.Args[2:])
+		runMeaning(args[1:])
 	case "claim":
-		runClaim(os.Args[2:])
+		runClaim(args[1:])
 	case "unit":
-		runUnit(os.Args[2:])
+		runUnit(args[1:])
 	case "version":
-		runVersion(os.Args[2:])
+		runVersion(args[1:])
+	case "branch":
+		runBranch(args[1:])
+	case "tag":
+		runTag(args[1:])
 	case "audit":
-		runAudit(os.Args[2:])
+		runAudit(args[1:])
 	case "export":
-		runExport(os.Args[2:])
+		runExport(args[1:])
+	case "key":
+		runKey(args[1:])
+	case "auth":
+		runAuth(args[1:])
+	case "policy":
+		runPolicy(args[1:])
+	case "tenant":
+		runTenant(args[1:])
 	case "serve":
-		runServe(os.Args[2:])
+		runServe(args[1:])
+	case "migrate":
+		runMigrate(args[1:])
+	case "fsck":
+		runFsck(args[1:])
 	case "--help", "-h", "help":
 		printUsage()
 	default:
-		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", args[0])
 		printUsage()
 		os.Exit(2)
 	}
@@ -55,24 +73,56 @@ func main() {
 func printUsage() {
 	fmt.Println("digiemu - minimal CLI for digiemu-core")
 	fmt.Println()
-	fmt.Println("Usage:")
+	fmt.Println("Usage: digiemu [--tenant TENANT_ID] <command> ...")
 	fmt.Println("  digiemu unit create [--key KEY] --title TITLE [--desc DESC|--description DESC] [--data ./data]")
-	fmt.Println("  digiemu version create --unit UNIT_KEY --content CONTENT [--data ./data]")
-	fmt.Println("  digiemu audit verify [--data ./data] [--strict-hash] [--unit UNIT_KEY]")
-	fmt.Println("  digiemu audit tail [--data ./data] [--n 50] [--type EVENT_TYPE] [--unit-id UNIT_ID] [--version-id VERSION_ID] [--json]")
-	fmt.Println("  digiemu export unit --unit UNIT_KEY [--data ./data] [--audit] [--pretty]")
-	fmt.Println("  digiemu serve [--addr :8080] [--data ./data]")
+	fmt.Println("  digiemu unit update --unit UNIT_KEY [--title TITLE] [--desc DESC] [--revision N] [--data ./data]")
+	fmt.Println("  digiemu unit history --unit UNIT_KEY [--data ./data]")
+	fmt.Println("  digiemu version create --unit UNIT_KEY --content CONTENT [--branch BRANCH] [--data ./data]")
+	fmt.Println("  digiemu version diff --unit UNIT_KEY [--from VERSION_ID] [--to VERSION_ID] [--context 3] [--format text|json] [--data ./data]")
+	fmt.Println("  digiemu version revert --unit UNIT_KEY --version VERSION_ID [--label LABEL] [--sidecars] [--branch BRANCH] [--base HEAD_VERSION_ID] [--data ./data]")
+	fmt.Println("  digiemu branch create --unit UNIT_KEY --name BRANCH [--from BRANCH | --version VERSION_ID] [--data ./data]")
+	fmt.Println("  digiemu branch list --unit UNIT_KEY [--data ./data]")
+	fmt.Println("  digiemu branch delete --unit UNIT_KEY --name BRANCH [--data ./data]")
+	fmt.Println("  digiemu branch merge --unit UNIT_KEY --source BRANCH [--into BRANCH] [--label LABEL] [--content-file FILE] [--base HEAD_VERSION_ID] [--data ./data]")
+	fmt.Println("  digiemu tag create --unit UNIT_KEY --name TAG [--version VERSION_ID | --branch BRANCH] [--data ./data]")
+	fmt.Println("  digiemu tag list --unit UNIT_KEY [--data ./data]")
+	fmt.Println("  digiemu audit verify [--data ./data] [--strict-hash] [--chain] [--signatures] [--payloads] [--unit UNIT_KEY] [--format text|json]")
+	fmt.Println("  digiemu audit tail [--data ./data] [--n 50] [--type EVENT_TYPE] [--unit-id UNIT_ID] [--version-id VERSION_ID] [--json] [--follow [--interval 250ms]]")
+	fmt.Println("  digiemu audit query [--data ./data] [--from T] [--to T] [--actor A,B] [--type T1,T2] [--unit-id ID,..] [--unit-prefix KEY_PREFIX] [--version-id VERSION_ID] [--limit 100] [--cursor C | --all] [--format text|json]")
+	fmt.Println("  digiemu audit proof inclusion <eventId> [--tree-size N] [--data ./data]")
+	fmt.Println("  digiemu audit proof consistency <oldSize> [<newSize>] [--data ./data]")
+	fmt.Println("  digiemu audit proof head [--data ./data]")
+	fmt.Println("  digiemu audit proof check <proof.json> [--public-key BASE64]")
+	fmt.Println("  digiemu audit rotate [--data ./data] [--max-bytes N] [--max-age 24h] [--now]")
+	fmt.Println("  digiemu audit export --format cloudevents|syslog|otlp-json [--data ./data] [--out FILE | --forward udp://HOST:PORT|tcp://HOST:PORT|file:///PATH] [--checkpoint NAME] [--source SRC] [--hostname HOST]")
+	fmt.Println("  digiemu audit anchor [verify] [--data ./data] [--file PATH] [--tsa URL [--tsa-receipts PATH] [--tsa-cert PEM]]")
+	fmt.Println("  digiemu export unit --unit UNIT_KEY [--version VERSION_ID|TAG] [--revision N] [--data ./data] [--audit] [--pretty]")
+	fmt.Println("  digiemu serve [--addr :8080] [--data ./data] [--auth FILE] [--jwt-issuer ISS] [--jwt-audience AUD] [--signing-key FILE]")
+	fmt.Println("  digiemu auth apikey --actor ACTOR_ID --file FILE")
+	fmt.Println("  digiemu tenant create --id TENANT_ID [--data ./data]")
+	fmt.Println("  digiemu tenant list [--data ./data]")
+	fmt.Println("  digiemu policy check --unit UNIT_KEY [--actor ACTOR_ID] [--data ./data]")
+	fmt.Println("  digiemu migrate [--data ./data]")
+	fmt.Println("  digiemu fsck [--data ./data] [--repair] [--actor ACTOR_ID]")
 	fmt.Println("  digiemu meaning set <unitKeyOrId> [--version <versionId>] --file <meaning.json> [--data ./data]")
-	fmt.Println("  digiemu meaning show <unitKeyOrId> [--version <versionId>] [--data ./data]")
+	fmt.Println("  digiemu meaning show <unitKeyOrId> [--version <versionId>|<tag>] [--data ./data]")
 	fmt.Println("  digiemu claim set <unitKeyOrId> [--version <versionId>] --file <claimset.json> [--data ./data]")
-	fmt.Println("  digiemu claim show <unitKeyOrId> [--version <versionId>] [--data ./data]")
+	fmt.Println("  digiemu claim show <unitKeyOrId> [--version <versionId>|<tag>] [--data ./data]")
 	fmt.Println("  digiemu uncertainty set <unitKeyOrId> [--version <versionId>] --file <uncertainty.json> [--data ./data]")
-	fmt.Println("  digiemu uncertainty show <unitKeyOrId> [--version <versionId>] [--data ./data]")
+	fmt.Println("  digiemu uncertainty show <unitKeyOrId> [--version <versionId>|<tag>] [--data ./data]")
+	fmt.Println("  digiemu key generate --actor ACTOR_ID --out FILE [--data ./data]")
+	fmt.Println("  digiemu key register --actor ACTOR_ID --public-key BASE64 [--data ./data]")
+	fmt.Println("  digiemu key revoke --key-id KEY_ID [--data ./data]")
+	fmt.Println("  digiemu key list [--data ./data]")
+	fmt.Println()
+	fmt.Println("Writes are made as $DIGIEMU_ACTOR (default cli) and checked against <data>/policy.json if it exists.")
+	fmt.Println("Events of the actor of $DIGIEMU_SIGNING_KEY (a key file of key generate) are signed with it.")
+	fmt.Println("Commands work on the tenant of --tenant or $DIGIEMU_TENANT (default: the data dir itself, <data>/tenants/<id> otherwise).")
 }
 
 func runUnit(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "unit subcommands: create")
+		fmt.Fprintln(os.Stderr, "unit subcommands: create | update | history")
 		os.Exit(2)
 	}
 
@@ -83,8 +133,8 @@ func runUnit(args []string) {
 		title := fs.String("title", "", "unit title (required)")
 		desc := fs.String("desc", "", "unit description (optional)")
 		description := fs.String("description", "", "unit description (optional, alias for --desc)")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		if *title == "" {
 			fmt.Fprintln(os.Stderr, "--title is required")
@@ -103,27 +153,89 @@ func runUnit(args []string) {
 		}
 
 		repo := fsrepo.NewUnitRepo(*data)
-		audit := fsrepo.NewAuditLog(*data)
+		audit := openAuditLog(*data)
 		clock := mem.RealClock{}
 
-		uc := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}
+		uc := policyGuard(*data, repo, audit)
+		uc.Units = usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock, Journal: openJournal(*data, repo, audit)}
 
-		in := ports.CreateUnitRequest{Key: k, Title: *title, Description: d, ActorID: "cli"}
+		in := ports.CreateUnitRequest{Key: k, Title: *title, Description: d, ActorID: cliActor(), TenantID: cliTenant}
 		out, err := uc.CreateUnit(in)
 		if err != nil {
 			log.Fatalf("create unit: %v", err)
 		}
 		fmt.Printf("OK: unit created id=%s key=%s\n", out.UnitID, out.Key)
 
+	case "update":
+		fs := flag.NewFlagSet("unit update", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		title := fs.String("title", "", "new unit title")
+		desc := fs.String("desc", "", "new unit description (\"\" clears it)")
+		base := fs.Int("revision", 0, "expected current revision (optimistic lock)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		in := ports.UpdateUnitRequest{UnitKey: *unit, BaseRevision: *base, ActorID: cliActor()}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "title":
+				in.Title = title
+			case "desc":
+				in.Description = desc
+			}
+		})
+		if *unit == "" || (in.Title == nil && in.Description == nil) {
+			fmt.Fprintln(os.Stderr, "--unit and --title or --desc are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		repo := fsrepo.NewUnitRepo(*data)
+		audit := openAuditLog(*data)
+
+		g := policyGuard(*data, repo, audit)
+		g.UnitUpdate = usecases.UpdateUnit{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: openJournal(*data, repo, audit)}
+
+		out, err := g.UpdateUnit(in)
+		if err != nil {
+			log.Fatalf("update unit: %v", err)
+		}
+		fmt.Printf("OK: unit updated id=%s key=%s revision=%d\n", out.UnitID, out.UnitKey, out.Revision.Revision)
+
+	case "history":
+		fs := flag.NewFlagSet("unit history", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" {
+			fmt.Fprintln(os.Stderr, "--unit is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		uc := usecases.ListUnitRevisions{Repo: fsrepo.NewUnitRepo(*data), Audit: fsrepo.NewAuditByUnitReader(*data)}
+		out, err := uc.ListUnitRevisions(ports.ListUnitRevisionsRequest{UnitKey: *unit})
+		if errors.Is(err, domain.ErrUnitNotFound) {
+			fmt.Fprintf(os.Stderr, "unit history: %v\n", err)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalf("unit history: %v", err)
+		}
+		for _, r := range out.Revisions {
+			fmt.Printf("%d title=%q desc=%q actor=%s at=%d\n", r.Revision, r.Title, r.Description, r.ActorID, r.AtUnix)
+		}
+
 	default:
-		fmt.Fprintln(os.Stderr, "unit subcommands: create")
+		fmt.Fprintln(os.Stderr, "unit subcommands: create | update | history")
 		os.Exit(2)
 	}
 }
 
 func runVersion(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "version subcommands: create")
+		fmt.Fprintln(os.Stderr, "version subcommands: create | diff | revert")
 		os.Exit(2)
 	}
 
@@ -132,8 +244,9 @@ func runVersion(args []string) {
 		fs := flag.NewFlagSet("version create", flag.ExitOnError)
 		unit := fs.String("unit", "", "unit key (required)")
 		content := fs.String("content", "", "version content (required)")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		branch := fs.String("branch", "", "branch to add the version to (default: main)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		if *unit == "" || *content == "" {
 			fmt.Fprintln(os.Stderr, "--unit and --content are required")
@@ -142,23 +255,57 @@ func runVersion(args []string) {
 		}
 
 		repo := fsrepo.NewUnitRepo(*data)
-		audit := fsrepo.NewAuditLog(*data)
+		audit := openAuditLog(*data)
 		clock := mem.RealClock{}
 
-		vc := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+		vc := policyGuard(*data, repo, audit)
+		vc.Vers = usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock, Journal: openJournal(*data, repo, audit)}
 
 		// v0.2.3+: milliseconds to reduce collisions
 		label := time.Now().UTC().Format("20060102T150405.000Z")
 
-		in := ports.CreateVersionRequest{UnitKey: *unit, Label: label, Content: *content, ActorID: "cli"}
+		in := ports.CreateVersionRequest{UnitKey: *unit, Label: label, Content: *content, Branch: *branch, ActorID: cliActor()}
 		out, err := vc.CreateVersion(in)
 		if err != nil {
 			log.Fatalf("create version: %v", err)
 		}
 		fmt.Printf("OK: version created id=%s unit=%s\n", out.VersionID, out.UnitID)
 
+	case "diff":
+		runVersionDiff(args[1:])
+
+	case "revert":
+		fs := flag.NewFlagSet("version revert", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		version := fs.String("version", "", "version id to revert to (required)")
+		label := fs.String("label", "", "label of the new version (default: the reverted version's)")
+		sidecars := fs.Bool("sidecars", false, "carry over meaning, claims and uncertainty")
+		branch := fs.String("branch", "", "branch to add the version to (default: main)")
+		base := fs.String("base", "", "expected head version id (optimistic locking)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" || *version == "" {
+			fmt.Fprintln(os.Stderr, "--unit and --version are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		repo := fsrepo.NewUnitRepo(*data)
+		audit := openAuditLog(*data)
+
+		vc := policyGuard(*data, repo, audit)
+		vc.Revert = usecases.RevertVersion{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: openJournal(*data, repo, audit)}
+
+		in := ports.RevertVersionRequest{UnitKey: *unit, SourceVersionID: *version, Label: *label, CarrySidecars: *sidecars, Branch: *branch, BaseVersionID: *base, ActorID: cliActor()}
+		out, err := vc.RevertVersion(in)
+		if err != nil {
+			log.Fatalf("revert version: %v", err)
+		}
+		fmt.Printf("OK: version reverted id=%s unit=%s source=%s prev=%s\n", out.VersionID, out.UnitID, out.SourceVersionID, out.PrevVersionID)
+
 	default:
-		fmt.Fprintln(os.Stderr, "version subcommands: create")
+		fmt.Fprintln(os.Stderr, "version subcommands: create | diff | revert")
 		os.Exit(2)
 	}
 }
@@ -174,8 +321,8 @@ func runMeaning(args []string) {
 		fs := flag.NewFlagSet("meaning set", flag.ExitOnError)
 		version := fs.String("version", "", "version id (optional, defaults to head)")
 		file := fs.String("file", "", "path to meaning.json")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		if *file == "" {
 			fmt.Fprintln(os.Stderr, "--file is required")
@@ -197,11 +344,12 @@ func runMeaning(args []string) {
 		}
 
 		repo := fsrepo.NewUnitRepo(*data)
-		audit := fsrepo.NewAuditLog(*data)
+		audit := openAuditLog(*data)
 		clock := mem.RealClock{}
 
-		uc := usecases.SetMeaning{Repo: repo, Audit: audit, Clock: clock}
-		out, err := uc.SetMeaning(ports.SetMeaningRequest{UnitKey: unitKeyOrID, VersionID: *version, MeaningJSON: b, ActorID: "cli"})
+		uc := policyGuard(*data, repo, audit)
+		uc.Meaning = usecases.SetMeaning{Repo: repo, Audit: audit, Clock: clock, Journal: openJournal(*data, repo, audit)}
+		out, err := uc.SetMeaning(ports.SetMeaningRequest{UnitKey: unitKeyOrID, VersionID: *version, MeaningJSON: b, ActorID: cliActor()})
 		if err != nil {
 			log.Fatalf("set meaning: %v", err)
 		}
@@ -209,9 +357,9 @@ func runMeaning(args []string) {
 
 	case "show":
 		fs := flag.NewFlagSet("meaning show", flag.ExitOnError)
-		version := fs.String("version", "", "version id (optional, defaults to head)")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		version := fs.String("version", "", "version id or tag (optional, defaults to head)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		repo := fsrepo.NewUnitRepo(*data)
 
@@ -240,7 +388,7 @@ func runMeaning(args []string) {
 			}
 		}
 
-		verID := *version
+		verID := unit.ResolveVersionRef(*version)
 		if verID == "" {
 			verID = unit.HeadVersionID
 		}
@@ -286,8 +434,8 @@ func runClaim(args []string) {
 		fs := flag.NewFlagSet("claim set", flag.ExitOnError)
 		version := fs.String("version", "", "version id (optional, defaults to head)")
 		file := fs.String("file", "", "path to claimset.json")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		if *file == "" {
 			fmt.Fprintln(os.Stderr, "--file is required")
@@ -308,11 +456,12 @@ func runClaim(args []string) {
 		}
 
 		repo := fsrepo.NewUnitRepo(*data)
-		audit := fsrepo.NewAuditLog(*data)
+		audit := openAuditLog(*data)
 		clock := mem.RealClock{}
 
-		uc := usecases.SetClaims{Repo: repo, Audit: audit, Clock: clock}
-		out, err := uc.SetClaims(ports.SetClaimsRequest{UnitKey: unitKeyOrID, VersionID: *version, BodyBytes: b, ActorID: "cli"})
+		uc := policyGuard(*data, repo, audit)
+		uc.Claims = usecases.SetClaims{Repo: repo, Audit: audit, Clock: clock, Journal: openJournal(*data, repo, audit)}
+		out, err := uc.SetClaims(ports.SetClaimsRequest{UnitKey: unitKeyOrID, VersionID: *version, BodyBytes: b, ActorID: cliActor()})
 		if err != nil {
 			log.Fatalf("set claims: %v", err)
 		}
@@ -320,9 +469,9 @@ func runClaim(args []string) {
 
 	case "show":
 		fs := flag.NewFlagSet("claim show", flag.ExitOnError)
-		version := fs.String("version", "", "version id (optional, defaults to head)")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		version := fs.String("version", "", "version id or tag (optional, defaults to head)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		repo := fsrepo.NewUnitRepo(*data)
 
@@ -350,7 +499,7 @@ func runClaim(args []string) {
 			}
 		}
 
-		verID := *version
+		verID := unit.ResolveVersionRef(*version)
 		if verID == "" {
 			verID = unit.HeadVersionID
 		}
@@ -395,8 +544,8 @@ func runUncertainty(args []string) {
 		fs := flag.NewFlagSet("uncertainty set", flag.ExitOnError)
 		version := fs.String("version", "", "version id (optional, defaults to head)")
 		file := fs.String("file", "", "path to uncertainty.json")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		if *file == "" {
 			fmt.Fprintln(os.Stderr, "--file is required")
@@ -417,11 +566,12 @@ func runUncertainty(args []string) {
 		}
 
 		repo := fsrepo.NewUnitRepo(*data)
-		audit := fsrepo.NewAuditLog(*data)
+		audit := openAuditLog(*data)
 		clock := mem.RealClock{}
 
-		uc := usecases.SetUncertainty{Repo: repo, Audit: audit, Clock: clock}
-		out, err := uc.SetUncertainty(ports.SetUncertaintyRequest{UnitKey: unitKeyOrID, VersionID: *version, BodyBytes: b, ActorID: "cli"})
+		uc := policyGuard(*data, repo, audit)
+		uc.Uncertainty = usecases.SetUncertainty{Repo: repo, Audit: audit, Clock: clock, Journal: openJournal(*data, repo, audit)}
+		out, err := uc.SetUncertainty(ports.SetUncertaintyRequest{UnitKey: unitKeyOrID, VersionID: *version, BodyBytes: b, ActorID: cliActor()})
 		if err != nil {
 			log.Fatalf("set uncertainty: %v", err)
 		}
@@ -429,9 +579,9 @@ func runUncertainty(args []string) {
 
 	case "show":
 		fs := flag.NewFlagSet("uncertainty show", flag.ExitOnError)
-		version := fs.String("version", "", "version id (optional, defaults to head)")
-		data := fs.String("data", "./data", "data directory")
-		fs.Parse(args[1:])
+		version := fs.String("version", "", "version id or tag (optional, defaults to head)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
 
 		repo := fsrepo.NewUnitRepo(*data)
 
@@ -459,7 +609,7 @@ func runUncertainty(args []string) {
 			}
 		}
 
-		verID := *version
+		verID := unit.ResolveVersionRef(*version)
 		if verID == "" {
 			verID = unit.HeadVersionID
 		}
@@ -496,35 +646,82 @@ func runUncertainty(args []string) {
 
 func runAudit(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "audit subcommands: verify | tail")
+		fmt.Fprintln(os.Stderr, "audit subcommands: verify | tail | query | proof | rotate | export | anchor")
 		os.Exit(2)
 	}
 
 	switch args[0] {
 	case "verify":
 		fs := flag.NewFlagSet("audit verify", flag.ExitOnError)
-		data := fs.String("data", "./data", "data directory")
+		data := dataFlag(fs)
 		strictHash := fs.Bool("strict-hash", false, "verify contentHash matches audit events")
 		unitKey := fs.String("unit", "", "verify only this unit key")
-		fs.Parse(args[1:])
+		chain := fs.Bool("chain", false, "verify the audit hash chain (gaps, reordering, truncation, edits)")
+		signatures := fs.Bool("signatures", false, "verify event signatures against the key registry")
+		payloads := fs.Bool("payloads", false, "report unknown event types and malformed payloads")
+		format := fs.String("format", "text", "output format: text | json")
+		parseFlags(fs, args[1:])
+		if *format != "text" && *format != "json" {
+			fmt.Fprintln(os.Stderr, "--format must be text or json")
+			os.Exit(2)
+		}
 
+		// exit codes: 0 verified, 1 findings, 3 verification could not run
 		repo := fsrepo.NewUnitRepo(*data)
 		reader := fsrepo.NewAuditReader(*data)
 
-		uc := usecases.VerifyAudit{Repo: repo, Audit: reader}
-		out, err := uc.VerifyAudit(ports.VerifyAuditRequest{UnitKey: *unitKey, StrictHash: *strictHash})
+		uc := usecases.VerifyAudit{
+			Repo:      repo,
+			Audit:     reader,
+			ChainHead: fsrepo.NewAuditChainHead(*data),
+			Keys:      fsrepo.NewKeyRegistry(*data),
+			Segments:  fsrepo.NewAuditSegmentChecker(*data),
+		}
+		req := ports.VerifyAuditRequest{UnitKey: *unitKey, StrictHash: *strictHash, Chain: *chain, Signatures: *signatures, Payloads: *payloads}
+
+		if *format == "json" {
+			rc := usecases.VerifyAuditReport{Verify: uc, Fingerprint: fsrepo.NewDataDirFingerprint(*data), Clock: mem.RealClock{}, TenantID: cliTenant}
+			report, err := rc.VerifyAuditReport(req)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+				os.Exit(3)
+			}
+			b, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+				os.Exit(3)
+			}
+			fmt.Println(string(b))
+			if !report.Ok {
+				os.Exit(1)
+			}
+			return
+		}
+
+		out, err := uc.VerifyAudit(req)
 		if err != nil {
-			log.Fatalf("audit verify: %v", err)
+			fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+			os.Exit(3)
 		}
 
 		if out.Ok {
+			if out.ChainChecked {
+				fmt.Printf("OK: audit verified (units=%d versions=%d chain=%d legacy=%d)\n", out.TotalUnits, out.TotalVersions, out.ChainLength, out.LegacyEvents)
+				return
+			}
 			fmt.Printf("OK: audit verified (units=%d versions=%d)\n", out.TotalUnits, out.TotalVersions)
 			return
 		}
 
 		fmt.Printf("AUDIT FINDINGS: units=%d versions=%d\n", out.TotalUnits, out.TotalVersions)
 		for _, m := range out.Missing {
-			if m.EventType == "unit.created" {
+			if m.Branch != "" {
+				fmt.Printf("MISSING: %s unitId=%s branch=%s\n", m.EventType, m.UnitID, m.Branch)
+			} else if m.Tag != "" {
+				fmt.Printf("MISSING: %s unitId=%s tag=%s versionId=%s\n", m.EventType, m.UnitID, m.Tag, m.VersionID)
+			} else if m.Revision != 0 {
+				fmt.Printf("MISSING: %s unitId=%s revision=%d\n", m.EventType, m.UnitID, m.Revision)
+			} else if m.EventType == domain.AuditUnitCreated {
 				fmt.Printf("MISSING: %s unitId=%s\n", m.EventType, m.UnitID)
 			} else {
 				fmt.Printf("MISSING: %s unitId=%s versionId=%s\n", m.EventType, m.UnitID, m.VersionID)
@@ -536,17 +733,57 @@ func runAudit(args []string) {
 		for _, hm := range out.HashMismatches {
 			fmt.Printf("HASH MISMATCH: unitId=%s versionId=%s expected=%s event=%s\n", hm.UnitID, hm.VersionID, hm.ExpectedHash, hm.EventHash)
 		}
+		for _, o := range out.OrphanEvents {
+			fmt.Printf("ORPHAN EVENT: %s pos=%d eventId=%s unitId=%s versionId=%s %s\n", o.EventType, o.Position, o.EventID, o.UnitID, o.VersionID, o.Detail)
+		}
+		for _, lm := range out.LineageMismatches {
+			if lm.RepoMergeParentID != lm.EventMergeParentID {
+				fmt.Printf("LINEAGE MISMATCH: unitId=%s versionId=%s repoPrev=%s eventPrev=%s repoMergeParent=%s eventMergeParent=%s\n", lm.UnitID, lm.VersionID, lm.RepoPrevVersionID, lm.EventPrevVersionID, lm.RepoMergeParentID, lm.EventMergeParentID)
+				continue
+			}
+			fmt.Printf("LINEAGE MISMATCH: unitId=%s versionId=%s repoPrev=%s eventPrev=%s\n", lm.UnitID, lm.VersionID, lm.RepoPrevVersionID, lm.EventPrevVersionID)
+		}
+		for _, hm := range out.HeadMismatches {
+			if hm.Branch != "" {
+				fmt.Printf("HEAD MISMATCH: unitId=%s branch=%s head=%s successor=%s\n", hm.UnitID, hm.Branch, hm.HeadVersionID, hm.LastVersionID)
+				continue
+			}
+			fmt.Printf("HEAD MISMATCH: unitId=%s head=%s lastVersion=%s\n", hm.UnitID, hm.HeadVersionID, hm.LastVersionID)
+		}
+		for _, tm := range out.TagMismatches {
+			fmt.Printf("TAG MISMATCH: unitId=%s tag=%s repoVersion=%s eventVersion=%s eventId=%s\n", tm.UnitID, tm.Tag, tm.RepoVersionID, tm.EventVersionID, tm.EventID)
+		}
+		for _, rm := range out.RevisionMismatches {
+			fmt.Printf("REVISION MISMATCH: unitId=%s revision=%d repoTitle=%q eventTitle=%q repoDesc=%q eventDesc=%q eventId=%s\n", rm.UnitID, rm.Revision, rm.RepoTitle, rm.EventTitle, rm.RepoDescription, rm.EventDescription, rm.EventID)
+		}
+		for _, tv := range out.TimeOrderViolations {
+			fmt.Printf("TIME ORDER: %s pos=%d eventId=%s unitId=%s at=%d before prevEventId=%s at=%d\n", tv.EventType, tv.Position, tv.EventID, tv.UnitID, tv.AtUnix, tv.PrevEventID, tv.PrevAtUnix)
+		}
+		for _, cb := range out.ChainBreaks {
+			fmt.Printf("CHAIN BREAK: %s pos=%d seq=%d eventId=%s %s\n", cb.Kind, cb.Position, cb.Seq, cb.EventID, cb.Detail)
+		}
+		for _, sf := range out.SignatureFindings {
+			fmt.Printf("SIGNATURE: %s pos=%d eventId=%s type=%s actor=%s keyId=%s\n", sf.Kind, sf.Position, sf.EventID, sf.EventType, sf.ActorID, sf.KeyID)
+		}
+		for _, sg := range out.SegmentFindings {
+			fmt.Printf("SEGMENT: %s segment=%d file=%s %s\n", sg.Kind, sg.Segment, sg.File, sg.Detail)
+		}
+		for _, pi := range out.PayloadIssues {
+			fmt.Printf("PAYLOAD: %s pos=%d eventId=%s type=%s %s\n", pi.Kind, pi.Position, pi.EventID, pi.EventType, pi.Detail)
+		}
 		os.Exit(1)
 
 	case "tail":
 		fs := flag.NewFlagSet("audit tail", flag.ExitOnError)
-		data := fs.String("data", "./data", "data directory")
+		data := dataFlag(fs)
 		n := fs.Int("n", 50, "last N matching events")
 		typ := fs.String("type", "", "filter by event type (e.g. version.created)")
 		unitID := fs.String("unit-id", "", "filter by unit id")
 		versionID := fs.String("version-id", "", "filter by version id")
 		asJSON := fs.Bool("json", false, "output events as JSON (one per line)")
-		fs.Parse(args[1:])
+		follow := fs.Bool("follow", false, "keep running and print events as they are appended")
+		interval := fs.Duration("interval", ports.DefaultAuditFollowInterval, "poll interval for --follow")
+		parseFlags(fs, args[1:])
 
 		tail := fsrepo.NewAuditTail(*data)
 		evs, err := tail.Tail(ports.AuditTailRequest{N: *n, Type: *typ, UnitID: *unitID, VersionID: *versionID})
@@ -554,20 +791,49 @@ func runAudit(args []string) {
 			log.Fatalf("audit tail: %v", err)
 		}
 
-		for _, ev := range evs {
+		printEvent := func(ev domain.AuditEvent) error {
 			if *asJSON {
 				b, err := json.Marshal(ev)
 				if err != nil {
-					log.Fatalf("audit tail json: %v", err)
+					return err
 				}
 				fmt.Println(string(b))
-				continue
+				return nil
 			}
 			fmt.Printf("%s at=%d actor=%s unit=%s ver=%s id=%s\n", ev.Type, ev.AtUnix, ev.ActorID, ev.UnitID, ev.VersionID, ev.ID)
+			return nil
+		}
+		for _, ev := range evs {
+			if err := printEvent(ev); err != nil {
+				log.Fatalf("audit tail json: %v", err)
+			}
 		}
 
+		if *follow {
+			in := ports.AuditFollowRequest{Type: *typ, UnitID: *unitID, VersionID: *versionID}
+			if len(evs) > 0 {
+				in.AfterEventID = evs[len(evs)-1].ID // continue exactly after the tail
+			}
+			followAudit(*data, *interval, in, printEvent)
+		}
+
+	case "query":
+		runAuditQuery(args[1:])
+
+	case "proof":
+		runAuditProof(args[1:])
+
+	case "rotate":
+		runAuditRotate(args[1:])
+
+	case "export":
+		runAuditExport(args[1:])
+
+	case "anchor":
+		runAuditAnchor(args[1:])
+
 	default:
-		fmt.Fprintln(os.Stderr, "audit subcommands: verify | tail")
+		fmt.Fprintln(os.Stderr, "audit subcommands: verify | tail | query | proof | rotate | export | anchor")
 		os.Exit(2)
 	}
 }
@@ -576,27 +842,17 @@ func runServe(args []string) {
 	fs := flag.NewFlagSet("serve", flag.ExitOnError)
 	addr := fs.String("addr", ":8080", "address to bind")
 	data := fs.String("data", "./data", "data directory")
-	fs.Parse(args)
-
-	repo := fsrepo.NewUnitRepo(*data)
-
-	// Minimal HTTP wiring (no audit in HTTP routes here unless your httpapi already injects it)
-	api := httpapi.API{
-		Units:       usecases.CreateUnit{Repo: repo, Audit: fsrepo.NewAuditLog(*data), Clock: mem.RealClock{}},
-		Vers:        usecases.CreateVersion{Repo: repo, Audit: fsrepo.NewAuditLog(*data), Clock: mem.RealClock{}},
-		Meaning:     usecases.SetMeaning{Repo: repo, Audit: fsrepo.NewAuditLog(*data), Clock: mem.RealClock{}},
-		Claims:      usecases.SetClaims{Repo: repo, Audit: fsrepo.NewAuditLog(*data), Clock: mem.RealClock{}},
-		Uncertainty: usecases.SetUncertainty{Repo: repo, Audit: fsrepo.NewAuditLog(*data), Clock: mem.RealClock{}},
-		Repo:        repo,
-	}
-	handler := httpapi.NewRouter(api)
-
-	srv := &http.Server{
-		Addr:         *addr,
-		Handler:      handler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	authFile := fs.String("auth", "", "keys file with API keys and HS256 secrets (see digiemu auth apikey)")
+	issuer := fs.String("jwt-issuer", "", "accept only JWTs with this iss")
+	audience := fs.String("jwt-audience", "", "accept only JWTs with this aud")
+	signingKey := fs.String("signing-key", "", "key file whose actor's events are signed (see digiemu key generate)")
+	parseFlags(fs, args)
+
+	// writes need an API key or a JWT (HS256 with a secret of --auth, or
+	// EdDSA with a key of the actor key registry); reads stay open
+	srv, err := server.New(server.Options{Addr: *addr, Data: *data, AuthFile: *authFile, JWTIssuer: *issuer, JWTAudience: *audience, SigningKey: *signingKey})
+	if err != nil {
+		log.Fatalf("serve: %v", err)
 	}
 
 	fmt.Printf("starting server on %s (data=%s)\n", *addr, *data)
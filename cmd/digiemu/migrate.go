@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+)
+
+// runMigrate upgrades the data dir layout. Opening a data dir migrates it
+// as well; this command makes the step explicit and reports what was moved.
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	data := dataFlag(fs)
+	parseFlags(fs, args)
+
+	out, err := fsrepo.MigrateLayout(*data)
+	if err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+	if out.FromVersion == out.ToVersion {
+		fmt.Printf("OK: layout v%d is up to date\n", out.ToVersion)
+		return
+	}
+	fmt.Printf("OK: migrated layout v%d -> v%d (units=%d versions=%d sidecars=%d)\n",
+		out.FromVersion, out.ToVersion, out.Units, out.Versions, out.Sidecars)
+}
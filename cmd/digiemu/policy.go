@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+	"digiemu-core/internal/server"
+)
+
+// cliActor is the actor CLI writes are made (and checked) as: $DIGIEMU_ACTOR,
+// or "cli" when unset.
+func cliActor() string {
+	if a := os.Getenv("DIGIEMU_ACTOR"); a != "" {
+		return a
+	}
+	return "cli"
+}
+
+// policyGuard checks writes against <data>/policy.json; without the file
+// every write is allowed. The caller fills in the usecases it guards.
+func policyGuard(data string, repo ports.UnitRepository, audit ports.AuditLog) usecases.PolicyGuard {
+	return server.PolicyGuard(data, repo, audit)
+}
+
+func runPolicy(args []string) {
+	if len(args) < 1 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "policy subcommands: check")
+		os.Exit(2)
+	}
+
+	// shows the role an actor holds on a unit key and the writes it allows
+	fs := flag.NewFlagSet("policy check", flag.ExitOnError)
+	data := dataFlag(fs)
+	actor := fs.String("actor", "", "actor id (default $DIGIEMU_ACTOR or cli)")
+	unit := fs.String("unit", "", "unit key (required)")
+	parseFlags(fs, args[1:])
+
+	if *unit == "" {
+		fmt.Fprintln(os.Stderr, "--unit is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *actor == "" {
+		*actor = cliActor()
+	}
+
+	p, ok, err := fsrepo.NewPolicyStore(*data).LoadPolicy()
+	if err != nil {
+		log.Fatalf("policy check: %v", err)
+	}
+	if !ok {
+		fmt.Printf("no policy (%s/policy.json): %s may write %s\n", *data, *actor, *unit)
+		return
+	}
+	role, _ := p.RoleOf(*actor, *unit)
+	if role == "" {
+		role = "none"
+	}
+	fmt.Printf("actor=%s unit=%s role=%s\n", *actor, *unit, role)
+	for _, action := range []string{
+		domain.ActionCreateUnit, domain.ActionUpdateUnit, domain.ActionCreateVersion,
+		domain.ActionSetMeaning, domain.ActionSetClaims, domain.ActionSetUncertainty,
+		domain.ActionCreateBranch, domain.ActionDeleteBranch, domain.ActionMergeBranch, domain.ActionCreateTag,
+	} {
+		verdict := "deny"
+		if domain.RoleIncludes(role, domain.ActionRole(action)) {
+			verdict = "allow"
+		}
+		fmt.Printf("  %-16s %-5s (needs %s)\n", action, verdict, domain.ActionRole(action))
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func runTag(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "tag subcommands: create | list")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("tag create", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		name := fs.String("name", "", "tag name (required)")
+		version := fs.String("version", "", "version to tag (default: the head of --branch)")
+		branch := fs.String("branch", "", "branch whose head to tag (default: main)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" || *name == "" {
+			fmt.Fprintln(os.Stderr, "--unit and --name are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		repo := fsrepo.NewUnitRepo(*data)
+		audit := openAuditLog(*data)
+
+		g := policyGuard(*data, repo, audit)
+		g.Tags = usecases.CreateTag{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: openJournal(*data, repo, audit)}
+
+		out, err := g.CreateTag(ports.CreateTagRequest{UnitKey: *unit, Name: *name, VersionID: *version, Branch: *branch, ActorID: cliActor()})
+		if err != nil {
+			log.Fatalf("create tag: %v", err)
+		}
+		fmt.Printf("OK: tag created name=%s unit=%s version=%s\n", out.Tag.Name, out.UnitID, out.Tag.VersionID)
+
+	case "list":
+		fs := flag.NewFlagSet("tag list", flag.ExitOnError)
+		unit := fs.String("unit", "", "unit key or id (required)")
+		data := dataFlag(fs)
+		parseFlags(fs, args[1:])
+
+		if *unit == "" {
+			fmt.Fprintln(os.Stderr, "--unit is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		uc := usecases.ListTags{Repo: fsrepo.NewUnitRepo(*data)}
+		out, err := uc.ListTags(ports.ListTagsRequest{UnitKey: *unit})
+		if errors.Is(err, domain.ErrUnitNotFound) {
+			fmt.Fprintf(os.Stderr, "tag list: %v\n", err)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalf("tag list: %v", err)
+		}
+		for _, t := range out.Tags {
+			fmt.Printf("%s version=%s actor=%s\n", t.Name, t.VersionID, t.ActorID)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, "tag subcommands: create | list")
+		os.Exit(2)
+	}
+}
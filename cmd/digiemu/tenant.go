@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+)
+
+// cliTenant is the tenant CLI commands work on, chosen by a leading
+// `--tenant ID` or $DIGIEMU_TENANT (the default tenant if neither is set).
+var cliTenant = os.Getenv("DIGIEMU_TENANT")
+
+// takeTenantFlag consumes a leading --tenant option of args.
+func takeTenantFlag(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	switch a := args[0]; {
+	case strings.HasPrefix(a, "--tenant="):
+		cliTenant = strings.TrimPrefix(a, "--tenant=")
+		return args[1:]
+	case a == "--tenant" && len(args) > 1:
+		cliTenant = args[1]
+		return args[2:]
+	}
+	return args
+}
+
+// tenantDataDir is the --data flag of commands that work on one tenant;
+// parseFlags resolves it to the data dir of cliTenant.
+type tenantDataDir struct {
+	base string
+	path string
+}
+
+func (d *tenantDataDir) String() string { return d.base }
+
+func (d *tenantDataDir) Set(base string) error {
+	d.base = base
+	return nil
+}
+
+// dataFlag registers --data on fs; after parseFlags the returned path is the
+// selected tenant's data dir.
+func dataFlag(fs *flag.FlagSet) *string {
+	d := &tenantDataDir{base: "./data"}
+	fs.Var(d, "data", "data directory")
+	return &d.path
+}
+
+// parseFlags parses args into fs and resolves its --data flag, if any.
+func parseFlags(fs *flag.FlagSet, args []string) {
+	fs.Parse(args)
+	if f := fs.Lookup("data"); f != nil {
+		if d, ok := f.Value.(*tenantDataDir); ok {
+			p, err := fsrepo.OpenTenant(d.base, cliTenant)
+			if err != nil {
+				log.Fatalf("tenant %q: %v", cliTenant, err)
+			}
+			d.path = p
+		}
+	}
+}
+
+func runTenant(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "tenant subcommands: create | list")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("tenant create", flag.ExitOnError)
+		id := fs.String("id", "", "tenant id: 3-63 of a-z, 0-9 and - (required)")
+		data := fs.String("data", "./data", "data directory")
+		parseFlags(fs, args[1:])
+
+		if *id == "" {
+			fmt.Fprintln(os.Stderr, "--id is required")
+			fs.Usage()
+			os.Exit(2)
+		}
+		p, err := fsrepo.CreateTenant(*data, *id)
+		if err != nil {
+			log.Fatalf("tenant create: %v", err)
+		}
+		fmt.Printf("OK: tenant %s created at %s\n", *id, p)
+
+	case "list":
+		fs := flag.NewFlagSet("tenant list", flag.ExitOnError)
+		data := fs.String("data", "./data", "data directory")
+		parseFlags(fs, args[1:])
+
+		ids, err := fsrepo.ListTenants(*data)
+		if err != nil {
+			log.Fatalf("tenant list: %v", err)
+		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, "tenant subcommands: create | list")
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func runVersionDiff(args []string) {
+	fs := flag.NewFlagSet("version diff", flag.ExitOnError)
+	unit := fs.String("unit", "", "unit key or id (required)")
+	from := fs.String("from", "", "version id or tag (default: the predecessor of --to)")
+	to := fs.String("to", "", "version id or tag (default: the head)")
+	context := fs.Int("context", 3, "unchanged lines around each change")
+	format := fs.String("format", "text", "output format: text | json")
+	data := dataFlag(fs)
+	parseFlags(fs, args)
+
+	if *unit == "" {
+		fmt.Fprintln(os.Stderr, "--unit is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "--format must be text or json")
+		os.Exit(2)
+	}
+
+	uc := usecases.DiffVersions{Repo: fsrepo.NewUnitRepo(*data)}
+	out, err := uc.DiffVersions(ports.DiffVersionsRequest{UnitKey: *unit, FromVersionID: *from, ToVersionID: *to, Context: *context})
+	if errors.Is(err, domain.ErrUnitNotFound) || errors.Is(err, domain.ErrVersionNotFound) {
+		fmt.Fprintf(os.Stderr, "version diff: %v\n", err)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("version diff: %v", err)
+	}
+
+	if *format == "json" {
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			log.Fatalf("version diff json: %v", err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+
+	fmt.Printf("unit %s (%s)\n", out.UnitKey, out.UnitID)
+	fmt.Printf("from %s\nto   %s\n", sideName(out.From), sideName(out.To))
+	for _, c := range out.Metadata {
+		fmt.Printf("%s: %q -> %q\n", c.Field, c.From, c.To)
+	}
+	for _, s := range []struct {
+		name    string
+		changed bool
+	}{{"meaning", out.MeaningChanged}, {"claimset", out.ClaimSetChanged}, {"uncertainty", out.UncertaintyChanged}} {
+		if s.changed {
+			fmt.Printf("%s changed\n", s.name)
+		}
+	}
+	if !out.ContentChanged {
+		fmt.Println("content unchanged")
+		return
+	}
+	fmt.Printf("content: +%d -%d\n", out.Added, out.Removed)
+	fmt.Print(out.Unified())
+}
+
+func sideName(s ports.DiffVersionSide) string {
+	if s.VersionID == "" {
+		return "(none)"
+	}
+	return fmt.Sprintf("%s %s by %s", s.VersionID, s.Label, s.ActorID)
+}
@@ -0,0 +1,96 @@
+// Package anchor stores digests of the audit log outside the data dir
+// (ports.Anchor): in an append-only file on separate storage, or as RFC 3161
+// timestamp tokens from a timestamping authority.
+package anchor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// Sink names.
+const (
+	SinkFile    = "file"
+	SinkRFC3161 = "rfc3161"
+)
+
+// FileAnchor appends anchors to a local NDJSON file. Put it on storage the
+// data dir's owners cannot rewrite: another disk, an append-only (chattr +a)
+// file, a WORM or write-only network mount. The file is its own receipt.
+type FileAnchor struct {
+	Path string
+}
+
+func (a FileAnchor) Name() string { return SinkFile }
+
+func (a FileAnchor) Anchor(an domain.AuditAnchor) (domain.AuditAnchor, error) {
+	an.Sink = SinkFile
+	if err := appendAnchor(a.Path, an); err != nil {
+		return domain.AuditAnchor{}, err
+	}
+	return an, nil
+}
+
+func (a FileAnchor) ListAnchors() ([]domain.AuditAnchor, error) {
+	return readAnchors(a.Path)
+}
+
+func (a FileAnchor) CheckAnchor(domain.AuditAnchor) error { return nil }
+
+// appendAnchor appends an as one line and syncs the file. O_APPEND keeps
+// lines of concurrent writers intact.
+func appendAnchor(path string, an domain.AuditAnchor) error {
+	b, err := json.Marshal(an)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readAnchors reads an anchor file; a missing file holds no anchors.
+func readAnchors(path string) ([]domain.AuditAnchor, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []domain.AuditAnchor
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var an domain.AuditAnchor
+		if err := json.Unmarshal(sc.Bytes(), &an); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		out = append(out, an)
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,235 @@
+package anchor
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+var stubGenTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// stubTSA answers RFC 3161 requests with tokens signed by a self-signed
+// Ed25519 timestamping certificate, returned as the only root.
+func stubTSA(t *testing.T) (*httptest.Server, *x509.CertPool) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "stub tsa"},
+		NotBefore:    stubGenTime.Add(-time.Hour),
+		NotAfter:     stubGenTime.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	marshal := func(v any) []byte {
+		b, err := asn1.Marshal(v)
+		if err != nil {
+			t.Error(err)
+		}
+		return b
+	}
+	set := func(b []byte) asn1.RawValue {
+		return asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: b}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req timeStampReq
+		if _, err := asn1.Unmarshal(body, &req); err != nil || r.Header.Get("Content-Type") != "application/timestamp-query" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		eContent := marshal(tstInfo{
+			Version:        1,
+			Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+			MessageImprint: req.MessageImprint,
+			SerialNumber:   big.NewInt(1),
+			GenTime:        stubGenTime,
+			Nonce:          req.Nonce,
+		})
+		sum := sha256.Sum256(eContent)
+		attrs := append(
+			marshal(attribute{Type: oidContentType, Values: set(marshal(oidTSTInfo))}),
+			marshal(attribute{Type: oidMessageDigest, Values: set(marshal(sum[:]))})...)
+		sig := ed25519.Sign(priv, marshal(set(attrs)))
+
+		signer := struct {
+			Version            int
+			SID                issuerAndSerial
+			DigestAlgorithm    pkix.AlgorithmIdentifier
+			SignedAttrs        asn1.RawValue
+			SignatureAlgorithm pkix.AlgorithmIdentifier
+			Signature          []byte
+		}{
+			1, issuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, Serial: cert.SerialNumber},
+			pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			asn1.RawValue{Class: asn1.ClassContextSpecific, IsCompound: true, Bytes: attrs},
+			pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+			sig,
+		}
+		sd := struct {
+			Version          int
+			DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+			EncapContentInfo encapContentInfo
+			Certificates     asn1.RawValue
+			SignerInfos      asn1.RawValue
+		}{
+			3, []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+			encapContentInfo{EContentType: oidTSTInfo, EContent: eContent},
+			asn1.RawValue{Class: asn1.ClassContextSpecific, IsCompound: true, Bytes: cert.Raw},
+			set(marshal(signer)),
+		}
+		token := marshal(contentInfo{
+			ContentType: oidSignedData,
+			Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, IsCompound: true, Bytes: marshal(sd)},
+		})
+
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(marshal(timeStampResp{TimeStampToken: asn1.RawValue{FullBytes: token}}))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, roots
+}
+
+func newLog(t *testing.T, n int, actor string) *memory.AuditLog {
+	t.Helper()
+	log := memory.NewAuditLog()
+	for i := 1; i <= n; i++ {
+		ev := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_" + strconv.Itoa(i), Type: domain.AuditUnitCreated, AtUnix: int64(i), ActorID: actor, UnitID: "u" + strconv.Itoa(i)}
+		if err := log.Append(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return log
+}
+
+func findingKinds(res ports.VerifyAnchorsResponse) map[string]int {
+	out := map[string]int{}
+	for _, f := range res.Findings {
+		out[f.Sink+"/"+f.Kind]++
+	}
+	return out
+}
+
+func TestAnchorAudit_FileAndRFC3161(t *testing.T) {
+	srv, roots := stubTSA(t)
+	dir := t.TempDir()
+	sinks := []ports.Anchor{
+		FileAnchor{Path: filepath.Join(dir, "offsite", "anchors.ndjson")},
+		RFC3161Anchor{URL: srv.URL, Receipts: filepath.Join(dir, "tsa.ndjson"), Roots: roots},
+	}
+	log := newLog(t, 3, "alice")
+	uc := usecases.AnchorAudit{Audit: memory.NewAuditReader(log), Anchors: sinks, Clock: memory.FakeClock{Now: 100}}
+
+	res, err := uc.AnchorAudit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Anchors) != 2 || !res.Anchored[0] || !res.Anchored[1] {
+		t.Fatalf("anchor: %+v", res)
+	}
+	if tsa := res.Anchors[1]; tsa.TreeSize != 3 || tsa.LastEventID != "evt_3" || tsa.Token == "" || tsa.GenTimeUnix != stubGenTime.Unix() {
+		t.Fatalf("rfc3161 anchor: %+v", tsa)
+	}
+
+	// nothing new: the latest anchors are reused
+	if res, err = uc.AnchorAudit(); err != nil || res.Anchored[0] || res.Anchored[1] {
+		t.Fatalf("second anchor: %+v %v", res, err)
+	}
+
+	log.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_4", Type: domain.AuditUnitCreated, AtUnix: 4, ActorID: "alice", UnitID: "u4"})
+	if _, err := uc.AnchorAudit(); err != nil {
+		t.Fatal(err)
+	}
+	ver, err := uc.VerifyAnchors()
+	if err != nil || !ver.Ok || ver.Checked != 4 || ver.Latest.TreeSize != 4 {
+		t.Fatalf("verify: %+v %v", ver, err)
+	}
+
+	// a rewritten log no longer matches any anchor and cannot be anchored
+	rewritten := usecases.AnchorAudit{Audit: memory.NewAuditReader(newLog(t, 4, "mallory")), Anchors: sinks, Clock: memory.FakeClock{Now: 200}}
+	ver, err = rewritten.VerifyAnchors()
+	if err != nil || ver.Ok {
+		t.Fatalf("verify rewritten: %+v %v", ver, err)
+	}
+	if k := findingKinds(ver); k["file/mismatch"] != 2 || k["rfc3161/mismatch"] != 2 {
+		t.Fatalf("rewritten findings: %v", k)
+	}
+	if _, err := rewritten.AnchorAudit(); err == nil {
+		t.Fatal("expected anchoring a rewritten log to fail")
+	}
+
+	// a truncated log falls short of the anchors
+	truncated := usecases.AnchorAudit{Audit: memory.NewAuditReader(newLog(t, 3, "alice")), Anchors: sinks}
+	ver, _ = truncated.VerifyAnchors()
+	if k := findingKinds(ver); k["file/beyond_log"] != 1 || k["rfc3161/beyond_log"] != 1 || len(ver.Findings) != 2 {
+		t.Fatalf("truncated findings: %v", k)
+	}
+
+	// tokens do not verify against another TSA's roots
+	_, otherRoots := stubTSA(t)
+	untrusted := usecases.AnchorAudit{Audit: memory.NewAuditReader(log), Anchors: []ports.Anchor{
+		RFC3161Anchor{URL: srv.URL, Receipts: filepath.Join(dir, "tsa.ndjson"), Roots: otherRoots},
+	}}
+	ver, _ = untrusted.VerifyAnchors()
+	if k := findingKinds(ver); k["rfc3161/receipt_invalid"] != 2 {
+		t.Fatalf("untrusted findings: %v", k)
+	}
+}
+
+func TestRFC3161Anchor_TokenBindsDigest(t *testing.T) {
+	srv, roots := stubTSA(t)
+	sink := RFC3161Anchor{URL: srv.URL, Receipts: filepath.Join(t.TempDir(), "tsa.ndjson"), Roots: roots}
+
+	an, err := sink.Anchor(domain.NewAuditAnchor(5, "aa", "evt_5", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.CheckAnchor(an); err != nil {
+		t.Fatal(err)
+	}
+
+	// the token of one digest does not vouch for another
+	other := domain.NewAuditAnchor(6, "bb", "evt_6", 1)
+	other.Token, other.GenTimeUnix = an.Token, an.GenTimeUnix
+	if err := sink.CheckAnchor(other); err == nil {
+		t.Fatal("expected a token for another digest to fail")
+	}
+
+	// nor for another time
+	an.GenTimeUnix++
+	if err := sink.CheckAnchor(an); err == nil {
+		t.Fatal("expected a token with another time to fail")
+	}
+}
@@ -0,0 +1,466 @@
+package anchor
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// RFC3161Anchor has each anchor digest timestamped by a timestamping
+// authority (RFC 3161 over HTTP) and keeps the anchors with their tokens in
+// an NDJSON file. The token is the receipt: it is signed by the TSA, so the
+// file may live in the data dir; deleting it loses the receipts but cannot
+// forge them.
+type RFC3161Anchor struct {
+	URL      string
+	Receipts string // path of the anchor file
+
+	// optional: the TSA certificate must chain to these (system roots if nil)
+	Roots *x509.CertPool
+
+	// optional: http.Client with a 30s timeout if nil
+	Client *http.Client
+}
+
+func (a RFC3161Anchor) Name() string { return SinkRFC3161 }
+
+func (a RFC3161Anchor) Anchor(an domain.AuditAnchor) (domain.AuditAnchor, error) {
+	digest, err := hex.DecodeString(an.Digest)
+	if err != nil || len(digest) != 32 {
+		return domain.AuditAnchor{}, fmt.Errorf("anchor digest %q is not a SHA-256", an.Digest)
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 63))
+	if err != nil {
+		return domain.AuditAnchor{}, err
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: digest},
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return domain.AuditAnchor{}, err
+	}
+
+	token, err := a.post(req)
+	if err != nil {
+		return domain.AuditAnchor{}, err
+	}
+	info, err := verifyTimeStampToken(token, digest, a.Roots)
+	if err != nil {
+		return domain.AuditAnchor{}, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return domain.AuditAnchor{}, fmt.Errorf("%w: nonce does not match the request", domain.ErrAnchorReceipt)
+	}
+
+	an.Sink = SinkRFC3161
+	an.TSA = a.URL
+	an.Token = base64.StdEncoding.EncodeToString(token)
+	an.GenTimeUnix = info.GenTime.Unix()
+	if err := appendAnchor(a.Receipts, an); err != nil {
+		return domain.AuditAnchor{}, err
+	}
+	return an, nil
+}
+
+func (a RFC3161Anchor) ListAnchors() ([]domain.AuditAnchor, error) {
+	return readAnchors(a.Receipts)
+}
+
+// CheckAnchor verifies the token: signed by a timestamping certificate,
+// over an.Digest, at an.GenTimeUnix.
+func (a RFC3161Anchor) CheckAnchor(an domain.AuditAnchor) error {
+	token, err := base64.StdEncoding.DecodeString(an.Token)
+	if err != nil || len(token) == 0 {
+		return fmt.Errorf("%w: no timestamp token", domain.ErrAnchorReceipt)
+	}
+	digest, err := hex.DecodeString(an.Digest)
+	if err != nil {
+		return fmt.Errorf("%w: digest is not hex", domain.ErrAnchorReceipt)
+	}
+	info, err := verifyTimeStampToken(token, digest, a.Roots)
+	if err != nil {
+		return err
+	}
+	if info.GenTime.Unix() != an.GenTimeUnix {
+		return fmt.Errorf("%w: token time %s, anchor says %d", domain.ErrAnchorReceipt, info.GenTime.UTC().Format(time.RFC3339), an.GenTimeUnix)
+	}
+	return nil
+}
+
+// post sends a TimeStampReq and returns the TimeStampToken of the reply.
+func (a RFC3161Anchor) post(req []byte) ([]byte, error) {
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Post(a.URL, "application/timestamp-query", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tsa %s: %s", a.URL, resp.Status)
+	}
+
+	var tsr timeStampResp
+	if _, err := asn1.Unmarshal(body, &tsr); err != nil {
+		return nil, fmt.Errorf("tsa %s: malformed response: %w", a.URL, err)
+	}
+	// 0 granted, 1 grantedWithMods
+	if tsr.Status.Status > 1 {
+		return nil, fmt.Errorf("tsa %s: request rejected (status %d) %v", a.URL, tsr.Status.Status, tsr.Status.StatusString)
+	}
+	if len(tsr.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("tsa %s: response carries no token", a.URL)
+	}
+	return tsr.TimeStampToken.FullBytes, nil
+}
+
+var (
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECPublicKey   = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA2 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA3 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA5 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519       = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Ordering       bool      `asn1:"optional"`
+	Nonce          *big.Int  `asn1:"optional"`
+	// tsa [0] and extensions [1] are not needed
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue // SET OF
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+// verifyTimeStampToken checks a TimeStampToken (CMS SignedData over a
+// TSTInfo): the imprint is digest (a SHA-256), the content digest and the
+// signature over the signed attributes verify with the signer's
+// certificate, and that certificate is for timestamping and chains to
+// roots at the token's time.
+func verifyTimeStampToken(token, digest []byte, roots *x509.CertPool) (tstInfo, error) {
+	fail := func(format string, args ...any) (tstInfo, error) {
+		return tstInfo{}, fmt.Errorf("%w: %s", domain.ErrAnchorReceipt, fmt.Sprintf(format, args...))
+	}
+
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(token, &ci); err != nil {
+		return fail("token: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) || ci.Content.Class != asn1.ClassContextSpecific || ci.Content.Tag != 0 {
+		return fail("token is not CMS signed data")
+	}
+	sd, err := parseSignedData(ci.Content.Bytes)
+	if err != nil {
+		return fail("signed data: %v", err)
+	}
+	if !sd.eContentType.Equal(oidTSTInfo) {
+		return fail("signed content is not a TSTInfo")
+	}
+
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.eContent, &info); err != nil {
+		return fail("tstinfo: %v", err)
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return fail("token timestamps another digest")
+	}
+
+	// signed attributes: content type and digest of the TSTInfo
+	var (
+		contentType asn1.ObjectIdentifier
+		msgDigest   []byte
+	)
+	for rest := sd.signer.signedAttrs.Bytes; len(rest) > 0; {
+		var attr attribute
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return fail("signed attributes: %v", err)
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &contentType)
+		case attr.Type.Equal(oidMessageDigest):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &msgDigest)
+		}
+		if err != nil {
+			return fail("signed attributes: %v", err)
+		}
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return fail("signed attributes name another content type")
+	}
+	hash, ok := hashOf(sd.signer.digestAlgorithm)
+	if !ok {
+		return fail("unsupported digest algorithm %v", sd.signer.digestAlgorithm)
+	}
+	h := hash.New()
+	h.Write(sd.eContent)
+	if !bytes.Equal(h.Sum(nil), msgDigest) {
+		return fail("content digest does not match the signed attributes")
+	}
+
+	cert := sd.signerCert()
+	if cert == nil {
+		return fail("signer certificate not in token")
+	}
+	algo, ok := signatureAlgorithm(sd.signer.signatureAlgorithm, sd.signer.digestAlgorithm)
+	if !ok {
+		return fail("unsupported signature algorithm %v", sd.signer.signatureAlgorithm)
+	}
+	// the signature covers the attributes DER-encoded as a SET, not as [0]
+	signed := append([]byte{0x31}, sd.signer.signedAttrs.FullBytes[1:]...)
+	if err := cert.CheckSignature(algo, signed, sd.signer.signature); err != nil {
+		return fail("signature: %v", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range sd.certs {
+		intermediates.AddCert(c)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return fail("tsa certificate: %v", err)
+	}
+	return info, nil
+}
+
+type signerInfo struct {
+	sid                asn1.RawValue
+	digestAlgorithm    asn1.ObjectIdentifier
+	signedAttrs        asn1.RawValue
+	signatureAlgorithm asn1.ObjectIdentifier
+	signature          []byte
+}
+
+type signedData struct {
+	eContentType asn1.ObjectIdentifier
+	eContent     []byte
+	certs        []*x509.Certificate
+	signer       signerInfo
+}
+
+// parseSignedData walks SignedData element by element: its optional fields
+// are implicitly tagged, which encoding/asn1 struct decoding cannot tell
+// apart reliably.
+func parseSignedData(b []byte) (signedData, error) {
+	var (
+		sd  signedData
+		seq asn1.RawValue
+	)
+	if _, err := asn1.Unmarshal(b, &seq); err != nil {
+		return sd, err
+	}
+	els, err := asn1Elements(seq.Bytes)
+	if err != nil {
+		return sd, err
+	}
+	// version, digestAlgorithms, encapContentInfo, [0] certs, [1] crls, signerInfos
+	if len(els) < 4 {
+		return sd, fmt.Errorf("too few fields")
+	}
+	var eci encapContentInfo
+	if _, err := asn1.Unmarshal(els[2].FullBytes, &eci); err != nil {
+		return sd, err
+	}
+	sd.eContentType, sd.eContent = eci.EContentType, eci.EContent
+	for _, el := range els[3 : len(els)-1] {
+		if el.Class == asn1.ClassContextSpecific && el.Tag == 0 {
+			if sd.certs, err = x509.ParseCertificates(el.Bytes); err != nil {
+				return sd, err
+			}
+		}
+	}
+
+	signers, err := asn1Elements(els[len(els)-1].Bytes)
+	if err != nil {
+		return sd, err
+	}
+	if len(signers) != 1 {
+		return sd, fmt.Errorf("%d signers, want 1", len(signers))
+	}
+	// version, sid, digestAlgorithm, [0] signedAttrs, signatureAlgorithm, signature, ...
+	f, err := asn1Elements(signers[0].Bytes)
+	if err != nil {
+		return sd, err
+	}
+	if len(f) < 6 || f[3].Class != asn1.ClassContextSpecific || f[3].Tag != 0 {
+		return sd, fmt.Errorf("signer without signed attributes")
+	}
+	var digestAlg, sigAlg pkix.AlgorithmIdentifier
+	if _, err := asn1.Unmarshal(f[2].FullBytes, &digestAlg); err != nil {
+		return sd, err
+	}
+	if _, err := asn1.Unmarshal(f[4].FullBytes, &sigAlg); err != nil {
+		return sd, err
+	}
+	var sig []byte
+	if _, err := asn1.Unmarshal(f[5].FullBytes, &sig); err != nil {
+		return sd, err
+	}
+	sd.signer = signerInfo{
+		sid:                f[1],
+		digestAlgorithm:    digestAlg.Algorithm,
+		signedAttrs:        f[3],
+		signatureAlgorithm: sigAlg.Algorithm,
+		signature:          sig,
+	}
+	return sd, nil
+}
+
+// signerCert finds the certificate named by the signer's sid
+// (issuerAndSerialNumber or [0] subjectKeyIdentifier).
+func (sd signedData) signerCert() *x509.Certificate {
+	sid := sd.signer.sid
+	for _, c := range sd.certs {
+		if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c
+			}
+			continue
+		}
+		var is issuerAndSerial
+		if _, err := asn1.Unmarshal(sid.FullBytes, &is); err != nil {
+			return nil
+		}
+		if bytes.Equal(c.RawIssuer, is.Issuer.FullBytes) && c.SerialNumber.Cmp(is.Serial) == 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+func asn1Elements(b []byte) ([]asn1.RawValue, error) {
+	var out []asn1.RawValue
+	for len(b) > 0 {
+		var el asn1.RawValue
+		rest, err := asn1.Unmarshal(b, &el)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, el)
+		b = rest
+	}
+	return out, nil
+}
+
+func hashOf(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func signatureAlgorithm(sig, digest asn1.ObjectIdentifier) (x509.SignatureAlgorithm, bool) {
+	hash, _ := hashOf(digest)
+	switch {
+	case sig.Equal(oidSHA256WithRSA), sig.Equal(oidRSAEncryption) && hash == crypto.SHA256:
+		return x509.SHA256WithRSA, true
+	case sig.Equal(oidSHA384WithRSA), sig.Equal(oidRSAEncryption) && hash == crypto.SHA384:
+		return x509.SHA384WithRSA, true
+	case sig.Equal(oidSHA512WithRSA), sig.Equal(oidRSAEncryption) && hash == crypto.SHA512:
+		return x509.SHA512WithRSA, true
+	case sig.Equal(oidECDSAWithSHA2), sig.Equal(oidECPublicKey) && hash == crypto.SHA256:
+		return x509.ECDSAWithSHA256, true
+	case sig.Equal(oidECDSAWithSHA3), sig.Equal(oidECPublicKey) && hash == crypto.SHA384:
+		return x509.ECDSAWithSHA384, true
+	case sig.Equal(oidECDSAWithSHA5), sig.Equal(oidECPublicKey) && hash == crypto.SHA512:
+		return x509.ECDSAWithSHA512, true
+	case sig.Equal(oidEd25519):
+		return x509.PureEd25519, true
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
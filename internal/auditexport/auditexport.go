@@ -0,0 +1,84 @@
+// Package auditexport converts audit events into formats external log
+// systems ingest (CloudEvents, RFC 5424 syslog, OTLP-JSON logs) and
+// delivers them to syslog listeners or files. It implements
+// ports.AuditEventEncoder and ports.AuditSink.
+package auditexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// Export formats.
+const (
+	FormatCloudEvents = "cloudevents"
+	FormatSyslog      = "syslog"
+	FormatOTLPJSON    = "otlp-json"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{FormatCloudEvents, FormatSyslog, FormatOTLPJSON}
+
+// Options describe where the events come from.
+type Options struct {
+	// Source identifies the data dir or instance (CloudEvents source,
+	// OTLP service.instance.id). Default "digiemu".
+	Source string
+
+	// Hostname for syslog HOSTNAME; "-" (nil value) if empty.
+	Hostname string
+}
+
+// NewEncoder returns the encoder of format.
+func NewEncoder(format string, opts Options) (ports.AuditEventEncoder, error) {
+	if opts.Source == "" {
+		opts.Source = "digiemu"
+	}
+	switch format {
+	case FormatCloudEvents:
+		return CloudEventsEncoder{Source: opts.Source}, nil
+	case FormatSyslog:
+		return SyslogEncoder{Hostname: opts.Hostname}, nil
+	case FormatOTLPJSON:
+		return OTLPJSONEncoder{Source: opts.Source}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// payloadFields returns the payload of ev as a JSON object: the fields of
+// its registered payload type (domain.DecodeAuditPayload), or Data as
+// written for unknown types and malformed payloads. Numbers keep their
+// literal form. nil if there is no payload object.
+func payloadFields(ev domain.AuditEvent) (map[string]any, error) {
+	data := ev.Data
+	if p, err := domain.DecodeAuditPayload(ev); p != nil && err == nil {
+		data = p
+	}
+	if data == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		// not an object: keep it under a single key
+		return map[string]any{"value": json.RawMessage(b)}, nil
+	}
+	return m, nil
+}
+
+// eventTypeName is the canonical event type in lower case, as used in the
+// type names of the export formats ("unit.created", "meaning_set").
+func eventTypeName(ev domain.AuditEvent) string {
+	return strings.ToLower(domain.CanonicalAuditEventType(ev.Type))
+}
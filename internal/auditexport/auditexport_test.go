@@ -0,0 +1,205 @@
+package auditexport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// testEvent is a MEANING_SET event as read back from a log (generic Data,
+// registered under an alias spelling).
+func testEvent() domain.AuditEvent {
+	return domain.AuditEvent{
+		Schema: "digiemu.audit.v1", ID: "evt_1", Type: "meaning.set", AtUnix: 1700000000, ActorID: `ali"ce]`,
+		UnitID: "u1", VersionID: "v1", Seq: 7, Hash: "abc",
+		Data: map[string]any{"meaning_hash": "mh", "meaning_path": "units/u1/v1.meaning.json"},
+	}
+}
+
+func TestEncoders_MapTypedPayloads(t *testing.T) {
+	ev := testEvent()
+
+	b, err := CloudEventsEncoder{Source: "test"}.EncodeAuditEvent(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ce map[string]any
+	if err := json.Unmarshal(b, &ce); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ce["data"].(map[string]any)
+	if ce["specversion"] != "1.0" || ce["type"] != "io.digiemu.audit.meaning_set" || ce["subject"] != "units/u1/versions/v1" ||
+		ce["time"] != "2023-11-14T22:13:20Z" || ce["digiemuseq"] != float64(7) || data["meaning_hash"] != "mh" {
+		t.Fatalf("unexpected cloudevent: %s", b)
+	}
+
+	b, err = SyslogEncoder{Hostname: "host one"}.EncodeAuditEvent(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<110>1 2023-11-14T22:13:20Z hostone digiemu - MEANING_SET [audit@32473 id="evt_1" type="meaning.set" actor="ali\"ce\]" unit="u1" version="v1" seq="7" hash="abc"]` +
+		`[payload@32473 meaning_hash="mh" meaning_path="units/u1/v1.meaning.json"] meaning.set by ali"ce]`
+	if string(b) != want {
+		t.Fatalf("syslog:\n got %s\nwant %s", b, want)
+	}
+
+	b, err = OTLPJSONEncoder{Source: "test"}.EncodeAuditEvent(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req otlpRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatal(err)
+	}
+	rec := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if rec.TimeUnixNano != "1700000000000000000" || rec.EventName != "MEANING_SET" || rec.Body.KvlistValue == nil ||
+		len(rec.Body.KvlistValue.Values) != 2 || *rec.Body.KvlistValue.Values[0].Value.StringValue != "mh" {
+		t.Fatalf("unexpected otlp record: %s", b)
+	}
+	last := rec.Attributes[len(rec.Attributes)-1]
+	if last.Key != "digiemu.seq" || last.Value.IntValue == nil || *last.Value.IntValue != "7" {
+		t.Fatalf("seq attribute: %+v", last)
+	}
+
+	if _, err := NewEncoder("xml", Options{}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestNetSink_Framing(t *testing.T) {
+	records := [][]byte{[]byte("first record"), []byte("second")}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	udp := &NetSink{Network: "udp", Addr: pc.LocalAddr().String()}
+	defer udp.Close()
+	if err := udp.Send(records); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	for _, want := range records {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf[:n]) != string(want) {
+			t.Fatalf("udp datagram %q, want %q", buf[:n], want)
+		}
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan []string, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			got <- nil
+			return
+		}
+		defer c.Close()
+		br := bufio.NewReader(c)
+		var frames []string
+		for len(frames) < len(records) {
+			n, err := br.ReadString(' ')
+			if err != nil {
+				break
+			}
+			size, _ := strconv.Atoi(strings.TrimSpace(n))
+			frame := make([]byte, size)
+			if _, err := io.ReadFull(br, frame); err != nil {
+				break
+			}
+			frames = append(frames, string(frame))
+		}
+		got <- frames
+	}()
+	sink, err := NewSink("tcp://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Send(records); err != nil {
+		t.Fatal(err)
+	}
+	if frames := <-got; len(frames) != 2 || frames[0] != "first record" || frames[1] != "second" {
+		t.Fatalf("tcp frames: %q", frames)
+	}
+}
+
+func TestForwardAudit_ResumesFromCheckpoint(t *testing.T) {
+	log := memory.NewAuditLog()
+	appendEvents := func(from, n int) {
+		for i := from; i < from+n; i++ {
+			ev := testEvent()
+			ev.ID, ev.Seq = "evt_"+strconv.Itoa(i), int64(i)
+			if err := log.Append(ev); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	appendEvents(1, 3)
+
+	var out bytes.Buffer
+	uc := usecases.ForwardAudit{
+		Audit:      log,
+		Encoder:    CloudEventsEncoder{Source: "test"},
+		Sink:       WriterSink{W: &out},
+		Checkpoint: fsrepo.NewAuditExportCheckpoint(t.TempDir(), "siem"),
+		Clock:      memory.FakeClock{Now: 42},
+	}
+	in := ports.ForwardAuditRequest{Format: FormatCloudEvents}
+	res, err := uc.ForwardAudit(context.Background(), in)
+	if err != nil || res.Forwarded != 3 || res.LastEventID != "evt_3" {
+		t.Fatalf("first run: %+v %v", res, err)
+	}
+
+	appendEvents(4, 2)
+	out.Reset()
+	res, err = uc.ForwardAudit(context.Background(), in)
+	if err != nil || res.Forwarded != 2 || res.LastEventID != "evt_5" {
+		t.Fatalf("second run: %+v %v", res, err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"id":"evt_4"`) {
+		t.Fatalf("second run sent %q", lines)
+	}
+
+	cp, ok, err := uc.Checkpoint.LoadCheckpoint()
+	if err != nil || !ok || cp.Forwarded != 5 || cp.LastEventID != "evt_5" || cp.UpdatedAtUnix != 42 {
+		t.Fatalf("checkpoint: %+v %v %v", cp, ok, err)
+	}
+
+	// a one-shot export drains more than one poll batch
+	appendEvents(6, 1200)
+	out.Reset()
+	res, err = uc.ForwardAudit(context.Background(), in)
+	if err != nil || res.Forwarded != 1200 || res.LastEventID != "evt_1205" {
+		t.Fatalf("large run: %+v %v", res, err)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 1200 {
+		t.Fatalf("large run sent %d records", n)
+	}
+
+	// a checkpoint belongs to one format
+	if _, err := uc.ForwardAudit(context.Background(), ports.ForwardAuditRequest{Format: FormatSyslog}); err == nil {
+		t.Fatal("expected an error for a checkpoint of another format")
+	}
+}
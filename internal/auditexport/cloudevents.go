@@ -0,0 +1,80 @@
+package auditexport
+
+import (
+	"encoding/json"
+	"time"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// CloudEventsEncoder renders an event as a CloudEvents 1.0 JSON structured
+// mode event (one object per record):
+//
+//	type     io.digiemu.audit.<canonical type, lower case>
+//	subject  units/<unitId>[/versions/<versionId>]
+//	data     the typed payload
+//
+// The chain position, hash, actor and signing key travel as extension
+// attributes (digiemuseq, digiemuhash, digiemuactor, digiemukeyid).
+type CloudEventsEncoder struct {
+	Source string
+}
+
+type cloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Source          string         `json:"source"`
+	Type            string         `json:"type"`
+	Subject         string         `json:"subject,omitempty"`
+	Time            string         `json:"time,omitempty"`
+	DataContentType string         `json:"datacontenttype"`
+	Data            map[string]any `json:"data,omitempty"`
+
+	// extension attributes
+	EventType string `json:"digiemutype"` // as written to the log
+	Schema    string `json:"digiemuschema,omitempty"`
+	Actor     string `json:"digiemuactor,omitempty"`
+	Seq       int64  `json:"digiemuseq,omitempty"`
+	Hash      string `json:"digiemuhash,omitempty"`
+	KeyID     string `json:"digiemukeyid,omitempty"`
+}
+
+const cloudEventsTypePrefix = "io.digiemu.audit."
+
+func (e CloudEventsEncoder) EncodeAuditEvent(ev domain.AuditEvent) ([]byte, error) {
+	data, err := payloadFields(ev)
+	if err != nil {
+		return nil, err
+	}
+	ce := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              ev.ID,
+		Source:          e.Source,
+		Type:            cloudEventsTypePrefix + eventTypeName(ev),
+		Subject:         auditSubject(ev),
+		DataContentType: "application/json",
+		Data:            data,
+		EventType:       ev.Type,
+		Schema:          ev.Schema,
+		Actor:           ev.ActorID,
+		Seq:             ev.Seq,
+		Hash:            ev.Hash,
+		KeyID:           ev.KeyID,
+	}
+	if ev.AtUnix != 0 {
+		ce.Time = time.Unix(ev.AtUnix, 0).UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(ce)
+}
+
+// auditSubject names the unit and version an event is about.
+func auditSubject(ev domain.AuditEvent) string {
+	if ev.UnitID == "" {
+		return ""
+	}
+	s := "units/" + ev.UnitID
+	if ev.VersionID != "" {
+		s += "/versions/" + ev.VersionID
+	}
+	return s
+}
@@ -0,0 +1,169 @@
+package auditexport
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// OTLPJSONEncoder renders an event as an OTLP/JSON ExportLogsServiceRequest
+// holding one log record (what the OTLP/HTTP logs endpoint and the
+// collector's file receiver accept):
+//
+//	eventName   canonical event type
+//	body        the typed payload as a kvlist
+//	attributes  digiemu.event.id, digiemu.event.type, digiemu.actor.id,
+//	            digiemu.unit.id, digiemu.version.id, digiemu.seq,
+//	            digiemu.hash, digiemu.key.id (when set)
+//
+// 64-bit integers are JSON strings, as the protobuf JSON mapping requires.
+type OTLPJSONEncoder struct {
+	Source string
+}
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano,omitempty"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	EventName      string         `json:"eventName"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string          `json:"stringValue,omitempty"`
+	BoolValue   *bool            `json:"boolValue,omitempty"`
+	IntValue    *string          `json:"intValue,omitempty"`
+	DoubleValue *float64         `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKvlistValue `json:"kvlistValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKvlistValue struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// severity number 9 is INFO
+const otlpSeverityInfo = 9
+
+func (e OTLPJSONEncoder) EncodeAuditEvent(ev domain.AuditEvent) ([]byte, error) {
+	payload, err := payloadFields(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := otlpLogRecord{
+		SeverityNumber: otlpSeverityInfo,
+		SeverityText:   "INFO",
+		EventName:      domain.CanonicalAuditEventType(ev.Type),
+		Body:           otlpValue(payload),
+		Attributes:     []otlpKeyValue{},
+	}
+	if ev.AtUnix != 0 {
+		rec.TimeUnixNano = strconv.FormatInt(ev.AtUnix, 10) + "000000000"
+	}
+	for _, a := range [][2]string{
+		{"digiemu.event.id", ev.ID},
+		{"digiemu.event.type", ev.Type},
+		{"digiemu.actor.id", ev.ActorID},
+		{"digiemu.unit.id", ev.UnitID},
+		{"digiemu.version.id", ev.VersionID},
+		{"digiemu.hash", ev.Hash},
+		{"digiemu.key.id", ev.KeyID},
+	} {
+		if a[1] != "" {
+			rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: a[0], Value: otlpString(a[1])})
+		}
+	}
+	if ev.Seq != 0 {
+		seq := strconv.FormatInt(ev.Seq, 10)
+		rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: "digiemu.seq", Value: otlpAnyValue{IntValue: &seq}})
+	}
+
+	return json.Marshal(otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: otlpString("digiemu")},
+			{Key: "service.instance.id", Value: otlpString(e.Source)},
+		}},
+		ScopeLogs: []otlpScopeLogs{{
+			Scope:      otlpScope{Name: "digiemu.audit"},
+			LogRecords: []otlpLogRecord{rec},
+		}},
+	}}})
+}
+
+func otlpString(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+// otlpValue converts a generically decoded JSON value (numbers as
+// json.Number) into an AnyValue; null becomes the empty value.
+func otlpValue(v any) otlpAnyValue {
+	switch x := v.(type) {
+	case string:
+		return otlpString(x)
+	case bool:
+		return otlpAnyValue{BoolValue: &x}
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			s := x.String()
+			return otlpAnyValue{IntValue: &s}
+		}
+		f, _ := x.Float64()
+		return otlpAnyValue{DoubleValue: &f}
+	case []any:
+		arr := &otlpArrayValue{Values: make([]otlpAnyValue, len(x))}
+		for i, e := range x {
+			arr.Values[i] = otlpValue(e)
+		}
+		return otlpAnyValue{ArrayValue: arr}
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kv := &otlpKvlistValue{Values: make([]otlpKeyValue, 0, len(x))}
+		for _, k := range keys {
+			kv.Values = append(kv.Values, otlpKeyValue{Key: k, Value: otlpValue(x[k])})
+		}
+		return otlpAnyValue{KvlistValue: kv}
+	case json.RawMessage:
+		return otlpString(string(x))
+	default:
+		return otlpAnyValue{}
+	}
+}
@@ -0,0 +1,142 @@
+package auditexport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"digiemu-core/internal/kernel/ports"
+)
+
+// Sink is a ports.AuditSink that holds a file or connection.
+type Sink interface {
+	ports.AuditSink
+	io.Closer
+}
+
+// netTimeout bounds dialing and each write to a listener.
+const netTimeout = 10 * time.Second
+
+// NewSink opens the destination of a forward:
+//
+//	udp://host:port   one record per datagram (RFC 5426 syslog over UDP)
+//	tcp://host:port   octet-counted records (RFC 6587 syslog over TCP)
+//	file:///path      records appended to a file, one per line
+//	path              same as file://
+func NewSink(dest string) (Sink, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // C:\... is a path
+		return OpenFileSink(dest)
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("forward destination %q: host:port missing", dest)
+		}
+		return &NetSink{Network: u.Scheme, Addr: u.Host}, nil
+	case "file":
+		return OpenFileSink(u.Path)
+	default:
+		return nil, fmt.Errorf("forward destination %q: unsupported scheme %q (want udp, tcp or file)", dest, u.Scheme)
+	}
+}
+
+// WriterSink writes records to w, one per line.
+type WriterSink struct {
+	W io.Writer
+}
+
+func (s WriterSink) Send(records [][]byte) error {
+	bw := bufio.NewWriter(s.W)
+	for _, r := range records {
+		bw.Write(r)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func (s WriterSink) Close() error { return nil }
+
+// FileSink appends records to a file, one per line, and syncs it after
+// every batch so the checkpoint never gets ahead of the file.
+type FileSink struct {
+	f *os.File
+}
+
+func OpenFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f}, nil
+}
+
+func (s *FileSink) Send(records [][]byte) error {
+	if err := (WriterSink{W: s.f}).Send(records); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+func (s *FileSink) Close() error { return s.f.Close() }
+
+// NetSink sends records to a syslog listener. It dials on first use and
+// after a failed send, so a listener restart costs one failed batch (which
+// the checkpoint makes the forwarder repeat on its next run).
+type NetSink struct {
+	Network string // "udp" or "tcp"
+	Addr    string
+
+	conn net.Conn
+}
+
+func (s *NetSink) Send(records [][]byte) error {
+	if s.conn == nil {
+		c, err := net.DialTimeout(s.Network, s.Addr, netTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = c
+	}
+	if err := s.send(records); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *NetSink) send(records [][]byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(netTimeout)); err != nil {
+		return err
+	}
+	if s.Network == "udp" {
+		for _, r := range records {
+			if _, err := s.conn.Write(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	bw := bufio.NewWriter(s.conn)
+	for _, r := range records {
+		bw.WriteString(strconv.Itoa(len(r)))
+		bw.WriteByte(' ')
+		bw.Write(r)
+	}
+	return bw.Flush()
+}
+
+func (s *NetSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,141 @@
+package auditexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// SyslogEncoder renders an event as an RFC 5424 message:
+//
+//	<110>1 TIMESTAMP HOSTNAME digiemu - MSGID [audit@32473 ...][payload@32473 ...] MSG
+//
+// Facility 13 (log audit), severity 6 (informational). MSGID is the
+// canonical event type; the event fields and the typed payload are
+// structured data (nested payload values as JSON). 32473 is the enterprise
+// number RFC 5612 reserves for examples, as digiemu has none of its own.
+type SyslogEncoder struct {
+	Hostname string
+}
+
+const (
+	syslogPri     = 13*8 + 6
+	syslogAppName = "digiemu"
+	syslogSDID    = "audit@32473"
+	syslogSDData  = "payload@32473"
+)
+
+func (e SyslogEncoder) EncodeAuditEvent(ev domain.AuditEvent) ([]byte, error) {
+	payload, err := payloadFields(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := "-"
+	if ev.AtUnix != 0 {
+		ts = time.Unix(ev.AtUnix, 0).UTC().Format(time.RFC3339)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s - %s ", syslogPri, ts, syslogHeaderField(e.Hostname, 255), syslogAppName, syslogHeaderField(domain.CanonicalAuditEventType(ev.Type), 32))
+
+	b.WriteString("[" + syslogSDID)
+	for _, p := range [][2]string{
+		{"id", ev.ID},
+		{"type", ev.Type},
+		{"actor", ev.ActorID},
+		{"unit", ev.UnitID},
+		{"version", ev.VersionID},
+		{"seq", seqParam(ev.Seq)},
+		{"hash", ev.Hash},
+		{"keyId", ev.KeyID},
+	} {
+		if p[1] != "" {
+			writeSDParam(&b, p[0], p[1])
+		}
+	}
+	b.WriteString("]")
+
+	if len(payload) > 0 {
+		keys := make([]string, 0, len(payload))
+		for k := range payload {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("[" + syslogSDData)
+		for _, k := range keys {
+			v, err := sdValue(payload[k])
+			if err != nil {
+				return nil, err
+			}
+			writeSDParam(&b, sdName(k), v)
+		}
+		b.WriteString("]")
+	}
+
+	fmt.Fprintf(&b, " %s by %s", ev.Type, ev.ActorID)
+	return []byte(b.String()), nil
+}
+
+func seqParam(seq int64) string {
+	if seq == 0 {
+		return ""
+	}
+	return strconv.FormatInt(seq, 10)
+}
+
+// syslogHeaderField keeps the printable ASCII of s, at most limit characters;
+// "-" (the nil value) if nothing is left.
+func syslogHeaderField(s string, limit int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > 32 && r < 127 && b.Len() < limit {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+// sdName turns a payload key into an SD-NAME (printable ASCII without
+// '=', ' ', ']' and '"', at most 32 characters).
+func sdName(k string) string {
+	var b strings.Builder
+	for _, r := range k {
+		if b.Len() == 32 {
+			break
+		}
+		if r <= 32 || r >= 127 || r == '=' || r == ']' || r == '"' {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sdValue renders a payload value: strings as they are, anything else as JSON.
+func sdValue(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// writeSDParam writes ` name="value"` with '"', '\' and ']' escaped.
+func writeSDParam(b *strings.Builder, name, value string) {
+	b.WriteString(" " + name + `="`)
+	for _, r := range value {
+		if r == '"' || r == '\\' || r == ']' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(`"`)
+}
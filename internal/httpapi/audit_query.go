@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// handleQueryAudit serves GET /v1/audit/events, one page of the events
+// matching the query in log order. Filters:
+//
+//	from, to         unix seconds or RFC 3339, both inclusive
+//	actor, type,     repeatable or comma separated; any value matches
+//	unitId
+//	unitKeyPrefix    units whose key starts with the prefix
+//	versionId
+//
+// limit sets the page size; cursor is the nextCursor of the previous page,
+// which is omitted on the last page.
+func (a API) handleQueryAudit(w http.ResponseWriter, r *http.Request) {
+	if a.Query == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "audit query not configured", nil)
+		return
+	}
+	q := r.URL.Query()
+	in := ports.AuditQueryRequest{
+		AuditQueryFilter: ports.AuditQueryFilter{
+			ActorIDs:  splitParam(q["actor"]),
+			Types:     splitParam(q["type"]),
+			UnitIDs:   splitParam(q["unitId"]),
+			VersionID: q.Get("versionId"),
+		},
+		UnitKeyPrefix: q.Get("unitKeyPrefix"),
+		Cursor:        q.Get("cursor"),
+	}
+	for name, dst := range map[string]*int64{"from": &in.FromUnix, "to": &in.ToUnix} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeParam(v)
+		if err != nil {
+			j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid %s: %q", name, v)
+			return
+		}
+		*dst = t
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid limit: %q", v)
+			return
+		}
+		in.Limit = n
+	}
+
+	out, err := a.Query.QueryAudit(in)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidAuditCursor):
+			j.ErrorCode(w, http.StatusBadRequest, "INVALID_CURSOR", err.Error(), nil)
+		case errors.Is(err, domain.ErrInvalidAuditQuery):
+			j.ErrorCode(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+		default:
+			j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+		}
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
+
+// splitParam flattens repeated and comma separated query values.
+func splitParam(vs []string) []string {
+	var out []string
+	for _, v := range vs {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// parseTimeParam accepts unix seconds or an RFC 3339 timestamp.
+func parseTimeParam(v string) (int64, error) {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
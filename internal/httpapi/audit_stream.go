@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// streamHeartbeat is how often an idle event stream sends a comment line, so
+// that proxies keep the connection open and clients notice dead servers.
+var streamHeartbeat = 15 * time.Second
+
+var errStreamClosed = errors.New("stream closed")
+
+// handleAuditStream serves GET /v1/audit/stream as Server-Sent Events: one
+// event per audit event appended after the request (or after Last-Event-ID),
+// with the event id as SSE id and the event type as SSE event name.
+//
+// Filters: ?type=, ?unitId=, ?versionId=. Browsers' EventSource cannot set
+// Last-Event-ID on the first request, so ?lastEventId= is accepted as well.
+func (a API) handleAuditStream(w http.ResponseWriter, r *http.Request) {
+	if a.Stream == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "audit stream not configured", nil)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		j.ErrorCode(w, http.StatusInternalServerError, "INTERNAL", "streaming not supported", nil)
+		return
+	}
+
+	q := r.URL.Query()
+	in := ports.AuditFollowRequest{
+		AfterEventID: r.Header.Get("Last-Event-ID"),
+		Type:         q.Get("type"),
+		UnitID:       q.Get("unitId"),
+		VersionID:    q.Get("versionId"),
+	}
+	if in.AfterEventID == "" {
+		in.AfterEventID = q.Get("lastEventId")
+	}
+	cur, err := a.Stream.StartFollow(in)
+	if err != nil {
+		if errors.Is(err, domain.ErrAuditEventNotFound) {
+			j.ErrorCode(w, http.StatusNotFound, "EVENT_NOT_FOUND", "last event id not found in audit log", nil)
+			return
+		}
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+		return
+	}
+
+	// the stream outlives the server's WriteTimeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var mu sync.Mutex // serializes writes of events and heartbeats
+	closed := false   // set once the handler returns; w must not be used after
+	write := func(format string, args ...any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return errStreamClosed
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	if err := write("retry: 2000\n\n"); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer func() {
+		cancel()
+		mu.Lock()
+		closed = true
+		mu.Unlock()
+	}()
+	go func() {
+		t := time.NewTicker(streamHeartbeat)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if write(": ping\n\n") != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// returns when the client goes away (ctx done or a failed write)
+	_ = a.Stream.FollowAudit(ctx, in, cur, func(ev domain.AuditEvent) error {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		return write("id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, b)
+	})
+}
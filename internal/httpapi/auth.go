@@ -0,0 +1,176 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+)
+
+// v0.6: authentication. NewRouter passes every request through API.Auth;
+// writes (POST, PUT, PATCH, DELETE) are rejected without a principal, and
+// the principal's actor id is what the audit log records for them. Reads
+// stay open, but presenting bad credentials fails them too.
+
+// Principal is an authenticated caller.
+type Principal struct {
+	ActorID  string
+	Method   string // "apikey", "hs256", "eddsa"
+	TenantID string // v0.6: the only tenant the caller may access
+}
+
+var (
+	// ErrNoCredentials: the request carries no credentials this
+	// authenticator understands; the next one may.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials: credentials were presented but do not verify.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+type AuthenticatorFunc func(r *http.Request) (Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (Principal, error) { return f(r) }
+
+// Authenticators tries each authenticator in turn; the first that does not
+// return ErrNoCredentials decides.
+type Authenticators []Authenticator
+
+func (as Authenticators) Authenticate(r *http.Request) (Principal, error) {
+	for _, a := range as {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+type principalKey struct{}
+
+// PrincipalFrom returns the principal NewRouter authenticated for the request.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// actorOf is the actor id handlers record for a write; writes only reach
+// handlers with a principal.
+func actorOf(r *http.Request) string {
+	p, _ := PrincipalFrom(r.Context())
+	return p.ActorID
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// needsPrincipal reports whether r is refused without credentials: writes,
+// and audit verifications, which read the whole data dir.
+func needsPrincipal(r *http.Request) bool {
+	return isWrite(r.Method) || r.URL.Path == "/v1/audit/verify"
+}
+
+func (a API) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var (
+			p   Principal
+			err = ErrNoCredentials
+		)
+		if a.Auth != nil {
+			p, err = a.Auth.Authenticate(r)
+		}
+		switch {
+		case err == nil && strings.TrimSpace(p.ActorID) == "":
+			err = fmt.Errorf("%w: no actor", ErrInvalidCredentials)
+		case errors.Is(err, ErrNoCredentials) && !needsPrincipal(r):
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="digiemu"`)
+			msg := "authentication required"
+			if !errors.Is(err, ErrNoCredentials) {
+				msg = err.Error()
+			}
+			j.ErrorCode(w, http.StatusUnauthorized, "UNAUTHENTICATED", msg, nil)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(h[7:]), true
+}
+
+// APIKeys authenticates static API keys, sent as "X-API-Key: KEY" or as an
+// opaque bearer token (one that is not a JWT). Only SHA-256 hashes of the
+// keys are kept.
+type APIKeys struct {
+	keys map[string]APIKey // by sha256 hex
+}
+
+func NewAPIKeys(keys []APIKey) (APIKeys, error) {
+	out := APIKeys{keys: make(map[string]APIKey, len(keys))}
+	for _, k := range keys {
+		h := strings.ToLower(strings.TrimSpace(k.KeySHA256))
+		if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+			return APIKeys{}, fmt.Errorf("api key of %q: keySha256 is not a SHA-256", k.ActorID)
+		}
+		if strings.TrimSpace(k.ActorID) == "" {
+			return APIKeys{}, fmt.Errorf("api key %s…: actorId missing", h[:8])
+		}
+		if err := domain.ValidateTenantID(k.TenantID); err != nil {
+			return APIKeys{}, fmt.Errorf("api key of %q: %w", k.ActorID, err)
+		}
+		out.keys[h] = k
+	}
+	return out, nil
+}
+
+// APIKeyHash is what an auth file stores for key.
+func APIKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (k APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		tok, ok := bearerToken(r)
+		if !ok || strings.Contains(tok, ".") {
+			return Principal{}, ErrNoCredentials
+		}
+		key = tok
+	}
+	ak, ok := k.keys[APIKeyHash(key)]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: unknown api key", ErrInvalidCredentials)
+	}
+	return Principal{ActorID: ak.ActorID, Method: "apikey", TenantID: ak.TenantID}, nil
+}
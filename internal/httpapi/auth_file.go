@@ -0,0 +1,108 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AuthFileSchema is the schema of the keys file `digiemu serve --auth` reads:
+//
+//	{
+//	  "schema": "digiemu.http_auth.v1",
+//	  "apiKeys": [{"actorId": "alice", "keySha256": "<hex>", "tenantId": "acme"}],
+//	  "hs256":   [{"kid": "gateway", "secret": "<base64>"}]
+//	}
+const AuthFileSchema = "digiemu.http_auth.v1"
+
+type AuthFile struct {
+	Schema  string        `json:"schema"`
+	APIKeys []APIKey      `json:"apiKeys,omitempty"`
+	HS256   []HS256Secret `json:"hs256,omitempty"`
+}
+
+type APIKey struct {
+	ActorID       string `json:"actorId"`
+	KeySHA256     string `json:"keySha256"`
+	CreatedAtUnix int64  `json:"createdAtUnix,omitempty"`
+	TenantID      string `json:"tenantId,omitempty"` // v0.6: default tenant if empty
+}
+
+type HS256Secret struct {
+	KeyID  string `json:"kid"`
+	Secret string `json:"secret"` // base64 (std)
+}
+
+// LoadAuthFile reads a keys file; a missing file is an empty one.
+func LoadAuthFile(path string) (AuthFile, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return AuthFile{Schema: AuthFileSchema}, nil
+	}
+	if err != nil {
+		return AuthFile{}, err
+	}
+	var f AuthFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return AuthFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Schema != AuthFileSchema {
+		return AuthFile{}, fmt.Errorf("%s: unsupported schema %q", path, f.Schema)
+	}
+	return f, nil
+}
+
+// SaveAuthFile replaces the keys file (mode 0600) through a rename.
+func SaveAuthFile(path string, f AuthFile) error {
+	f.Schema = AuthFileSchema
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".auth-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Authenticator returns the authenticators for the file's API keys and
+// HS256 secrets, the latter added to jwt (which carries the EdDSA key
+// registry, clock and accepted issuer/audience).
+func (f AuthFile) Authenticator(jwt JWTAuth) (Authenticator, error) {
+	keys, err := NewAPIKeys(f.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[string][]byte, len(f.HS256)+len(jwt.HS256))
+	for kid, s := range jwt.HS256 {
+		secrets[kid] = s
+	}
+	for _, s := range f.HS256 {
+		b, err := base64.StdEncoding.DecodeString(s.Secret)
+		if err != nil || len(b) < 32 {
+			return nil, fmt.Errorf("hs256 secret %q: want at least 32 bytes, base64", s.KeyID)
+		}
+		secrets[s.KeyID] = b
+	}
+	jwt.HS256 = secrets
+	return Authenticators{keys, jwt}, nil
+}
@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+type createBranchReq struct {
+	Name          string `json:"name"`
+	From          string `json:"from,omitempty"`
+	FromVersionID string `json:"fromVersionId,omitempty"`
+}
+
+type mergeBranchReq struct {
+	Into          string `json:"into,omitempty"`
+	Label         string `json:"label,omitempty"`
+	Content       string `json:"content,omitempty"`
+	BaseVersionID string `json:"baseVersionId,omitempty"`
+}
+
+// handleListBranches serves GET /v1/units/{key}/branches.
+func (a API) handleListBranches(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.BranchList == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "branches not configured", nil)
+		return
+	}
+	out, err := a.BranchList.ListBranches(ports.ListBranchesRequest{UnitKey: unitKey})
+	if err != nil {
+		writeBranchError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
+
+// handleCreateBranch serves POST /v1/units/{key}/branches: a branch starting
+// at body.fromVersionId or at the head of body.from (default main).
+func (a API) handleCreateBranch(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.Branches == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "branches not configured", nil)
+		return
+	}
+	var req createBranchReq
+	if err := j.Read(r, &req); err != nil {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid json: %v", err)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "name required")
+		return
+	}
+	out, err := a.Branches.CreateBranch(ports.CreateBranchRequest{
+		UnitKey:       unitKey,
+		Name:          req.Name,
+		FromBranch:    req.From,
+		FromVersionID: req.FromVersionID,
+		ActorID:       actorOf(r),
+	})
+	if err != nil {
+		writeBranchError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusCreated, out)
+}
+
+// handleDeleteBranch serves DELETE /v1/units/{key}/branches/{name}.
+func (a API) handleDeleteBranch(w http.ResponseWriter, r *http.Request, unitKey, name string) {
+	if a.DelBranch == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "branches not configured", nil)
+		return
+	}
+	out, err := a.DelBranch.DeleteBranch(ports.DeleteBranchRequest{UnitKey: unitKey, Name: name, ActorID: actorOf(r)})
+	if err != nil {
+		writeBranchError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
+
+// handleMergeBranch serves POST /v1/units/{key}/branches/{name}/merge: merges
+// the branch into body.into (default main). Conflicts fail with 409
+// MERGE_CONFLICT, the conflicting regions and the marked-up text; the
+// resolved text is sent back as body.content.
+func (a API) handleMergeBranch(w http.ResponseWriter, r *http.Request, unitKey, name string) {
+	if a.Merge == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "branches not configured", nil)
+		return
+	}
+	var req mergeBranchReq
+	if err := j.Read(r, &req); err != nil {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid json: %v", err)
+		return
+	}
+	out, err := a.Merge.MergeBranch(ports.MergeBranchRequest{
+		UnitKey:       unitKey,
+		Source:        name,
+		Target:        req.Into,
+		Label:         req.Label,
+		Content:       req.Content,
+		BaseVersionID: req.BaseVersionID,
+		ActorID:       actorOf(r),
+	})
+	var conflict *domain.MergeConflictError
+	if errors.As(err, &conflict) {
+		j.ErrorCode(w, http.StatusConflict, "MERGE_CONFLICT", err.Error(), map[string]interface{}{
+			"conflicts": conflict.Conflicts,
+			"content":   conflict.Text,
+		})
+		return
+	}
+	if err != nil {
+		writeBranchError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusCreated, out)
+}
+
+// writeBranchError maps the errors of the branch usecases to responses.
+func writeBranchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnitNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
+	case errors.Is(err, domain.ErrBranchNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "BRANCH_NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, domain.ErrVersionNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "VERSION_NOT_FOUND", "version not found", nil)
+	case errors.Is(err, domain.ErrInvalidBranchName):
+		j.ErrorCode(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, domain.ErrBranchAlreadyExists):
+		j.ErrorCode(w, http.StatusConflict, "BRANCH_EXISTS", err.Error(), nil)
+	case errors.Is(err, domain.ErrNothingToMerge):
+		j.ErrorCode(w, http.StatusConflict, "NOTHING_TO_MERGE", err.Error(), nil)
+	case errors.Is(err, domain.ErrConflict):
+		j.ErrorCode(w, http.StatusConflict, "CONFLICT", "branch head moved", nil)
+	case errors.Is(err, domain.ErrAccessDenied):
+		j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+	default:
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+	}
+}
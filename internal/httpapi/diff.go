@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// handleDiffVersions serves GET /v1/units/{key}/diff, the differences
+// between two versions of the unit:
+//
+//	from      version id (default: the predecessor of to)
+//	to        version id (default: the head)
+//	context   unchanged lines around each change (default 3)
+//	format    json (default) or text for a plain unified diff
+func (a API) handleDiffVersions(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.Diff == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "version diff not configured", nil)
+		return
+	}
+	q := r.URL.Query()
+	in := ports.DiffVersionsRequest{UnitKey: unitKey, FromVersionID: q.Get("from"), ToVersionID: q.Get("to"), Context: 3}
+	if v := q.Get("context"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid context: %q", v)
+			return
+		}
+		in.Context = n
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "text" {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid format: %q", format)
+		return
+	}
+
+	out, err := a.Diff.DiffVersions(in)
+	switch {
+	case errors.Is(err, domain.ErrUnitNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
+		return
+	case errors.Is(err, domain.ErrVersionNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "VERSION_NOT_FOUND", "version not found", nil)
+		return
+	case err != nil:
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+		return
+	}
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(out.Unified()))
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
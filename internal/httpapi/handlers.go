@@ -1,8 +1,10 @@
 package httpapi
 
 import (
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +20,35 @@ type API struct {
 	Claims      ports.SetClaimsUsecase
 	Uncertainty ports.SetUncertaintyUsecase
 	Repo        ports.UnitRepository
+
+	// v0.6: GET /v1/audit/verify, GET /v1/audit/stream, GET /v1/audit/events
+	Verify ports.VerifyAuditReportUsecase
+	Stream ports.FollowAuditUsecase
+	Query  ports.AuditQueryUsecase
+
+	// v0.6: GET /v1/units/{key}/diff, POST /v1/units/{key}/revert
+	Diff   ports.DiffVersionsUsecase
+	Revert ports.RevertVersionUsecase
+
+	// v0.6: /v1/units/{key}/branches[/{name}[/merge]]
+	Branches   ports.CreateBranchUsecase
+	BranchList ports.ListBranchesUsecase
+	DelBranch  ports.DeleteBranchUsecase
+	Merge      ports.MergeBranchUsecase
+
+	// v0.6: GET/POST /v1/units/{key}/tags
+	Tags    ports.CreateTagUsecase
+	TagList ports.ListTagsUsecase
+
+	// v0.6: PATCH /v1/units/{key}, GET /v1/units/{key}/history
+	UnitUpdate ports.UpdateUnitUsecase
+	History    ports.ListUnitRevisionsUsecase
+
+	// v0.6: authenticates requests; without it every write is rejected
+	Auth Authenticator
+
+	// v0.6: tenant the usecases above are bound to
+	TenantID string
 }
 
 type createUnitReq struct {
@@ -66,9 +97,13 @@ func (a API) handleCreateUnit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	in := ports.CreateUnitRequest{Key: keyVal, Title: titleVal, Description: descVal}
+	in := ports.CreateUnitRequest{Key: keyVal, Title: titleVal, Description: descVal, ActorID: actorOf(r), TenantID: a.TenantID}
 	out, err := a.Units.CreateUnit(in)
 	if err != nil {
+		if errors.Is(err, domain.ErrAccessDenied) {
+			j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+			return
+		}
 		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
 		return
 	}
@@ -84,6 +119,7 @@ func (a API) handleCreateUnit(w http.ResponseWriter, r *http.Request) {
 type createVersionReq struct {
 	Content string `json:"content"`
 	Note    string `json:"note,omitempty"`
+	Branch  string `json:"branch,omitempty"` // v0.6: default main
 }
 
 type createVersionRes struct {
@@ -104,7 +140,7 @@ func (a API) handleCreateVersion(w http.ResponseWriter, r *http.Request, unitKey
 
 	// label: simple timestamp
 	label := time.Now().UTC().Format("20060102T150405Z")
-	in := ports.CreateVersionRequest{UnitKey: unitKey, Label: label, Content: req.Content}
+	in := ports.CreateVersionRequest{UnitKey: unitKey, Label: label, Content: req.Content, Branch: req.Branch, ActorID: actorOf(r)}
 	out, err := a.Vers.CreateVersion(in)
 	if err != nil {
 		// if unit not found, map to 404
@@ -112,6 +148,14 @@ func (a API) handleCreateVersion(w http.ResponseWriter, r *http.Request, unitKey
 			j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
 			return
 		}
+		if errors.Is(err, domain.ErrBranchNotFound) {
+			j.ErrorCode(w, http.StatusNotFound, "BRANCH_NOT_FOUND", err.Error(), nil)
+			return
+		}
+		if errors.Is(err, domain.ErrAccessDenied) {
+			j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+			return
+		}
 		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
 		return
 	}
@@ -130,13 +174,17 @@ func (a API) handleSetMeaning(w http.ResponseWriter, r *http.Request, unitKey st
 		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid body: %v", err)
 		return
 	}
-	in := ports.SetMeaningRequest{UnitKey: unitKey, VersionID: version, MeaningJSON: body, ActorID: "http"}
+	in := ports.SetMeaningRequest{UnitKey: unitKey, VersionID: version, MeaningJSON: body, ActorID: actorOf(r)}
 	out, err := a.Meaning.SetMeaning(in)
 	if err != nil {
 		if err == domain.ErrUnitNotFound {
 			j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
 			return
 		}
+		if errors.Is(err, domain.ErrAccessDenied) {
+			j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+			return
+		}
 		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
 		return
 	}
@@ -154,13 +202,17 @@ func (a API) handleSetClaims(w http.ResponseWriter, r *http.Request, unitKey str
 		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid body: %v", err)
 		return
 	}
-	in := ports.SetClaimsRequest{UnitKey: unitKey, VersionID: version, BodyBytes: body, ActorID: "http"}
+	in := ports.SetClaimsRequest{UnitKey: unitKey, VersionID: version, BodyBytes: body, ActorID: actorOf(r)}
 	out, err := a.Claims.SetClaims(in)
 	if err != nil {
 		if err == domain.ErrUnitNotFound {
 			j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
 			return
 		}
+		if errors.Is(err, domain.ErrAccessDenied) {
+			j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+			return
+		}
 		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
 		return
 	}
@@ -191,6 +243,7 @@ func (a API) handleGetClaims(w http.ResponseWriter, r *http.Request, unitKey str
 		}
 		u = u2
 	}
+	version = u.ResolveVersionRef(version) // v0.6: a tag names its version
 	if version == "" {
 		version = u.HeadVersionID
 	}
@@ -229,13 +282,17 @@ func (a API) handleSetUncertainty(w http.ResponseWriter, r *http.Request, unitKe
 		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid body: %v", err)
 		return
 	}
-	in := ports.SetUncertaintyRequest{UnitKey: unitKey, VersionID: version, BodyBytes: body, ActorID: "http"}
+	in := ports.SetUncertaintyRequest{UnitKey: unitKey, VersionID: version, BodyBytes: body, ActorID: actorOf(r)}
 	out, err := a.Uncertainty.SetUncertainty(in)
 	if err != nil {
 		if err == domain.ErrUnitNotFound {
 			j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
 			return
 		}
+		if errors.Is(err, domain.ErrAccessDenied) {
+			j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+			return
+		}
 		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
 		return
 	}
@@ -265,6 +322,7 @@ func (a API) handleGetUncertainty(w http.ResponseWriter, r *http.Request, unitKe
 		}
 		u = u2
 	}
+	version = u.ResolveVersionRef(version) // v0.6: a tag names its version
 	if version == "" {
 		version = u.HeadVersionID
 	}
@@ -316,6 +374,7 @@ func (a API) handleGetMeaning(w http.ResponseWriter, r *http.Request, unitKey st
 		}
 		u = u2
 	}
+	version = u.ResolveVersionRef(version) // v0.6: a tag names its version
 	if version == "" {
 		version = u.HeadVersionID
 	}
@@ -346,3 +405,38 @@ func (a API) handleGetMeaning(w http.ResponseWriter, r *http.Request, unitKey st
 		MeaningHash string `json:"meaning_hash"`
 	}{Meaning: m, MeaningHash: v.MeaningHash})
 }
+
+// handleVerifyAudit returns the verification report. Findings are part of a
+// successful response (200 with "ok": false); only a failed verification run
+// is an error.
+func (a API) handleVerifyAudit(w http.ResponseWriter, r *http.Request) {
+	if a.Verify == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "audit verification not configured", nil)
+		return
+	}
+	q := r.URL.Query()
+	in := ports.VerifyAuditRequest{UnitKey: q.Get("unit")}
+	for name, dst := range map[string]*bool{"strictHash": &in.StrictHash, "chain": &in.Chain, "signatures": &in.Signatures, "payloads": &in.Payloads} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid %s: %q", name, v)
+			return
+		}
+		*dst = b
+	}
+
+	out, err := a.Verify.VerifyAuditReport(in)
+	if err != nil {
+		if err == domain.ErrUnitNotFound {
+			j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
+			return
+		}
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
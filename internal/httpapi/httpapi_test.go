@@ -1,26 +1,44 @@
 package httpapi
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	j "digiemu-core/internal/httpapi/json"
 	fsrepo "digiemu-core/internal/kernel/adapters/fs"
 	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
 	usecases "digiemu-core/internal/kernel/usecases"
 )
 
+// testAuth authenticates every request as "tester".
+var testAuth = AuthenticatorFunc(func(*http.Request) (Principal, error) {
+	return Principal{ActorID: "tester", Method: "test"}, nil
+})
+
 func TestAPI_CreateUnit_And_Version(t *testing.T) {
 	dir := t.TempDir()
 	repo := fsrepo.NewUnitRepo(dir)
 	audit := fsrepo.NewAuditLog(dir)
 	clock := mem.RealClock{}
-	api := API{Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}, Vers: usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}}
+	api := API{Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}, Vers: usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}, Auth: testAuth}
 	srv := httptest.NewServer(NewRouter(api))
 	defer srv.Close()
 
@@ -73,7 +91,7 @@ func TestAPI_Version_UnknownUnit_404(t *testing.T) {
 	repo := fsrepo.NewUnitRepo(dir)
 	audit := fsrepo.NewAuditLog(dir)
 	clock := mem.RealClock{}
-	api := API{Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}, Vers: usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}}
+	api := API{Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}, Vers: usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}, Auth: testAuth}
 	srv := httptest.NewServer(NewRouter(api))
 	defer srv.Close()
 
@@ -93,7 +111,7 @@ func TestAPI_ValidationAndErrors(t *testing.T) {
 	repo := fsrepo.NewUnitRepo(dir)
 	audit := fsrepo.NewAuditLog(dir)
 	clock := mem.RealClock{}
-	api := API{Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}, Vers: usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}}
+	api := API{Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}, Vers: usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}, Auth: testAuth}
 	srv := httptest.NewServer(NewRouter(api))
 	defer srv.Close()
 
@@ -164,3 +182,793 @@ func TestAPI_ValidationAndErrors(t *testing.T) {
 	}
 	_ = io.EOF
 }
+
+func TestAPI_VerifyAuditReport(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := mem.FakeClock{Now: 1700000000}
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	verify := usecases.VerifyAudit{Repo: repo, Audit: fsrepo.NewAuditReader(dir), ChainHead: fsrepo.NewAuditChainHead(dir)}
+	auth, err := NewAPIKeys([]APIKey{{ActorID: "alice", KeySHA256: APIKeyHash("alice-key")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := API{Verify: usecases.VerifyAuditReport{Verify: verify, Fingerprint: fsrepo.NewDataDirFingerprint(dir), Clock: clock}, Auth: auth}
+	srv := httptest.NewServer(NewRouter(api))
+	defer srv.Close()
+
+	// a full verification is not served to anonymous callers
+	res, err := http.Get(srv.URL + "/v1/audit/verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous verify: expected 401, got %d", res.StatusCode)
+	}
+
+	get := func(query string, wantStatus int) ports.VerifyAuditReport {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/audit/verify"+query, nil)
+		req.Header.Set("X-API-Key", "alice-key")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get verify: %v", err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != wantStatus {
+			t.Fatalf("expected %d, got %d", wantStatus, res.StatusCode)
+		}
+		var rep ports.VerifyAuditReport
+		_ = json.NewDecoder(res.Body).Decode(&rep)
+		return rep
+	}
+
+	rep := get("?chain=true", http.StatusOK)
+	if !rep.Ok || rep.Schema != ports.VerifyAuditReportSchema || rep.VerifierVersion == "" || rep.Fingerprint == "" {
+		t.Fatalf("unexpected clean report: %+v", rep)
+	}
+	if !rep.Request.Chain || !rep.Summary.ChainChecked || rep.Summary.Units != 1 || rep.GeneratedAtUnix != 1700000000 {
+		t.Fatalf("unexpected request/summary: %+v", rep)
+	}
+
+	// an event about a unit the repo does not have
+	if err := audit.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "unit.created", AtUnix: 1700000001, UnitID: "unit_gone"}); err != nil {
+		t.Fatal(err)
+	}
+	bad := get("", http.StatusOK)
+	if bad.Ok || len(bad.Findings) != 1 || bad.Summary.Errors != 1 {
+		t.Fatalf("expected one finding, got %+v", bad)
+	}
+	if f := bad.Findings[0]; f.Category != ports.FindingOrphan || f.Severity != ports.SeverityError || f.UnitID != "unit_gone" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+	if bad.Fingerprint == rep.Fingerprint {
+		t.Fatalf("fingerprint did not change with the audit log")
+	}
+
+	get("?unit=missing", http.StatusNotFound)
+	get("?chain=maybe", http.StatusBadRequest)
+}
+
+func TestAPI_AuditStream(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := mem.FakeClock{Now: 1700000000}
+	api := API{Stream: usecases.FollowAudit{Audit: fsrepo.NewAuditReader(dir), Interval: 10 * time.Millisecond}}
+	srv := httptest.NewServer(NewRouter(api))
+	defer srv.Close()
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title"}); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func(lastEventID string) (*http.Response, *bufio.Reader) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/audit/stream?type=version.created", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("open stream: %v", err)
+		}
+		return res, bufio.NewReader(res.Body)
+	}
+	// next reads one SSE message and returns its id and event fields
+	next := func(br *bufio.Reader) (id, event string) {
+		t.Helper()
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read stream: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case line == "" && id != "":
+				return id, event
+			}
+		}
+	}
+
+	res, br := open("")
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected stream response: %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	var ids []string
+	for _, c := range []string{"one", "two"} {
+		out, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: c, Content: c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, out.VersionID)
+	}
+	firstEvent, typ := next(br)
+	if typ != "version.created" {
+		t.Fatalf("expected version.created (unit.created is filtered), got %s", typ)
+	}
+	if secondEvent, _ := next(br); secondEvent == firstEvent {
+		t.Fatalf("duplicate event id %s", secondEvent)
+	}
+	res.Body.Close()
+
+	// resume after the first event: only the second one is replayed
+	res, br = open(firstEvent)
+	var got struct {
+		VersionID string `json:"versionId"`
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read resumed stream: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &got)
+			break
+		}
+	}
+	res.Body.Close()
+	if got.VersionID != ids[1] {
+		t.Fatalf("resumed stream sent %s, want %s", got.VersionID, ids[1])
+	}
+
+	res, _ = open("evt_unknown")
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown Last-Event-ID, got %d", res.StatusCode)
+	}
+}
+
+func TestAPI_AuditQuery(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	for i, key := range []string{"dept-a", "dept-b", "other"} {
+		uc := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: mem.FakeClock{Now: int64(1700000000 + i)}}
+		if _, err := uc.CreateUnit(ports.CreateUnitRequest{Key: key, Title: "Title", ActorID: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api := API{Query: usecases.QueryAudit{Audit: fsrepo.NewAuditReader(dir), Repo: repo}}
+	srv := httptest.NewServer(NewRouter(api))
+	defer srv.Close()
+
+	get := func(query string, wantStatus int) ports.AuditQueryResponse {
+		t.Helper()
+		res, err := http.Get(srv.URL + "/v1/audit/events" + query)
+		if err != nil {
+			t.Fatalf("get events: %v", err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != wantStatus {
+			t.Fatalf("%s: expected %d, got %d", query, wantStatus, res.StatusCode)
+		}
+		var out ports.AuditQueryResponse
+		_ = json.NewDecoder(res.Body).Decode(&out)
+		return out
+	}
+
+	page := get("?actor=alice&type=unit.created&unitKeyPrefix=dept-&limit=1", http.StatusOK)
+	if len(page.Events) != 1 || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page = get("?actor=alice&type=unit.created&unitKeyPrefix=dept-&limit=1&cursor="+page.NextCursor, http.StatusOK)
+	if len(page.Events) != 1 || page.Events[0].AtUnix != 1700000001 || page.NextCursor != "" {
+		t.Fatalf("unexpected last page: %+v", page)
+	}
+
+	page = get("?from=2023-11-14T22:13:21Z&to=1700000002", http.StatusOK)
+	if len(page.Events) != 2 {
+		t.Fatalf("expected 2 events in time range, got %+v", page)
+	}
+
+	get("?from=yesterday", http.StatusBadRequest)
+	get("?limit=0", http.StatusBadRequest)
+	get("?from=5&to=1", http.StatusBadRequest)
+	get("?cursor=bogus", http.StatusBadRequest)
+}
+
+func signJWT(t *testing.T, alg, kid string, claims map[string]any, sign func([]byte) []byte) string {
+	t.Helper()
+	hdr, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	body, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(hdr) + "." + base64.RawURLEncoding.EncodeToString(body)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func TestAPI_AuthenticatedWrites(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := mem.FakeClock{Now: 1700000000}
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	bobKey, _ := domain.NewActorKey("bob", base64.StdEncoding.EncodeToString(pub), 1)
+	keys := fsrepo.NewKeyRegistry(dir)
+	if err := keys.RegisterKey(bobKey); err != nil {
+		t.Fatal(err)
+	}
+	secret := bytes.Repeat([]byte("s"), 32)
+	auth, err := AuthFile{
+		APIKeys: []APIKey{{ActorID: "alice", KeySHA256: APIKeyHash("alice-key")}},
+		HS256:   []HS256Secret{{KeyID: "gateway", Secret: base64.StdEncoding.EncodeToString(secret)}},
+	}.Authenticator(JWTAuth{Keys: keys, Audience: "digiemu", Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := API{
+		Units: usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock},
+		Vers:  usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock},
+		Query: usecases.QueryAudit{Audit: fsrepo.NewAuditReader(dir), Repo: repo},
+		Auth:  auth,
+	}
+	srv := httptest.NewServer(NewRouter(api))
+	defer srv.Close()
+
+	hs256 := func(claims map[string]any) string {
+		return signJWT(t, "HS256", "gateway", claims, func(b []byte) []byte {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write(b)
+			return mac.Sum(nil)
+		})
+	}
+	eddsa := func(claims map[string]any) string {
+		return signJWT(t, "EdDSA", bobKey.KeyID, claims, func(b []byte) []byte { return ed25519.Sign(priv, b) })
+	}
+	do := func(method, path, body string, header map[string]string, want int) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != want {
+			b, _ := io.ReadAll(res.Body)
+			t.Fatalf("%s %s %v: expected %d, got %d %s", method, path, header, want, res.StatusCode, b)
+		}
+	}
+	bearer := func(tok string) map[string]string { return map[string]string{"Authorization": "Bearer " + tok} }
+	exp := clock.Now + 300
+
+	// unauthenticated writes are rejected, reads are not
+	do("POST", "/v1/units", `{"key":"alpha","title":"Alpha"}`, nil, http.StatusUnauthorized)
+	do("GET", "/v1/audit/events", "", nil, http.StatusOK)
+	do("GET", "/v1/audit/events", "", map[string]string{"X-API-Key": "wrong"}, http.StatusUnauthorized)
+
+	do("POST", "/v1/units", `{"key":"alpha","title":"Alpha"}`, map[string]string{"X-API-Key": "alice-key"}, http.StatusCreated)
+	do("POST", "/v1/units/alpha/versions", `{"content":"one"}`, bearer(hs256(map[string]any{"sub": "carol", "aud": "digiemu", "exp": exp})), http.StatusCreated)
+	do("POST", "/v1/units/alpha/versions", `{"content":"two"}`, bearer(eddsa(map[string]any{"sub": "bob", "aud": []string{"x", "digiemu"}, "exp": exp})), http.StatusCreated)
+
+	for name, tok := range map[string]string{
+		"expired":       hs256(map[string]any{"sub": "carol", "aud": "digiemu", "exp": clock.Now - 3600}),
+		"no exp":        hs256(map[string]any{"sub": "carol", "aud": "digiemu"}),
+		"other aud":     hs256(map[string]any{"sub": "carol", "aud": "other", "exp": exp}),
+		"other actor":   eddsa(map[string]any{"sub": "mallory", "aud": "digiemu", "exp": exp}),
+		"alg none":      signJWT(t, "none", "", map[string]any{"sub": "carol", "aud": "digiemu", "exp": exp}, func([]byte) []byte { return nil }),
+		"unknown key":   "opaque-key",
+		"tampered body": strings.Replace(hs256(map[string]any{"sub": "carol", "aud": "digiemu", "exp": exp}), ".", ".e30", 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			do("POST", "/v1/units/alpha/versions", `{"content":"x"}`, bearer(tok), http.StatusUnauthorized)
+		})
+	}
+
+	var actors []string
+	_ = fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		actors = append(actors, ev.ActorID)
+		return nil
+	})
+	if strings.Join(actors, ",") != "alice,carol,bob" {
+		t.Fatalf("audit actors %v", actors)
+	}
+}
+
+func TestAPI_PolicyForbidden(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := mem.FakeClock{Now: 1700000000}
+
+	policy := `{"schema":"digiemu.policy.v1","grants":[{"actor":"tester","role":"author","prefix":"open-"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "policy.json"), []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	guard := usecases.PolicyGuard{
+		Units:  usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock},
+		Vers:   usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock},
+		Policy: fsrepo.NewPolicyStore(dir),
+		Repo:   repo,
+		Audit:  audit,
+		Clock:  clock,
+	}
+	srv := httptest.NewServer(NewRouter(API{Units: guard, Vers: guard, Auth: testAuth}))
+	defer srv.Close()
+
+	post := func(path, body string) (int, string) {
+		t.Helper()
+		res, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var e j.ErrorBody
+		_ = json.NewDecoder(res.Body).Decode(&e)
+		return res.StatusCode, e.Error.Code
+	}
+	if st, _ := post("/v1/units", `{"key":"open-a","title":"Open"}`); st != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", st)
+	}
+	if st, code := post("/v1/units", `{"key":"closed-a","title":"Closed"}`); st != http.StatusForbidden || code != "FORBIDDEN" {
+		t.Fatalf("expected 403 FORBIDDEN, got %d %s", st, code)
+	}
+	if st, _ := post("/v1/units/open-a/versions", `{"content":"one"}`); st != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", st)
+	}
+
+	var types []string
+	_ = fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		types = append(types, ev.Type)
+		return nil
+	})
+	if strings.Join(types, ",") != "unit.created,ACCESS_DENIED,version.created" {
+		t.Fatalf("audit types %v", types)
+	}
+}
+
+func TestAPI_Tenants(t *testing.T) {
+	dir := t.TempDir()
+	clock := mem.FakeClock{Now: 1700000000}
+	if _, err := fsrepo.CreateTenant(dir, "acme"); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := NewAPIKeys([]APIKey{
+		{ActorID: "alice", KeySHA256: APIKeyHash("alice-key")},
+		{ActorID: "ann", KeySHA256: APIKeyHash("ann-key"), TenantID: "acme"},
+		{ActorID: "ivan", KeySHA256: APIKeyHash("ivan-key"), TenantID: "initech"},
+		{ActorID: "bob", KeySHA256: APIKeyHash("bob-key"), TenantID: "broken"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func(tenant string) (API, error) {
+		if tenant == "broken" {
+			return API{}, errors.New("recover intents: journal unreadable")
+		}
+		p, err := fsrepo.OpenTenant(dir, tenant)
+		if err != nil {
+			return API{}, err
+		}
+		repo := fsrepo.NewUnitRepo(p)
+		audit := fsrepo.NewAuditLog(p)
+		return API{
+			Units:  usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock},
+			Vers:   usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock},
+			Claims: usecases.SetClaims{Repo: repo, Audit: audit, Clock: clock},
+			Repo:   repo,
+		}, nil
+	}
+	srv := httptest.NewServer(NewTenantRouter(auth, open))
+	defer srv.Close()
+
+	do := func(method, path, body, key, tenant string, want int) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		if tenant != "" {
+			req.Header.Set(TenantHeader, tenant)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != want {
+			b, _ := io.ReadAll(res.Body)
+			t.Fatalf("%s %s as %q on %q: expected %d, got %d %s", method, path, key, tenant, want, res.StatusCode, b)
+		}
+	}
+
+	// the same key in both tenants; callers land in their own tenant
+	do("POST", "/v1/units", `{"key":"shared","title":"Root"}`, "alice-key", "", http.StatusCreated)
+	do("POST", "/v1/units", `{"key":"shared","title":"Acme"}`, "ann-key", "", http.StatusCreated)
+	do("POST", "/v1/units", `{"key":"acme-only","title":"Acme only"}`, "ann-key", "acme", http.StatusCreated)
+	do("POST", "/v1/units/acme-only/versions", `{"content":"x"}`, "ann-key", "", http.StatusCreated)
+
+	// no access to other tenants, whether they exist or not
+	do("POST", "/v1/units/acme-only/versions", `{"content":"x"}`, "alice-key", "acme", http.StatusForbidden)
+	do("POST", "/v1/units/acme-only/versions", `{"content":"x"}`, "alice-key", "", http.StatusNotFound)
+	do("GET", "/v1/units/shared/claims", "", "ann-key", "nope", http.StatusForbidden)
+	do("GET", "/v1/units/shared/claims", "", "", "acme", http.StatusUnauthorized)
+	do("GET", "/v1/units/shared/claims", "", "", "../x", http.StatusBadRequest)
+	do("POST", "/v1/units", `{"key":"abc","title":"Initech"}`, "ivan-key", "", http.StatusNotFound)
+	do("GET", "/healthz", "", "", "", http.StatusOK)
+
+	// a tenant that cannot be opened fails alone
+	do("POST", "/v1/units", `{"key":"abc","title":"Broken"}`, "bob-key", "", http.StatusInternalServerError)
+	do("POST", "/v1/units", `{"key":"after","title":"After"}`, "alice-key", "", http.StatusCreated)
+
+	for tenant, want := range map[string]string{"": "Root", "acme": "Acme"} {
+		p, _ := fsrepo.OpenTenant(dir, tenant)
+		u, ok, err := fsrepo.NewUnitRepo(p).FindUnitByKey("shared")
+		if err != nil || !ok || u.Title != want || u.TenantID != tenant {
+			t.Fatalf("tenant %q: %+v %v %v", tenant, u, ok, err)
+		}
+	}
+	if _, ok, _ := fsrepo.NewUnitRepo(dir).FindUnitByKey("acme-only"); ok {
+		t.Fatal("acme unit visible in the default tenant")
+	}
+}
+
+func TestAPI_DiffVersions(t *testing.T) {
+	repo := mem.NewUnitRepo()
+	audit := mem.NewAuditLog()
+	clock := mem.FakeClock{Now: 1700000000}
+	units := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}
+	vers := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := units.CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc"}); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "a\nb\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "a\nc\n"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewRouter(API{Diff: usecases.DiffVersions{Repo: repo}, Auth: testAuth}))
+	defer srv.Close()
+
+	get := func(path string, want int) []byte {
+		t.Helper()
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		if res.StatusCode != want {
+			t.Fatalf("GET %s: status %d, want %d: %s", path, res.StatusCode, want, b)
+		}
+		return b
+	}
+
+	var out ports.DiffVersionsResponse
+	if err := json.Unmarshal(get("/v1/units/doc/diff", http.StatusOK), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.From.VersionID != v1.VersionID || out.Added != 1 || out.Removed != 1 || len(out.Hunks) != 1 {
+		t.Fatalf("unexpected diff %+v", out)
+	}
+	if text := string(get("/v1/units/doc/diff?format=text&context=0", http.StatusOK)); !strings.Contains(text, "@@ -2 +2 @@\n-b\n+c\n") {
+		t.Fatalf("text diff:\n%s", text)
+	}
+	get("/v1/units/doc/diff?from=ver_missing", http.StatusNotFound)
+	get("/v1/units/nope/diff", http.StatusNotFound)
+	get("/v1/units/doc/diff?context=-1", http.StatusBadRequest)
+}
+
+func TestAPI_RevertVersion(t *testing.T) {
+	repo := mem.NewUnitRepo()
+	audit := mem.NewAuditLog()
+	clock := mem.FakeClock{Now: 1700000000}
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc"}); err != nil {
+		t.Fatal(err)
+	}
+	vers := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	v1, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewRouter(API{Revert: usecases.RevertVersion{Repo: repo, Audit: audit, Clock: clock}, Auth: testAuth}))
+	defer srv.Close()
+
+	post := func(body string, want int) []byte {
+		t.Helper()
+		res, err := http.Post(srv.URL+"/v1/units/doc/revert", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		if res.StatusCode != want {
+			t.Fatalf("status %d, want %d: %s", res.StatusCode, want, b)
+		}
+		return b
+	}
+
+	var out struct {
+		VersionID       string `json:"versionId"`
+		SourceVersionID string `json:"sourceVersionId"`
+		PrevVersionID   string `json:"prevVersionId"`
+	}
+	if err := json.Unmarshal(post(`{"versionId":"`+v1.VersionID+`","baseVersionId":"`+v2.VersionID+`"}`, http.StatusCreated), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.SourceVersionID != v1.VersionID || out.PrevVersionID != v2.VersionID {
+		t.Fatalf("unexpected revert %+v", out)
+	}
+	v, _, _ := repo.FindVersionByID(out.VersionID)
+	if v.Content != "first" || v.ActorID != "tester" {
+		t.Fatalf("unexpected version %+v", v)
+	}
+
+	post(`{"versionId":"`+v1.VersionID+`","baseVersionId":"`+v2.VersionID+`"}`, http.StatusConflict)
+	post(`{"versionId":"ver_missing"}`, http.StatusNotFound)
+	post(`{}`, http.StatusBadRequest)
+}
+
+func TestAPI_Branches(t *testing.T) {
+	repo := mem.NewUnitRepo()
+	audit := mem.NewAuditLog()
+	clock := mem.FakeClock{Now: 1700000000}
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc"}); err != nil {
+		t.Fatal(err)
+	}
+	vers := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "a\nb\nc"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewRouter(API{
+		Vers:       vers,
+		Branches:   usecases.CreateBranch{Repo: repo, Audit: audit, Clock: clock},
+		BranchList: usecases.ListBranches{Repo: repo},
+		DelBranch:  usecases.DeleteBranch{Repo: repo, Audit: audit, Clock: clock},
+		Merge:      usecases.MergeBranch{Repo: repo, Audit: audit, Clock: clock},
+		Auth:       testAuth,
+	}))
+	defer srv.Close()
+
+	do := func(method, path, body string, want int) []byte {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		if res.StatusCode != want {
+			t.Fatalf("%s %s: status %d, want %d: %s", method, path, res.StatusCode, want, b)
+		}
+		return b
+	}
+
+	do("POST", "/v1/units/doc/branches", `{"name":"review"}`, http.StatusCreated)
+	do("POST", "/v1/units/doc/branches", `{"name":"review"}`, http.StatusConflict)
+	do("POST", "/v1/units/doc/branches", `{"name":"Not Valid"}`, http.StatusBadRequest)
+	do("POST", "/v1/units/nope/branches", `{"name":"x1"}`, http.StatusNotFound)
+
+	do("POST", "/v1/units/doc/versions", `{"content":"a\nreview\nc","branch":"review"}`, http.StatusCreated)
+	do("POST", "/v1/units/doc/versions", `{"content":"x","branch":"nope"}`, http.StatusNotFound)
+	do("POST", "/v1/units/doc/versions", `{"content":"a\nmain\nc"}`, http.StatusCreated)
+
+	var list ports.ListBranchesResponse
+	if err := json.Unmarshal(do("GET", "/v1/units/doc/branches", "", http.StatusOK), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Branches) != 2 || list.Branches[0].Name != "main" || list.Branches[1].Name != "review" {
+		t.Fatalf("unexpected branches %+v", list)
+	}
+
+	var conflict struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				Conflicts []map[string]any `json:"conflicts"`
+				Content   string           `json:"content"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	b := do("POST", "/v1/units/doc/branches/review/merge", `{}`, http.StatusConflict)
+	if err := json.Unmarshal(b, &conflict); err != nil {
+		t.Fatal(err)
+	}
+	if conflict.Error.Code != "MERGE_CONFLICT" || len(conflict.Error.Details.Conflicts) != 1 || !strings.Contains(conflict.Error.Details.Content, ">>>>>>> review") {
+		t.Fatalf("unexpected conflict %s", b)
+	}
+
+	var merged ports.MergeBranchResponse
+	if err := json.Unmarshal(do("POST", "/v1/units/doc/branches/review/merge", `{"content":"a\nboth\nc"}`, http.StatusCreated), &merged); err != nil {
+		t.Fatal(err)
+	}
+	v, _, _ := repo.FindVersionByID(merged.VersionID)
+	if !merged.Resolved || v.Content != "a\nboth\nc" || v.ActorID != "tester" || v.MergeParentID == "" {
+		t.Fatalf("unexpected merge %+v %+v", merged, v)
+	}
+	do("POST", "/v1/units/doc/branches/review/merge", `{}`, http.StatusConflict)
+
+	do("DELETE", "/v1/units/doc/branches/review", "", http.StatusOK)
+	do("DELETE", "/v1/units/doc/branches/review", "", http.StatusNotFound)
+	do("DELETE", "/v1/units/doc/branches/main", "", http.StatusBadRequest)
+
+	res, err := usecases.VerifyAudit{Repo: repo, Audit: audit}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+	if err != nil || !res.Ok {
+		t.Fatalf("verify %+v: %v", res, err)
+	}
+}
+
+func TestAPI_Tags(t *testing.T) {
+	repo := mem.NewUnitRepo()
+	audit := mem.NewAuditLog()
+	clock := mem.FakeClock{Now: 1700000000}
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc"}); err != nil {
+		t.Fatal(err)
+	}
+	vers := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	v1, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (usecases.SetMeaning{Repo: repo, Audit: audit, Clock: clock}).SetMeaning(ports.SetMeaningRequest{
+		UnitKey: "doc", VersionID: v1.VersionID, MeaningJSON: []byte(`{"schema_version":"meaning/v1","title":"Tagged"}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewRouter(API{
+		Repo:    repo,
+		Tags:    usecases.CreateTag{Repo: repo, Audit: audit, Clock: clock},
+		TagList: usecases.ListTags{Repo: repo},
+		Auth:    testAuth,
+	}))
+	defer srv.Close()
+
+	post := func(body string, want int) []byte {
+		t.Helper()
+		res, err := http.Post(srv.URL+"/v1/units/doc/tags", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		if res.StatusCode != want {
+			t.Fatalf("status %d, want %d: %s", res.StatusCode, want, b)
+		}
+		return b
+	}
+	get := func(path string, want int) []byte {
+		t.Helper()
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		if res.StatusCode != want {
+			t.Fatalf("GET %s: status %d, want %d: %s", path, res.StatusCode, want, b)
+		}
+		return b
+	}
+
+	var created ports.TagResponse
+	if err := json.Unmarshal(post(`{"name":"approved"}`, http.StatusCreated), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Tag.VersionID != v1.VersionID || created.Tag.ActorID != "tester" {
+		t.Fatalf("unexpected tag %+v", created)
+	}
+	if _, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	post(`{"name":"approved"}`, http.StatusConflict)
+	post(`{"name":"ver_x"}`, http.StatusBadRequest)
+	post(`{"name":"later","versionId":"ver_missing"}`, http.StatusNotFound)
+
+	var list ports.ListTagsResponse
+	if err := json.Unmarshal(get("/v1/units/doc/tags", http.StatusOK), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Tags) != 1 || list.Tags[0].Name != "approved" {
+		t.Fatalf("unexpected tags %+v", list)
+	}
+
+	// read paths take a tag wherever they take a version id
+	if b := get("/v1/units/doc/meaning?version=approved", http.StatusOK); !strings.Contains(string(b), "Tagged") {
+		t.Fatalf("meaning by tag: %s", b)
+	}
+	get("/v1/units/doc/meaning", http.StatusNotFound) // the head has no meaning
+	get("/v1/units/doc/meaning?version=unknown-tag", http.StatusNotFound)
+}
+
+func TestAPI_UpdateUnit(t *testing.T) {
+	repo := mem.NewUnitRepo()
+	audit := mem.NewAuditLog()
+	clock := mem.FakeClock{Now: 1700000000}
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewRouter(API{
+		Repo:       repo,
+		UnitUpdate: usecases.UpdateUnit{Repo: repo, Audit: audit, Clock: clock},
+		History:    usecases.ListUnitRevisions{Repo: repo},
+		Auth:       testAuth,
+	}))
+	defer srv.Close()
+
+	patch := func(path, body string, want int) []byte {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPatch, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		if res.StatusCode != want {
+			t.Fatalf("PATCH %s: status %d, want %d: %s", path, res.StatusCode, want, b)
+		}
+		return b
+	}
+
+	var up ports.UpdateUnitResponse
+	if err := json.Unmarshal(patch("/v1/units/doc", `{"title":"Doc two","description":"second","baseRevision":1}`, http.StatusOK), &up); err != nil {
+		t.Fatal(err)
+	}
+	if up.Revision.Revision != 2 || up.Revision.Title != "Doc two" || up.Revision.ActorID != "tester" {
+		t.Fatalf("unexpected update %+v", up)
+	}
+	patch("/v1/units/doc", `{"title":"Doc three","baseRevision":1}`, http.StatusConflict)
+	patch("/v1/units/doc", `{"description":"second"}`, http.StatusConflict) // nothing to update
+	patch("/v1/units/doc", `{"title":"x"}`, http.StatusBadRequest)
+	patch("/v1/units/doc", `{}`, http.StatusBadRequest)
+	patch("/v1/units/missing", `{"title":"Whatever"}`, http.StatusNotFound)
+
+	res, err := http.Get(srv.URL + "/v1/units/doc/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var hist ports.ListUnitRevisionsResponse
+	if err := json.NewDecoder(res.Body).Decode(&hist); err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || len(hist.Revisions) != 2 || hist.Revisions[0].Title != "Doc" || hist.Revisions[1].Description != "second" {
+		t.Fatalf("unexpected history %d %+v", res.StatusCode, hist)
+	}
+}
@@ -0,0 +1,180 @@
+package httpapi
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// JWTAuth authenticates bearer JWTs (RFC 7519) signed with a local key:
+//
+//	HS256  kid names a shared secret of HS256; sub is the actor
+//	EdDSA  kid is a key id of the actor key registry; sub must be the
+//	       key's actor and the key must not be revoked
+//
+// exp is required; nbf and iat are checked if present, with a minute of
+// leeway. iss and aud are checked if configured. The optional tenant claim
+// names the caller's tenant; as the actor key registry is the default
+// tenant's, only HS256 tokens (minted by a trusted issuer) may carry it.
+type JWTAuth struct {
+	HS256 map[string][]byte      // secrets by kid
+	Keys  ports.ActorKeyRegistry // optional: EdDSA tokens are refused without it
+
+	Issuer   string // optional
+	Audience string // optional
+
+	Clock ports.Clock
+}
+
+const jwtLeeway = 60
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Sub string          `json:"sub"`
+	Iss string          `json:"iss"`
+	Aud json.RawMessage `json:"aud"`
+	Exp *int64          `json:"exp"`
+	Nbf *int64          `json:"nbf"`
+	Iat *int64          `json:"iat"`
+
+	Tenant string `json:"tenant"` // v0.6
+}
+
+func (a JWTAuth) Authenticate(r *http.Request) (Principal, error) {
+	tok, ok := bearerToken(r)
+	if !ok || strings.Count(tok, ".") != 2 {
+		return Principal{}, ErrNoCredentials
+	}
+	p, err := a.verify(tok)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return p, nil
+}
+
+func (a JWTAuth) verify(tok string) (Principal, error) {
+	if a.Clock == nil {
+		return Principal{}, domain.ErrClockNotConfigured
+	}
+	parts := strings.Split(tok, ".")
+	var (
+		hdr    jwtHeader
+		claims jwtClaims
+	)
+	if err := decodeJWTPart(parts[0], &hdr); err != nil {
+		return Principal{}, fmt.Errorf("header: %v", err)
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("claims: %v", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("signature: %v", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	now := a.Clock.NowUnix()
+
+	p := Principal{ActorID: claims.Sub, TenantID: claims.Tenant}
+	switch hdr.Alg {
+	case "HS256":
+		secret, ok := a.HS256[hdr.Kid]
+		if !ok {
+			return Principal{}, fmt.Errorf("unknown hs256 kid %q", hdr.Kid)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return Principal{}, fmt.Errorf("bad signature")
+		}
+		p.Method = "hs256"
+
+	case "EdDSA":
+		if a.Keys == nil {
+			return Principal{}, fmt.Errorf("eddsa tokens not accepted")
+		}
+		k, ok, err := a.Keys.FindKey(hdr.Kid)
+		if err != nil {
+			return Principal{}, err
+		}
+		if !ok {
+			return Principal{}, fmt.Errorf("unknown key %q", hdr.Kid)
+		}
+		if k.RevokedAt(now) {
+			return Principal{}, fmt.Errorf("key %s revoked", k.KeyID)
+		}
+		pub, err := domain.DecodePublicKey(k.PublicKey)
+		if err != nil {
+			return Principal{}, err
+		}
+		if !ed25519.Verify(pub, signed, sig) {
+			return Principal{}, fmt.Errorf("bad signature")
+		}
+		if claims.Sub != k.ActorID {
+			return Principal{}, fmt.Errorf("key %s belongs to %q, not %q", k.KeyID, k.ActorID, claims.Sub)
+		}
+		if claims.Tenant != domain.DefaultTenant {
+			return Principal{}, fmt.Errorf("eddsa tokens cannot name a tenant")
+		}
+		p.Method = "eddsa"
+
+	default:
+		return Principal{}, fmt.Errorf("unsupported alg %q", hdr.Alg)
+	}
+
+	switch {
+	case claims.Sub == "":
+		return Principal{}, fmt.Errorf("sub missing")
+	case domain.ValidateTenantID(claims.Tenant) != nil:
+		return Principal{}, fmt.Errorf("invalid tenant %q", claims.Tenant)
+	case claims.Exp == nil:
+		return Principal{}, fmt.Errorf("exp missing")
+	case now > *claims.Exp+jwtLeeway:
+		return Principal{}, fmt.Errorf("token expired")
+	case claims.Nbf != nil && now < *claims.Nbf-jwtLeeway,
+		claims.Iat != nil && now < *claims.Iat-jwtLeeway:
+		return Principal{}, fmt.Errorf("token not yet valid")
+	case a.Issuer != "" && claims.Iss != a.Issuer:
+		return Principal{}, fmt.Errorf("issuer %q not accepted", claims.Iss)
+	case a.Audience != "" && !audienceHas(claims.Aud, a.Audience):
+		return Principal{}, fmt.Errorf("audience does not include %q", a.Audience)
+	}
+	return p, nil
+}
+
+func decodeJWTPart(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// audienceHas reports whether aud (a string or an array of strings) names want.
+func audienceHas(aud json.RawMessage, want string) bool {
+	var one string
+	if json.Unmarshal(aud, &one) == nil {
+		return one == want
+	}
+	var many []string
+	if json.Unmarshal(aud, &many) != nil {
+		return false
+	}
+	for _, a := range many {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+type revertVersionReq struct {
+	VersionID     string `json:"versionId"`
+	Label         string `json:"label,omitempty"`
+	Sidecars      bool   `json:"sidecars,omitempty"`
+	BaseVersionID string `json:"baseVersionId,omitempty"`
+}
+
+type revertVersionRes struct {
+	VersionID       string `json:"versionId"`
+	UnitID          string `json:"unitId"`
+	SourceVersionID string `json:"sourceVersionId"`
+	PrevVersionID   string `json:"prevVersionId,omitempty"`
+	Label           string `json:"label"`
+	ContentHash     string `json:"contentHash"`
+	MeaningHash     string `json:"meaningHash,omitempty"`
+	ClaimSetHash    string `json:"claimSetHash,omitempty"`
+	UncertaintyHash string `json:"uncertaintyHash,omitempty"`
+}
+
+// handleRevertVersion serves POST /v1/units/{key}/revert: a new version with
+// the content of body.versionId on top of the head (409 CONFLICT if the head
+// is no longer body.baseVersionId).
+func (a API) handleRevertVersion(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.Revert == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "revert not configured", nil)
+		return
+	}
+	var req revertVersionReq
+	if err := j.Read(r, &req); err != nil {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid json: %v", err)
+		return
+	}
+	if strings.TrimSpace(req.VersionID) == "" {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "versionId required")
+		return
+	}
+
+	out, err := a.Revert.RevertVersion(ports.RevertVersionRequest{
+		UnitKey:         unitKey,
+		SourceVersionID: req.VersionID,
+		Label:           req.Label,
+		CarrySidecars:   req.Sidecars,
+		BaseVersionID:   req.BaseVersionID,
+		ActorID:         actorOf(r),
+	})
+	switch {
+	case errors.Is(err, domain.ErrUnitNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
+		return
+	case errors.Is(err, domain.ErrVersionNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "VERSION_NOT_FOUND", "version not found", nil)
+		return
+	case errors.Is(err, domain.ErrConflict):
+		j.ErrorCode(w, http.StatusConflict, "CONFLICT", "unit head moved", nil)
+		return
+	case errors.Is(err, domain.ErrAccessDenied):
+		j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+		return
+	case err != nil:
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+		return
+	}
+	_ = j.Write(w, http.StatusCreated, revertVersionRes{
+		VersionID:       out.VersionID,
+		UnitID:          out.UnitID,
+		SourceVersionID: out.SourceVersionID,
+		PrevVersionID:   out.PrevVersionID,
+		Label:           out.Label,
+		ContentHash:     out.ContentHash,
+		MeaningHash:     out.MeaningHash,
+		ClaimSetHash:    out.ClaimSetHash,
+		UncertaintyHash: out.UncertaintyHash,
+	})
+}
@@ -7,20 +7,65 @@ import (
 
 // simple router using stdlib. expects paths:
 // POST /v1/units
+// PATCH /v1/units/{unitId}
+// GET  /v1/units/{unitId}/history
 // POST /v1/units/{unitId}/versions
-// PUT/GET /v1/units/{unitId}/meaning
-// PUT/GET /v1/units/{unitId}/claims
+// PUT/GET /v1/units/{unitId}/meaning[?version=]  (GET: version id or tag)
+// PUT/GET /v1/units/{unitId}/claims[?version=]
+// PUT/GET /v1/units/{unitId}/uncertainty[?version=]
+// GET  /v1/units/{unitId}/diff[?from=&to=&context=&format=json|text]  (from/to: version id or tag)
+// POST /v1/units/{unitId}/revert
+// GET/POST /v1/units/{unitId}/branches
+// DELETE /v1/units/{unitId}/branches/{name}
+// POST /v1/units/{unitId}/branches/{name}/merge
+// GET/POST /v1/units/{unitId}/tags
+// GET  /v1/audit/verify[?unit=&strictHash=&chain=&signatures=&payloads=]
+// GET  /v1/audit/stream[?type=&unitId=&versionId=]  (Server-Sent Events)
+// GET  /v1/audit/events[?from=&to=&actor=&type=&unitId=&unitKeyPrefix=&versionId=&limit=&cursor=]
 // GET  /healthz
+//
+// Requests are authenticated by api.Auth first; writes and audit
+// verifications need a principal (see auth.go), and callers of another
+// tenant than api.TenantID are turned away (see tenant.go).
 func NewRouter(api API) http.Handler {
+	return api.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || admitTenant(w, r, api.TenantID) {
+			api.routes().ServeHTTP(w, r)
+		}
+	}))
+}
+
+func (api API) routes() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		p := r.URL.Path
 		switch {
 		case r.Method == http.MethodGet && p == "/healthz":
 			api.handleHealth(w, r)
 			return
+		case r.Method == http.MethodGet && p == "/v1/audit/verify":
+			api.handleVerifyAudit(w, r)
+			return
+		case r.Method == http.MethodGet && p == "/v1/audit/stream":
+			api.handleAuditStream(w, r)
+			return
+		case r.Method == http.MethodGet && p == "/v1/audit/events":
+			api.handleQueryAudit(w, r)
+			return
 		case r.Method == http.MethodPost && p == "/v1/units":
 			api.handleCreateUnit(w, r)
 			return
+		case r.Method == http.MethodPatch && strings.HasPrefix(p, "/v1/units/"):
+			parts := strings.Split(p, "/")
+			if len(parts) == 4 && parts[3] != "" {
+				api.handleUpdateUnit(w, r, parts[3])
+				return
+			}
+		case r.Method == http.MethodGet && strings.HasPrefix(p, "/v1/units/") && strings.HasSuffix(p, "/history"):
+			parts := strings.Split(p, "/")
+			if len(parts) == 5 && parts[1] == "v1" && parts[2] == "units" && parts[4] == "history" && parts[3] != "" {
+				api.handleUnitHistory(w, r, parts[3])
+				return
+			}
 		case r.Method == http.MethodPost && strings.HasPrefix(p, "/v1/units/") && strings.HasSuffix(p, "/versions"):
 			// expecting: /v1/units/{key}/versions
 			parts := strings.Split(p, "/")
@@ -35,6 +80,45 @@ func NewRouter(api API) http.Handler {
 				return
 			}
 
+		case r.Method == http.MethodGet && strings.HasPrefix(p, "/v1/units/") && strings.HasSuffix(p, "/diff"):
+			parts := strings.Split(p, "/")
+			if len(parts) == 5 && parts[1] == "v1" && parts[2] == "units" && parts[4] == "diff" && parts[3] != "" {
+				api.handleDiffVersions(w, r, parts[3])
+				return
+			}
+		case r.Method == http.MethodPost && strings.HasPrefix(p, "/v1/units/") && strings.HasSuffix(p, "/revert"):
+			parts := strings.Split(p, "/")
+			if len(parts) == 5 && parts[1] == "v1" && parts[2] == "units" && parts[4] == "revert" && parts[3] != "" {
+				api.handleRevertVersion(w, r, parts[3])
+				return
+			}
+		case (r.Method == http.MethodGet || r.Method == http.MethodPost) && strings.HasPrefix(p, "/v1/units/") && strings.HasSuffix(p, "/tags"):
+			parts := strings.Split(p, "/")
+			if len(parts) == 5 && parts[1] == "v1" && parts[2] == "units" && parts[4] == "tags" && parts[3] != "" {
+				if r.Method == http.MethodPost {
+					api.handleCreateTag(w, r, parts[3])
+					return
+				}
+				api.handleListTags(w, r, parts[3])
+				return
+			}
+		case isBranchesPath(p):
+			// parts: ["", "v1", "units", "{key}", "branches"[, "{name}"[, "merge"]]]
+			parts := strings.Split(p, "/")
+			switch {
+			case len(parts) == 5 && r.Method == http.MethodGet:
+				api.handleListBranches(w, r, parts[3])
+				return
+			case len(parts) == 5 && r.Method == http.MethodPost:
+				api.handleCreateBranch(w, r, parts[3])
+				return
+			case len(parts) == 6 && parts[5] != "" && r.Method == http.MethodDelete:
+				api.handleDeleteBranch(w, r, parts[3], parts[5])
+				return
+			case len(parts) == 7 && parts[5] != "" && parts[6] == "merge" && r.Method == http.MethodPost:
+				api.handleMergeBranch(w, r, parts[3], parts[5])
+				return
+			}
 		case (r.Method == http.MethodPut || r.Method == http.MethodGet) && strings.HasPrefix(p, "/v1/units/") && strings.HasSuffix(p, "/meaning"):
 			parts := strings.Split(p, "/")
 			if len(parts) == 5 && parts[1] == "v1" && parts[2] == "units" && parts[4] == "meaning" {
@@ -84,3 +168,9 @@ func NewRouter(api API) http.Handler {
 		http.NotFound(w, r)
 	})
 }
+
+// isBranchesPath reports whether p is /v1/units/{key}/branches or below.
+func isBranchesPath(p string) bool {
+	parts := strings.Split(p, "/")
+	return len(parts) >= 5 && parts[1] == "v1" && parts[2] == "units" && parts[3] != "" && parts[4] == "branches"
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+type createTagReq struct {
+	Name      string `json:"name"`
+	VersionID string `json:"versionId,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+}
+
+// handleListTags serves GET /v1/units/{key}/tags.
+func (a API) handleListTags(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.TagList == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "tags not configured", nil)
+		return
+	}
+	out, err := a.TagList.ListTags(ports.ListTagsRequest{UnitKey: unitKey})
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
+
+// handleCreateTag serves POST /v1/units/{key}/tags: tags body.versionId, or
+// the head of body.branch (default main). Tags never move: an existing name
+// is 409 TAG_EXISTS.
+func (a API) handleCreateTag(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.Tags == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "tags not configured", nil)
+		return
+	}
+	var req createTagReq
+	if err := j.Read(r, &req); err != nil {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid json: %v", err)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "name required")
+		return
+	}
+	out, err := a.Tags.CreateTag(ports.CreateTagRequest{
+		UnitKey:   unitKey,
+		Name:      req.Name,
+		VersionID: req.VersionID,
+		Branch:    req.Branch,
+		ActorID:   actorOf(r),
+	})
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusCreated, out)
+}
+
+// writeTagError maps the errors of the tag usecases to responses.
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnitNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
+	case errors.Is(err, domain.ErrVersionNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "VERSION_NOT_FOUND", "version not found", nil)
+	case errors.Is(err, domain.ErrBranchNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "BRANCH_NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, domain.ErrInvalidTagName):
+		j.ErrorCode(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, domain.ErrTagAlreadyExists):
+		j.ErrorCode(w, http.StatusConflict, "TAG_EXISTS", err.Error(), nil)
+	case errors.Is(err, domain.ErrAccessDenied):
+		j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+	default:
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+	}
+}
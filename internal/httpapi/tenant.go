@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+)
+
+// v0.6: tenants. A request is served from the data of exactly one tenant:
+// the one named by the TenantHeader, or else the caller's own. Callers may
+// only access their own tenant (Principal.TenantID), and reads of a named
+// tenant need credentials; only the default tenant keeps open reads (but
+// not audit verification, see needsPrincipal).
+
+const TenantHeader = "X-Digiemu-Tenant"
+
+// TenantAPIs returns the API bound to a tenant; it fails with
+// domain.ErrTenantNotFound for tenants that do not exist.
+type TenantAPIs func(tenantID string) (API, error)
+
+// NewTenantRouter authenticates requests with auth, selects their tenant
+// and serves them with the routes of NewRouter on that tenant's API.
+func NewTenantRouter(auth Authenticator, open TenantAPIs) http.Handler {
+	return API{Auth: auth}.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			API{}.handleHealth(w, r)
+			return
+		}
+		tenant := r.Header.Get(TenantHeader)
+		if p, ok := PrincipalFrom(r.Context()); ok && tenant == "" {
+			tenant = p.TenantID
+		}
+		if err := domain.ValidateTenantID(tenant); err != nil {
+			j.ErrorCode(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+			return
+		}
+		if !admitTenant(w, r, tenant) {
+			return
+		}
+		api, err := open(tenant)
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			j.ErrorCode(w, http.StatusNotFound, "TENANT_NOT_FOUND", "tenant not found", nil)
+			return
+		}
+		if err != nil {
+			j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+			return
+		}
+		api.TenantID = tenant
+		api.routes().ServeHTTP(w, r)
+	}))
+}
+
+// admitTenant reports whether the request may access tenant, and answers it
+// if not.
+func admitTenant(w http.ResponseWriter, r *http.Request, tenant string) bool {
+	p, ok := PrincipalFrom(r.Context())
+	switch {
+	case !ok && tenant == domain.DefaultTenant:
+		return true
+	case !ok:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="digiemu"`)
+		j.ErrorCode(w, http.StatusUnauthorized, "UNAUTHENTICATED", "authentication required", nil)
+		return false
+	case p.TenantID != tenant:
+		j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", "tenant not accessible", nil)
+		return false
+	}
+	return true
+}
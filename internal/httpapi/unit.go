@@ -0,0 +1,81 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	j "digiemu-core/internal/httpapi/json"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// updateUnitReq changes the fields that are present; baseRevision is the
+// revision the caller read (0 skips the check).
+type updateUnitReq struct {
+	Title        *string `json:"title,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	BaseRevision int     `json:"baseRevision,omitempty"`
+}
+
+// handleUpdateUnit serves PATCH /v1/units/{key}. A stale baseRevision is
+// 409 CONFLICT.
+func (a API) handleUpdateUnit(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.UnitUpdate == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "unit updates not configured", nil)
+		return
+	}
+	var req updateUnitReq
+	if err := j.Read(r, &req); err != nil {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid json: %v", err)
+		return
+	}
+	if req.Title == nil && req.Description == nil {
+		j.Errorf(w, http.StatusBadRequest, "VALIDATION_ERROR", "title or description required")
+		return
+	}
+	out, err := a.UnitUpdate.UpdateUnit(ports.UpdateUnitRequest{
+		UnitKey:      unitKey,
+		Title:        req.Title,
+		Description:  req.Description,
+		BaseRevision: req.BaseRevision,
+		ActorID:      actorOf(r),
+	})
+	if err != nil {
+		writeUnitError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
+
+// handleUnitHistory serves GET /v1/units/{key}/history: the unit's metadata
+// revisions, oldest first.
+func (a API) handleUnitHistory(w http.ResponseWriter, r *http.Request, unitKey string) {
+	if a.History == nil {
+		j.ErrorCode(w, http.StatusNotImplemented, "NOT_CONFIGURED", "unit history not configured", nil)
+		return
+	}
+	out, err := a.History.ListUnitRevisions(ports.ListUnitRevisionsRequest{UnitKey: unitKey})
+	if err != nil {
+		writeUnitError(w, err)
+		return
+	}
+	_ = j.Write(w, http.StatusOK, out)
+}
+
+// writeUnitError maps the errors of the unit metadata usecases to responses.
+func writeUnitError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnitNotFound):
+		j.ErrorCode(w, http.StatusNotFound, "UNIT_NOT_FOUND", "unit not found", nil)
+	case errors.Is(err, domain.ErrInvalidUnitTitle):
+		j.ErrorCode(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, domain.ErrNothingToUpdate):
+		j.ErrorCode(w, http.StatusConflict, "NOTHING_TO_UPDATE", err.Error(), nil)
+	case errors.Is(err, domain.ErrUnitRevisionConflict):
+		j.ErrorCode(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	case errors.Is(err, domain.ErrAccessDenied):
+		j.ErrorCode(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+	default:
+		j.Errorf(w, http.StatusInternalServerError, "INTERNAL", "%v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeFileAtomic replaces path with data via <path>.tmp + rename. The temp
+// file is fsynced before the rename and the directory after it, so after a
+// crash path holds either the old or the new content. Callers hold the data
+// dir lock, which keeps the fixed temp name free of concurrent writers.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// appendFileSync appends data to path (created if missing) and fsyncs it.
+func appendFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes directory entries (renames) to disk, best-effort:
+// directories cannot be synced on every platform.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
+
+// ndjsonLogs are the append-only files of a data dir.
+var ndjsonLogs = []string{"audit.ndjson", "audit.leaves.ndjson", "audit.sth.ndjson"}
+
+// recoverDataDir cleans up after a crash of an earlier writer. Called with
+// the data dir lock held, so no write is in flight:
+//
+//   - *.json.tmp files are writes whose rename never happened; the target
+//     still has its previous content, the temp file is removed
+//   - a torn last line of an NDJSON log (append interrupted) is completed
+//     with its newline if it is valid JSON, otherwise cut off; appenders
+//     also do this before each append, as the crash may come after this
+//     process ran its recovery
+//   - an audit segment sealed in the manifest but not yet moved is moved
+func recoverDataDir(basePath string) error {
+	err := filepath.WalkDir(basePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() && p == filepath.Join(basePath, tenantsDir) {
+			return fs.SkipDir // tenants recover under their own lock
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".json.tmp") {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range ndjsonLogs {
+		if err := repairTornTail(filepath.Join(basePath, name)); err != nil {
+			return err
+		}
+	}
+	return recoverAuditSegments(basePath)
+}
+
+// repairTornTail fixes an NDJSON file whose last line lacks its newline.
+// Callers hold the data dir lock.
+func repairTornTail(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := st.Size()
+	if size == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, size-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+
+	// find the start of the torn line (bounded like the chain tail reader)
+	start := size - maxAuditLineBytes
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, size-start)
+	if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+		return err
+	}
+	cut := int64(0)
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		cut = start + int64(i) + 1
+	} else if start > 0 {
+		return nil // longer than any valid line; leave it to the verifier
+	}
+
+	if json.Valid(buf[cut-start:]) {
+		if _, err := f.WriteAt([]byte{'\n'}, size); err != nil {
+			return err
+		}
+	} else if err := f.Truncate(cut); err != nil {
+		return err
+	}
+	return f.Sync()
+}
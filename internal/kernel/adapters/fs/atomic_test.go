@@ -0,0 +1,101 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+)
+
+func TestOpen_RemovesStrayTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	units := filepath.Join(dir, "units")
+	if err := os.MkdirAll(units, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stray := []string{
+		filepath.Join(units, "unit_1.json.tmp"),
+		filepath.Join(dir, "audit.head.json.tmp"),
+	}
+	for _, p := range stray {
+		if err := os.WriteFile(p, []byte(`{"half`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsrepo.NewUnitRepo(dir)
+
+	for _, p := range stray {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, err=%v", p, err)
+		}
+	}
+}
+
+func TestOpen_RepairsTornAuditTail(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		tail string
+		want int // events after repair
+	}{
+		{"partial line cut", `{"schema":"digiemu.audit.v1","id":"ev`, 1},
+		{"complete line kept", `{"schema":"digiemu.audit.v1","id":"evt_2","type":"x"}`, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, "audit.ndjson")
+			first := `{"schema":"digiemu.audit.v1","id":"evt_1","type":"x"}` + "\n"
+			if err := os.WriteFile(p, []byte(first+tc.tail), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			fsrepo.NewAuditLog(dir)
+
+			b, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(string(b), "\n") {
+				t.Fatalf("log must end with a newline: %q", b)
+			}
+			if got := strings.Count(string(b), "\n"); got != tc.want {
+				t.Fatalf("expected %d lines, got %d: %q", tc.want, got, b)
+			}
+		})
+	}
+}
+
+// A writer that crashed mid-append after this process recovered the data
+// dir (a CLI next to a running serve) must not break the next append.
+func TestAppend_RepairsTornTailOfAnotherWriter(t *testing.T) {
+	dir := t.TempDir()
+	log := fsrepo.NewAuditLog(dir)
+	if err := log.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_1", Type: "x", AtUnix: 1}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "audit.ndjson"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"schema":"digiemu.audit.v1","id":"evt_`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := log.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_2", Type: "x", AtUnix: 2}); err != nil {
+		t.Fatalf("append after a torn tail: %v", err)
+	}
+	var seqs []int64
+	if err := fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		seqs = append(seqs, ev.Seq)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 2 || seqs[0] != 1 || seqs[1] != 2 {
+		t.Fatalf("expected events 1 and 2 in the chain, got seqs %v", seqs)
+	}
+}
@@ -0,0 +1,162 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+const auditHeadSchema = "digiemu.audit.head.v1"
+
+// maxAuditLineBytes bounds a single NDJSON line when scanning for the chain tail.
+const maxAuditLineBytes = 1 << 20
+
+type auditHeadFile struct {
+	Schema string `json:"schema"`
+	domain.AuditChainHead
+}
+
+// readChainTail returns the head of the hash chain in the log at path.
+// If the log only holds legacy (unchained) events, genesis describes them so
+// the writer can record the start of the chain. Missing log = empty chain.
+func readChainTail(path string) (domain.AuditChainHead, *domain.AuditChainGenesisData, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return domain.AuditChainHead{}, nil, nil
+	}
+	if err != nil {
+		return domain.AuditChainHead{}, nil, err
+	}
+	defer f.Close()
+
+	// fast path: last line is a chained event
+	last, err := lastNonEmptyLine(f)
+	if err != nil {
+		return domain.AuditChainHead{}, nil, err
+	}
+	if last == nil {
+		return domain.AuditChainHead{}, nil, nil
+	}
+	var ev domain.AuditEvent
+	if err := json.Unmarshal(last, &ev); err != nil {
+		return domain.AuditChainHead{}, nil, fmt.Errorf("audit log tail unreadable: %w", err)
+	}
+	if ev.IsChained() {
+		return domain.AuditChainHead{Seq: ev.Seq, Hash: ev.Hash, EventID: ev.ID}, nil, nil
+	}
+
+	// slow path: scan once to find the last chained event or summarize legacy events
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return domain.AuditChainHead{}, nil, err
+	}
+	var head domain.AuditChainHead
+	legacy := domain.AuditChainGenesisData{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), maxAuditLineBytes)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e domain.AuditEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			return domain.AuditChainHead{}, nil, err
+		}
+		if e.IsChained() {
+			head = domain.AuditChainHead{Seq: e.Seq, Hash: e.Hash, EventID: e.ID}
+			continue
+		}
+		if head.Seq == 0 {
+			legacy.LegacyEvents++
+			if legacy.LegacyHash, err = domain.FoldLegacyHash(legacy.LegacyHash, e); err != nil {
+				return domain.AuditChainHead{}, nil, err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return domain.AuditChainHead{}, nil, err
+	}
+	if head.Seq > 0 {
+		return head, nil, nil
+	}
+	return head, &legacy, nil
+}
+
+// lastNonEmptyLine reads backwards from the end of f and returns the last
+// non-empty line (without newline), or nil if the file has none.
+func lastNonEmptyLine(f *os.File) ([]byte, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	const block = 4096
+	end := st.Size()
+	var tail []byte
+	for end > 0 {
+		start := end - block
+		if start < 0 {
+			start = 0
+		}
+		buf := make([]byte, end-start)
+		if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+		end = start
+
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if len(trimmed) == 0 {
+			continue
+		}
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if end == 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+func writeChainHead(path string, h domain.AuditChainHead) error {
+	b, err := json.MarshalIndent(auditHeadFile{Schema: auditHeadSchema, AuditChainHead: h}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b, 0o644)
+}
+
+// AuditChainHead reads the head recorded by AuditLog.Append. It lets the
+// verifier detect a log that was truncated after the last append.
+type AuditChainHead struct {
+	path string
+}
+
+func NewAuditChainHead(basePath string) *AuditChainHead {
+	return &AuditChainHead{path: filepath.Join(basePath, "audit.head.json")}
+}
+
+func (h *AuditChainHead) ReadChainHead() (domain.AuditChainHead, bool, error) {
+	b, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return domain.AuditChainHead{}, false, nil
+	}
+	if err != nil {
+		return domain.AuditChainHead{}, false, err
+	}
+	var f auditHeadFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return domain.AuditChainHead{}, false, fmt.Errorf("audit head invalid: %w", err)
+	}
+	if f.Schema != auditHeadSchema {
+		return domain.AuditChainHead{}, false, errors.New("audit head schema mismatch: " + f.Schema)
+	}
+	return f.AuditChainHead, true, nil
+}
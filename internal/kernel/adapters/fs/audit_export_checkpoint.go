@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"digiemu-core/internal/kernel/ports"
+)
+
+const auditExportCheckpointSchema = "digiemu.audit.export_checkpoint.v1"
+
+// AuditExportCheckpoint implements ports.AuditExportCheckpointStore as
+// exports/<name>.checkpoint.json in the data dir. Like index/ it is not
+// part of the data dir fingerprint.
+type AuditExportCheckpoint struct {
+	basePath string
+	path     string
+}
+
+type auditExportCheckpointFile struct {
+	Schema string `json:"schema"`
+	ports.AuditExportCheckpoint
+}
+
+// NewAuditExportCheckpoint returns the checkpoint of the export called name
+// (one per destination).
+func NewAuditExportCheckpoint(basePath, name string) *AuditExportCheckpoint {
+	return &AuditExportCheckpoint{
+		basePath: basePath,
+		path:     filepath.Join(basePath, "exports", filepath.Base(name)+".checkpoint.json"),
+	}
+}
+
+func (c *AuditExportCheckpoint) LoadCheckpoint() (ports.AuditExportCheckpoint, bool, error) {
+	b, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return ports.AuditExportCheckpoint{}, false, nil
+	}
+	if err != nil {
+		return ports.AuditExportCheckpoint{}, false, err
+	}
+	var f auditExportCheckpointFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return ports.AuditExportCheckpoint{}, false, fmt.Errorf("export checkpoint invalid: %w", err)
+	}
+	if f.Schema != auditExportCheckpointSchema {
+		return ports.AuditExportCheckpoint{}, false, errors.New("export checkpoint schema mismatch: " + f.Schema)
+	}
+	return f.AuditExportCheckpoint, true, nil
+}
+
+func (c *AuditExportCheckpoint) SaveCheckpoint(cp ports.AuditExportCheckpoint) error {
+	b, err := json.MarshalIndent(auditExportCheckpointFile{Schema: auditExportCheckpointSchema, AuditExportCheckpoint: cp}, "", "  ")
+	if err != nil {
+		return err
+	}
+	unlock, err := lockDataDir(c.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, b, 0o644)
+}
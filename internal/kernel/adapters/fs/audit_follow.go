@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: AuditReader implements ports.AuditFollower. The cursor is the byte
+// offset after the last complete line read, so a line another process is
+// still appending is picked up by the next Poll once its newline is written.
+// Offsets count across sealed segments and survive a rotation.
+
+func (r *AuditReader) CursorAfter(afterEventID string) (ports.AuditCursor, error) {
+	f, err := openAuditLogView(r.path)
+	if os.IsNotExist(err) {
+		if afterEventID != "" {
+			return ports.AuditCursor{}, domain.ErrAuditEventNotFound
+		}
+		return ports.AuditCursor{}, nil
+	}
+	if err != nil {
+		return ports.AuditCursor{}, err
+	}
+	defer f.Close()
+
+	// the offset index knows the end of the log and usually the line of
+	// afterEventID; an entry that does not check out falls back to a scan
+	if idx, err := r.offsets.load(f); err == nil {
+		if afterEventID == "" {
+			return ports.AuditCursor{Offset: idx.Size}, nil
+		}
+		if off, ok, err := idx.eventOffset(afterEventID); err == nil && ok {
+			if ev, next, err := readAuditLineAt(f, off); err == nil && ev.ID == afterEventID {
+				return ports.AuditCursor{Offset: next}, nil
+			}
+			r.offsets.discard()
+		}
+	}
+
+	var found bool
+	off, err := readAuditLines(f.reader(0), 0, func(_ int64, ev domain.AuditEvent) bool {
+		found = ev.ID == afterEventID && afterEventID != ""
+		return !found
+	})
+	if err != nil {
+		return ports.AuditCursor{}, err
+	}
+	if afterEventID != "" && !found {
+		return ports.AuditCursor{}, domain.ErrAuditEventNotFound
+	}
+	return ports.AuditCursor{Offset: off}, nil
+}
+
+func (r *AuditReader) Poll(cur ports.AuditCursor, max int) ([]domain.AuditEvent, ports.AuditCursor, error) {
+	if max <= 0 {
+		max = ports.DefaultAuditPollMax
+	}
+	f, err := openAuditLogView(r.path)
+	if os.IsNotExist(err) {
+		return nil, cur, nil
+	}
+	if err != nil {
+		return nil, cur, err
+	}
+	defer f.Close()
+
+	if f.Size() < cur.Offset {
+		return nil, cur, fmt.Errorf("audit log shrank below offset %d (size %d)", cur.Offset, f.Size())
+	}
+	if f.Size() == cur.Offset {
+		return nil, cur, nil
+	}
+
+	var out []domain.AuditEvent
+	n, err := readAuditLines(f.reader(cur.Offset), cur.Offset, func(_ int64, ev domain.AuditEvent) bool {
+		out = append(out, ev)
+		return len(out) < max
+	})
+	if err != nil {
+		return nil, cur, err
+	}
+	return out, ports.AuditCursor{Offset: n}, nil
+}
+
+// readAuditLines decodes the complete lines of rd (positioned at offset)
+// until fn returns false, and returns the offset after the last line
+// consumed. fn gets the offset each line starts at. A trailing line without
+// newline is left unread.
+func readAuditLines(rd io.Reader, offset int64, fn func(off int64, ev domain.AuditEvent) bool) (int64, error) {
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return offset, nil // partial or no line
+		}
+		if err != nil {
+			return offset, err
+		}
+		start := offset
+		offset += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		ev, err := decodeAuditLine(line)
+		if err != nil {
+			return offset, fmt.Errorf("audit log line at offset %d: %w", start, err)
+		}
+		if !fn(start, ev) {
+			return offset, nil
+		}
+	}
+}
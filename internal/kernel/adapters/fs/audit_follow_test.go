@@ -0,0 +1,103 @@
+package fs_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+func TestAuditFollow_PollsAppendedLines(t *testing.T) {
+	dir := t.TempDir()
+	log := fsrepo.NewAuditLog(dir)
+	r := fsrepo.NewAuditReader(dir)
+
+	start, err := r.CursorAfter("")
+	if err != nil || start.Offset != 0 {
+		t.Fatalf("cursor on missing log: %+v %v", start, err)
+	}
+	if _, err := r.CursorAfter("evt_nope"); !errors.Is(err, domain.ErrAuditEventNotFound) {
+		t.Fatalf("expected ErrAuditEventNotFound, got %v", err)
+	}
+
+	ev1 := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_1", Type: "unit.created", AtUnix: 1, UnitID: "u1"}
+	ev2 := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_2", Type: "version.created", AtUnix: 2, UnitID: "u1", VersionID: "v1"}
+	for _, ev := range []domain.AuditEvent{ev1, ev2} {
+		if err := log.Append(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	evs, cur, err := r.Poll(start, 0)
+	if err != nil || len(evs) != 2 || evs[1].ID != "evt_2" {
+		t.Fatalf("poll: %+v %v", evs, err)
+	}
+	if again, same, err := r.Poll(cur, 0); err != nil || len(again) != 0 || same != cur {
+		t.Fatalf("second poll: %+v %+v %v", again, same, err)
+	}
+	after1, err := r.CursorAfter("evt_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evs, _, _ := r.Poll(after1, 0); len(evs) != 1 || evs[0].ID != "evt_2" {
+		t.Fatalf("resume after evt_1: %+v", evs)
+	}
+
+	// a line still being written is not returned until it is complete
+	p := filepath.Join(dir, "audit.ndjson")
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(`{"schema":"digiemu.audit.v1","id":"evt_3",`); err != nil {
+		t.Fatal(err)
+	}
+	if evs, next, err := r.Poll(cur, 0); err != nil || len(evs) != 0 || next != cur {
+		t.Fatalf("partial line returned: %+v %v", evs, err)
+	}
+	if _, err := f.WriteString(`"type":"NOTE","atUnix":3,"actorId":"a"}` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	evs, cur, err = r.Poll(cur, 0)
+	if err != nil || len(evs) != 1 || evs[0].ID != "evt_3" {
+		t.Fatalf("completed line: %+v %v", evs, err)
+	}
+
+	if _, _, err := r.Poll(ports.AuditCursor{Offset: cur.Offset + 1000}, 0); err == nil {
+		t.Fatalf("expected error for cursor beyond the log")
+	}
+}
+
+func TestAuditFollow_PollIsBounded(t *testing.T) {
+	dir := t.TempDir()
+	log := fsrepo.NewAuditLog(dir)
+	r := fsrepo.NewAuditReader(dir)
+	for i := 0; i < 5; i++ {
+		if err := log.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: fmt.Sprintf("evt_%d", i), Type: "NOTE", AtUnix: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cur ports.AuditCursor
+	var got []string
+	for _, want := range []int{2, 2, 1, 0} {
+		evs, next, err := r.Poll(cur, 2)
+		if err != nil || len(evs) != want {
+			t.Fatalf("poll at %d: %d events, want %d (%v)", cur.Offset, len(evs), want, err)
+		}
+		for _, ev := range evs {
+			got = append(got, ev.ID)
+		}
+		cur = next
+	}
+	if strings.Join(got, ",") != "evt_0,evt_1,evt_2,evt_3,evt_4" {
+		t.Fatalf("batches resumed wrongly: %v", got)
+	}
+}
@@ -0,0 +1,149 @@
+package fs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// AuditMerkleStore keeps the Merkle leaves and signed tree heads next to
+// audit.ndjson:
+//
+//	audit.leaves.ndjson  one domain.MerkleLeaf per audit event, in log order
+//	audit.sth.ndjson     published domain.TreeHead records, oldest first
+type AuditMerkleStore struct {
+	basePath   string
+	leavesPath string
+	headsPath  string
+	mu         sync.Mutex
+}
+
+func NewAuditMerkleStore(basePath string) *AuditMerkleStore {
+	return &AuditMerkleStore{
+		basePath:   basePath,
+		leavesPath: filepath.Join(basePath, "audit.leaves.ndjson"),
+		headsPath:  filepath.Join(basePath, "audit.sth.ndjson"),
+	}
+}
+
+func (s *AuditMerkleStore) ListLeaves() ([]domain.MerkleLeaf, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLeaves()
+}
+
+// AppendLeaves appends leaves; the first index must continue the stored ones.
+func (s *AuditMerkleStore) AppendLeaves(leaves []domain.MerkleLeaf) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(leaves) == 0 {
+		return nil
+	}
+	unlock, err := lockDataDir(s.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := s.readLeaves()
+	if err != nil {
+		return err
+	}
+	next := int64(len(existing))
+	for _, l := range leaves {
+		if l.Index != next {
+			return fmt.Errorf("%w: leaf index %d, expected %d", domain.ErrMerkleLeafMismatch, l.Index, next)
+		}
+		next++
+	}
+	return appendNDJSON(s.leavesPath, leaves)
+}
+
+func (s *AuditMerkleStore) ListTreeHeads() ([]domain.TreeHead, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []domain.TreeHead{}
+	err := scanNDJSON(s.headsPath, func(line []byte) error {
+		var th domain.TreeHead
+		if err := json.Unmarshal(line, &th); err != nil {
+			return fmt.Errorf("tree head invalid: %w", err)
+		}
+		out = append(out, th)
+		return nil
+	})
+	return out, err
+}
+
+func (s *AuditMerkleStore) AppendTreeHead(th domain.TreeHead) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockDataDir(s.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return appendNDJSON(s.headsPath, []domain.TreeHead{th})
+}
+
+func (s *AuditMerkleStore) readLeaves() ([]domain.MerkleLeaf, error) {
+	out := []domain.MerkleLeaf{}
+	err := scanNDJSON(s.leavesPath, func(line []byte) error {
+		var l domain.MerkleLeaf
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("merkle leaf invalid: %w", err)
+		}
+		out = append(out, l)
+		return nil
+	})
+	return out, err
+}
+
+// scanNDJSON calls fn for every non-empty line; a missing file is empty.
+func scanNDJSON(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), maxAuditLineBytes)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// appendNDJSON appends recs to path, first repairing a torn last line a
+// crashed writer left (see repairTornTail). Callers hold the data dir lock.
+func appendNDJSON[T any](path string, recs []T) error {
+	if err := repairTornTail(path); err != nil {
+		return err
+	}
+	var out []byte
+	for _, r := range recs {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		out = append(out, b...)
+		out = append(out, '\n')
+	}
+	return appendFileSync(path, out)
+}
@@ -0,0 +1,344 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// v0.6: event id / unit id -> byte offset index of the audit log (offsets
+// count across sealed segments, see audit_segments.go), so readers that need
+// a few events of a large log seek to their lines instead of scanning it.
+// Like the other files under index/ it is derived data:
+//
+//   - it covers the log up to the end of its last record; readers index the
+//     lines appended since (catch-up) and append their records best-effort
+//     under the data dir lock, the records already written never change
+//   - it is rebuilt when missing, corrupt or no longer matching the log
+//     (log shorter than the index, last indexed event not at its offset)
+//   - it is never trusted blindly: callers check the event they read at an
+//     offset and fall back to a scan on mismatch
+//
+// index/audit_offsets.idx is a 16 byte header (auditOffsetsMagic, then
+// zeros) followed by one fixed-width record per log line, in log order:
+//
+//	offset  int64 big endian   first byte of the line
+//	end     int64 big endian   first byte after the line
+//	event   [16]byte           sha256(event id), truncated
+//	unit    [16]byte           sha256(unit id), truncated; zero without unit
+//
+// A torn record at the end (crash during an append) is ignored and
+// overwritten by the next append.
+
+const (
+	auditOffsetsMagic  = "DGAOFF01"
+	auditOffsetsHeader = 16
+	auditOffsetsRecord = 48
+)
+
+type auditOffsetKey [16]byte
+
+func auditOffsetKeyOf(id string) auditOffsetKey {
+	var k auditOffsetKey
+	if id != "" {
+		sum := sha256.Sum256([]byte(id))
+		copy(k[:], sum[:])
+	}
+	return k
+}
+
+type auditOffsetRecord struct {
+	Off, End int64
+	Event    auditOffsetKey
+	Unit     auditOffsetKey
+}
+
+func (rec auditOffsetRecord) encode(b []byte) {
+	binary.BigEndian.PutUint64(b[0:8], uint64(rec.Off))
+	binary.BigEndian.PutUint64(b[8:16], uint64(rec.End))
+	copy(b[16:32], rec.Event[:])
+	copy(b[32:48], rec.Unit[:])
+}
+
+func decodeAuditOffsetRecord(b []byte) auditOffsetRecord {
+	var rec auditOffsetRecord
+	rec.Off = int64(binary.BigEndian.Uint64(b[0:8]))
+	rec.End = int64(binary.BigEndian.Uint64(b[8:16]))
+	copy(rec.Event[:], b[16:32])
+	copy(rec.Unit[:], b[32:48])
+	return rec
+}
+
+type auditOffsetIndex struct {
+	basePath string
+	path     string
+}
+
+// newAuditOffsetIndex returns the offset index of the audit log at logPath.
+func newAuditOffsetIndex(logPath string) *auditOffsetIndex {
+	base := filepath.Dir(logPath)
+	return &auditOffsetIndex{basePath: base, path: filepath.Join(base, "index", "audit_offsets.idx")}
+}
+
+// auditOffsets is the index as loaded for one view of the log: the first n
+// records of the file, then the records of the lines indexed by this load
+// (kept in memory, whether or not saving them worked), covering Size bytes.
+type auditOffsets struct {
+	path string
+	n    int64
+	tail []auditOffsetRecord
+	Size int64
+}
+
+// load returns the index of the open log f, brought up to date with its
+// complete lines. New records are appended to the file best-effort.
+func (x *auditOffsetIndex) load(f *auditLogView) (auditOffsets, error) {
+	size := f.Size()
+
+	idx, err := x.open(f, size)
+	fresh := err != nil
+	if fresh {
+		idx = auditOffsets{path: x.path}
+	}
+	if idx.Size == size {
+		return idx, nil
+	}
+
+	var recs []auditOffsetRecord
+	end, err := readAuditLines(io.NewSectionReader(f, idx.Size, size-idx.Size), idx.Size, func(off int64, ev domain.AuditEvent) bool {
+		if n := len(recs); n > 0 {
+			recs[n-1].End = off
+		}
+		recs = append(recs, auditOffsetRecord{Off: off, Event: auditOffsetKeyOf(ev.ID), Unit: auditOffsetKeyOf(ev.UnitID)})
+		return true
+	})
+	if err != nil {
+		return auditOffsets{}, err
+	}
+	if len(recs) == 0 { // only a partial last line (or blank lines) appended
+		return idx, nil
+	}
+	recs[len(recs)-1].End = end
+	x.save(idx, recs, fresh)
+	idx.tail, idx.Size = recs, end
+	return idx, nil
+}
+
+// open validates the index file against the log and returns it without
+// its torn tail, if any.
+func (x *auditOffsetIndex) open(f io.ReaderAt, size int64) (auditOffsets, error) {
+	file, err := os.Open(x.path)
+	if err != nil {
+		return auditOffsets{}, err
+	}
+	defer file.Close()
+	st, err := file.Stat()
+	if err != nil {
+		return auditOffsets{}, err
+	}
+	head := make([]byte, auditOffsetsHeader)
+	if _, err := io.ReadFull(file, head); err != nil || string(head[:len(auditOffsetsMagic)]) != auditOffsetsMagic {
+		return auditOffsets{}, errors.New("audit offset index header invalid")
+	}
+	idx := auditOffsets{path: x.path, n: (st.Size() - auditOffsetsHeader) / auditOffsetsRecord}
+	if idx.n == 0 {
+		return idx, nil
+	}
+	last, err := readAuditOffsetRecord(file, idx.n-1)
+	if err != nil {
+		return auditOffsets{}, err
+	}
+	if last.End > size || last.End <= last.Off {
+		return auditOffsets{}, errors.New("audit offset index ends beyond the log")
+	}
+	ev, _, err := readAuditLineAt(f, last.Off)
+	if err != nil || auditOffsetKeyOf(ev.ID) != last.Event {
+		return auditOffsets{}, errors.New("audit offset index does not match the log")
+	}
+	idx.Size = last.End
+	return idx, nil
+}
+
+func readAuditOffsetRecord(file io.ReaderAt, i int64) (auditOffsetRecord, error) {
+	b := make([]byte, auditOffsetsRecord)
+	if _, err := file.ReadAt(b, auditOffsetsHeader+i*auditOffsetsRecord); err != nil {
+		return auditOffsetRecord{}, err
+	}
+	return decodeAuditOffsetRecord(b), nil
+}
+
+// save appends recs (the lines after idx) under the data dir lock, or
+// writes a new file with them if fresh; errors are not fatal for readers.
+// Records another reader appended in the meantime are not written twice.
+func (x *auditOffsetIndex) save(idx auditOffsets, recs []auditOffsetRecord, fresh bool) {
+	unlock, err := lockDataDir(x.basePath)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	if err := os.MkdirAll(filepath.Dir(x.path), 0o755); err != nil {
+		return
+	}
+
+	if fresh {
+		b := make([]byte, auditOffsetsHeader+len(recs)*auditOffsetsRecord)
+		copy(b, auditOffsetsMagic)
+		for i, rec := range recs {
+			rec.encode(b[auditOffsetsHeader+i*auditOffsetsRecord:])
+		}
+		if writeFileAtomic(x.path, b, 0o644) == nil {
+			// the JSON index of earlier versions, now unused
+			_ = os.Remove(filepath.Join(filepath.Dir(x.path), "audit_offsets.json"))
+		}
+		return
+	}
+
+	file, err := os.OpenFile(x.path, os.O_RDWR, 0o644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	st, err := file.Stat()
+	if err != nil {
+		return
+	}
+	n := (st.Size() - auditOffsetsHeader) / auditOffsetsRecord
+	if n < idx.n {
+		return // replaced by a rebuild since it was loaded
+	}
+	if n > idx.n {
+		last, err := readAuditOffsetRecord(file, n-1)
+		if err != nil {
+			return
+		}
+		for len(recs) > 0 && recs[0].Off < last.End {
+			recs = recs[1:]
+		}
+	}
+	if len(recs) == 0 {
+		return
+	}
+	b := make([]byte, len(recs)*auditOffsetsRecord)
+	for i, rec := range recs {
+		rec.encode(b[i*auditOffsetsRecord:])
+	}
+	_, _ = file.WriteAt(b, auditOffsetsHeader+n*auditOffsetsRecord)
+}
+
+// discard removes an index found to be wrong, so the next load rebuilds it.
+func (x *auditOffsetIndex) discard() {
+	unlock, err := lockDataDir(x.basePath)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	_ = os.Remove(x.path)
+}
+
+// scan calls fn with every record, in log order.
+func (idx auditOffsets) scan(fn func(auditOffsetRecord) bool) error {
+	if idx.n > 0 {
+		file, err := os.Open(idx.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r := bufio.NewReaderSize(io.NewSectionReader(file, auditOffsetsHeader, idx.n*auditOffsetsRecord), 64<<10)
+		b := make([]byte, auditOffsetsRecord)
+		for i := int64(0); i < idx.n; i++ {
+			if _, err := io.ReadFull(r, b); err != nil {
+				return err
+			}
+			if !fn(decodeAuditOffsetRecord(b)) {
+				return nil
+			}
+		}
+	}
+	for _, rec := range idx.tail {
+		if !fn(rec) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// unitOffsets returns the offsets of the lines of any of unitIDs from the
+// offset from on, in log order.
+func (idx auditOffsets) unitOffsets(unitIDs []string, from int64) ([]int64, error) {
+	keys := make(map[auditOffsetKey]bool, len(unitIDs))
+	for _, id := range unitIDs {
+		keys[auditOffsetKeyOf(id)] = true
+	}
+	var out []int64
+	err := idx.scan(func(rec auditOffsetRecord) bool {
+		if rec.Off >= from && keys[rec.Unit] {
+			out = append(out, rec.Off)
+		}
+		return true
+	})
+	return out, err
+}
+
+// eventOffset returns the offset of the line of eventID. The search runs
+// backwards: followers resume close to the end of the log.
+func (idx auditOffsets) eventOffset(eventID string) (int64, bool, error) {
+	k := auditOffsetKeyOf(eventID)
+	for i := len(idx.tail) - 1; i >= 0; i-- {
+		if idx.tail[i].Event == k {
+			return idx.tail[i].Off, true, nil
+		}
+	}
+	if idx.n == 0 {
+		return 0, false, nil
+	}
+	file, err := os.Open(idx.path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	const chunk = 1024
+	b := make([]byte, chunk*auditOffsetsRecord)
+	for hi := idx.n; hi > 0; {
+		lo := hi - chunk
+		if lo < 0 {
+			lo = 0
+		}
+		buf := b[:(hi-lo)*auditOffsetsRecord]
+		if _, err := file.ReadAt(buf, auditOffsetsHeader+lo*auditOffsetsRecord); err != nil {
+			return 0, false, err
+		}
+		for i := hi - lo - 1; i >= 0; i-- {
+			rec := decodeAuditOffsetRecord(buf[i*auditOffsetsRecord:])
+			if rec.Event == k {
+				return rec.Off, true, nil
+			}
+		}
+		hi = lo
+	}
+	return 0, false, nil
+}
+
+// readAuditLineAt decodes the line starting at off and returns the event
+// and the offset after the line.
+func readAuditLineAt(f io.ReaderAt, off int64) (domain.AuditEvent, int64, error) {
+	line, err := bufio.NewReader(io.NewSectionReader(f, off, 1<<62)).ReadBytes('\n')
+	if err == io.EOF {
+		return domain.AuditEvent{}, 0, fmt.Errorf("audit log: no complete line at offset %d", off)
+	}
+	if err != nil {
+		return domain.AuditEvent{}, 0, err
+	}
+	ev, err := decodeAuditLine(bytes.TrimSpace(line))
+	if err != nil {
+		return domain.AuditEvent{}, 0, fmt.Errorf("audit log line at offset %d: %w", off, err)
+	}
+	return ev, off + int64(len(line)), nil
+}
@@ -0,0 +1,199 @@
+package fs_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// seedAuditLog appends n events spread over units u0..u2 and returns them.
+func seedAuditLog(t *testing.T, dir string, from, n int) []domain.AuditEvent {
+	t.Helper()
+	log := fsrepo.NewAuditLog(dir)
+	var out []domain.AuditEvent
+	for i := from; i < from+n; i++ {
+		ev := domain.AuditEvent{
+			Schema: "digiemu.audit.v1",
+			ID:     fmt.Sprintf("evt_%04d", i),
+			Type:   "version.created",
+			AtUnix: int64(i),
+			UnitID: fmt.Sprintf("u%d", i%3),
+			Data:   map[string]any{"note": fmt.Sprintf("event %d of the seeded log", i)},
+		}
+		if err := log.Append(ev); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+func eventIDs(evs []domain.AuditEvent) []string {
+	ids := make([]string, len(evs))
+	for i, ev := range evs {
+		ids[i] = ev.ID
+	}
+	return ids
+}
+
+func sameIDs(t *testing.T, what string, got, want []domain.AuditEvent) {
+	t.Helper()
+	g, w := eventIDs(got), eventIDs(want)
+	if fmt.Sprint(g) != fmt.Sprint(w) {
+		t.Fatalf("%s: got %v, want %v", what, g, w)
+	}
+}
+
+func TestAuditTail_ReadsBackwardsAcrossBlocks(t *testing.T) {
+	dir := t.TempDir()
+	all := seedAuditLog(t, dir, 0, 600) // several 64 KiB blocks
+
+	if st, err := os.Stat(filepath.Join(dir, "audit.ndjson")); err != nil || st.Size() < 2*64<<10 {
+		t.Fatalf("seeded log too small for the test: %v %v", st, err)
+	}
+
+	tail := fsrepo.NewAuditTail(dir)
+	got, err := tail.Tail(ports.AuditTailRequest{N: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "last 50", got, all[len(all)-50:])
+
+	var u1 []domain.AuditEvent
+	for _, ev := range all {
+		if ev.UnitID == "u1" {
+			u1 = append(u1, ev)
+		}
+	}
+	got, err = tail.Tail(ports.AuditTailRequest{N: 150, UnitID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "last 150 of u1", got, u1[len(u1)-150:])
+
+	got, err = tail.Tail(ports.AuditTailRequest{N: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "whole log", got, all)
+
+	// a line still being appended is skipped
+	f, err := os.OpenFile(filepath.Join(dir, "audit.ndjson"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(`{"schema":"digiemu.audit.v1","id":"evt_torn",`); err != nil {
+		t.Fatal(err)
+	}
+	got, err = tail.Tail(ports.AuditTailRequest{N: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "tail with torn line", got, all[len(all)-2:])
+}
+
+func TestAuditByUnit_UsesAndRepairsOffsetIndex(t *testing.T) {
+	dir := t.TempDir()
+	all := seedAuditLog(t, dir, 0, 30)
+	byUnit := func(evs []domain.AuditEvent, unitID string) []domain.AuditEvent {
+		var out []domain.AuditEvent
+		for _, ev := range evs {
+			if ev.UnitID == unitID {
+				out = append(out, ev)
+			}
+		}
+		return out
+	}
+
+	r := fsrepo.NewAuditByUnitReader(dir)
+	got, err := r.ListByUnitID("u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "first read", got, byUnit(all, "u2"))
+
+	indexPath := filepath.Join(dir, "index", "audit_offsets.idx")
+	st, err := os.Stat(indexPath)
+	if err != nil {
+		t.Fatalf("offset index not written: %v", err)
+	}
+
+	// events appended later are indexed on the next read, by appending
+	// records to the file (16 byte header, 48 bytes per line)
+	all = append(all, seedAuditLog(t, dir, 30, 6)...)
+	got, err = r.ListByUnitID("u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "after append", got, byUnit(all, "u2"))
+	if st2, err := os.Stat(indexPath); err != nil || st2.Size() != st.Size()+6*48 || st2.Size() != 16+36*48 {
+		t.Fatalf("expected 6 records appended, got %v %v", st2, err)
+	}
+
+	// stale entries: the record of evt_0000 (u0) points at evt_0001 (u1)
+	b, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(b[16:32], b[64:80])
+	if err := os.WriteFile(indexPath, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = r.ListByUnitID("u0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "stale index", got, byUnit(all, "u0"))
+
+	// ... which was dropped and is rebuilt by the next read
+	if _, err := r.ListByUnitID("u0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a torn last record is overwritten by the next append
+	if b, err = os.ReadFile(indexPath); err != nil || len(b) != 16+36*48 {
+		t.Fatalf("stale index not rebuilt: %d bytes, %v", len(b), err)
+	}
+	if err := os.WriteFile(indexPath, b[:len(b)-20], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	all = append(all, seedAuditLog(t, dir, 36, 1)...)
+	got, err = r.ListByUnitID("u0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "torn index", got, byUnit(all, "u0"))
+	if st, err := os.Stat(indexPath); err != nil || st.Size() != 16+37*48 {
+		t.Fatalf("torn record not replaced: %v %v", st, err)
+	}
+
+	// a corrupt index is rebuilt
+	if err := os.WriteFile(indexPath, []byte("{not an index"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = r.ListByUnitID("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "corrupt index", got, byUnit(all, "u1"))
+	if st, err := os.Stat(indexPath); err != nil || st.Size() != 16+int64(len(all))*48 {
+		t.Fatalf("index not rebuilt: %v %v", st, err)
+	}
+
+	// the follower resumes through the index as well
+	cur, err := fsrepo.NewAuditReader(dir).CursorAfter(all[33].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs, _, err := fsrepo.NewAuditReader(dir).Poll(cur, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "resume", evs, all[34:])
+}
@@ -0,0 +1,107 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: AuditReader implements ports.AuditQuery; the cursor is the byte
+// offset of a line. Queries restricted to units read the lines listed in the
+// audit offset index instead of scanning the log.
+
+func (r *AuditReader) QueryAudit(f ports.AuditQueryFilter, cur ports.AuditCursor, limit int) ([]domain.AuditEvent, ports.AuditCursor, bool, error) {
+	file, err := openAuditLogView(r.path)
+	if os.IsNotExist(err) {
+		return []domain.AuditEvent{}, ports.AuditCursor{}, false, nil
+	}
+	if err != nil {
+		return nil, ports.AuditCursor{}, false, err
+	}
+	defer file.Close()
+
+	if cur.Offset > file.Size() {
+		return nil, ports.AuditCursor{}, false, fmt.Errorf("%w: offset %d beyond end of log", domain.ErrInvalidAuditCursor, cur.Offset)
+	}
+
+	if len(f.UnitIDs) > 0 {
+		if idx, err := r.offsets.load(file); err == nil {
+			if evs, next, more, ok := queryIndexed(file, idx, f, cur, limit); ok {
+				return evs, next, more, nil
+			}
+			r.offsets.discard()
+		}
+	}
+
+	out := []domain.AuditEvent{}
+	var next ports.AuditCursor
+	var more bool
+	_, err = readAuditLines(file.reader(cur.Offset), cur.Offset, func(off int64, ev domain.AuditEvent) bool {
+		if !matchAuditQuery(ev, f) {
+			return true
+		}
+		if len(out) == limit {
+			next, more = ports.AuditCursor{Offset: off}, true
+			return false
+		}
+		out = append(out, ev)
+		return true
+	})
+	if err != nil {
+		return nil, ports.AuditCursor{}, false, err
+	}
+	return out, next, more, nil
+}
+
+// queryIndexed answers a unit-restricted query from the offsets the index
+// lists for the units. ok is false if an entry turns out to be stale.
+func queryIndexed(file *auditLogView, idx auditOffsets, f ports.AuditQueryFilter, cur ports.AuditCursor, limit int) ([]domain.AuditEvent, ports.AuditCursor, bool, bool) {
+	offs, err := idx.unitOffsets(f.UnitIDs, cur.Offset)
+	if err != nil {
+		return nil, ports.AuditCursor{}, false, false
+	}
+
+	out := []domain.AuditEvent{}
+	for _, off := range offs {
+		ev, _, err := readAuditLineAt(file, off)
+		if err != nil || !anyOf(f.UnitIDs, ev.UnitID) {
+			return nil, ports.AuditCursor{}, false, false
+		}
+		if !matchAuditQuery(ev, f) {
+			continue
+		}
+		if len(out) == limit {
+			return out, ports.AuditCursor{Offset: off}, true, true
+		}
+		out = append(out, ev)
+	}
+	return out, ports.AuditCursor{}, false, true
+}
+
+func matchAuditQuery(ev domain.AuditEvent, f ports.AuditQueryFilter) bool {
+	if f.FromUnix != 0 && ev.AtUnix < f.FromUnix {
+		return false
+	}
+	if f.ToUnix != 0 && ev.AtUnix > f.ToUnix {
+		return false
+	}
+	if f.VersionID != "" && ev.VersionID != f.VersionID {
+		return false
+	}
+	return anyOf(f.ActorIDs, ev.ActorID) && anyOf(f.Types, domain.CanonicalAuditEventType(ev.Type)) && anyOf(f.UnitIDs, ev.UnitID)
+}
+
+// anyOf reports whether s is in set; an empty set matches everything.
+func anyOf(set []string, s string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	for _, v := range set {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
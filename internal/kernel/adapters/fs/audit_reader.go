@@ -10,16 +10,19 @@ import (
 )
 
 // AuditReader scans the append-only NDJSON audit log.
+// v0.6: sealed segments are read first, then the active segment.
 type AuditReader struct {
-	path string
+	path    string
+	offsets *auditOffsetIndex
 }
 
 func NewAuditReader(basePath string) *AuditReader {
-	return &AuditReader{path: filepath.Join(basePath, "audit.ndjson")}
+	path := filepath.Join(basePath, "audit.ndjson")
+	return &AuditReader{path: path, offsets: newAuditOffsetIndex(path)}
 }
 
 func (r *AuditReader) Scan(fn func(ev domain.AuditEvent) error) error {
-	f, err := os.Open(r.path)
+	v, err := openAuditLogView(r.path)
 	if os.IsNotExist(err) {
 		// no audit log yet: treat as empty
 		return nil
@@ -27,16 +30,16 @@ func (r *AuditReader) Scan(fn func(ev domain.AuditEvent) error) error {
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	defer v.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := bufio.NewScanner(v.reader(0))
 	for sc.Scan() {
 		line := sc.Bytes()
 		if len(line) == 0 {
 			continue
 		}
-		var ev domain.AuditEvent
-		if err := json.Unmarshal(line, &ev); err != nil {
+		ev, err := decodeAuditLine(line)
+		if err != nil {
 			return err
 		}
 		if err := fn(ev); err != nil {
@@ -48,3 +51,16 @@ func (r *AuditReader) Scan(fn func(ev domain.AuditEvent) error) error {
 	}
 	return nil
 }
+
+// decodeAuditLine decodes one line of the log.
+// v0.6: Data is decoded into the payload type registered for the event type
+// (domain.NormalizeAuditPayload). Unknown or malformed payloads keep their
+// generic form; audit verify --payloads reports them.
+func decodeAuditLine(line []byte) (domain.AuditEvent, error) {
+	var ev domain.AuditEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return domain.AuditEvent{}, err
+	}
+	_ = domain.NormalizeAuditPayload(&ev)
+	return ev, nil
+}
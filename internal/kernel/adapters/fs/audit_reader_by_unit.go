@@ -2,7 +2,6 @@ package fs
 
 import (
 	"bufio"
-	"encoding/json"
 	"os"
 	"path/filepath"
 
@@ -10,15 +9,21 @@ import (
 )
 
 type AuditByUnitReader struct {
-	path string
+	path    string
+	offsets *auditOffsetIndex
 }
 
 func NewAuditByUnitReader(basePath string) *AuditByUnitReader {
-	return &AuditByUnitReader{path: filepath.Join(basePath, "audit.ndjson")}
+	path := filepath.Join(basePath, "audit.ndjson")
+	return &AuditByUnitReader{path: path, offsets: newAuditOffsetIndex(path)}
 }
 
+// ListByUnitID returns the events of unitID in log order.
+// v0.6: the lines are read at the offsets the audit offset index lists for
+// the unit; if the index cannot be loaded or an entry is stale, the whole
+// log is scanned as before.
 func (r *AuditByUnitReader) ListByUnitID(unitID string) ([]domain.AuditEvent, error) {
-	f, err := os.Open(r.path)
+	f, err := openAuditLogView(r.path)
 	if os.IsNotExist(err) {
 		return []domain.AuditEvent{}, nil
 	}
@@ -27,15 +32,30 @@ func (r *AuditByUnitReader) ListByUnitID(unitID string) ([]domain.AuditEvent, er
 	}
 	defer f.Close()
 
+	if offs, err := r.unitOffsets(f, unitID); err == nil {
+		out := make([]domain.AuditEvent, 0, len(offs))
+		for _, off := range offs {
+			ev, _, err := readAuditLineAt(f, off)
+			if err != nil || ev.UnitID != unitID {
+				break
+			}
+			out = append(out, ev)
+		}
+		if len(out) == len(offs) {
+			return out, nil
+		}
+		r.offsets.discard()
+	}
+
 	out := make([]domain.AuditEvent, 0, 64)
-	sc := bufio.NewScanner(f)
+	sc := bufio.NewScanner(f.reader(0))
 	for sc.Scan() {
 		line := sc.Bytes()
 		if len(line) == 0 {
 			continue
 		}
-		var ev domain.AuditEvent
-		if err := json.Unmarshal(line, &ev); err != nil {
+		ev, err := decodeAuditLine(line)
+		if err != nil {
 			return nil, err
 		}
 		if ev.UnitID == unitID {
@@ -47,3 +67,11 @@ func (r *AuditByUnitReader) ListByUnitID(unitID string) ([]domain.AuditEvent, er
 	}
 	return out, nil
 }
+
+func (r *AuditByUnitReader) unitOffsets(f *auditLogView, unitID string) ([]int64, error) {
+	idx, err := r.offsets.load(f)
+	if err != nil {
+		return nil, err
+	}
+	return idx.unitOffsets([]string{unitID}, 0)
+}
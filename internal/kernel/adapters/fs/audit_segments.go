@@ -0,0 +1,513 @@
+package fs
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: audit log segments. audit.ndjson is the active segment; rotation
+// seals it by recording it in the manifest and moving it under audit/:
+//
+//	audit/manifest.json   rotation policy and the sealed segments, oldest first
+//	audit/000001.ndjson   sealed segment 1, never written again
+//
+// Readers see the sealed segments followed by the active one as a single
+// log (auditLogView), so byte offsets, follow cursors and the offset index
+// stay valid across a rotation. The manifest entry is written before the
+// rename; recoverAuditSegments completes a rename a crash interrupted.
+// Readers take no lock, so a view opened between the two steps finds the
+// last sealed segment still at the active path (newAuditLogView).
+
+const auditManifestSchema = "digiemu.audit.manifest.v1"
+
+const auditManifestRel = "audit/manifest.json"
+
+// AuditRotation is the rotation policy of a data dir; a zero field disables
+// its trigger. Before an append, the active segment is sealed once it holds
+// MaxBytes, or once its first event is MaxAgeSeconds older than the new one.
+type AuditRotation struct {
+	MaxBytes      int64 `json:"maxBytes,omitempty"`
+	MaxAgeSeconds int64 `json:"maxAgeSeconds,omitempty"`
+}
+
+// AuditSegment is a sealed segment as recorded in the manifest.
+type AuditSegment struct {
+	Number       int    `json:"number"`
+	File         string `json:"file"`   // relative to the data dir
+	Offset       int64  `json:"offset"` // of its first byte in the whole log
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+	Events       int64  `json:"events"`
+	FirstEventID string `json:"firstEventId"`
+	LastEventID  string `json:"lastEventId"`
+	FirstSeq     int64  `json:"firstSeq,omitempty"`
+	LastSeq      int64  `json:"lastSeq"`
+	LastHash     string `json:"lastHash"`
+	FirstAtUnix  int64  `json:"firstAtUnix"`
+	LastAtUnix   int64  `json:"lastAtUnix"`
+}
+
+type auditManifestFile struct {
+	Schema   string         `json:"schema"`
+	Rotation AuditRotation  `json:"rotation"`
+	Segments []AuditSegment `json:"segments"`
+}
+
+func readAuditManifest(basePath string) (auditManifestFile, error) {
+	b, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(auditManifestRel)))
+	if os.IsNotExist(err) {
+		return auditManifestFile{Schema: auditManifestSchema, Segments: []AuditSegment{}}, nil
+	}
+	if err != nil {
+		return auditManifestFile{}, err
+	}
+	var m auditManifestFile
+	if err := json.Unmarshal(b, &m); err != nil {
+		return auditManifestFile{}, fmt.Errorf("audit manifest invalid: %w", err)
+	}
+	if m.Schema != auditManifestSchema {
+		return auditManifestFile{}, errors.New("audit manifest schema mismatch: " + m.Schema)
+	}
+	if m.Segments == nil {
+		m.Segments = []AuditSegment{}
+	}
+	return m, nil
+}
+
+// writeAuditManifest replaces the manifest; the data dir lock must be held.
+func writeAuditManifest(basePath string, m auditManifestFile) error {
+	p := filepath.Join(basePath, filepath.FromSlash(auditManifestRel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p, b, 0o644)
+}
+
+// ReadAuditSegments returns the rotation policy and the sealed segments of
+// basePath, oldest first.
+func ReadAuditSegments(basePath string) (AuditRotation, []AuditSegment, error) {
+	m, err := readAuditManifest(basePath)
+	if err != nil {
+		return AuditRotation{}, nil, err
+	}
+	return m.Rotation, m.Segments, nil
+}
+
+// SetAuditRotation stores the rotation policy every writer of basePath
+// applies from its next append on.
+func SetAuditRotation(basePath string, r AuditRotation) error {
+	if r.MaxBytes < 0 || r.MaxAgeSeconds < 0 {
+		return errors.New("audit rotation limits must not be negative")
+	}
+	unlock, err := lockDataDir(basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m, err := readAuditManifest(basePath)
+	if err != nil {
+		return err
+	}
+	m.Rotation = r
+	return writeAuditManifest(basePath, m)
+}
+
+// Rotate seals the active segment regardless of the policy. ok is false if
+// the active segment holds no events.
+func (l *AuditLog) Rotate() (AuditSegment, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockDataDir(l.basePath)
+	if err != nil {
+		return AuditSegment{}, false, err
+	}
+	defer unlock()
+
+	head, genesis, err := readChainTail(l.path)
+	if err != nil {
+		return AuditSegment{}, false, err
+	}
+	if head.Seq == 0 && genesis == nil {
+		return AuditSegment{}, false, nil // empty
+	}
+	if genesis != nil {
+		return AuditSegment{}, false, errors.New("audit log has no chained event yet; append one before rotating")
+	}
+	m, err := readAuditManifest(l.basePath)
+	if err != nil {
+		return AuditSegment{}, false, err
+	}
+	seg, err := sealActiveSegment(l.basePath, l.path, &m)
+	if err != nil {
+		return AuditSegment{}, false, err
+	}
+	return seg, true, nil
+}
+
+// rotationDue reports whether the policy asks to seal the active segment
+// before appending an event dated at.
+func rotationDue(path string, r AuditRotation, at int64) (bool, error) {
+	if r.MaxBytes == 0 && r.MaxAgeSeconds == 0 {
+		return false, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if st.Size() == 0 {
+		return false, nil
+	}
+	if r.MaxBytes > 0 && st.Size() >= r.MaxBytes {
+		return true, nil
+	}
+	if r.MaxAgeSeconds > 0 {
+		first, err := bufio.NewReader(f).ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		var ev domain.AuditEvent
+		if err := json.Unmarshal(first, &ev); err != nil {
+			return false, fmt.Errorf("audit log first line unreadable: %w", err)
+		}
+		return at-ev.AtUnix >= r.MaxAgeSeconds, nil
+	}
+	return false, nil
+}
+
+// sealActiveSegment records the active segment at path in m and moves it to
+// its segment file. The data dir lock must be held and the segment must end
+// in a chained event.
+func sealActiveSegment(basePath, path string, m *auditManifestFile) (AuditSegment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AuditSegment{}, err
+	}
+	defer f.Close()
+
+	n := len(m.Segments) + 1
+	seg := AuditSegment{Number: n, File: fmt.Sprintf("audit/%06d.ndjson", n)}
+	if n > 1 {
+		prev := m.Segments[n-2]
+		seg.Offset = prev.Offset + prev.Size
+	}
+	h := sha256.New()
+	end, err := readAuditLines(io.TeeReader(f, h), 0, func(_ int64, ev domain.AuditEvent) bool {
+		if seg.Events == 0 {
+			seg.FirstEventID, seg.FirstSeq, seg.FirstAtUnix = ev.ID, ev.Seq, ev.AtUnix
+		}
+		seg.Events++
+		seg.LastEventID, seg.LastSeq, seg.LastHash, seg.LastAtUnix = ev.ID, ev.Seq, ev.Hash, ev.AtUnix
+		return true
+	})
+	if err != nil {
+		return AuditSegment{}, err
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return AuditSegment{}, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return AuditSegment{}, err
+	}
+	if end != st.Size() {
+		return AuditSegment{}, fmt.Errorf("active audit segment ends in a partial line at offset %d", end)
+	}
+	seg.Size = end
+	seg.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	m.Segments = append(m.Segments, seg)
+	if err := writeAuditManifest(basePath, *m); err != nil {
+		return AuditSegment{}, err
+	}
+	dst := filepath.Join(basePath, filepath.FromSlash(seg.File))
+	if err := os.Rename(path, dst); err != nil {
+		return AuditSegment{}, err
+	}
+	syncDir(filepath.Dir(dst))
+	syncDir(basePath)
+	return seg, nil
+}
+
+// recoverAuditSegments completes a seal whose rename did not happen: the
+// last segment is in the manifest, its file is missing and the active
+// segment is exactly the recorded content.
+func recoverAuditSegments(basePath string) error {
+	m, err := readAuditManifest(basePath)
+	if err != nil {
+		return err
+	}
+	if len(m.Segments) == 0 {
+		return nil
+	}
+	last := m.Segments[len(m.Segments)-1]
+	dst := filepath.Join(basePath, filepath.FromSlash(last.File))
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		return err
+	}
+	active := filepath.Join(basePath, "audit.ndjson")
+	st, err := os.Stat(active)
+	if err != nil || st.Size() != last.Size {
+		return nil // left for audit verify to report
+	}
+	if h, err := hashFile(active); err != nil || h != last.SHA256 {
+		return err
+	}
+	if err := os.Rename(active, dst); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(dst))
+	syncDir(basePath)
+	return nil
+}
+
+// auditLogView reads the sealed segments and the active segment of a log as
+// one file of the size they had when it was opened.
+type auditLogView struct {
+	parts []auditLogPart
+	size  int64
+}
+
+type auditLogPart struct {
+	f    *os.File
+	off  int64
+	size int64
+}
+
+// openAuditLogView opens the log whose active segment is at path. Without
+// sealed segments, a missing active segment fails like os.Open; a missing
+// sealed segment fails with domain.ErrAuditSegmentMissing.
+func openAuditLogView(path string) (*auditLogView, error) {
+	basePath := filepath.Dir(path)
+	for attempt := 0; ; attempt++ {
+		m, err := readAuditManifest(basePath)
+		if err != nil {
+			return nil, err
+		}
+		active, err := os.Open(path)
+		if err != nil && (!os.IsNotExist(err) || len(m.Segments) == 0) {
+			return nil, err
+		}
+		// a rotation between reading the manifest and opening the active
+		// segment would hide the segment it sealed
+		again, err := readAuditManifest(basePath)
+		if err != nil {
+			closeFile(active)
+			return nil, err
+		}
+		if len(again.Segments) != len(m.Segments) {
+			closeFile(active)
+			if attempt < 3 {
+				continue
+			}
+			return nil, errors.New("audit log is being rotated, try again")
+		}
+		v, err := newAuditLogView(basePath, again.Segments, active)
+		if err != nil {
+			closeFile(active)
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// newAuditLogView opens segs and appends active. The seal of the last
+// segment may be half done: its file still missing (the active handle is
+// that segment) or renamed after active was opened (both are one file).
+func newAuditLogView(basePath string, segs []AuditSegment, active *os.File) (*auditLogView, error) {
+	v := &auditLogView{}
+	var activeSt os.FileInfo
+	if active != nil {
+		st, err := active.Stat()
+		if err != nil {
+			return nil, err
+		}
+		activeSt = st
+	}
+	for i, s := range segs {
+		last := i == len(segs)-1
+		f, err := os.Open(filepath.Join(basePath, filepath.FromSlash(s.File)))
+		if os.IsNotExist(err) && last && activeSt != nil && activeSt.Size() == s.Size {
+			// sealed in the manifest, rename still pending
+			f, err, active, activeSt = active, nil, nil, nil
+		}
+		if os.IsNotExist(err) {
+			v.Close()
+			return nil, fmt.Errorf("%w: %s", domain.ErrAuditSegmentMissing, s.File)
+		}
+		if err != nil {
+			v.Close()
+			return nil, err
+		}
+		st, err := f.Stat()
+		if err != nil {
+			f.Close()
+			v.Close()
+			return nil, err
+		}
+		if last && activeSt != nil && os.SameFile(st, activeSt) {
+			// renamed after active was opened: no active segment yet
+			active.Close()
+			active, activeSt = nil, nil
+		}
+		v.parts = append(v.parts, auditLogPart{f: f, off: v.size, size: st.Size()})
+		v.size += st.Size()
+	}
+	if active != nil {
+		v.parts = append(v.parts, auditLogPart{f: active, off: v.size, size: activeSt.Size()})
+		v.size += activeSt.Size()
+	}
+	return v, nil
+}
+
+func closeFile(f *os.File) {
+	if f != nil {
+		f.Close()
+	}
+}
+
+func (v *auditLogView) Size() int64 { return v.size }
+
+func (v *auditLogView) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("audit log: negative offset")
+	}
+	n := 0
+	for _, pt := range v.parts {
+		if n == len(p) {
+			break
+		}
+		if off >= pt.off+pt.size {
+			continue
+		}
+		k := pt.off + pt.size - off
+		if k > int64(len(p)-n) {
+			k = int64(len(p) - n)
+		}
+		m, err := pt.f.ReadAt(p[n:n+int(k)], off-pt.off)
+		n += m
+		off += int64(m)
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		if int64(m) < k {
+			return n, io.ErrUnexpectedEOF
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// reader returns the log from offset on.
+func (v *auditLogView) reader(offset int64) io.Reader {
+	return io.NewSectionReader(v, offset, v.size-offset)
+}
+
+func (v *auditLogView) Close() error {
+	for _, pt := range v.parts {
+		pt.f.Close()
+	}
+	v.parts = nil
+	return nil
+}
+
+// AuditSegmentChecker implements ports.AuditSegmentChecker: every sealed
+// segment must exist with the size and hash recorded in the manifest,
+// continue its predecessor, and no other segment file may be present.
+type AuditSegmentChecker struct {
+	basePath string
+}
+
+func NewAuditSegmentChecker(basePath string) *AuditSegmentChecker {
+	return &AuditSegmentChecker{basePath: basePath}
+}
+
+func (c *AuditSegmentChecker) CheckAuditSegments() ([]ports.AuditSegmentFinding, int, error) {
+	m, err := readAuditManifest(c.basePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	findings := []ports.AuditSegmentFinding{}
+	add := func(kind string, s AuditSegment, format string, args ...any) {
+		findings = append(findings, ports.AuditSegmentFinding{Kind: kind, Segment: s.Number, File: s.File, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	listed := map[string]bool{}
+	var prev *AuditSegment
+	for i := range m.Segments {
+		s := m.Segments[i]
+		listed[s.File] = true
+		switch {
+		case s.Number != i+1:
+			add(ports.SegmentUnlinked, s, "segment number %d at position %d", s.Number, i+1)
+		case prev != nil && s.Offset != prev.Offset+prev.Size:
+			add(ports.SegmentUnlinked, s, "offset %d, expected %d", s.Offset, prev.Offset+prev.Size)
+		case prev != nil && prev.LastSeq > 0 && s.FirstSeq != prev.LastSeq+1:
+			add(ports.SegmentUnlinked, s, "first seq %d, expected %d", s.FirstSeq, prev.LastSeq+1)
+		}
+		prev = &m.Segments[i]
+
+		p := filepath.Join(c.basePath, filepath.FromSlash(s.File))
+		st, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			add(ports.SegmentMissing, s, "events %s..%s", s.FirstEventID, s.LastEventID)
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if st.Size() != s.Size {
+			add(ports.SegmentAltered, s, "size %d, sealed with %d", st.Size(), s.Size)
+			continue
+		}
+		h, err := hashFile(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		if h != s.SHA256 {
+			add(ports.SegmentAltered, s, "sha256 %s, sealed with %s", h, s.SHA256)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(c.basePath, "audit"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, 0, err
+	}
+	var stray []string
+	for _, e := range entries {
+		rel := "audit/" + e.Name()
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".ndjson") && !listed[rel] {
+			stray = append(stray, rel)
+		}
+	}
+	sort.Strings(stray)
+	for _, rel := range stray {
+		findings = append(findings, ports.AuditSegmentFinding{Kind: ports.SegmentUnlisted, File: rel, Detail: "segment file not in the manifest"})
+	}
+	return findings, len(m.Segments), nil
+}
@@ -0,0 +1,195 @@
+package fs_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+func TestAuditSegments_RotateAndReadAcross(t *testing.T) {
+	dir := t.TempDir()
+	if err := fsrepo.SetAuditRotation(dir, fsrepo.AuditRotation{MaxBytes: 2048}); err != nil {
+		t.Fatal(err)
+	}
+	all := seedAuditLog(t, dir, 0, 40)
+
+	_, segs, err := fsrepo.ReadAuditSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) < 3 {
+		t.Fatalf("expected several sealed segments, got %d", len(segs))
+	}
+	for i, s := range segs {
+		if i > 0 && (s.FirstSeq != segs[i-1].LastSeq+1 || s.Offset != segs[i-1].Offset+segs[i-1].Size) {
+			t.Fatalf("segment %d does not continue %d: %+v %+v", s.Number, segs[i-1].Number, segs[i-1], s)
+		}
+	}
+
+	// the chain continues across segments
+	var scanned []domain.AuditEvent
+	if err := fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		scanned = append(scanned, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "scan", scanned, all)
+	for i, ev := range scanned {
+		if ev.Seq != int64(i+1) {
+			t.Fatalf("event %d has seq %d", i, ev.Seq)
+		}
+	}
+
+	tail, err := fsrepo.NewAuditTail(dir).Tail(ports.AuditTailRequest{N: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "tail", tail, all)
+
+	byUnit, err := fsrepo.NewAuditByUnitReader(dir).ListByUnitID("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u1 []domain.AuditEvent
+	for _, ev := range all {
+		if ev.UnitID == "u1" {
+			u1 = append(u1, ev)
+		}
+	}
+	sameIDs(t, "by unit", byUnit, u1)
+
+	// a follow cursor taken before a rotation stays valid after it
+	r := fsrepo.NewAuditReader(dir)
+	cur, err := r.CursorAfter(all[len(all)-1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := fsrepo.NewAuditLog(dir).Rotate(); err != nil || !ok {
+		t.Fatalf("rotate: %v %v", ok, err)
+	}
+	more := seedAuditLog(t, dir, 40, 2)
+	evs, _, err := r.Poll(cur, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, "poll after rotation", evs, more)
+
+	findings, sealed, err := fsrepo.NewAuditSegmentChecker(dir).CheckAuditSegments()
+	if err != nil || len(findings) != 0 || sealed != len(segs)+1 {
+		t.Fatalf("clean segments: %+v sealed=%d %v", findings, sealed, err)
+	}
+}
+
+func TestAuditSegments_RotateByAge(t *testing.T) {
+	dir := t.TempDir()
+	if err := fsrepo.SetAuditRotation(dir, fsrepo.AuditRotation{MaxAgeSeconds: 10}); err != nil {
+		t.Fatal(err)
+	}
+	seedAuditLog(t, dir, 0, 25) // AtUnix 0..24
+	_, segs, err := fsrepo.ReadAuditSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 || segs[0].FirstAtUnix != 0 || segs[0].LastAtUnix != 9 || segs[1].FirstAtUnix != 10 {
+		t.Fatalf("unexpected segments: %+v", segs)
+	}
+}
+
+func TestAuditSegments_CheckerFindings(t *testing.T) {
+	dir := t.TempDir()
+	log := fsrepo.NewAuditLog(dir)
+	for i := 0; i < 3; i++ {
+		seedAuditLog(t, dir, i*5, 5)
+		if _, _, err := log.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, segs, err := fsrepo.ReadAuditSegments(dir)
+	if err != nil || len(segs) != 3 {
+		t.Fatalf("segments: %+v %v", segs, err)
+	}
+
+	altered := filepath.Join(dir, filepath.FromSlash(segs[0].File))
+	b, err := os.ReadFile(altered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-3] ^= 1
+	if err := os.WriteFile(altered, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "audit", "000009.ndjson"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, filepath.FromSlash(segs[1].File))
+	if err := os.Rename(missing, missing+".bak"); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, sealed, err := fsrepo.NewAuditSegmentChecker(dir).CheckAuditSegments()
+	if err != nil || sealed != 3 {
+		t.Fatalf("check: sealed=%d %v", sealed, err)
+	}
+	kinds := map[string]string{}
+	for _, f := range findings {
+		kinds[f.File] = f.Kind
+	}
+	if len(findings) != 3 || kinds[segs[0].File] != ports.SegmentAltered || kinds[segs[1].File] != ports.SegmentMissing || kinds["audit/000009.ndjson"] != ports.SegmentUnlisted {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	// readers refuse to skip over a missing segment
+	err = fsrepo.NewAuditReader(dir).Scan(func(domain.AuditEvent) error { return nil })
+	if !errors.Is(err, domain.ErrAuditSegmentMissing) {
+		t.Fatalf("expected ErrAuditSegmentMissing, got %v", err)
+	}
+}
+
+func TestAuditSegments_RecoversInterruptedSeal(t *testing.T) {
+	src := t.TempDir()
+	seedAuditLog(t, src, 0, 4)
+	seg, ok, err := fsrepo.NewAuditLog(src).Rotate()
+	if err != nil || !ok {
+		t.Fatalf("rotate: %v %v", ok, err)
+	}
+
+	// a data dir whose manifest lists the segment but whose rename never
+	// happened (recovery runs once per process and data dir)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "audit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for from, to := range map[string]string{
+		filepath.Join(src, "audit", "manifest.json"):     filepath.Join(dir, "audit", "manifest.json"),
+		filepath.Join(src, filepath.FromSlash(seg.File)): filepath.Join(dir, "audit.ndjson"),
+	} {
+		b, err := os.ReadFile(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(to, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	more := seedAuditLog(t, dir, 4, 1)
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(seg.File))); err != nil {
+		t.Fatalf("segment not moved into place: %v", err)
+	}
+	var got []domain.AuditEvent
+	if err := fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		got = append(got, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[4].ID != more[0].ID || got[4].Seq != 5 {
+		t.Fatalf("unexpected log after recovery: %v", eventIDs(got))
+	}
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// A reader that opened the active segment while it was being sealed must see
+// every event exactly once, whether or not the rename already happened.
+func TestAuditLogView_HalfSealedSegment(t *testing.T) {
+	for _, renamed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("renamed=%v", renamed), func(t *testing.T) {
+			dir := t.TempDir()
+			log := NewAuditLog(dir)
+			for i := 0; i < 3; i++ {
+				if err := log.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: fmt.Sprintf("evt_%d", i), Type: "version.created", AtUnix: int64(i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			active := filepath.Join(dir, "audit.ndjson")
+			want, err := os.ReadFile(active)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(active)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seg, ok, err := log.Rotate()
+			if err != nil || !ok {
+				t.Fatalf("rotate: %v %v", ok, err)
+			}
+			if !renamed {
+				// manifest written, rename not done yet
+				if err := os.Rename(filepath.Join(dir, filepath.FromSlash(seg.File)), active); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, segs, err := ReadAuditSegments(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := newAuditLogView(dir, segs, f)
+			if err != nil {
+				t.Fatalf("view: %v", err)
+			}
+			defer v.Close()
+			got := make([]byte, v.Size())
+			if _, err := v.ReadAt(got, 0); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("view holds %d bytes, want the %d of the sealed segment", len(got), len(want))
+			}
+		})
+	}
+}
@@ -1,8 +1,8 @@
 package fs
 
 import (
-	"bufio"
-	"encoding/json"
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -10,6 +10,9 @@ import (
 	"digiemu-core/internal/kernel/ports"
 )
 
+// auditTailBlockSize is the read size of Tail's backward scan.
+const auditTailBlockSize = 64 << 10
+
 type AuditTail struct {
 	path string
 }
@@ -24,7 +27,7 @@ func (t *AuditTail) Tail(in ports.AuditTailRequest) ([]domain.AuditEvent, error)
 		n = 50
 	}
 
-	f, err := os.Open(t.path)
+	f, err := openAuditLogView(t.path)
 	if os.IsNotExist(err) {
 		return []domain.AuditEvent{}, nil
 	}
@@ -33,37 +36,69 @@ func (t *AuditTail) Tail(in ports.AuditTailRequest) ([]domain.AuditEvent, error)
 	}
 	defer f.Close()
 
-	// Simple ring buffer via slice
-	buf := make([]domain.AuditEvent, 0, n)
-	sc := bufio.NewScanner(f)
-	for sc.Scan() {
-		line := sc.Bytes()
-		if len(line) == 0 {
-			continue
+	// v0.6: read the log backwards in blocks from its end (across sealed
+	// segments), newest line
+	// first, and stop once n events matched. rest is the start of a line
+	// whose beginning lies in an earlier block.
+	out := make([]domain.AuditEvent, 0, n)
+	var rest []byte
+	complete := false // a line without newline at the end is still being appended
+	for pos := f.Size(); pos > 0 && len(out) < n; {
+		size := int64(auditTailBlockSize)
+		if size > pos {
+			size = pos
 		}
-		var ev domain.AuditEvent
-		if err := json.Unmarshal(line, &ev); err != nil {
+		pos -= size
+		buf := make([]byte, size, size+int64(len(rest)))
+		if _, err := f.ReadAt(buf, pos); err != nil {
 			return nil, err
 		}
-		if !matchAuditTailFilters(ev, in) {
-			continue
+		buf = append(buf, rest...)
+
+		if !complete {
+			i := bytes.LastIndexByte(buf, '\n')
+			if i < 0 {
+				rest = nil
+				continue
+			}
+			buf, complete = buf[:i], true
 		}
-		if len(buf) < n {
-			buf = append(buf, ev)
-		} else {
-			// shift left (O(n) per overflow), acceptable for small n
-			copy(buf, buf[1:])
-			buf[len(buf)-1] = ev
+
+		for len(out) < n {
+			i := bytes.LastIndexByte(buf, '\n')
+			if i < 0 && pos > 0 {
+				break // buf ends a line that starts in an earlier block
+			}
+			line := bytes.TrimSpace(buf[i+1:])
+			if i >= 0 {
+				buf = buf[:i]
+			} else {
+				buf = nil
+			}
+			if len(line) > 0 {
+				ev, err := decodeAuditLine(line)
+				if err != nil {
+					return nil, fmt.Errorf("audit log line at offset %d: %w", pos+int64(i+1), err)
+				}
+				if matchAuditTailFilters(ev, in) {
+					out = append(out, ev)
+				}
+			}
+			if i < 0 {
+				break
+			}
 		}
+		rest = append([]byte(nil), buf...)
 	}
-	if err := sc.Err(); err != nil {
-		return nil, err
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
 	}
-	return buf, nil
+	return out, nil
 }
 
 func matchAuditTailFilters(ev domain.AuditEvent, in ports.AuditTailRequest) bool {
-	if in.Type != "" && ev.Type != in.Type {
+	if in.Type != "" && domain.CanonicalAuditEventType(ev.Type) != domain.CanonicalAuditEventType(in.Type) {
 		return false
 	}
 	if in.UnitID != "" && ev.UnitID != in.UnitID {
@@ -2,32 +2,109 @@ package fs
 
 import (
 	"encoding/json"
-	"os"
 	"path/filepath"
+	"sync"
 
 	"digiemu-core/internal/kernel/domain"
 )
 
 // AuditLog is an append-only NDJSON log.
+// v0.6: every appended event is sealed into a hash chain (seq + prevHash + hash).
+// v0.6: appends go to the active segment audit.ndjson, which is rotated into
+// sealed segments per the data dir's rotation policy (audit_segments.go).
 type AuditLog struct {
-	path string
+	basePath string
+	path     string
+	headPath string
+	mu       sync.Mutex
 }
 
 func NewAuditLog(basePath string) *AuditLog {
-	return &AuditLog{path: filepath.Join(basePath, "audit.ndjson")}
+	openDataDir(basePath)
+	return &AuditLog{
+		basePath: basePath,
+		path:     filepath.Join(basePath, "audit.ndjson"),
+		headPath: filepath.Join(basePath, "audit.head.json"),
+	}
 }
 
 func (l *AuditLog) Append(ev domain.AuditEvent) error {
-	b, err := json.Marshal(ev)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockDataDir(l.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// another process may have crashed mid-append since this one recovered
+	// the data dir; the torn line would otherwise end up in front of ev
+	if err := repairTornTail(l.path); err != nil {
+		return err
+	}
+	head, genesis, err := readChainTail(l.path)
 	if err != nil {
 		return err
 	}
-	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+
+	// v0.6: seal the active segment if the rotation policy asks for it; an
+	// empty active segment continues the chain of the last sealed one
+	m, err := readAuditManifest(l.basePath)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	if genesis == nil && head.Seq > 0 {
+		due, err := rotationDue(l.path, m.Rotation, ev.AtUnix)
+		if err != nil {
+			return err
+		}
+		if due {
+			if _, err := sealActiveSegment(l.basePath, l.path, &m); err != nil {
+				return err
+			}
+		}
+	} else if genesis == nil && len(m.Segments) > 0 {
+		last := m.Segments[len(m.Segments)-1]
+		head = domain.AuditChainHead{Seq: last.LastSeq, Hash: last.LastHash, EventID: last.LastEventID}
+	}
+
+	var out []byte
+
+	// Legacy log without any chained event: record where the chain starts.
+	if genesis != nil {
+		g := domain.AuditEvent{
+			Schema:  ev.Schema,
+			ID:      domain.NewID("evt"),
+			Type:    domain.AuditChainGenesisType,
+			AtUnix:  ev.AtUnix,
+			ActorID: "system",
+			Data:    *genesis,
+		}
+		if err := domain.SealAuditEvent(&g, head); err != nil {
+			return err
+		}
+		b, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		out = append(out, b...)
+		out = append(out, '\n')
+		head = domain.AuditChainHead{Seq: g.Seq, Hash: g.Hash, EventID: g.ID}
+	}
+
+	if err := domain.SealAuditEvent(&ev, head); err != nil {
+		return err
+	}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	out = append(out, b...)
+	out = append(out, '\n')
+
+	if err := appendFileSync(l.path, out); err != nil {
+		return err
+	}
 
-	_, err = f.Write(append(b, '\n'))
-	return err
+	return writeChainHead(l.headPath, domain.AuditChainHead{Seq: ev.Seq, Hash: ev.Hash, EventID: ev.ID})
 }
@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"fmt"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// SaveBranch implements ports.BranchRepository: the branch is stored in the
+// unit header, replacing a branch of the same name. Replacing it may only
+// move its head to a version of the branch built on the current head
+// (domain.ErrConflict otherwise), as for UpdateUnitHead.
+func (r *UnitRepo) SaveBranch(unitID string, b domain.Branch) error {
+	return r.updateHeader(unitID, func(h *UnitHeader) error {
+		rec := BranchRecord{
+			Name:          b.Name,
+			HeadVersionID: b.HeadVersionID,
+			BaseVersionID: b.BaseVersionID,
+			CreatedAtUnix: b.CreatedAtUnix,
+			ActorID:       b.ActorID,
+		}
+		for i, x := range h.Branches {
+			if x.Name != b.Name {
+				continue
+			}
+			if x.BaseVersionID != rec.BaseVersionID {
+				return fmt.Errorf("%w: %q", domain.ErrBranchAlreadyExists, b.Name)
+			}
+			if x.HeadVersionID != rec.HeadVersionID {
+				if err := r.checkHeadMove(unitID, x.HeadVersionID, rec.HeadVersionID, b.Name); err != nil {
+					return err
+				}
+			}
+			h.Branches[i] = rec
+			return nil
+		}
+		h.Branches = append(h.Branches, rec)
+		sort.Slice(h.Branches, func(i, j int) bool { return h.Branches[i].Name < h.Branches[j].Name })
+		return nil
+	})
+}
+
+// DeleteBranch implements ports.BranchRepository.
+func (r *UnitRepo) DeleteBranch(unitID, name string) error {
+	return r.updateBranches(unitID, func(bs []BranchRecord) []BranchRecord {
+		kept := bs[:0]
+		for _, b := range bs {
+			if b.Name != name {
+				kept = append(kept, b)
+			}
+		}
+		return kept
+	})
+}
+
+func (r *UnitRepo) updateBranches(unitID string, fn func([]BranchRecord) []BranchRecord) error {
+	return r.updateHeader(unitID, func(h *UnitHeader) error {
+		h.Branches = fn(h.Branches)
+		return nil
+	})
+}
+
+// updateHeader rewrites the header of a unit under the data dir lock.
+func (r *UnitRepo) updateHeader(unitID string, fn func(*UnitHeader) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, ok, err := r.readHeader(unitID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	if err := fn(&h); err != nil {
+		return err
+	}
+	return r.writeHeader(h)
+}
@@ -1,50 +1,67 @@
 package fs
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"path/filepath"
+	"os"
 
 	"digiemu-core/internal/kernel/domain"
 )
 
-// FindVersionByID scans unit records to locate a version by ID.
-// v0.2.3: simple implementation; can be optimized later by indexing or splitting storage.
+// FindVersionByID locates a version by ID.
+// v0.2.3: simple implementation; can be optimized later by indexing.
+// v0.6: looked up in index/versions_by_id.json; the unit headers are only
+// scanned if the index does not know the version (or is stale).
 func (r *UnitRepo) FindVersionByID(versionID string) (domain.Version, bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	files, err := ioutil.ReadDir(r.unitsDir)
+	if r.index != nil {
+		r.ensureVersionIndex()
+		if unitID, ok := r.index.getUnitIDByVersion(versionID); ok {
+			v, found, err := r.findListedVersion(unitID, versionID)
+			if err != nil || found {
+				return v, found, err
+			}
+		}
+	}
+
+	var unitID string
+	err := r.scanHeaders(func(h UnitHeader) bool {
+		for _, id := range h.VersionIDs {
+			if id == versionID {
+				unitID = h.ID
+				return true
+			}
+		}
+		return false
+	})
 	if err != nil {
 		return domain.Version{}, false, err
 	}
-	for _, f := range files {
-		if f.IsDir() {
+	if unitID == "" {
+		return domain.Version{}, false, nil
+	}
+	return r.findListedVersion(unitID, versionID)
+}
+
+// findListedVersion loads versionID if unitID's header lists it (a version
+// file is only valid once its unit header references it).
+func (r *UnitRepo) findListedVersion(unitID, versionID string) (domain.Version, bool, error) {
+	h, ok, err := r.readHeader(unitID)
+	if err != nil || !ok {
+		return domain.Version{}, false, err
+	}
+	for _, id := range h.VersionIDs {
+		if id != versionID {
 			continue
 		}
-		p := filepath.Join(r.unitsDir, f.Name())
-		b, err := ioutil.ReadFile(p)
-		if err != nil {
-			return domain.Version{}, false, err
+		v, err := r.loadVersion(unitID, versionID)
+		if os.IsNotExist(err) {
+			return domain.Version{}, false, nil
 		}
-		var ur UnitRecord
-		if err := json.Unmarshal(b, &ur); err != nil {
+		if err != nil {
 			return domain.Version{}, false, err
 		}
-		for _, vr := range ur.Versions {
-			if vr.ID == versionID {
-				return domain.Version{
-					ID:            vr.ID,
-					UnitID:        ur.ID,
-					Label:         vr.Label,
-					Content:       vr.Content,
-					PrevVersionID: vr.PrevVersionID,
-					ContentHash:   vr.ContentHash,
-					CreatedAtUnix: 0,
-					ActorID:       vr.ActorID,
-				}, true, nil
-			}
-		}
+		return v, true, nil
 	}
 	return domain.Version{}, false, nil
 }
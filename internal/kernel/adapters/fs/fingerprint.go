@@ -0,0 +1,105 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// v0.6: DataDirFingerprint implements ports.DataDirFingerprinter. The
+// fingerprint covers the primary data only (layout, units, audit log and its
+// sealed segments, Merkle files, public key registry); indexes, the journal,
+// private keys and temp files are left out, so rebuilding an index does not
+// change it.
+//
+//	sha256( for each file, sorted by relative path: "<path>\x00<sha256 hex>\n" )
+type DataDirFingerprint struct {
+	basePath string
+}
+
+func NewDataDirFingerprint(basePath string) *DataDirFingerprint {
+	return &DataDirFingerprint{basePath: basePath}
+}
+
+var fingerprintFiles = []string{
+	"tenant.json",
+	"layout.json",
+	"audit.ndjson",
+	"audit.head.json",
+	"audit.leaves.ndjson",
+	"audit.sth.ndjson",
+	"keys/registry.json",
+}
+
+// fingerprintDirs are walked recursively (v0.6: audit/ holds the sealed
+// audit segments and their manifest).
+var fingerprintDirs = []string{"units", "audit"}
+
+func (f *DataDirFingerprint) Fingerprint() (string, error) {
+	unlock, err := lockDataDir(f.basePath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	var files []string
+	for _, rel := range fingerprintFiles {
+		if _, err := os.Stat(filepath.Join(f.basePath, filepath.FromSlash(rel))); err == nil {
+			files = append(files, rel)
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	for _, dir := range fingerprintDirs {
+		err = filepath.WalkDir(filepath.Join(f.basePath, dir), func(p string, d iofs.DirEntry, err error) error {
+			if os.IsNotExist(err) {
+				return iofs.SkipDir
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || strings.HasSuffix(d.Name(), ".tmp") {
+				return nil
+			}
+			rel, err := filepath.Rel(f.basePath, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	sort.Strings(files)
+
+	sum := sha256.New()
+	for _, rel := range files {
+		h, err := hashFile(filepath.Join(f.basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", fmt.Errorf("fingerprint %s: %w", rel, err)
+		}
+		fmt.Fprintf(sum, "%s\x00%s\n", rel, h)
+	}
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func hashFile(p string) (string, error) {
+	fh, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fh); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
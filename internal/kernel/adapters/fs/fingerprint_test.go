@@ -0,0 +1,38 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+)
+
+func TestFingerprint_CoversPrimaryDataOnly(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	if err := repo.SaveUnit(domain.Unit{ID: "unit_1", Key: "abc", Title: "Title"}); err != nil {
+		t.Fatal(err)
+	}
+	fp := fsrepo.NewDataDirFingerprint(dir)
+	first, err := fp.Fingerprint()
+	if err != nil || first == "" {
+		t.Fatalf("Fingerprint: %q %v", first, err)
+	}
+
+	// derived data does not count
+	if err := os.WriteFile(filepath.Join(dir, "index", "units_by_key.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := fp.Fingerprint(); again != first {
+		t.Fatalf("index change altered fingerprint")
+	}
+
+	if err := repo.SaveVersion(domain.Version{ID: "ver_1", UnitID: "unit_1", Content: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := fp.Fingerprint(); after == first {
+		t.Fatalf("new version did not alter fingerprint")
+	}
+}
@@ -0,0 +1,488 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: DataDirChecker implements ports.DataDirChecker for a layout v2 data
+// dir. Checking reads the files directly and takes the data dir lock without
+// crash recovery, so leftovers of an interrupted write are reported rather
+// than cleaned up. RebuildIndex only rewrites files under index/.
+type DataDirChecker struct {
+	basePath string
+	r        *UnitRepo
+}
+
+const (
+	unitsByKeyRel   = "index/units_by_key.json"
+	versionsByIDRel = "index/versions_by_id.json"
+)
+
+var sidecarKinds = map[string]bool{"meaning": true, "claimset": true, "uncertainty": true, "hashes": true}
+
+func NewDataDirChecker(basePath string) *DataDirChecker {
+	return &DataDirChecker{
+		basePath: basePath,
+		r:        &UnitRepo{basePath: basePath, unitsDir: filepath.Join(basePath, "units")},
+	}
+}
+
+func (c *DataDirChecker) rel(p string) string {
+	if r, err := filepath.Rel(c.basePath, p); err == nil {
+		return filepath.ToSlash(r)
+	}
+	return filepath.ToSlash(p)
+}
+
+// checkedUnit is a unit header found in the units dir.
+type checkedUnit struct {
+	h        UnitHeader
+	versions []domain.Version
+}
+
+func (c *DataDirChecker) CheckDataDir() (ports.DataDirCheck, error) {
+	if st, err := os.Stat(c.basePath); err != nil {
+		return ports.DataDirCheck{}, err
+	} else if !st.IsDir() {
+		return ports.DataDirCheck{}, fmt.Errorf("%s is not a directory", c.basePath)
+	}
+	unlock, err := lockDataDirNoRecover(c.basePath)
+	if err != nil {
+		return ports.DataDirCheck{}, err
+	}
+	defer unlock()
+
+	out := ports.DataDirCheck{Units: []ports.FsckUnit{}, Findings: []ports.FsckFinding{}}
+	add := func(f ports.FsckFinding) { out.Findings = append(out.Findings, f) }
+
+	layout, err := ReadLayoutVersion(c.basePath)
+	if err != nil {
+		return out, err
+	}
+	if layout != CurrentLayoutVersion {
+		// nothing below applies to another layout
+		add(ports.FsckFinding{
+			Kind:   ports.FsckLayoutOutdated,
+			Path:   "layout.json",
+			Detail: fmt.Sprintf("layout %d, expected %d (run `digiemu migrate`)", layout, CurrentLayoutVersion),
+		})
+		return out, nil
+	}
+
+	if err := c.checkTempFiles(add); err != nil {
+		return out, err
+	}
+	if err := c.checkJournal(add); err != nil {
+		return out, err
+	}
+
+	units, err := c.checkUnits(add)
+	if err != nil {
+		return out, err
+	}
+	if err := c.checkUnitsByKey(units, add); err != nil {
+		return out, err
+	}
+	if err := c.checkVersionsByID(units, add); err != nil {
+		return out, err
+	}
+
+	for _, u := range units {
+		out.Units = append(out.Units, ports.FsckUnit{Unit: unitFromHeader(u.h), Versions: u.versions})
+	}
+	return out, nil
+}
+
+func (c *DataDirChecker) checkTempFiles(add func(ports.FsckFinding)) error {
+	var found []string
+	err := filepath.WalkDir(c.basePath, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p == filepath.Join(c.basePath, tenantsDir) {
+			return iofs.SkipDir // checked per tenant
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".tmp") {
+			found = append(found, c.rel(p))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(found)
+	for _, p := range found {
+		add(ports.FsckFinding{Kind: ports.FsckTempFile, Path: p, Detail: "leftover of an interrupted write"})
+	}
+	return nil
+}
+
+func (c *DataDirChecker) checkJournal(add func(ports.FsckFinding)) error {
+	entries, err := os.ReadDir(filepath.Join(c.basePath, "journal"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		add(ports.FsckFinding{
+			Kind:   ports.FsckIntentPending,
+			Path:   "journal/" + e.Name(),
+			Detail: "recovered by the next write command",
+		})
+	}
+	return nil
+}
+
+// checkUnits validates every unit header, its version files and sidecars,
+// and returns the readable units ordered by id.
+func (c *DataDirChecker) checkUnits(add func(ports.FsckFinding)) ([]checkedUnit, error) {
+	entries, err := os.ReadDir(c.r.unitsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := dataDirTenant(c.basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var units []checkedUnit
+	headers := map[string]bool{}
+	var dirs []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			dirs = append(dirs, name)
+			continue
+		}
+		if filepath.Ext(name) != ".json" {
+			continue
+		}
+		p := filepath.Join(c.r.unitsDir, name)
+		fileID := strings.TrimSuffix(name, ".json")
+		h, err := readHeaderFile(p)
+		if err != nil {
+			add(ports.FsckFinding{Kind: ports.FsckUnitUnreadable, UnitID: fileID, Path: c.rel(p), Detail: err.Error()})
+			continue
+		}
+		if h.ID != fileID {
+			add(ports.FsckFinding{
+				Kind: ports.FsckUnitMismatch, UnitID: fileID, Path: c.rel(p),
+				Detail: fmt.Sprintf("unit file declares id %q", h.ID),
+			})
+			continue
+		}
+		headers[h.ID] = true
+		if h.TenantID != tenant {
+			add(ports.FsckFinding{
+				Kind: ports.FsckUnitForeign, UnitID: h.ID, Path: c.rel(p),
+				Detail: fmt.Sprintf("unit of tenant %q in the data dir of tenant %q", h.TenantID, tenant),
+			})
+			continue
+		}
+		u := checkedUnit{h: h, versions: []domain.Version{}}
+		if err := c.checkVersions(&u, add); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+
+	for _, d := range dirs {
+		if !headers[d] {
+			add(ports.FsckFinding{
+				Kind: ports.FsckUnitOrphan, UnitID: d, Path: c.rel(c.r.unitDir(d)),
+				Detail: "unit dir without unit file",
+			})
+		}
+	}
+
+	byKey := map[string][]string{}
+	for _, u := range units {
+		byKey[u.h.Key] = append(byKey[u.h.Key], u.h.ID)
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ids := byKey[k]; len(ids) > 1 {
+			add(ports.FsckFinding{
+				Kind:   ports.FsckDuplicateKey,
+				Detail: fmt.Sprintf("key %q is used by units %v", k, ids),
+			})
+		}
+	}
+	return units, nil
+}
+
+// checkVersions checks the version files and sidecars of one unit and fills
+// u.versions with the readable listed versions, in header order.
+func (c *DataDirChecker) checkVersions(u *checkedUnit, add func(ports.FsckFinding)) error {
+	unitID := u.h.ID
+	listed := map[string]bool{}
+	hashes := map[string]SidecarHashes{}
+
+	for _, verID := range u.h.VersionIDs {
+		if listed[verID] {
+			add(ports.FsckFinding{Kind: ports.FsckVersionMismatch, UnitID: unitID, VersionID: verID, Path: c.rel(c.r.unitPath(unitID)), Detail: "version listed twice"})
+			continue
+		}
+		listed[verID] = true
+
+		vp := c.r.versionPath(unitID, verID)
+		b, err := os.ReadFile(vp)
+		if os.IsNotExist(err) {
+			add(ports.FsckFinding{Kind: ports.FsckVersionMissing, UnitID: unitID, VersionID: verID, Path: c.rel(vp)})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var vf VersionFile
+		if err := json.Unmarshal(b, &vf); err != nil {
+			add(ports.FsckFinding{Kind: ports.FsckVersionUnreadable, UnitID: unitID, VersionID: verID, Path: c.rel(vp), Detail: err.Error()})
+			continue
+		}
+		if vf.Schema != versionFileSchema {
+			add(ports.FsckFinding{Kind: ports.FsckVersionUnreadable, UnitID: unitID, VersionID: verID, Path: c.rel(vp), Detail: "schema mismatch: " + vf.Schema})
+			continue
+		}
+		if vf.ID != verID || vf.UnitID != unitID {
+			add(ports.FsckFinding{
+				Kind: ports.FsckVersionMismatch, UnitID: unitID, VersionID: verID, Path: c.rel(vp),
+				Detail: fmt.Sprintf("version file declares id %q of unit %q", vf.ID, vf.UnitID),
+			})
+			continue
+		}
+		hs, err := c.r.readHashes(unitID, verID)
+		if err != nil {
+			add(ports.FsckFinding{
+				Kind: ports.FsckVersionUnreadable, UnitID: unitID, VersionID: verID,
+				Path: c.rel(c.r.sidecarPath(unitID, verID, "hashes")), Detail: err.Error(),
+			})
+			continue
+		}
+		hashes[verID] = hs
+		u.versions = append(u.versions, domain.Version{
+			ID:              vf.ID,
+			UnitID:          unitID,
+			Label:           vf.Label,
+			Content:         vf.Content,
+			PrevVersionID:   vf.PrevVersionID,
+			ContentHash:     vf.ContentHash,
+			CreatedAtUnix:   vf.CreatedAtUnix,
+			ActorID:         vf.ActorID,
+			MeaningHash:     hs.MeaningHash,
+			ClaimSetHash:    hs.ClaimSetHash,
+			UncertaintyHash: hs.UncertaintyHash,
+			Branch:          vf.Branch,
+			MergeParentID:   vf.MergeParentID,
+		})
+	}
+
+	// version files the header does not list
+	versionsDir := filepath.Join(c.r.unitDir(unitID), "versions")
+	entries, err := os.ReadDir(versionsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		if verID := strings.TrimSuffix(name, ".json"); !listed[verID] {
+			add(ports.FsckFinding{
+				Kind: ports.FsckVersionOrphan, UnitID: unitID, VersionID: verID,
+				Path: c.rel(filepath.Join(versionsDir, name)), Detail: "version file not listed by the unit",
+			})
+		}
+	}
+
+	// sidecars: every file belongs to a listed version, every recorded hash
+	// has its file
+	sidecarsDir := filepath.Join(c.r.unitDir(unitID), "sidecars")
+	entries, err = os.ReadDir(sidecarsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	present := map[string]bool{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		p := filepath.Join(sidecarsDir, name)
+		rest := strings.TrimSuffix(name, ".json")
+		dot := strings.LastIndexByte(rest, '.')
+		if dot <= 0 || !sidecarKinds[rest[dot+1:]] {
+			add(ports.FsckFinding{Kind: ports.FsckSidecarOrphan, UnitID: unitID, Path: c.rel(p), Detail: "unknown sidecar file"})
+			continue
+		}
+		verID, kind := rest[:dot], rest[dot+1:]
+		present[verID+"."+kind] = true
+		if !listed[verID] {
+			add(ports.FsckFinding{Kind: ports.FsckSidecarOrphan, UnitID: unitID, VersionID: verID, Path: c.rel(p), Detail: "version not listed by the unit"})
+			continue
+		}
+		hs, ok := hashes[verID]
+		if !ok || kind == "hashes" {
+			continue // unreadable version, reported above
+		}
+		if sidecarHash(hs, kind) == "" {
+			add(ports.FsckFinding{Kind: ports.FsckSidecarOrphan, UnitID: unitID, VersionID: verID, Path: c.rel(p), Detail: "no " + kind + " hash recorded"})
+		}
+	}
+	for _, v := range u.versions {
+		for _, kind := range legacySidecarKinds {
+			if sidecarHash(hashes[v.ID], kind) != "" && !present[v.ID+"."+kind] {
+				add(ports.FsckFinding{
+					Kind: ports.FsckSidecarMissing, UnitID: unitID, VersionID: v.ID,
+					Path: c.rel(c.r.sidecarPath(unitID, v.ID, kind)), Detail: kind + " hash recorded but file missing",
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func sidecarHash(hs SidecarHashes, kind string) string {
+	switch kind {
+	case "meaning":
+		return hs.MeaningHash
+	case "claimset":
+		return hs.ClaimSetHash
+	case "uncertainty":
+		return hs.UncertaintyHash
+	}
+	return ""
+}
+
+// checkUnitsByKey compares index/units_by_key.json with the unit headers. A
+// missing index is not a finding: it is built on first use.
+func (c *DataDirChecker) checkUnitsByKey(units []checkedUnit, add func(ports.FsckFinding)) error {
+	s := newIndexStore(c.basePath)
+	if _, err := os.Stat(s.unitsByKeyPath()); os.IsNotExist(err) {
+		return nil
+	}
+	m, err := s.loadUnitsByKey()
+	if err != nil {
+		add(ports.FsckFinding{Kind: ports.FsckIndexInvalid, Path: unitsByKeyRel, Detail: err.Error(), Repairable: true})
+		return nil
+	}
+
+	keyOf := map[string]string{}
+	for _, u := range units {
+		keyOf[u.h.ID] = u.h.Key
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		id := m[k]
+		key, ok := keyOf[id]
+		switch {
+		case !ok:
+			add(ports.FsckFinding{Kind: ports.FsckIndexDangling, UnitID: id, Path: unitsByKeyRel, Detail: fmt.Sprintf("key %q maps to a missing unit", k), Repairable: true})
+		case key != k:
+			add(ports.FsckFinding{Kind: ports.FsckIndexMismatch, UnitID: id, Path: unitsByKeyRel, Detail: fmt.Sprintf("key %q maps to a unit with key %q", k, key), Repairable: true})
+		}
+	}
+	for _, u := range units {
+		if _, ok := m[u.h.Key]; !ok {
+			add(ports.FsckFinding{Kind: ports.FsckIndexMissing, UnitID: u.h.ID, Path: unitsByKeyRel, Detail: fmt.Sprintf("key %q not indexed", u.h.Key), Repairable: true})
+		}
+	}
+	return nil
+}
+
+// checkVersionsByID compares index/versions_by_id.json with the version
+// lists of the unit headers.
+func (c *DataDirChecker) checkVersionsByID(units []checkedUnit, add func(ports.FsckFinding)) error {
+	s := newIndexStore(c.basePath)
+	if _, err := os.Stat(s.versionsByIDPath()); os.IsNotExist(err) {
+		return nil
+	}
+	m, err := s.loadVersionsByID()
+	if err != nil {
+		add(ports.FsckFinding{Kind: ports.FsckIndexInvalid, Path: versionsByIDRel, Detail: err.Error(), Repairable: true})
+		return nil
+	}
+
+	owner := map[string]string{}
+	for _, u := range units {
+		for _, verID := range u.h.VersionIDs {
+			owner[verID] = u.h.ID
+		}
+	}
+	ids := make([]string, 0, len(m))
+	for verID := range m {
+		ids = append(ids, verID)
+	}
+	sort.Strings(ids)
+	for _, verID := range ids {
+		unitID, ok := owner[verID]
+		switch {
+		case !ok:
+			add(ports.FsckFinding{Kind: ports.FsckIndexDangling, UnitID: m[verID], VersionID: verID, Path: versionsByIDRel, Detail: "version is not listed by any unit", Repairable: true})
+		case unitID != m[verID]:
+			add(ports.FsckFinding{Kind: ports.FsckIndexMismatch, UnitID: unitID, VersionID: verID, Path: versionsByIDRel, Detail: fmt.Sprintf("indexed under unit %q", m[verID]), Repairable: true})
+		}
+	}
+	for _, u := range units {
+		for _, verID := range u.h.VersionIDs {
+			if _, ok := m[verID]; !ok {
+				add(ports.FsckFinding{Kind: ports.FsckIndexMissing, UnitID: u.h.ID, VersionID: verID, Path: versionsByIDRel, Detail: "version not indexed", Repairable: true})
+			}
+		}
+	}
+	return nil
+}
+
+// RebuildIndex regenerates index/units_by_key.json or
+// index/versions_by_id.json from the unit headers.
+func (c *DataDirChecker) RebuildIndex(path string) (ports.FsckRepair, error) {
+	unlock, err := lockDataDir(c.basePath)
+	if err != nil {
+		return ports.FsckRepair{}, err
+	}
+	defer unlock()
+
+	s := newIndexStore(c.basePath)
+	var m map[string]string
+	switch path {
+	case unitsByKeyRel:
+		if m, err = rebuildUnitsByKey(c.basePath); err == nil {
+			err = s.saveUnitsByKeyAtomic(m)
+		}
+	case versionsByIDRel:
+		if m, err = rebuildVersionsByID(c.basePath); err == nil {
+			err = s.saveVersionsByIDAtomic(m)
+		}
+	default:
+		return ports.FsckRepair{}, fmt.Errorf("no index at %s", path)
+	}
+	if err != nil {
+		return ports.FsckRepair{}, err
+	}
+	return ports.FsckRepair{Kind: "index_rebuilt", Path: path, Entries: len(m)}, nil
+}
@@ -13,15 +13,24 @@ import (
 
 // indexStore keeps a persistent mapping from unit key -> unit id.
 // Stored on disk at: <data>/index/units_by_key.json
+// v0.6: and version id -> unit id at <data>/index/versions_by_id.json plus
+// the journal versions_by_id.log (see index_versions.go).
 type indexStore struct {
 	mu   sync.Mutex
 	base string
 
 	// loaded indicates whether the index has been loaded (even if empty)
-	loaded bool
+	loaded         bool
+	versionsLoaded bool
 
 	// in-memory maps
-	unitIDByKey map[string]string
+	unitIDByKey     map[string]string
+	unitIDByVersion map[string]string
+
+	// where unitIDByVersion was read up to (see index_versions.go)
+	versionsSnap   os.FileInfo
+	versionsLogOff int64
+	versionsLogN   int
 }
 
 type unitsByKeyFile struct {
@@ -33,9 +42,10 @@ const unitsByKeySchema = "digiemu.index.units_by_key.v1"
 
 func newIndexStore(basePath string) *indexStore {
 	return &indexStore{
-		base:        basePath,
-		loaded:      false,
-		unitIDByKey: map[string]string{},
+		base:            basePath,
+		loaded:          false,
+		unitIDByKey:     map[string]string{},
+		unitIDByVersion: map[string]string{},
 	}
 }
 
@@ -85,6 +95,12 @@ func (s *indexStore) ensureLoaded(rebuild func() error) error {
 	return nil
 }
 
+func (s *indexStore) isLoaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded
+}
+
 // loadLocked attempts to load the index file into memory. It must be called while
 // holding s.mu or it will lock internally. It guarantees s.unitIDByKey is non-nil
 // on success (may be empty map).
@@ -123,8 +139,11 @@ func (s *indexStore) listUnitIDs() []string {
 }
 
 // upsertUnitKey updates index in memory and persists best-effort.
+// Callers hold the data dir lock; the file is re-read first so that keys
+// added by other processes are kept.
 func (s *indexStore) upsertUnitKey(key, unitID string) {
 	s.mu.Lock()
+	s.reloadFromDiskLocked()
 	if s.unitIDByKey == nil {
 		s.unitIDByKey = map[string]string{}
 	}
@@ -139,6 +158,48 @@ func (s *indexStore) upsertUnitKey(key, unitID string) {
 	_ = s.saveUnitsByKeyAtomic(snapshot)
 }
 
+// removeUnitKey drops key from the index if it still maps to unitID.
+func (s *indexStore) removeUnitKey(key, unitID string) {
+	s.mu.Lock()
+	s.reloadFromDiskLocked()
+	if s.unitIDByKey[key] != unitID {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.unitIDByKey, key)
+	snapshot := make(map[string]string, len(s.unitIDByKey))
+	for k, v := range s.unitIDByKey {
+		snapshot[k] = v
+	}
+	s.mu.Unlock()
+
+	_ = s.saveUnitsByKeyAtomic(snapshot)
+}
+
+// lookupOnDisk re-reads the index file and returns the unit of key. Callers
+// hold the data dir lock, so the answer stays true until they release it.
+func (s *indexStore) lookupOnDisk(key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.loadUnitsByKey()
+	if err != nil {
+		return "", false, err
+	}
+	s.unitIDByKey = m
+	s.loaded = true
+	id, ok := m[key]
+	return id, ok, nil
+}
+
+// reloadFromDiskLocked replaces the in-memory map with the index file if it
+// is readable. Must be called while holding s.mu.
+func (s *indexStore) reloadFromDiskLocked() {
+	if m, err := s.loadUnitsByKey(); err == nil {
+		s.unitIDByKey = m
+		s.loaded = true
+	}
+}
+
 func (s *indexStore) loadUnitsByKey() (map[string]string, error) {
 	p := s.unitsByKeyPath()
 	b, err := os.ReadFile(p)
@@ -192,11 +253,5 @@ func (s *indexStore) saveUnitsByKeyAtomic(m map[string]string) error {
 		return err
 	}
 
-	dst := s.unitsByKeyPath()
-	tmp := dst + ".tmp"
-
-	if err := os.WriteFile(tmp, b, 0o644); err != nil {
-		return err
-	}
-	return os.Rename(tmp, dst)
+	return writeFileAtomic(s.unitsByKeyPath(), b, 0o644)
 }
@@ -1,8 +1,11 @@
 package fs_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	fsrepo "digiemu-core/internal/kernel/adapters/fs"
@@ -72,3 +75,146 @@ func TestIndex_ListUnits_Works_WhenIndexEmpty(t *testing.T) {
 		t.Fatalf("expected 0 units, got %d", len(us))
 	}
 }
+
+// seedVersions saves unit_1 with two versions and returns the index path.
+func seedVersions(t *testing.T, dir string) (*fsrepo.UnitRepo, string) {
+	t.Helper()
+	repo := fsrepo.NewUnitRepo(dir)
+	if err := repo.SaveUnit(domain.Unit{ID: "unit_1", Key: "abc", Title: "Title"}); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	for _, id := range []string{"ver_1", "ver_2"} {
+		if err := repo.SaveVersion(domain.Version{ID: id, UnitID: "unit_1", Label: "v", Content: "c"}); err != nil {
+			t.Fatalf("SaveVersion: %v", err)
+		}
+	}
+	return repo, filepath.Join(dir, "index", "versions_by_id.json")
+}
+
+func TestVersionIndex_UpdatedOnSaveVersion(t *testing.T) {
+	dir := t.TempDir()
+	repo, idxPath := seedVersions(t, dir)
+
+	// first lookup builds the index; later saves keep it current
+	if _, ok, err := repo.FindVersionByID("ver_1"); err != nil || !ok {
+		t.Fatalf("FindVersionByID: %v %v", ok, err)
+	}
+	snapshot, err := os.ReadFile(idxPath)
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	var f struct {
+		Schema   string            `json:"schema"`
+		Versions map[string]string `json:"versions"`
+	}
+	if err := json.Unmarshal(snapshot, &f); err != nil {
+		t.Fatalf("index json: %v", err)
+	}
+	if f.Schema != "digiemu.index.versions_by_id.v1" || len(f.Versions) != 2 || f.Versions["ver_2"] != "unit_1" {
+		t.Fatalf("unexpected index: %+v", f)
+	}
+
+	// a save appends to the journal instead of rewriting the snapshot; a
+	// torn record of a crashed writer is cut off first
+	logPath := filepath.Join(filepath.Dir(idxPath), "versions_by_id.log")
+	if err := os.WriteFile(logPath, []byte(`{"op":"put","versionId":"ver_x"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveVersion(domain.Version{ID: "ver_3", UnitID: "unit_1", Label: "v", Content: "c"}); err != nil {
+		t.Fatalf("SaveVersion: %v", err)
+	}
+	if b, err := os.ReadFile(idxPath); err != nil || !bytes.Equal(b, snapshot) {
+		t.Fatalf("snapshot rewritten: %s %v", b, err)
+	}
+	b, err := os.ReadFile(logPath)
+	if err != nil || string(b) != `{"op":"put","versionId":"ver_3","unitId":"unit_1"}`+"\n" {
+		t.Fatalf("unexpected journal: %q %v", b, err)
+	}
+
+	// another process replays the journal
+	rep, err := fsrepo.NewDataDirChecker(dir).CheckDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fnd := range rep.Findings {
+		if fnd.Path == "index/versions_by_id.json" {
+			t.Fatalf("unexpected finding: %+v", fnd)
+		}
+	}
+
+	// a rebuild folds the journal into the snapshot
+	if _, err := fsrepo.NewDataDirChecker(dir).RebuildIndex("index/versions_by_id.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Fatalf("journal kept after rebuild: %v", err)
+	}
+	if b, err := os.ReadFile(idxPath); err != nil || !strings.Contains(string(b), `"ver_3": "unit_1"`) {
+		t.Fatalf("rebuilt snapshot: %s %v", b, err)
+	}
+}
+
+func TestVersionIndex_Rebuild(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(p string) error
+	}{
+		{"missing", func(p string) error { return os.RemoveAll(filepath.Dir(p)) }},
+		{"corrupt", func(p string) error { return os.WriteFile(p, []byte("{ this is not json"), 0o644) }},
+		{"empty", func(p string) error { return os.WriteFile(p, []byte("  "), 0o644) }},
+		{"schema mismatch", func(p string) error {
+			return os.WriteFile(p, []byte(`{"schema":"digiemu.index.versions_by_id.v0","versions":{"ver_1":"unit_x"}}`), 0o644)
+		}},
+		{"empty ids", func(p string) error {
+			return os.WriteFile(p, []byte(`{"schema":"digiemu.index.versions_by_id.v1","versions":{"ver_1":" "}}`), 0o644)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			_, idxPath := seedVersions(t, dir)
+			if err := os.MkdirAll(filepath.Dir(idxPath), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.write(idxPath); err != nil {
+				t.Fatal(err)
+			}
+
+			// a fresh repo (new process) has to load the broken file
+			repo := fsrepo.NewUnitRepo(dir)
+			got, ok, err := repo.FindVersionByID("ver_2")
+			if err != nil {
+				t.Fatalf("FindVersionByID: %v", err)
+			}
+			if !ok || got.UnitID != "unit_1" {
+				t.Fatalf("expected ver_2 of unit_1, got ok=%v unit=%s", ok, got.UnitID)
+			}
+			b, err := os.ReadFile(idxPath)
+			if err != nil || !strings.Contains(string(b), `"ver_1": "unit_1"`) {
+				t.Fatalf("expected rebuilt index, got %s %v", b, err)
+			}
+		})
+	}
+}
+
+func TestVersionIndex_StaleEntryFallsBackToScan(t *testing.T) {
+	dir := t.TempDir()
+	_, idxPath := seedVersions(t, dir)
+	stale := `{"schema":"digiemu.index.versions_by_id.v1","versions":{"ver_1":"unit_gone"}}`
+	if err := os.MkdirAll(filepath.Dir(idxPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(idxPath, []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := fsrepo.NewUnitRepo(dir)
+	for _, id := range []string{"ver_1", "ver_2"} {
+		got, ok, err := repo.FindVersionByID(id)
+		if err != nil || !ok || got.UnitID != "unit_1" {
+			t.Fatalf("%s: expected unit_1, got ok=%v unit=%s err=%v", id, ok, got.UnitID, err)
+		}
+	}
+	if _, ok, err := repo.FindVersionByID("ver_missing"); err != nil || ok {
+		t.Fatalf("expected not found, got ok=%v err=%v", ok, err)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"strings"
 )
 
-// rebuildUnitsByKey scans <data>/units/*.json and rebuilds key->id map.
+// rebuildUnitsByKey scans the unit headers <data>/units/*.json and rebuilds key->id map.
 // It is intentionally forgiving: skips unreadable/broken unit files.
 func rebuildUnitsByKey(basePath string) (map[string]string, error) {
 	unitsDir := filepath.Join(basePath, "units")
@@ -32,7 +32,7 @@ func rebuildUnitsByKey(basePath string) (map[string]string, error) {
 		if err != nil {
 			continue
 		}
-		var rec UnitRecord
+		var rec UnitHeader
 		if err := json.Unmarshal(b, &rec); err != nil {
 			continue
 		}
@@ -45,3 +45,37 @@ func rebuildUnitsByKey(basePath string) (map[string]string, error) {
 	}
 	return out, nil
 }
+
+// rebuildVersionsByID scans the unit headers and rebuilds versionID->unitID.
+// Forgiving like rebuildUnitsByKey: unreadable/legacy unit files are skipped.
+func rebuildVersionsByID(basePath string) (map[string]string, error) {
+	unitsDir := filepath.Join(basePath, "units")
+	entries, err := os.ReadDir(unitsDir)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		h, err := readHeaderFile(filepath.Join(unitsDir, e.Name()))
+		if err != nil {
+			continue
+		}
+		uid := strings.TrimSpace(h.ID)
+		if uid == "" {
+			continue
+		}
+		for _, vid := range h.VersionIDs {
+			if vid = strings.TrimSpace(vid); vid != "" {
+				out[vid] = uid
+			}
+		}
+	}
+	return out, nil
+}
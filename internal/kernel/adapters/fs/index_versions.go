@@ -0,0 +1,370 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// v0.6: version id -> unit id index, so FindVersionByID reads one version
+// file instead of scanning unit headers. It follows units_by_key.json:
+// rebuilt when missing or corrupt, updated best-effort by writers, and
+// never trusted for "not found" (callers fall back to a scan).
+//
+// Unlike the key index it grows with every SaveVersion, so writers do not
+// rewrite it: versions_by_id.json is a snapshot written on rebuild, and
+// versions_by_id.log an append-only journal of the changes since, folded
+// into a new snapshot once it has more records than the snapshot entries.
+
+type versionsByIDFile struct {
+	Schema   string            `json:"schema"`
+	Versions map[string]string `json:"versions"` // versionID -> unitID
+}
+
+const versionsByIDSchema = "digiemu.index.versions_by_id.v1"
+
+// versionsLogRecord is one line of the journal.
+type versionsLogRecord struct {
+	Op        string `json:"op"` // versionsLogPut or versionsLogDel
+	VersionID string `json:"versionId"`
+	UnitID    string `json:"unitId"`
+}
+
+const (
+	versionsLogPut = "put"
+	versionsLogDel = "del"
+
+	// versionsLogCompactMin keeps small data dirs from compacting on
+	// almost every write.
+	versionsLogCompactMin = 1024
+)
+
+func (rec versionsLogRecord) validate() error {
+	if rec.Op != versionsLogPut && rec.Op != versionsLogDel {
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	if strings.TrimSpace(rec.VersionID) == "" || strings.TrimSpace(rec.UnitID) == "" {
+		return errors.New("empty version or unit id")
+	}
+	return nil
+}
+
+// apply applies rec to m and reports whether m changed. A delete only
+// drops the version if it still maps to rec.UnitID.
+func (rec versionsLogRecord) apply(m map[string]string) bool {
+	switch rec.Op {
+	case versionsLogPut:
+		if m[rec.VersionID] == rec.UnitID {
+			return false
+		}
+		m[rec.VersionID] = rec.UnitID
+	case versionsLogDel:
+		if m[rec.VersionID] != rec.UnitID {
+			return false
+		}
+		delete(m, rec.VersionID)
+	default:
+		return false
+	}
+	return true
+}
+
+// versionsByIDState is the snapshot with the journal replayed onto it.
+type versionsByIDState struct {
+	m      map[string]string
+	snap   os.FileInfo // snapshot the map was loaded from
+	logOff int64       // end of the last journal record replayed
+	logN   int         // journal records replayed
+}
+
+func (s *indexStore) versionsByIDPath() string {
+	return filepath.Join(s.indexDir(), "versions_by_id.json")
+}
+
+func (s *indexStore) versionsLogPath() string {
+	return filepath.Join(s.indexDir(), "versions_by_id.log")
+}
+
+// ensureVersionsLoaded is ensureLoaded for the version index.
+func (s *indexStore) ensureVersionsLoaded(rebuild func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.versionsLoaded {
+		return nil
+	}
+	load := func() error {
+		st, err := s.readVersionsByID()
+		if err != nil {
+			return err
+		}
+		s.setVersionsLocked(st)
+		return nil
+	}
+	if err := load(); err == nil || rebuild == nil {
+		return err
+	}
+	if err := rebuild(); err != nil {
+		return err
+	}
+	return load()
+}
+
+func (s *indexStore) isVersionsLoaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versionsLoaded
+}
+
+// getUnitIDByVersion returns (unitID, ok). Call ensureVersionsLoaded beforehand.
+func (s *indexStore) getUnitIDByVersion(versionID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.unitIDByVersion[versionID]
+	return id, ok
+}
+
+// upsertVersions maps versionIDs to unitID and persists best-effort.
+// Callers hold the data dir lock.
+func (s *indexStore) upsertVersions(unitID string, versionIDs ...string) {
+	recs := make([]versionsLogRecord, 0, len(versionIDs))
+	for _, id := range versionIDs {
+		recs = append(recs, versionsLogRecord{Op: versionsLogPut, VersionID: id, UnitID: unitID})
+	}
+	s.updateVersions(recs)
+}
+
+// removeVersions drops versionIDs from the index if they map to unitID.
+func (s *indexStore) removeVersions(unitID string, versionIDs ...string) {
+	recs := make([]versionsLogRecord, 0, len(versionIDs))
+	for _, id := range versionIDs {
+		recs = append(recs, versionsLogRecord{Op: versionsLogDel, VersionID: id, UnitID: unitID})
+	}
+	s.updateVersions(recs)
+}
+
+// updateVersions catches up with the journal (entries other processes
+// appended), applies recs and appends the ones that changed something. The
+// journal is folded into the snapshot once it outgrows it.
+func (s *indexStore) updateVersions(recs []versionsLogRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshVersionsLocked(); err != nil {
+		// unknown on-disk state: leave it to the next rebuild
+		return
+	}
+	var buf []byte
+	for _, rec := range recs {
+		if !rec.apply(s.unitIDByVersion) {
+			continue
+		}
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return
+		}
+		buf = append(append(buf, b...), '\n')
+		s.versionsLogN++
+	}
+	if len(buf) == 0 {
+		return
+	}
+
+	// Best-effort writes; errors are not fatal for core ops.
+	if err := s.appendVersionsLog(buf); err != nil {
+		s.versionsLoaded = false
+		return
+	}
+	s.versionsLogOff += int64(len(buf))
+	if s.versionsLogN > versionsLogCompactMin && s.versionsLogN > len(s.unitIDByVersion) {
+		snapshot := make(map[string]string, len(s.unitIDByVersion))
+		for k, v := range s.unitIDByVersion {
+			snapshot[k] = v
+		}
+		if err := s.saveVersionsByIDAtomic(snapshot); err != nil {
+			s.versionsLoaded = false
+			return
+		}
+		s.versionsSnap, _ = os.Stat(s.versionsByIDPath())
+		s.versionsLogOff, s.versionsLogN = 0, 0
+	}
+}
+
+// refreshVersionsLocked brings the in-memory map up to date with the files:
+// a new snapshot (rebuilt or compacted elsewhere) is loaded afresh,
+// otherwise only the journal records after versionsLogOff are replayed.
+// Callers hold s.mu.
+func (s *indexStore) refreshVersionsLocked() error {
+	fi, err := os.Stat(s.versionsByIDPath())
+	if err != nil {
+		s.versionsLoaded = false
+		return err
+	}
+	if s.versionsLoaded && s.versionsSnap != nil && os.SameFile(fi, s.versionsSnap) {
+		st := versionsByIDState{m: s.unitIDByVersion, snap: s.versionsSnap, logOff: s.versionsLogOff, logN: s.versionsLogN}
+		if err := s.replayVersionsLog(&st); err == nil {
+			s.setVersionsLocked(st)
+			return nil
+		}
+	}
+	st, err := s.readVersionsByID()
+	if err != nil {
+		s.versionsLoaded = false
+		return err
+	}
+	s.setVersionsLocked(st)
+	return nil
+}
+
+func (s *indexStore) setVersionsLocked(st versionsByIDState) {
+	s.unitIDByVersion = st.m
+	s.versionsSnap = st.snap
+	s.versionsLogOff = st.logOff
+	s.versionsLogN = st.logN
+	s.versionsLoaded = true
+}
+
+// appendVersionsLog appends complete records at versionsLogOff, cutting off
+// a torn record a crashed writer left behind. Callers hold the data dir lock.
+func (s *indexStore) appendVersionsLog(b []byte) error {
+	p := s.versionsLogPath()
+	if fi, err := os.Stat(p); err == nil && fi.Size() > s.versionsLogOff {
+		if err := os.Truncate(p, s.versionsLogOff); err != nil {
+			return err
+		}
+	}
+	return appendFileSync(p, b)
+}
+
+func (s *indexStore) loadVersionsByID() (map[string]string, error) {
+	st, err := s.readVersionsByID()
+	if err != nil {
+		return nil, err
+	}
+	return st.m, nil
+}
+
+// readVersionsByID loads the snapshot and replays the journal onto it.
+func (s *indexStore) readVersionsByID() (versionsByIDState, error) {
+	fi, err := os.Stat(s.versionsByIDPath())
+	if os.IsNotExist(err) {
+		return versionsByIDState{}, errors.New("version index file missing")
+	}
+	if err != nil {
+		return versionsByIDState{}, err
+	}
+	m, err := s.loadVersionsSnapshot()
+	if err != nil {
+		return versionsByIDState{}, err
+	}
+	st := versionsByIDState{m: m, snap: fi}
+	if err := s.replayVersionsLog(&st); err != nil {
+		return versionsByIDState{}, err
+	}
+	return st, nil
+}
+
+// replayVersionsLog applies the complete journal records after st.logOff.
+// A trailing record without its newline is still being written (or torn)
+// and is left for later.
+func (s *indexStore) replayVersionsLog(st *versionsByIDState) error {
+	f, err := os.Open(s.versionsLogPath())
+	if os.IsNotExist(err) {
+		if st.logOff > 0 {
+			return errors.New("version index journal removed")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() < st.logOff {
+		return fmt.Errorf("version index journal shrank below offset %d", st.logOff)
+	}
+	b, err := io.ReadAll(io.NewSectionReader(f, st.logOff, fi.Size()-st.logOff))
+	if err != nil {
+		return err
+	}
+	for {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil
+		}
+		var rec versionsLogRecord
+		if err := json.Unmarshal(b[:i], &rec); err != nil {
+			return fmt.Errorf("version index journal at offset %d: %w", st.logOff, err)
+		}
+		if err := rec.validate(); err != nil {
+			return fmt.Errorf("version index journal at offset %d: %w", st.logOff, err)
+		}
+		rec.apply(st.m)
+		st.logOff += int64(i + 1)
+		st.logN++
+		b = b[i+1:]
+	}
+}
+
+func (s *indexStore) loadVersionsSnapshot() (map[string]string, error) {
+	b, err := os.ReadFile(s.versionsByIDPath())
+	if os.IsNotExist(err) {
+		return nil, errors.New("version index file missing")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return nil, errors.New("version index file empty")
+	}
+
+	var f versionsByIDFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("version index json invalid: %w", err)
+	}
+	if f.Schema != versionsByIDSchema {
+		return nil, fmt.Errorf("version index schema mismatch: %s", f.Schema)
+	}
+	if f.Versions == nil {
+		return nil, errors.New("version index versions missing")
+	}
+
+	out := make(map[string]string, len(f.Versions))
+	for vid, uid := range f.Versions {
+		vid = strings.TrimSpace(vid)
+		uid = strings.TrimSpace(uid)
+		if vid == "" || uid == "" {
+			return nil, errors.New("version index contains empty version or unit id")
+		}
+		out[vid] = uid
+	}
+	return out, nil
+}
+
+func (s *indexStore) saveVersionsByIDAtomic(m map[string]string) error {
+	if err := os.MkdirAll(s.indexDir(), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(versionsByIDFile{Schema: versionsByIDSchema, Versions: m}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.versionsByIDPath(), b, 0o644); err != nil {
+		return err
+	}
+	// the snapshot holds everything the journal did; replaying a journal
+	// that survives a crash here is harmless
+	if err := os.Remove(s.versionsLogPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// IntentJournal stores pending intents as <data>/journal/<intentId>.json.
+// Begin syncs the file before returning so that the intent survives a crash
+// of the following repository writes; Commit removes it.
+//
+// From Begin until Commit or Abort the intent holds a shared flock on
+// <data>/journal/.lock (one file description per intent, so this also works
+// between goroutines). LockRecovery takes it exclusively: recovery never
+// sees an intent whose writer is still running. A writer that dies releases
+// its lock with the process.
+type IntentJournal struct {
+	basePath string
+	dir      string
+
+	mu     sync.Mutex
+	owners map[string]*os.File // intents in flight -> their lock
+}
+
+func NewIntentJournal(basePath string) *IntentJournal {
+	openDataDir(basePath)
+	return &IntentJournal{
+		basePath: basePath,
+		dir:      filepath.Join(basePath, "journal"),
+		owners:   map[string]*os.File{},
+	}
+}
+
+func (j *IntentJournal) path(id string) string {
+	return filepath.Join(j.dir, id+".json")
+}
+
+func (j *IntentJournal) openLock() (*os.File, error) {
+	if err := os.MkdirAll(j.dir, 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(j.dir, ".lock"), os.O_CREATE|os.O_RDWR, 0o644)
+}
+
+func (j *IntentJournal) Begin(in domain.Intent) error {
+	f, err := j.openLock()
+	if err != nil {
+		return err
+	}
+	if err := lockFileShared(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := j.write(in); err != nil {
+		_ = unlockFile(f)
+		f.Close()
+		return err
+	}
+	j.mu.Lock()
+	j.owners[in.ID] = f
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *IntentJournal) write(in domain.Intent) error {
+	unlock, err := lockDataDir(j.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	b, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(j.path(in.ID), b, 0o644)
+}
+
+func (j *IntentJournal) Commit(intentID string) error {
+	defer j.release(intentID)
+
+	unlock, err := lockDataDir(j.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(j.path(intentID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Abort rewrites the intent marked as aborted and releases it, leaving it
+// to RecoverIntents to undo.
+func (j *IntentJournal) Abort(intentID string) error {
+	defer j.release(intentID)
+
+	b, err := os.ReadFile(j.path(intentID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var in domain.Intent
+	if err := json.Unmarshal(b, &in); err != nil {
+		return fmt.Errorf("intent %s invalid: %w", intentID, err)
+	}
+	in.Aborted = true
+	return j.write(in)
+}
+
+func (j *IntentJournal) release(intentID string) {
+	j.mu.Lock()
+	f, ok := j.owners[intentID]
+	delete(j.owners, intentID)
+	j.mu.Unlock()
+	if ok {
+		_ = unlockFile(f)
+		f.Close()
+	}
+}
+
+// Close releases the intents still in flight, as the exit of the process
+// would; their entries stay pending for recovery.
+func (j *IntentJournal) Close() error {
+	j.mu.Lock()
+	ids := make([]string, 0, len(j.owners))
+	for id := range j.owners {
+		ids = append(ids, id)
+	}
+	j.mu.Unlock()
+	for _, id := range ids {
+		j.release(id)
+	}
+	return nil
+}
+
+// LockRecovery implements ports.IntentJournalLocker.
+func (j *IntentJournal) LockRecovery() (func(), error) {
+	f, err := j.openLock()
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// ListPending returns journaled intents ordered by (AtUnix, ID). Leftover
+// .tmp files belong to a Begin that never completed (the operation did not
+// start); they are skipped here and removed by recoverDataDir.
+func (j *IntentJournal) ListPending() ([]domain.Intent, error) {
+	entries, err := os.ReadDir(j.dir)
+	if os.IsNotExist(err) {
+		return []domain.Intent{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := []domain.Intent{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			continue
+		}
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(j.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var in domain.Intent
+		if err := json.Unmarshal(b, &in); err != nil {
+			return nil, fmt.Errorf("intent %s invalid: %w", name, err)
+		}
+		if in.Schema != domain.IntentSchema {
+			return nil, fmt.Errorf("intent %s schema mismatch: %s", name, in.Schema)
+		}
+		out = append(out, in)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].AtUnix != out[b].AtUnix {
+			return out[a].AtUnix < out[b].AtUnix
+		}
+		return out[a].ID < out[b].ID
+	})
+	return out, nil
+}
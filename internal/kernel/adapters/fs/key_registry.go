@@ -0,0 +1,130 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// KeyRegistry stores actor public keys at <data>/keys/registry.json.
+type KeyRegistry struct {
+	basePath string
+	path     string
+	mu       sync.Mutex
+}
+
+type keyRegistryFile struct {
+	Schema string            `json:"schema"`
+	Keys   []domain.ActorKey `json:"keys"`
+}
+
+const keyRegistrySchema = "digiemu.keys.registry.v1"
+
+func NewKeyRegistry(basePath string) *KeyRegistry {
+	return &KeyRegistry{basePath: basePath, path: filepath.Join(basePath, "keys", "registry.json")}
+}
+
+func (r *KeyRegistry) load() ([]domain.ActorKey, error) {
+	b, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return []domain.ActorKey{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f keyRegistryFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("key registry invalid: %w", err)
+	}
+	if f.Schema != keyRegistrySchema {
+		return nil, fmt.Errorf("key registry schema mismatch: %s", f.Schema)
+	}
+	if f.Keys == nil {
+		f.Keys = []domain.ActorKey{}
+	}
+	return f.Keys, nil
+}
+
+func (r *KeyRegistry) save(keys []domain.ActorKey) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(keyRegistryFile{Schema: keyRegistrySchema, Keys: keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, b, 0o644)
+}
+
+func (r *KeyRegistry) RegisterKey(k domain.ActorKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := r.load()
+	if err != nil {
+		return err
+	}
+	for _, existing := range keys {
+		if existing.KeyID == k.KeyID {
+			return domain.ErrKeyAlreadyExists
+		}
+	}
+	return r.save(append(keys, k))
+}
+
+func (r *KeyRegistry) RevokeKey(keyID string, atUnix int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := r.load()
+	if err != nil {
+		return err
+	}
+	for i := range keys {
+		if keys[i].KeyID == keyID {
+			if keys[i].RevokedAtUnix != 0 {
+				return domain.ErrKeyRevoked
+			}
+			keys[i].RevokedAtUnix = atUnix
+			return r.save(keys)
+		}
+	}
+	return domain.ErrKeyNotFound
+}
+
+func (r *KeyRegistry) FindKey(keyID string) (domain.ActorKey, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys, err := r.load()
+	if err != nil {
+		return domain.ActorKey{}, false, err
+	}
+	for _, k := range keys {
+		if k.KeyID == keyID {
+			return k, true, nil
+		}
+	}
+	return domain.ActorKey{}, false, nil
+}
+
+// ListKeys returns keys in registration order.
+func (r *KeyRegistry) ListKeys() ([]domain.ActorKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
@@ -0,0 +1,122 @@
+package fs
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// KeyFile is the private key of one actor, kept in a file of its own
+// outside any data dir: whoever can read a data dir can only verify
+// signatures (keys/registry.json), never sign as its actors.
+type KeyFile struct {
+	KeyID   string
+	ActorID string
+	Key     ed25519.PrivateKey
+}
+
+type privateKeyFile struct {
+	Schema     string `json:"schema"`
+	KeyID      string `json:"keyId"`
+	ActorID    string `json:"actorId"`
+	PrivateKey string `json:"privateKey"` // base64 (std) of the 64-byte key
+}
+
+const privateKeySchema = "digiemu.keys.private.v1"
+
+// WriteKeyFile stores priv for actorID at path (mode 0600). An existing
+// file is never overwritten, so a key cannot be lost to a second generate.
+func WriteKeyFile(path, actorID string, priv ed25519.PrivateKey) (KeyFile, error) {
+	kf := KeyFile{KeyID: domain.ActorKeyID(priv.Public().(ed25519.PublicKey)), ActorID: actorID, Key: priv}
+	b, err := json.MarshalIndent(privateKeyFile{
+		Schema:     privateKeySchema,
+		KeyID:      kf.KeyID,
+		ActorID:    actorID,
+		PrivateKey: base64.StdEncoding.EncodeToString(priv),
+	}, "", "  ")
+	if err != nil {
+		return KeyFile{}, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return KeyFile{}, err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return KeyFile{}, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return KeyFile{}, err
+	}
+	return kf, f.Close()
+}
+
+// ReadKeyFile loads the key file at path.
+func ReadKeyFile(path string) (KeyFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return KeyFile{}, err
+	}
+	var pf privateKeyFile
+	if err := json.Unmarshal(b, &pf); err != nil {
+		return KeyFile{}, fmt.Errorf("key file %s invalid: %w", path, err)
+	}
+	if pf.Schema != privateKeySchema {
+		return KeyFile{}, fmt.Errorf("key file %s schema mismatch: %s", path, pf.Schema)
+	}
+	raw, err := base64.StdEncoding.DecodeString(pf.PrivateKey)
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		return KeyFile{}, fmt.Errorf("key file %s: private key invalid", path)
+	}
+	priv := ed25519.PrivateKey(raw)
+	if id := domain.ActorKeyID(priv.Public().(ed25519.PublicKey)); id != pf.KeyID {
+		return KeyFile{}, fmt.Errorf("key file %s: key id %s does not match the key (%s)", path, pf.KeyID, id)
+	}
+	return KeyFile{KeyID: pf.KeyID, ActorID: pf.ActorID, Key: priv}, nil
+}
+
+// KeySigner implements ports.AuditSigner and ports.TreeHeadSigner with the
+// key of one actor: it signs that actor's events (and tree heads if the
+// actor is domain.TreeHeadActorID) while the key is registered in Keys for
+// the actor and not revoked. Events of other actors stay unsigned.
+type KeySigner struct {
+	Key  KeyFile
+	Keys ports.ActorKeyRegistry
+}
+
+func (s KeySigner) SignAuditEvent(ev domain.AuditEvent) (domain.AuditEvent, bool, error) {
+	ok, err := s.usable(ev.ActorID)
+	if err != nil || !ok {
+		return ev, false, err
+	}
+	if err := domain.SignAuditEvent(&ev, s.Key.KeyID, s.Key.Key); err != nil {
+		return ev, false, err
+	}
+	return ev, true, nil
+}
+
+func (s KeySigner) SignTreeHead(th domain.TreeHead) (domain.TreeHead, bool, error) {
+	ok, err := s.usable(domain.TreeHeadActorID)
+	if err != nil || !ok {
+		return th, false, err
+	}
+	domain.SignTreeHead(&th, s.Key.KeyID, s.Key.Key)
+	return th, true, nil
+}
+
+func (s KeySigner) usable(actorID string) (bool, error) {
+	if s.Key.Key == nil || s.Key.ActorID != actorID {
+		return false, nil
+	}
+	reg, ok, err := s.Keys.FindKey(s.Key.KeyID)
+	if err != nil {
+		return false, err
+	}
+	return ok && reg.RevokedAtUnix == 0 && reg.ActorID == actorID, nil
+}
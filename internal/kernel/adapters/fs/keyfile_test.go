@@ -0,0 +1,60 @@
+package fs_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+)
+
+func TestKeyFile_SignsOnlyItsRegisteredActor(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "alice.key")
+	if _, err := fsrepo.WriteKeyFile(p, "alice", priv); err != nil {
+		t.Fatal(err)
+	}
+	if st, err := os.Stat(p); err != nil || st.Mode().Perm() != 0o600 {
+		t.Fatalf("key file mode: %v %v", st, err)
+	}
+	if _, err := fsrepo.WriteKeyFile(p, "alice", priv); err == nil {
+		t.Fatal("expected an existing key file not to be overwritten")
+	}
+	kf, err := fsrepo.ReadKeyFile(p)
+	if err != nil || kf.ActorID != "alice" || !kf.Key.Equal(priv) {
+		t.Fatalf("read key file: %+v %v", kf, err)
+	}
+
+	keys := fsrepo.NewKeyRegistry(dir)
+	signer := fsrepo.KeySigner{Key: kf, Keys: keys}
+	ev := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: "evt_1", Type: "x", AtUnix: 1, ActorID: "alice"}
+	if _, ok, err := signer.SignAuditEvent(ev); err != nil || ok {
+		t.Fatalf("unregistered key signed: %v %v", ok, err)
+	}
+
+	k, err := domain.NewActorKey("alice", base64.StdEncoding.EncodeToString(pub), 1700000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.RegisterKey(k); err != nil {
+		t.Fatal(err)
+	}
+	if signed, ok, err := signer.SignAuditEvent(ev); err != nil || !ok || signed.KeyID != kf.KeyID {
+		t.Fatalf("registered key did not sign: %+v %v %v", signed, ok, err)
+	}
+	ev.ActorID = "bob"
+	if _, ok, err := signer.SignAuditEvent(ev); err != nil || ok {
+		t.Fatalf("signed an event of another actor: %v %v", ok, err)
+	}
+	if _, ok, err := signer.SignTreeHead(domain.TreeHead{TreeSize: 1}); err != nil || ok {
+		t.Fatalf("signed a tree head with a key of alice: %v %v", ok, err)
+	}
+}
@@ -0,0 +1,251 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Data dir layout versions (recorded in <data>/layout.json):
+//
+//	1  units/<unitId>.json embeds every version; sidecars next to it as
+//	   units/<unitId>.<versionId>.<kind>.json (no layout.json)
+//	2  per-version files and per-unit sidecar dirs (see schema.go)
+const CurrentLayoutVersion = 2
+
+const layoutSchema = "digiemu.fs.layout.v1"
+
+type layoutFile struct {
+	Schema  string `json:"schema"`
+	Version int    `json:"version"`
+}
+
+// LayoutMigration reports what MigrateLayout did.
+type LayoutMigration struct {
+	FromVersion int
+	ToVersion   int
+	Units       int
+	Versions    int
+	Sidecars    int
+}
+
+var legacySidecarKinds = []string{"meaning", "claimset", "uncertainty"}
+
+// ReadLayoutVersion returns the layout version of basePath (1 if the data
+// dir predates layout.json).
+func ReadLayoutVersion(basePath string) (int, error) {
+	b, err := os.ReadFile(filepath.Join(basePath, "layout.json"))
+	if os.IsNotExist(err) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var f layoutFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return 0, fmt.Errorf("layout.json invalid: %w", err)
+	}
+	if f.Schema != layoutSchema {
+		return 0, fmt.Errorf("layout.json schema mismatch: %s", f.Schema)
+	}
+	return f.Version, nil
+}
+
+// MigrateLayout upgrades basePath to CurrentLayoutVersion. Each unit is
+// migrated by writing its version files and moving its sidecars first and
+// rewriting the unit file last, so an interrupted migration is simply run
+// again (units already in the new format are skipped). layout.json is
+// written once every unit is migrated.
+func MigrateLayout(basePath string) (LayoutMigration, error) {
+	unlock, err := lockDataDir(basePath)
+	if err != nil {
+		return LayoutMigration{}, err
+	}
+	defer unlock()
+
+	from, err := ReadLayoutVersion(basePath)
+	if err != nil {
+		return LayoutMigration{}, err
+	}
+	out := LayoutMigration{FromVersion: from, ToVersion: from}
+	if from == CurrentLayoutVersion {
+		return out, nil
+	}
+	if from > CurrentLayoutVersion {
+		return out, fmt.Errorf("data dir layout %d is newer than supported (%d)", from, CurrentLayoutVersion)
+	}
+
+	r := &UnitRepo{basePath: basePath, unitsDir: filepath.Join(basePath, "units")}
+	entries, err := os.ReadDir(r.unitsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return out, err
+	}
+	for _, e := range entries {
+		// unit files are <unitId>.json; legacy sidecars carry more dots
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".json" || strings.Contains(strings.TrimSuffix(name, ".json"), ".") {
+			continue
+		}
+		if err := r.migrateUnitV1(filepath.Join(r.unitsDir, name), &out); err != nil {
+			return out, fmt.Errorf("migrate %s: %w", name, err)
+		}
+	}
+
+	b, err := json.MarshalIndent(layoutFile{Schema: layoutSchema, Version: CurrentLayoutVersion}, "", "  ")
+	if err != nil {
+		return out, err
+	}
+	if err := writeFileAtomic(filepath.Join(basePath, "layout.json"), b, 0o644); err != nil {
+		return out, err
+	}
+	out.ToVersion = CurrentLayoutVersion
+	return out, nil
+}
+
+func (r *UnitRepo) migrateUnitV1(p string, out *LayoutMigration) error {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	var probe struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return err
+	}
+	if probe.Schema == unitHeaderSchema {
+		return nil // migrated before an interruption
+	}
+	var ur UnitRecord
+	if err := json.Unmarshal(b, &ur); err != nil {
+		return err
+	}
+	if ur.ID == "" || ur.ID+".json" != filepath.Base(p) {
+		return fmt.Errorf("unit id %q does not match file name", ur.ID)
+	}
+
+	h := UnitHeader{
+		ID:            ur.ID,
+		Key:           ur.Key,
+		Title:         ur.Title,
+		Description:   ur.Description,
+		CreatedAt:     ur.CreatedAt,
+		HeadVersionID: ur.HeadVersionID,
+		VersionIDs:    make([]string, 0, len(ur.Versions)),
+	}
+	for _, vr := range ur.Versions {
+		data, err := json.MarshalIndent(VersionFile{
+			Schema:        versionFileSchema,
+			ID:            vr.ID,
+			UnitID:        ur.ID,
+			Label:         vr.Label,
+			Content:       vr.Content,
+			CreatedAt:     vr.CreatedAt,
+			PrevVersionID: vr.PrevVersionID,
+			ContentHash:   vr.ContentHash,
+			ActorID:       vr.ActorID,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		vp := r.versionPath(ur.ID, vr.ID)
+		if err := os.MkdirAll(filepath.Dir(vp), 0o755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(vp, data, 0o644); err != nil {
+			return err
+		}
+
+		for _, kind := range legacySidecarKinds {
+			moved, err := r.moveLegacySidecar(ur.ID, vr.ID, kind)
+			if err != nil {
+				return err
+			}
+			if moved {
+				out.Sidecars++
+			}
+		}
+		if vr.MeaningHash != "" || vr.ClaimSetHash != "" || vr.UncertaintyHash != "" {
+			hb, err := json.MarshalIndent(SidecarHashes{
+				Schema:          sidecarHashesSchema,
+				MeaningHash:     vr.MeaningHash,
+				ClaimSetHash:    vr.ClaimSetHash,
+				UncertaintyHash: vr.UncertaintyHash,
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			hp := r.sidecarPath(ur.ID, vr.ID, "hashes")
+			if err := os.MkdirAll(filepath.Dir(hp), 0o755); err != nil {
+				return err
+			}
+			if err := writeFileAtomic(hp, hb, 0o644); err != nil {
+				return err
+			}
+		}
+		h.VersionIDs = append(h.VersionIDs, vr.ID)
+		out.Versions++
+	}
+
+	// stray legacy sidecars of versions that are not in the record
+	if err := r.moveOrphanLegacySidecars(ur.ID, out); err != nil {
+		return err
+	}
+
+	if err := r.writeHeader(h); err != nil {
+		return err
+	}
+	out.Units++
+	return nil
+}
+
+// moveLegacySidecar renames units/<u>.<v>.<kind>.json into the unit's sidecar dir.
+func (r *UnitRepo) moveLegacySidecar(unitID, versionID, kind string) (bool, error) {
+	src := filepath.Join(r.unitsDir, unitID+"."+versionID+"."+kind+".json")
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	dst := r.sidecarPath(unitID, versionID, kind)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return false, err
+	}
+	syncDir(filepath.Dir(dst))
+	syncDir(r.unitsDir)
+	return true, nil
+}
+
+// moveOrphanLegacySidecars moves remaining units/<unitId>.<x>.<kind>.json
+// files so that no legacy file stays behind in the units dir.
+func (r *UnitRepo) moveOrphanLegacySidecars(unitID string, out *LayoutMigration) error {
+	entries, err := os.ReadDir(r.unitsDir)
+	if err != nil {
+		return err
+	}
+	prefix := unitID + "."
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || name == unitID+".json" {
+			continue
+		}
+		rest := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".json")
+		dot := strings.LastIndexByte(rest, '.')
+		if dot <= 0 {
+			continue
+		}
+		moved, err := r.moveLegacySidecar(unitID, rest[:dot], rest[dot+1:])
+		if err != nil {
+			return err
+		}
+		if moved {
+			out.Sidecars++
+		}
+	}
+	return nil
+}
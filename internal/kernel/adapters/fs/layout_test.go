@@ -0,0 +1,187 @@
+package fs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+)
+
+// writeLegacyDir builds a layout v1 data dir: one unit file embedding all
+// versions plus a meaning sidecar next to it.
+func writeLegacyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	units := filepath.Join(dir, "units")
+	if err := os.MkdirAll(units, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rec := fsrepo.UnitRecord{
+		ID: "unit_1", Key: "abc", Title: "Title", CreatedAt: "2024-01-01T00:00:00Z",
+		HeadVersionID: "ver_c",
+		Versions: []fsrepo.VersionRecord{
+			{ID: "ver_b", Label: "v1", Content: "one", ContentHash: "h1", MeaningHash: "mh"},
+			{ID: "ver_a", Label: "v2", Content: "two", PrevVersionID: "ver_b"},
+			{ID: "ver_c", Label: "v3", Content: "three", PrevVersionID: "ver_a", ActorID: "alice"},
+		},
+	}
+	b, _ := json.MarshalIndent(rec, "", "  ")
+	if err := os.WriteFile(filepath.Join(units, "unit_1.json"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := json.Marshal(domain.Meaning{SchemaVersion: "meaning/v1", Title: "T"})
+	if err := os.WriteFile(filepath.Join(units, "unit_1.ver_b.meaning.json"), m, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLayout_MigratesLegacyDataDir(t *testing.T) {
+	dir := writeLegacyDir(t)
+	repo := fsrepo.NewUnitRepo(dir) // migrates on open
+
+	if v, err := fsrepo.ReadLayoutVersion(dir); err != nil || v != fsrepo.CurrentLayoutVersion {
+		t.Fatalf("expected layout %d, got %d %v", fsrepo.CurrentLayoutVersion, v, err)
+	}
+
+	u, ok, err := repo.FindUnitByKey("abc")
+	if err != nil || !ok || u.HeadVersionID != "ver_c" {
+		t.Fatalf("unit after migration: %+v %v %v", u, ok, err)
+	}
+	vs, err := repo.ListVersionsByUnitID("unit_1")
+	if err != nil {
+		t.Fatalf("ListVersionsByUnitID: %v", err)
+	}
+	var order []string
+	for _, v := range vs {
+		order = append(order, v.ID)
+	}
+	if len(order) != 3 || order[0] != "ver_b" || order[1] != "ver_a" || order[2] != "ver_c" {
+		t.Fatalf("version order not preserved: %v", order)
+	}
+	if vs[0].MeaningHash != "mh" || vs[0].ContentHash != "h1" || vs[2].ActorID != "alice" {
+		t.Fatalf("version fields lost: %+v", vs)
+	}
+
+	if _, ok, err := repo.LoadMeaning("unit_1", "ver_b"); err != nil || !ok {
+		t.Fatalf("meaning sidecar not migrated: %v %v", ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "units", "unit_1.ver_b.meaning.json")); !os.IsNotExist(err) {
+		t.Fatalf("legacy sidecar left behind: %v", err)
+	}
+
+	got, ok, err := repo.FindVersionByID("ver_a")
+	if err != nil || !ok || got.UnitID != "unit_1" || got.Content != "two" {
+		t.Fatalf("FindVersionByID: %+v %v %v", got, ok, err)
+	}
+
+	// second run is a no-op
+	mig, err := fsrepo.MigrateLayout(dir)
+	if err != nil || mig.Units != 0 || mig.FromVersion != fsrepo.CurrentLayoutVersion {
+		t.Fatalf("expected no-op migration, got %+v %v", mig, err)
+	}
+}
+
+func TestLayout_ResumesInterruptedMigration(t *testing.T) {
+	dir := writeLegacyDir(t)
+
+	// a second unit that was already migrated before the interruption
+	other := t.TempDir()
+	pre := fsrepo.NewUnitRepo(other)
+	if err := pre.SaveUnit(domain.Unit{ID: "unit_2", Key: "def", Title: "Other"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(other, "units", "unit_2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "units", "unit_2.json"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mig, err := fsrepo.MigrateLayout(dir)
+	if err != nil {
+		t.Fatalf("MigrateLayout: %v", err)
+	}
+	if mig.FromVersion != 1 || mig.Units != 1 || mig.Versions != 3 || mig.Sidecars != 1 {
+		t.Fatalf("unexpected migration report: %+v", mig)
+	}
+	units, err := fsrepo.NewUnitRepo(dir).ListUnits()
+	if err != nil || len(units) != 2 {
+		t.Fatalf("expected 2 units, got %d %v", len(units), err)
+	}
+}
+
+func TestLayout_FailedMigrationFailsEveryCall(t *testing.T) {
+	dir := writeLegacyDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "units", "unit_9.json"), []byte("{ not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := fsrepo.NewUnitRepo(dir)
+	if _, err := repo.ListUnits(); err == nil {
+		t.Fatal("ListUnits: expected the migration error")
+	}
+	if _, _, err := repo.FindUnitByKey("abc"); err == nil {
+		t.Fatal("FindUnitByKey: expected the migration error")
+	}
+	if _, _, err := repo.LoadMeaning("unit_1", "ver_b"); err == nil {
+		t.Fatal("LoadMeaning: expected the migration error")
+	}
+	if err := repo.SaveUnit(domain.Unit{ID: "unit_3", Key: "ghi", Title: "New"}); err == nil {
+		t.Fatal("SaveUnit: expected the migration error")
+	}
+}
+
+func TestLayout_SaveVersionDoesNotRewriteVersions(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	if err := repo.SaveUnit(domain.Unit{ID: "unit_1", Key: "abc", Title: "Title"}); err != nil {
+		t.Fatal(err)
+	}
+	first := domain.Version{ID: "ver_1", UnitID: "unit_1", Label: "v1", Content: "one"}
+	if err := repo.SaveVersion(first); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, "units", "unit_1", "versions", "ver_1.json")
+	before, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, _ := os.Stat(p)
+
+	if err := repo.SaveVersion(domain.Version{ID: "ver_2", UnitID: "unit_1", Label: "v2", Content: "two", PrevVersionID: "ver_1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveMeaning("unit_1", "ver_1", domain.Meaning{SchemaVersion: "meaning/v1", Title: "T"}, "mh"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st2, _ := os.Stat(p)
+	if !bytes.Equal(before, after) || !st.ModTime().Equal(st2.ModTime()) {
+		t.Fatalf("version file was rewritten")
+	}
+	header, err := os.ReadFile(filepath.Join(dir, "units", "unit_1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(header, []byte(`"one"`)) {
+		t.Fatalf("unit header must not embed version content: %s", header)
+	}
+
+	if err := repo.SaveVersion(first); err == nil {
+		t.Fatalf("expected saving an existing version to fail")
+	}
+	vs, err := repo.ListVersionsByUnitID("unit_1")
+	if err != nil || len(vs) != 2 || vs[0].MeaningHash != "mh" {
+		t.Fatalf("unexpected versions: %+v %v", vs, err)
+	}
+}
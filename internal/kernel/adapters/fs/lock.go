@@ -0,0 +1,99 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// dataDirLock serializes writers of one data directory: inside the process
+// via mu, across processes via an advisory lock on <data>/.lock (see
+// lockFile). Every adapter writing to the data dir takes it around its whole
+// read-modify-write, so e.g. `digiemu serve` and a concurrent
+// `digiemu version create` cannot overwrite each other's unit records.
+//
+// The lock is not reentrant: a method holding it must not call another
+// locking method.
+type dataDirLock struct {
+	mu        sync.Mutex
+	basePath  string
+	recovered bool // recoverDataDir ran (once per process)
+}
+
+var dataDirLocks = struct {
+	sync.Mutex
+	m map[string]*dataDirLock
+}{m: map[string]*dataDirLock{}}
+
+func dataDirLockFor(basePath string) *dataDirLock {
+	key := filepath.Clean(basePath)
+	if abs, err := filepath.Abs(basePath); err == nil {
+		key = abs
+	}
+
+	dataDirLocks.Lock()
+	defer dataDirLocks.Unlock()
+	l, ok := dataDirLocks.m[key]
+	if !ok {
+		l = &dataDirLock{basePath: basePath}
+		dataDirLocks.m[key] = l
+	}
+	return l
+}
+
+// lockDataDir acquires the writer lock of basePath and returns its release
+// func. The first acquisition in a process also recovers what an earlier
+// crash may have left behind (recoverDataDir).
+func lockDataDir(basePath string) (func(), error) {
+	return acquireDataDir(basePath, true)
+}
+
+// lockDataDirNoRecover acquires the lock without running recoverDataDir, for
+// readers that must see the data dir as a crash left it (fsck).
+func lockDataDirNoRecover(basePath string) (func(), error) {
+	return acquireDataDir(basePath, false)
+}
+
+func acquireDataDir(basePath string, withRecovery bool) (func(), error) {
+	l := dataDirLockFor(basePath)
+	l.mu.Lock()
+
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(basePath, ".lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		l.mu.Unlock()
+		return nil, err
+	}
+
+	if withRecovery && !l.recovered {
+		if err := recoverDataDir(basePath); err != nil {
+			_ = unlockFile(f)
+			f.Close()
+			l.mu.Unlock()
+			return nil, err
+		}
+		l.recovered = true
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+		l.mu.Unlock()
+	}, nil
+}
+
+// openDataDir runs the crash recovery of basePath once per process. Adapter
+// constructors call it best-effort; errors surface on the next write.
+func openDataDir(basePath string) {
+	if unlock, err := lockDataDir(basePath); err == nil {
+		unlock()
+	}
+}
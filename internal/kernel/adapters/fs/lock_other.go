@@ -0,0 +1,14 @@
+//go:build !unix
+
+package fs
+
+import "os"
+
+// lockFile is a no-op where flock is unavailable: writers are then only
+// serialized within the process. Do not share a data dir between processes
+// on these platforms.
+func lockFile(f *os.File) error { return nil }
+
+func lockFileShared(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,126 @@
+//go:build unix
+
+package fs_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+)
+
+// TestLock_HelperProcess is not a real test: it is re-executed by
+// TestLock_ConcurrentProcessesKeepAllVersions as a separate writer process.
+func TestLock_HelperProcess(t *testing.T) {
+	dir := os.Getenv("DIGIEMU_LOCK_HELPER_DIR")
+	if dir == "" {
+		t.Skip("helper process only")
+	}
+	n, _ := strconv.Atoi(os.Getenv("DIGIEMU_LOCK_HELPER_N"))
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	for i := 0; i < n; i++ {
+		v, err := domain.NewVersion("unit_1", "v", fmt.Sprintf("%d-%d", os.Getpid(), i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// build on the head as it is now; another writer may win the race
+		// to move it, then the version is taken back and built again
+		for {
+			u, _, err := repo.FindUnitByKey("abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.PrevVersionID = u.HeadVersionID
+			if err := repo.SaveVersion(v); err != nil {
+				t.Fatal(err)
+			}
+			err = repo.UpdateUnitHead("unit_1", v.ID)
+			if errors.Is(err, domain.ErrConflict) {
+				if err := repo.DeleteVersion("unit_1", v.ID); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+		if err := audit.Append(domain.AuditEvent{Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "version.created", VersionID: v.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLock_ConcurrentProcessesKeepAllVersions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	if err := repo.SaveUnit(domain.Unit{ID: "unit_1", Key: "abc", Title: "Title"}); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+
+	const procs, perProc = 4, 15
+	var wg sync.WaitGroup
+	errs := make(chan error, procs)
+	for p := 0; p < procs; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestLock_HelperProcess$")
+			cmd.Env = append(os.Environ(),
+				"DIGIEMU_LOCK_HELPER_DIR="+dir,
+				"DIGIEMU_LOCK_HELPER_N="+strconv.Itoa(perProc),
+			)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("helper: %v\n%s", err, out)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	vs, err := repo.ListVersionsByUnitID("unit_1")
+	if err != nil {
+		t.Fatalf("ListVersionsByUnitID: %v", err)
+	}
+	if len(vs) != procs*perProc {
+		t.Fatalf("expected %d versions, got %d (lost writes)", procs*perProc, len(vs))
+	}
+	// and they form one chain
+	prev := map[string]bool{}
+	for _, v := range vs {
+		if prev[v.PrevVersionID] {
+			t.Fatalf("two versions build on %q", v.PrevVersionID)
+		}
+		prev[v.PrevVersionID] = true
+	}
+
+	// the audit chain must not fork either
+	seq := int64(0)
+	err = fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		seq++
+		if ev.Seq != seq {
+			return fmt.Errorf("event %s has seq %d, expected %d", ev.ID, ev.Seq, seq)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq != procs*perProc {
+		t.Fatalf("expected %d audit events, got %d", procs*perProc, seq)
+	}
+}
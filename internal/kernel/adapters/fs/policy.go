@@ -0,0 +1,39 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// PolicyStore reads the access policy from <data>/policy.json on every call,
+// so edits apply to running servers without a restart. The file is edited
+// by hand; without it nothing is enforced.
+type PolicyStore struct {
+	path string
+}
+
+func NewPolicyStore(basePath string) *PolicyStore {
+	return &PolicyStore{path: filepath.Join(basePath, "policy.json")}
+}
+
+func (s *PolicyStore) LoadPolicy() (domain.Policy, bool, error) {
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return domain.Policy{}, false, nil
+	}
+	if err != nil {
+		return domain.Policy{}, false, err
+	}
+	var p domain.Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return domain.Policy{}, false, fmt.Errorf("%w: %v", domain.ErrInvalidPolicy, err)
+	}
+	if err := p.Validate(); err != nil {
+		return domain.Policy{}, false, err
+	}
+	return p, true, nil
+}
@@ -3,6 +3,16 @@ package fs
 import "time"
 
 // Persistence schema for FS adapter
+//
+// v0.6 (layout v2, see layout.go):
+//
+//	units/<unitId>.json                          UnitHeader
+//	units/<unitId>/versions/<versionId>.json     VersionFile (immutable)
+//	units/<unitId>/sidecars/<versionId>.<kind>.json
+//	                                             meaning | claimset | uncertainty | hashes
+//
+// UnitRecord / VersionRecord describe the legacy (layout v1) unit file that
+// embedded every version; they are only read by the migrator.
 type VersionRecord struct {
 	ID        string `json:"id"`
 	Label     string `json:"label"`
@@ -30,6 +40,95 @@ type UnitRecord struct {
 	Versions      []VersionRecord `json:"versions"`
 }
 
+const (
+	unitHeaderSchema    = "digiemu.fs.unit.v2"
+	versionFileSchema   = "digiemu.fs.version.v2"
+	sidecarHashesSchema = "digiemu.fs.sidecar_hashes.v2"
+)
+
+// UnitHeader is the small per-unit file. VersionIDs keeps the versions in
+// the order they were saved (the order ListVersionsByUnitID returns).
+type UnitHeader struct {
+	Schema        string   `json:"schema"`
+	ID            string   `json:"id"`
+	Key           string   `json:"key"`
+	Title         string   `json:"title"`
+	Description   string   `json:"description,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	HeadVersionID string   `json:"head_version_id,omitempty"`
+	VersionIDs    []string `json:"version_ids"`
+	TenantID      string   `json:"tenant_id,omitempty"` // v0.6
+
+	// v0.6: the unit's creation as its usecase recorded it
+	CreatedAtUnix int64  `json:"created_at_unix,omitempty"`
+	ActorID       string `json:"actor_id,omitempty"`
+
+	// v0.6: named branches, sorted by name (main is HeadVersionID)
+	Branches []BranchRecord `json:"branches,omitempty"`
+
+	// v0.6: release tags, sorted by name
+	Tags []TagRecord `json:"tags,omitempty"`
+
+	// v0.6: metadata history, oldest first; the last one is Title and
+	// Description (absent until the unit's first update)
+	Revisions []UnitRevisionRecord `json:"revisions,omitempty"`
+}
+
+// BranchRecord is a named branch in a UnitHeader.
+type BranchRecord struct {
+	Name          string `json:"name"`
+	HeadVersionID string `json:"head_version_id"`
+	BaseVersionID string `json:"base_version_id"`
+	CreatedAtUnix int64  `json:"created_at_unix,omitempty"`
+	ActorID       string `json:"actor_id,omitempty"`
+}
+
+// TagRecord is a release tag in a UnitHeader.
+type TagRecord struct {
+	Name          string `json:"name"`
+	VersionID     string `json:"version_id"`
+	CreatedAtUnix int64  `json:"created_at_unix,omitempty"`
+	ActorID       string `json:"actor_id,omitempty"`
+}
+
+// UnitRevisionRecord is a metadata revision in a UnitHeader.
+type UnitRevisionRecord struct {
+	Revision    int    `json:"revision"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	AtUnix      int64  `json:"at_unix,omitempty"`
+	ActorID     string `json:"actor_id,omitempty"`
+}
+
+// VersionFile holds one version. It is written once and never rewritten;
+// the mutable sidecar hashes live in SidecarHashes.
+type VersionFile struct {
+	Schema        string `json:"schema"`
+	ID            string `json:"id"`
+	UnitID        string `json:"unit_id"`
+	Label         string `json:"label"`
+	Content       string `json:"content"`
+	CreatedAt     string `json:"created_at"`
+	CreatedAtUnix int64  `json:"created_at_unix,omitempty"`
+	PrevVersionID string `json:"prev_version_id,omitempty"`
+	ContentHash   string `json:"content_hash,omitempty"`
+	ActorID       string `json:"actor_id,omitempty"`
+
+	// v0.6
+	Branch        string `json:"branch,omitempty"`
+	MergeParentID string `json:"merge_parent_id,omitempty"`
+	UnitRevision  int    `json:"unit_revision,omitempty"`
+}
+
+// SidecarHashes records the hashes of a version's sidecars
+// (sidecars/<versionId>.hashes.json).
+type SidecarHashes struct {
+	Schema          string `json:"schema"`
+	MeaningHash     string `json:"meaning_hash,omitempty"`
+	ClaimSetHash    string `json:"claimset_hash,omitempty"`
+	UncertaintyHash string `json:"uncertainty_hash,omitempty"`
+}
+
 func nowRFC3339() string {
 	return time.Now().UTC().Format(time.RFC3339)
 }
@@ -0,0 +1,32 @@
+package fs
+
+import (
+	"fmt"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// SaveTag implements ports.TagRepository: the tag is stored in the unit
+// header. Saving a tag again is a no-op; moving it fails.
+func (r *UnitRepo) SaveTag(unitID string, t domain.Tag) error {
+	return r.updateHeader(unitID, func(h *UnitHeader) error {
+		for _, x := range h.Tags {
+			if x.Name != t.Name {
+				continue
+			}
+			if x.VersionID != t.VersionID {
+				return fmt.Errorf("%w: %q points to %s", domain.ErrTagAlreadyExists, t.Name, x.VersionID)
+			}
+			return nil
+		}
+		h.Tags = append(h.Tags, TagRecord{
+			Name:          t.Name,
+			VersionID:     t.VersionID,
+			CreatedAtUnix: t.CreatedAtUnix,
+			ActorID:       t.ActorID,
+		})
+		sort.Slice(h.Tags, func(i, j int) bool { return h.Tags[i].Name < h.Tags[j].Name })
+		return nil
+	})
+}
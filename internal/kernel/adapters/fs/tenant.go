@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// v0.6: tenants. A named tenant's data lives in a data dir of its own,
+//
+//	<data>/tenants/<tenantId>/              units, indexes, audit log, keys, ...
+//	<data>/tenants/<tenantId>/tenant.json   TenantFile
+//
+// so every adapter opened at that path is confined to the tenant. The
+// default tenant is <data> itself. UnitRepo reads tenant.json and neither
+// returns nor saves units of another tenant.
+
+const tenantsDir = "tenants"
+
+const tenantSchema = "digiemu.tenant.v1"
+
+type TenantFile struct {
+	Schema    string `json:"schema"`
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+}
+
+// TenantPath returns the data dir of tenantID under basePath, whether or not
+// the tenant exists.
+func TenantPath(basePath, tenantID string) (string, error) {
+	if err := domain.ValidateTenantID(tenantID); err != nil {
+		return "", err
+	}
+	if tenantID == domain.DefaultTenant {
+		return basePath, nil
+	}
+	return filepath.Join(basePath, tenantsDir, tenantID), nil
+}
+
+// OpenTenant returns the data dir of an existing tenant
+// (domain.ErrTenantNotFound otherwise).
+func OpenTenant(basePath, tenantID string) (string, error) {
+	p, err := TenantPath(basePath, tenantID)
+	if err != nil || tenantID == domain.DefaultTenant {
+		return p, err
+	}
+	id, err := readTenantID(p)
+	if err != nil {
+		return "", err
+	}
+	if id != tenantID {
+		return "", fmt.Errorf("%w: %s", domain.ErrTenantNotFound, tenantID)
+	}
+	return p, nil
+}
+
+// CreateTenant creates the data dir of a new tenant and returns its path.
+func CreateTenant(basePath, tenantID string) (string, error) {
+	if tenantID == domain.DefaultTenant {
+		return "", fmt.Errorf("%w: the default tenant always exists", domain.ErrInvalidTenantID)
+	}
+	p, err := TenantPath(basePath, tenantID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := readTenantID(p); err == nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrTenantAlreadyExists, tenantID)
+	} else if !errors.Is(err, domain.ErrTenantNotFound) {
+		return "", err
+	}
+	if err := os.MkdirAll(p, 0o755); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(TenantFile{Schema: tenantSchema, ID: tenantID, CreatedAt: nowRFC3339()}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// tenant.json goes last: a tenant exists once it is written
+	if err := writeFileAtomic(filepath.Join(p, "tenant.json"), b, 0o644); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// ListTenants returns the ids of the named tenants under basePath, sorted.
+func ListTenants(basePath string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(basePath, tenantsDir))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if id, err := readTenantID(filepath.Join(basePath, tenantsDir, e.Name())); err == nil && id == e.Name() {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// readTenantID returns the tenant of the data dir at p; a dir without
+// tenant.json yields domain.ErrTenantNotFound.
+func readTenantID(p string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(p, "tenant.json"))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: %s", domain.ErrTenantNotFound, filepath.Base(p))
+	}
+	if err != nil {
+		return "", err
+	}
+	var f TenantFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return "", fmt.Errorf("tenant.json invalid: %w", err)
+	}
+	if f.Schema != tenantSchema {
+		return "", fmt.Errorf("tenant.json schema mismatch: %s", f.Schema)
+	}
+	if err := domain.ValidateTenantID(f.ID); err != nil {
+		return "", err
+	}
+	return f.ID, nil
+}
+
+// dataDirTenant is the tenant the data dir at basePath belongs to.
+func dataDirTenant(basePath string) (string, error) {
+	id, err := readTenantID(basePath)
+	if errors.Is(err, domain.ErrTenantNotFound) {
+		return domain.DefaultTenant, nil
+	}
+	return id, err
+}
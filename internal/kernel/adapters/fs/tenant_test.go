@@ -0,0 +1,126 @@
+package fs_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+func TestTenants_Isolated(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := fsrepo.OpenTenant(dir, "acme"); !errors.Is(err, domain.ErrTenantNotFound) {
+		t.Fatalf("expected ErrTenantNotFound, got %v", err)
+	}
+	if _, err := fsrepo.CreateTenant(dir, "../x"); !errors.Is(err, domain.ErrInvalidTenantID) {
+		t.Fatalf("expected ErrInvalidTenantID, got %v", err)
+	}
+	acmeDir, err := fsrepo.CreateTenant(dir, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsrepo.CreateTenant(dir, "acme"); !errors.Is(err, domain.ErrTenantAlreadyExists) {
+		t.Fatalf("expected ErrTenantAlreadyExists, got %v", err)
+	}
+	if _, err := fsrepo.CreateTenant(dir, "globex"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := fsrepo.ListTenants(dir); strings.Join(ids, ",") != "acme,globex" {
+		t.Fatalf("tenants %v", ids)
+	}
+	if p, err := fsrepo.OpenTenant(dir, "acme"); err != nil || p != acmeDir {
+		t.Fatalf("OpenTenant = %q, %v", p, err)
+	}
+
+	root := fsrepo.NewUnitRepo(dir)
+	acme := fsrepo.NewUnitRepo(acmeDir)
+
+	// the same key in two tenants
+	ru, _ := domain.NewUnit("shared", "Root unit", "")
+	au, _ := domain.NewUnit("shared", "Acme unit", "")
+	au.TenantID = "acme"
+	if err := root.SaveUnit(ru); err != nil {
+		t.Fatal(err)
+	}
+	if err := acme.SaveUnit(au); err != nil {
+		t.Fatal(err)
+	}
+	if err := acme.SaveUnit(domain.Unit{ID: "unit_x", Key: "other", Title: "Other"}); !errors.Is(err, domain.ErrTenantMismatch) {
+		t.Fatalf("expected ErrTenantMismatch, got %v", err)
+	}
+
+	got, ok, err := acme.FindUnitByKey("shared")
+	if err != nil || !ok || got.ID != au.ID || got.TenantID != "acme" {
+		t.Fatalf("acme unit: %+v %v %v", got, ok, err)
+	}
+	if _, ok, _ := acme.FindUnitByID(ru.ID); ok {
+		t.Fatal("acme sees a unit of the default tenant")
+	}
+	if _, ok, _ := root.FindUnitByID(au.ID); ok {
+		t.Fatal("default tenant sees a unit of acme")
+	}
+	if us, _ := root.ListUnits(); len(us) != 1 || us[0].ID != ru.ID {
+		t.Fatalf("default tenant units %+v", us)
+	}
+
+	// a unit file copied into another tenant is not served, and fsck flags it
+	b, err := os.ReadFile(filepath.Join(acmeDir, "units", au.ID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "units", au.ID+".json"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := fsrepo.NewUnitRepo(dir).FindUnitByID(au.ID); ok {
+		t.Fatal("foreign unit file served")
+	}
+	check, err := fsrepo.NewDataDirChecker(dir).CheckDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, f := range check.Findings {
+		kinds = append(kinds, f.Kind)
+	}
+	if !strings.Contains(strings.Join(kinds, ","), ports.FsckUnitForeign) {
+		t.Fatalf("expected %s finding, got %v", ports.FsckUnitForeign, kinds)
+	}
+}
+
+func TestTenants_Fingerprint(t *testing.T) {
+	dir := t.TempDir()
+	acmeDir, err := fsrepo.CreateTenant(dir, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := domain.NewUnit("abc", "Title", "")
+	if err := fsrepo.NewUnitRepo(dir).SaveUnit(u); err != nil {
+		t.Fatal(err)
+	}
+	before, err := fsrepo.NewDataDirFingerprint(dir).Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acmeBefore, err := fsrepo.NewDataDirFingerprint(acmeDir).Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	au, _ := domain.NewUnit("abc", "Title", "")
+	au.TenantID = "acme"
+	if err := fsrepo.NewUnitRepo(acmeDir).SaveUnit(au); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := fsrepo.NewDataDirFingerprint(dir).Fingerprint(); after != before {
+		t.Fatal("a tenant's write changed the default tenant's fingerprint")
+	}
+	if after, _ := fsrepo.NewDataDirFingerprint(acmeDir).Fingerprint(); after == acmeBefore {
+		t.Fatal("fingerprint of acme did not change")
+	}
+}
@@ -2,10 +2,13 @@ package fs
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"digiemu-core/internal/kernel/domain"
 )
@@ -17,13 +20,35 @@ type UnitRepo struct {
 
 	// v0.2.7: persistent index (key -> unitID)
 	index *indexStore
+
+	// v0.6: tenant of the data dir (from tenant.json, see tenant.go);
+	// units of other tenants are neither returned nor saved
+	tenantID string
+
+	// v0.6: why the data dir cannot be used (failed layout migration,
+	// unreadable tenant.json); returned by every call
+	openErr error
 }
 
+// errLegacyLayout is returned for unit files that were not migrated.
+var errLegacyLayout = errors.New("unit file uses the legacy layout (run `digiemu migrate`)")
+
 func NewUnitRepo(basePath string) *UnitRepo {
 	units := filepath.Join(basePath, "units")
 	_ = os.MkdirAll(units, 0o755)
+	openDataDir(basePath)
 
 	r := &UnitRepo{basePath: basePath, unitsDir: units}
+
+	// v0.6: move a legacy data dir to the per-version layout. A half
+	// migrated data dir is not served.
+	if _, err := MigrateLayout(basePath); err != nil {
+		r.openErr = fmt.Errorf("migrate data dir layout: %w", err)
+	}
+	tenantID, err := dataDirTenant(basePath)
+	if r.openErr == nil {
+		r.tenantID, r.openErr = tenantID, err
+	}
 	r.index = newIndexStore(basePath)
 	return r
 }
@@ -32,74 +57,231 @@ func (r *UnitRepo) unitPath(id string) string {
 	return filepath.Join(r.unitsDir, id+".json")
 }
 
-func (r *UnitRepo) unitTempPath(id string) string {
-	return filepath.Join(r.unitsDir, id+".json.tmp")
+func (r *UnitRepo) unitDir(id string) string {
+	return filepath.Join(r.unitsDir, id)
 }
 
-func (r *UnitRepo) ExistsByKey(key string) (bool, error) {
-	// ensure index is loaded (or rebuilt) best-effort
-	if r.index != nil {
-		_ = r.index.ensureLoaded(func() error {
-			m, err := rebuildUnitsByKey(r.basePath)
-			if err != nil {
-				return err
-			}
-			return r.index.saveUnitsByKeyAtomic(m)
-		})
-		_, ok := r.index.getUnitIDByKey(key)
-		if ok {
-			return true, nil
+func (r *UnitRepo) versionPath(unitID, versionID string) string {
+	return filepath.Join(r.unitsDir, unitID, "versions", versionID+".json")
+}
+
+// sidecarPath returns units/<unitId>/sidecars/<versionId>.<kind>.json.
+func (r *UnitRepo) sidecarPath(unitID, versionID, kind string) string {
+	return filepath.Join(r.unitsDir, unitID, "sidecars", versionID+"."+kind+".json")
+}
+
+// ensureIndex loads the key index, rebuilding it if missing or corrupt. The
+// data dir lock is taken before the index lock, in the same order as writers.
+func (r *UnitRepo) ensureIndex() {
+	if r.index.isLoaded() {
+		return
+	}
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	_ = r.index.ensureLoaded(func() error {
+		m, err := rebuildUnitsByKey(r.basePath)
+		if err != nil {
+			return err
 		}
+		return r.index.saveUnitsByKeyAtomic(m)
+	})
+}
+
+// ensureVersionIndex is ensureIndex for the version id index.
+func (r *UnitRepo) ensureVersionIndex() {
+	if r.index.isVersionsLoaded() {
+		return
+	}
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return
 	}
+	defer unlock()
+	_ = r.index.ensureVersionsLoaded(func() error {
+		m, err := rebuildVersionsByID(r.basePath)
+		if err != nil {
+			return err
+		}
+		return r.index.saveVersionsByIDAtomic(m)
+	})
+}
 
-	// fallback: scan directory for unit with matching key
+// readHeaderFile parses a unit header; legacy unit files yield errLegacyLayout.
+func readHeaderFile(p string) (UnitHeader, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return UnitHeader{}, err
+	}
+	var h UnitHeader
+	if err := json.Unmarshal(b, &h); err != nil {
+		return UnitHeader{}, err
+	}
+	if h.Schema != unitHeaderSchema {
+		return UnitHeader{}, fmt.Errorf("%s: %w", filepath.Base(p), errLegacyLayout)
+	}
+	return h, nil
+}
+
+func (r *UnitRepo) readHeader(unitID string) (UnitHeader, bool, error) {
+	if r.openErr != nil {
+		return UnitHeader{}, false, r.openErr
+	}
+	h, err := readHeaderFile(r.unitPath(unitID))
+	if os.IsNotExist(err) {
+		return UnitHeader{}, false, nil
+	}
+	if err != nil {
+		return UnitHeader{}, false, err
+	}
+	if h.TenantID != r.tenantID {
+		return UnitHeader{}, false, nil
+	}
+	return h, true, nil
+}
+
+func (r *UnitRepo) writeHeader(h UnitHeader) error {
+	h.Schema = unitHeaderSchema
+	if h.VersionIDs == nil {
+		h.VersionIDs = []string{}
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.unitPath(h.ID), data, 0o644)
+}
+
+// scanHeaders calls fn for every unit header in the units dir.
+func (r *UnitRepo) scanHeaders(fn func(h UnitHeader) (stop bool)) error {
+	if r.openErr != nil {
+		return r.openErr
+	}
 	files, err := ioutil.ReadDir(r.unitsDir)
 	if err != nil {
-		return false, err
+		return err
 	}
 	for _, f := range files {
-		if f.IsDir() {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
 			continue
 		}
-		b, err := ioutil.ReadFile(filepath.Join(r.unitsDir, f.Name()))
+		h, err := readHeaderFile(filepath.Join(r.unitsDir, f.Name()))
+		if os.IsNotExist(err) {
+			continue // removed concurrently
+		}
 		if err != nil {
-			return false, err
+			return err
+		}
+		if h.TenantID != r.tenantID {
+			continue
 		}
-		var ur UnitRecord
-		if err := json.Unmarshal(b, &ur); err != nil {
-			return false, err
+		if fn(h) {
+			return nil
 		}
-		if ur.Key == key {
+	}
+	return nil
+}
+
+func unitFromHeader(h UnitHeader) domain.Unit {
+	u := domain.Unit{
+		ID:            h.ID,
+		Key:           h.Key,
+		Title:         h.Title,
+		Description:   h.Description,
+		HeadVersionID: h.HeadVersionID,
+		TenantID:      h.TenantID,
+		CreatedAtUnix: h.CreatedAtUnix,
+		ActorID:       h.ActorID,
+	}
+	if u.CreatedAtUnix == 0 {
+		// units saved before the field: the time the header was written
+		if t, err := time.Parse(time.RFC3339, h.CreatedAt); err == nil {
+			u.CreatedAtUnix = t.Unix()
+		}
+	}
+	for _, b := range h.Branches {
+		u.Branches = append(u.Branches, domain.Branch{
+			Name:          b.Name,
+			HeadVersionID: b.HeadVersionID,
+			BaseVersionID: b.BaseVersionID,
+			CreatedAtUnix: b.CreatedAtUnix,
+			ActorID:       b.ActorID,
+		})
+	}
+	for _, t := range h.Tags {
+		u.Tags = append(u.Tags, domain.Tag{
+			Name:          t.Name,
+			VersionID:     t.VersionID,
+			CreatedAtUnix: t.CreatedAtUnix,
+			ActorID:       t.ActorID,
+		})
+	}
+	for _, x := range h.Revisions {
+		u.Revisions = append(u.Revisions, domain.UnitRevision{
+			Revision:    x.Revision,
+			Title:       x.Title,
+			Description: x.Description,
+			AtUnix:      x.AtUnix,
+			ActorID:     x.ActorID,
+		})
+	}
+	return u
+}
+
+func (r *UnitRepo) ExistsByKey(key string) (bool, error) {
+	// ensure index is loaded (or rebuilt) best-effort
+	if r.index != nil {
+		r.ensureIndex()
+		_, ok := r.index.getUnitIDByKey(key)
+		if ok {
 			return true, nil
 		}
 	}
-	return false, nil
+
+	// fallback: scan directory for unit with matching key
+	found := false
+	err := r.scanHeaders(func(h UnitHeader) bool {
+		found = h.Key == key
+		return found
+	})
+	return found, err
 }
 
 func (r *UnitRepo) SaveUnit(u domain.Unit) error {
+	if r.openErr != nil {
+		return r.openErr
+	}
+	if u.TenantID != r.tenantID {
+		return fmt.Errorf("%w: %q, not %q", domain.ErrTenantMismatch, u.TenantID, r.tenantID)
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	ur := UnitRecord{
+	// the caller's ExistsByKey ran without the lock: check again
+	if id, taken, err := r.unitIDByKeyLocked(u.Key); err != nil {
+		return err
+	} else if taken && id != u.ID {
+		return fmt.Errorf("%w: %q", domain.ErrUnitAlreadyExists, u.Key)
+	}
+
+	if err := r.writeHeader(UnitHeader{
 		ID:            u.ID,
 		Key:           u.Key,
 		Title:         u.Title,
 		Description:   u.Description,
 		CreatedAt:     nowRFC3339(),
 		HeadVersionID: u.HeadVersionID,
-		Versions:      []VersionRecord{},
-	}
-
-	data, err := json.MarshalIndent(ur, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	tmp := r.unitTempPath(u.ID)
-	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
-		return err
-	}
-	if err := os.Rename(tmp, r.unitPath(u.ID)); err != nil {
+		TenantID:      u.TenantID,
+		CreatedAtUnix: u.CreatedAtUnix,
+		ActorID:       u.ActorID,
+	}); err != nil {
 		return err
 	}
 
@@ -110,16 +292,38 @@ func (r *UnitRepo) SaveUnit(u domain.Unit) error {
 	return nil
 }
 
+// unitIDByKeyLocked returns the unit stored under key as the data dir has
+// it now (other processes may have added units since the index was loaded).
+// Callers hold the data dir lock.
+func (r *UnitRepo) unitIDByKeyLocked(key string) (string, bool, error) {
+	if r.index != nil {
+		if id, ok, err := r.index.lookupOnDisk(key); err == nil {
+			if !ok {
+				return "", false, nil
+			}
+			if _, found, err := r.readHeader(id); err != nil || found {
+				return id, found, err
+			}
+			// stale index entry: fall back to the headers
+		}
+	}
+	var (
+		id    string
+		found bool
+	)
+	err := r.scanHeaders(func(h UnitHeader) bool {
+		if h.Key == key {
+			id, found = h.ID, true
+		}
+		return found
+	})
+	return id, found, err
+}
+
 func (r *UnitRepo) FindUnitByKey(key string) (domain.Unit, bool, error) {
 	// ensure index is loaded (or rebuilt) best-effort
 	if r.index != nil {
-		_ = r.index.ensureLoaded(func() error {
-			m, err := rebuildUnitsByKey(r.basePath)
-			if err != nil {
-				return err
-			}
-			return r.index.saveUnitsByKeyAtomic(m)
-		})
+		r.ensureIndex()
 		if id, ok := r.index.getUnitIDByKey(key); ok {
 			if id != "" {
 				return r.FindUnitByID(id)
@@ -129,68 +333,34 @@ func (r *UnitRepo) FindUnitByKey(key string) (domain.Unit, bool, error) {
 	}
 
 	// fallback: scan directory for unit with matching key
-	files, err := ioutil.ReadDir(r.unitsDir)
+	var (
+		out   domain.Unit
+		found bool
+	)
+	err := r.scanHeaders(func(h UnitHeader) bool {
+		if h.Key == key {
+			out, found = unitFromHeader(h), true
+		}
+		return found
+	})
 	if err != nil {
 		return domain.Unit{}, false, err
 	}
-	for _, f := range files {
-		if f.IsDir() {
-			continue
-		}
-		p := filepath.Join(r.unitsDir, f.Name())
-		b, err := ioutil.ReadFile(p)
-		if err != nil {
-			return domain.Unit{}, false, err
-		}
-		var ur UnitRecord
-		if err := json.Unmarshal(b, &ur); err != nil {
-			return domain.Unit{}, false, err
-		}
-		if ur.Key == key {
-			return domain.Unit{
-				ID:            ur.ID,
-				Key:           ur.Key,
-				Title:         ur.Title,
-				Description:   ur.Description,
-				HeadVersionID: ur.HeadVersionID,
-			}, true, nil
-		}
-	}
-	return domain.Unit{}, false, nil
+	return out, found, nil
 }
 
 func (r *UnitRepo) FindUnitByID(id string) (domain.Unit, bool, error) {
-	p := r.unitPath(id)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return domain.Unit{}, false, nil
-	}
-	if err != nil {
+	h, ok, err := r.readHeader(id)
+	if err != nil || !ok {
 		return domain.Unit{}, false, err
 	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
-		return domain.Unit{}, false, err
-	}
-	return domain.Unit{
-		ID:            ur.ID,
-		Key:           ur.Key,
-		Title:         ur.Title,
-		Description:   ur.Description,
-		HeadVersionID: ur.HeadVersionID,
-	}, true, nil
+	return unitFromHeader(h), true, nil
 }
 
 func (r *UnitRepo) ListUnits() ([]domain.Unit, error) {
 	// ensure index is loaded (or rebuilt) best-effort
 	if r.index != nil {
-		_ = r.index.ensureLoaded(func() error {
-			m, err := rebuildUnitsByKey(r.basePath)
-			if err != nil {
-				return err
-			}
-			return r.index.saveUnitsByKeyAtomic(m)
-		})
+		r.ensureIndex()
 		ids := r.index.listUnitIDs()
 		out := make([]domain.Unit, 0, len(ids))
 		for _, id := range ids {
@@ -206,292 +376,289 @@ func (r *UnitRepo) ListUnits() ([]domain.Unit, error) {
 		return out, nil
 	}
 
-	files, err := ioutil.ReadDir(r.unitsDir)
+	out := []domain.Unit{}
+	err := r.scanHeaders(func(h UnitHeader) bool {
+		out = append(out, unitFromHeader(h))
+		return false
+	})
 	if err != nil {
 		return nil, err
 	}
-	out := make([]domain.Unit, 0, len(files))
-	for _, f := range files {
-		if f.IsDir() {
-			continue
-		}
-		p := filepath.Join(r.unitsDir, f.Name())
-		b, err := ioutil.ReadFile(p)
-		if err != nil {
-			return nil, err
-		}
-		var ur UnitRecord
-		if err := json.Unmarshal(b, &ur); err != nil {
-			return nil, err
-		}
-		out = append(out, domain.Unit{
-			ID:            ur.ID,
-			Key:           ur.Key,
-			Title:         ur.Title,
-			Description:   ur.Description,
-			HeadVersionID: ur.HeadVersionID,
-		})
-	}
 	return out, nil
 }
 
+// SaveVersion writes the version file and appends its id to the unit
+// header. Only the (small) header is rewritten.
+//
+// The head is not checked here: it only moves by UpdateUnitHead and
+// SaveBranch, which compare and swap it under the data dir lock, so of two
+// writers building on one head the second fails there with
+// domain.ErrConflict and undoes its version.
 func (r *UnitRepo) SaveVersion(v domain.Version) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-
-	p := r.unitPath(v.UnitID)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return domain.ErrUnitNotFound
-	}
+	unlock, err := lockDataDir(r.basePath)
 	if err != nil {
 		return err
 	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
+	defer unlock()
+
+	h, ok, err := r.readHeader(v.UnitID)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	for _, id := range h.VersionIDs {
+		if id == v.ID {
+			return fmt.Errorf("version %s already exists", v.ID)
+		}
+	}
 
-	vr := VersionRecord{
+	data, err := json.MarshalIndent(VersionFile{
+		Schema:        versionFileSchema,
 		ID:            v.ID,
+		UnitID:        v.UnitID,
 		Label:         v.Label,
 		Content:       v.Content,
 		CreatedAt:     nowRFC3339(),
+		CreatedAtUnix: v.CreatedAtUnix,
 		PrevVersionID: v.PrevVersionID,
 		ContentHash:   v.ContentHash,
 		ActorID:       v.ActorID,
-	}
-
-	ur.Versions = append(ur.Versions, vr)
-
-	data, err := json.MarshalIndent(ur, "", "  ")
+		Branch:        v.Branch,
+		MergeParentID: v.MergeParentID,
+		UnitRevision:  v.UnitRevision,
+	}, "", "  ")
 	if err != nil {
 		return err
 	}
-	tmp := r.unitTempPath(ur.ID)
-	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
+	p := r.versionPath(v.UnitID, v.ID)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
 		return err
 	}
-	if err := os.Rename(tmp, p); err != nil {
+	// the version file is only visible once the header lists it, so a
+	// crash in between leaves an unreferenced file that is overwritten on retry
+	if err := writeFileAtomic(p, data, 0o644); err != nil {
 		return err
 	}
+
+	h.VersionIDs = append(h.VersionIDs, v.ID)
+	if err := r.writeHeader(h); err != nil {
+		return err
+	}
+
+	// v0.6: update version index best-effort
+	if r.index != nil {
+		r.index.upsertVersions(v.UnitID, v.ID)
+	}
 	return nil
 }
 
+// UpdateUnitHead moves the head of the main branch to headVersionID, which
+// must be a main version built on the current head (domain.ErrConflict
+// otherwise). Moving it to where it is is a no-op.
 func (r *UnitRepo) UpdateUnitHead(unitID, headVersionID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-
-	p := r.unitPath(unitID)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return domain.ErrUnitNotFound
-	}
+	unlock, err := lockDataDir(r.basePath)
 	if err != nil {
 		return err
 	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
-		return err
-	}
+	defer unlock()
 
-	ur.HeadVersionID = headVersionID
-
-	data, err := json.MarshalIndent(ur, "", "  ")
+	h, ok, err := r.readHeader(unitID)
 	if err != nil {
 		return err
 	}
-	tmp := r.unitTempPath(ur.ID)
-	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	if h.HeadVersionID == headVersionID {
+		return nil
+	}
+	if err := r.checkHeadMove(unitID, h.HeadVersionID, headVersionID, ""); err != nil {
 		return err
 	}
-	if err := os.Rename(tmp, p); err != nil {
+	h.HeadVersionID = headVersionID
+	return r.writeHeader(h)
+}
+
+// checkHeadMove fails with domain.ErrConflict unless the version to becomes
+// the head of branch by building on the head from. Callers hold the data
+// dir lock.
+func (r *UnitRepo) checkHeadMove(unitID, from, to, branch string) error {
+	v, err := r.loadVersion(unitID, to)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", domain.ErrVersionNotFound, to)
+	}
+	if err != nil {
 		return err
 	}
+	if v.Branch != branch || v.PrevVersionID != from {
+		return fmt.Errorf("%w: %s builds on %q, head is %q", domain.ErrConflict, to, v.PrevVersionID, from)
+	}
 	return nil
 }
 
 func (r *UnitRepo) ListVersionsByUnitID(unitID string) ([]domain.Version, error) {
-	p := r.unitPath(unitID)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return nil, nil
-	}
+	h, ok, err := r.readHeader(unitID)
 	if err != nil {
 		return nil, err
 	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
-		return nil, err
+	if !ok {
+		return nil, nil
 	}
-	out := make([]domain.Version, 0, len(ur.Versions))
-	for _, vr := range ur.Versions {
-		out = append(out, domain.Version{
-			ID:              vr.ID,
-			UnitID:          unitID,
-			Label:           vr.Label,
-			Content:         vr.Content,
-			PrevVersionID:   vr.PrevVersionID,
-			ContentHash:     vr.ContentHash,
-			ActorID:         vr.ActorID,
-			MeaningHash:     vr.MeaningHash,
-			ClaimSetHash:    vr.ClaimSetHash,
-			UncertaintyHash: vr.UncertaintyHash,
-		})
+	out := make([]domain.Version, 0, len(h.VersionIDs))
+	for _, id := range h.VersionIDs {
+		v, err := r.loadVersion(unitID, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
 	}
 	return out, nil
 }
 
-// meaningPath returns the filesystem path for a unit's meaning.json file.
-func (r *UnitRepo) meaningPath(unitID, versionID string) string {
-	return filepath.Join(r.unitsDir, unitID+"."+versionID+".meaning.json")
+// loadVersion reads a listed version together with its sidecar hashes.
+func (r *UnitRepo) loadVersion(unitID, versionID string) (domain.Version, error) {
+	b, err := ioutil.ReadFile(r.versionPath(unitID, versionID))
+	if err != nil {
+		return domain.Version{}, err
+	}
+	var vf VersionFile
+	if err := json.Unmarshal(b, &vf); err != nil {
+		return domain.Version{}, fmt.Errorf("version %s invalid: %w", versionID, err)
+	}
+	if vf.Schema != versionFileSchema {
+		return domain.Version{}, fmt.Errorf("version %s schema mismatch: %s", versionID, vf.Schema)
+	}
+	hs, err := r.readHashes(unitID, versionID)
+	if err != nil {
+		return domain.Version{}, err
+	}
+	return domain.Version{
+		ID:              vf.ID,
+		UnitID:          unitID,
+		Label:           vf.Label,
+		Content:         vf.Content,
+		PrevVersionID:   vf.PrevVersionID,
+		ContentHash:     vf.ContentHash,
+		CreatedAtUnix:   vf.CreatedAtUnix,
+		ActorID:         vf.ActorID,
+		MeaningHash:     hs.MeaningHash,
+		ClaimSetHash:    hs.ClaimSetHash,
+		UncertaintyHash: hs.UncertaintyHash,
+		Branch:          vf.Branch,
+		MergeParentID:   vf.MergeParentID,
+		UnitRevision:    vf.UnitRevision,
+	}, nil
 }
 
-func (r *UnitRepo) meaningTempPath(unitID, versionID string) string {
-	return filepath.Join(r.unitsDir, unitID+"."+versionID+".meaning.json.tmp")
+func (r *UnitRepo) readHashes(unitID, versionID string) (SidecarHashes, error) {
+	b, err := ioutil.ReadFile(r.sidecarPath(unitID, versionID, "hashes"))
+	if os.IsNotExist(err) {
+		return SidecarHashes{}, nil
+	}
+	if err != nil {
+		return SidecarHashes{}, err
+	}
+	var hs SidecarHashes
+	if err := json.Unmarshal(b, &hs); err != nil {
+		return SidecarHashes{}, fmt.Errorf("sidecar hashes of %s invalid: %w", versionID, err)
+	}
+	return hs, nil
 }
 
-func (r *UnitRepo) claimsetPath(unitID, versionID string) string {
-	return filepath.Join(r.unitsDir, unitID+"."+versionID+".claimset.json")
+// meaningPath returns the filesystem path for a unit's meaning.json file.
+func (r *UnitRepo) meaningPath(unitID, versionID string) string {
+	return r.sidecarPath(unitID, versionID, "meaning")
 }
 
-func (r *UnitRepo) claimsetTempPath(unitID, versionID string) string {
-	return filepath.Join(r.unitsDir, unitID+"."+versionID+".claimset.json.tmp")
+func (r *UnitRepo) claimsetPath(unitID, versionID string) string {
+	return r.sidecarPath(unitID, versionID, "claimset")
 }
 
 func (r *UnitRepo) uncertaintyPath(unitID, versionID string) string {
-	return filepath.Join(r.unitsDir, unitID+"."+versionID+".uncertainty.json")
-}
-
-func (r *UnitRepo) uncertaintyTempPath(unitID, versionID string) string {
-	return filepath.Join(r.unitsDir, unitID+"."+versionID+".uncertainty.json.tmp")
+	return r.sidecarPath(unitID, versionID, "uncertainty")
 }
 
-// SaveMeaning stores a canonicalized meaning.json for the unit atomically.
-// Returns ErrUnitNotFound if the unit does not exist.
-func (r *UnitRepo) SaveMeaning(unitID, versionID string, m domain.Meaning, meaningHash string) error {
+// saveSidecar writes a version's sidecar and records its hash. Only the
+// sidecar and the version's small hashes file are rewritten.
+func (r *UnitRepo) saveSidecar(unitID, versionID, path string, v any, setHash func(*SidecarHashes)) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-
-	p := r.unitPath(unitID)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return domain.ErrUnitNotFound
-	}
+	unlock, err := lockDataDir(r.basePath)
 	if err != nil {
 		return err
 	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
+	defer unlock()
+
+	h, ok, err := r.readHeader(unitID)
+	if err != nil {
 		return err
 	}
-
-	// find version
-	found := false
-	for i := range ur.Versions {
-		if ur.Versions[i].ID == versionID {
-			ur.Versions[i].MeaningHash = meaningHash
-			found = true
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	listed := false
+	for _, id := range h.VersionIDs {
+		if id == versionID {
+			listed = true
 			break
 		}
 	}
-	if !found {
+	if !listed {
 		return domain.ErrVersionNotFound
 	}
 
-	// write meaning sidecar
-	sidecar := filepath.Join(r.unitsDir, unitID+"."+versionID+".meaning.json")
-	tmp := sidecar + ".tmp"
-	data, err := json.MarshalIndent(m, "", "  ")
-	if err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
 		return err
 	}
-	if err := os.Rename(tmp, sidecar); err != nil {
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
 		return err
 	}
 
-	// persist updated unit record
-	out, err := json.MarshalIndent(ur, "", "  ")
+	hs, err := r.readHashes(unitID, versionID)
 	if err != nil {
 		return err
 	}
-	tmpUnit := r.unitTempPath(ur.ID)
-	if err := ioutil.WriteFile(tmpUnit, out, 0o644); err != nil {
-		return err
-	}
-	if err := os.Rename(tmpUnit, p); err != nil {
+	hs.Schema = sidecarHashesSchema
+	setHash(&hs)
+	out, err := json.MarshalIndent(hs, "", "  ")
+	if err != nil {
 		return err
 	}
-	return nil
+	return writeFileAtomic(r.sidecarPath(unitID, versionID, "hashes"), out, 0o644)
+}
+
+// SaveMeaning stores a canonicalized meaning.json for the unit atomically.
+// Returns ErrUnitNotFound if the unit does not exist.
+func (r *UnitRepo) SaveMeaning(unitID, versionID string, m domain.Meaning, meaningHash string) error {
+	return r.saveSidecar(unitID, versionID, r.meaningPath(unitID, versionID), m, func(hs *SidecarHashes) {
+		hs.MeaningHash = meaningHash
+	})
 }
 
 // SaveClaimSet stores a canonicalized claimset JSON for the unit atomically and
-// updates the embedded version record's claimset_hash. Returns ErrUnitNotFound
+// updates the version's claimset_hash. Returns ErrUnitNotFound
 // or ErrVersionNotFound where applicable.
 func (r *UnitRepo) SaveClaimSet(unitID, versionID string, cs domain.ClaimSet, claimSetHash string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	p := r.unitPath(unitID)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return domain.ErrUnitNotFound
-	}
-	if err != nil {
-		return err
-	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
-		return err
-	}
-
-	// find version
-	found := false
-	for i := range ur.Versions {
-		if ur.Versions[i].ID == versionID {
-			ur.Versions[i].ClaimSetHash = claimSetHash
-			found = true
-			break
-		}
-	}
-	if !found {
-		return domain.ErrVersionNotFound
-	}
-
-	// write claimset sidecar
-	sidecar := r.claimsetPath(unitID, versionID)
-	tmp := r.claimsetTempPath(unitID, versionID)
-	data, err := json.MarshalIndent(cs, "", "  ")
-	if err != nil {
-		return err
-	}
-	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
-		return err
-	}
-	if err := os.Rename(tmp, sidecar); err != nil {
-		return err
-	}
-
-	// persist updated unit record
-	out, err := json.MarshalIndent(ur, "", "  ")
-	if err != nil {
-		return err
-	}
-	tmpUnit := r.unitTempPath(ur.ID)
-	if err := ioutil.WriteFile(tmpUnit, out, 0o644); err != nil {
-		return err
-	}
-	if err := os.Rename(tmpUnit, p); err != nil {
-		return err
-	}
-	return nil
+	return r.saveSidecar(unitID, versionID, r.claimsetPath(unitID, versionID), cs, func(hs *SidecarHashes) {
+		hs.ClaimSetHash = claimSetHash
+	})
 }
 
 // LoadClaimSet loads a version-scoped claimset sidecar if present.
 func (r *UnitRepo) LoadClaimSet(unitID, versionID string) (domain.ClaimSet, bool, error) {
+	if r.openErr != nil {
+		return domain.ClaimSet{}, false, r.openErr
+	}
 	p := r.claimsetPath(unitID, versionID)
 	b, err := ioutil.ReadFile(p)
 	if os.IsNotExist(err) {
@@ -508,68 +675,19 @@ func (r *UnitRepo) LoadClaimSet(unitID, versionID string) (domain.ClaimSet, bool
 }
 
 // SaveUncertainty stores a canonicalized uncertainty sidecar for the unit atomically
-// and updates the embedded version record's uncertainty_hash. Returns ErrUnitNotFound
+// and updates the version's uncertainty_hash. Returns ErrUnitNotFound
 // or ErrVersionNotFound where applicable.
 func (r *UnitRepo) SaveUncertainty(unitID, versionID string, u domain.Uncertainty, uncertaintyHash string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	p := r.unitPath(unitID)
-	b, err := ioutil.ReadFile(p)
-	if os.IsNotExist(err) {
-		return domain.ErrUnitNotFound
-	}
-	if err != nil {
-		return err
-	}
-	var ur UnitRecord
-	if err := json.Unmarshal(b, &ur); err != nil {
-		return err
-	}
-
-	// find version
-	found := false
-	for i := range ur.Versions {
-		if ur.Versions[i].ID == versionID {
-			ur.Versions[i].UncertaintyHash = uncertaintyHash
-			found = true
-			break
-		}
-	}
-	if !found {
-		return domain.ErrVersionNotFound
-	}
-
-	sidecar := r.uncertaintyPath(unitID, versionID)
-	tmp := r.uncertaintyTempPath(unitID, versionID)
-	data, err := json.MarshalIndent(u, "", "  ")
-	if err != nil {
-		return err
-	}
-	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
-		return err
-	}
-	if err := os.Rename(tmp, sidecar); err != nil {
-		return err
-	}
-
-	// persist updated unit record
-	out, err := json.MarshalIndent(ur, "", "  ")
-	if err != nil {
-		return err
-	}
-	tmpUnit := r.unitTempPath(ur.ID)
-	if err := ioutil.WriteFile(tmpUnit, out, 0o644); err != nil {
-		return err
-	}
-	if err := os.Rename(tmpUnit, p); err != nil {
-		return err
-	}
-	return nil
+	return r.saveSidecar(unitID, versionID, r.uncertaintyPath(unitID, versionID), u, func(hs *SidecarHashes) {
+		hs.UncertaintyHash = uncertaintyHash
+	})
 }
 
 // LoadUncertainty loads a version-scoped uncertainty sidecar if present.
 func (r *UnitRepo) LoadUncertainty(unitID, versionID string) (domain.Uncertainty, bool, error) {
+	if r.openErr != nil {
+		return domain.Uncertainty{}, false, r.openErr
+	}
 	p := r.uncertaintyPath(unitID, versionID)
 	b, err := ioutil.ReadFile(p)
 	if os.IsNotExist(err) {
@@ -587,6 +705,9 @@ func (r *UnitRepo) LoadUncertainty(unitID, versionID string) (domain.Uncertainty
 
 // LoadMeaning loads a version-scoped meaning sidecar if present.
 func (r *UnitRepo) LoadMeaning(unitID, versionID string) (domain.Meaning, bool, error) {
+	if r.openErr != nil {
+		return domain.Meaning{}, false, r.openErr
+	}
 	p := r.meaningPath(unitID, versionID)
 	b, err := ioutil.ReadFile(p)
 	if os.IsNotExist(err) {
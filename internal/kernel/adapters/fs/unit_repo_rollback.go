@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// DeleteUnit implements ports.UnitRepositoryRollback: it removes the unit
+// header, its version and sidecar files and its index entry. Used by intent
+// recovery only.
+func (r *UnitRepo) DeleteUnit(unitID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, ok, err := r.readHeader(unitID)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := os.Remove(r.unitPath(unitID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.RemoveAll(r.unitDir(unitID)); err != nil {
+		return err
+	}
+	if r.index != nil {
+		r.index.removeUnitKey(h.Key, unitID)
+		r.index.removeVersions(unitID, h.VersionIDs...)
+	}
+	return nil
+}
+
+// DeleteVersion implements ports.UnitRepositoryRollback: it removes the
+// version and its sidecars. The head (and a branch head) is reset to the
+// version's predecessor if it points at the removed version.
+func (r *UnitRepo) DeleteVersion(unitID, versionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := lockDataDir(r.basePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, ok, err := r.readHeader(unitID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+
+	kept := h.VersionIDs[:0]
+	for _, id := range h.VersionIDs {
+		if id != versionID {
+			kept = append(kept, id)
+		}
+	}
+	h.VersionIDs = kept
+	movesHead := h.HeadVersionID == versionID
+	for _, b := range h.Branches {
+		movesHead = movesHead || b.HeadVersionID == versionID
+	}
+	if movesHead {
+		v, err := r.loadVersion(unitID, versionID)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if h.HeadVersionID == versionID {
+			h.HeadVersionID = v.PrevVersionID
+		}
+		for i := range h.Branches {
+			if h.Branches[i].HeadVersionID == versionID {
+				h.Branches[i].HeadVersionID = v.PrevVersionID
+			}
+		}
+	}
+
+	// unlist first: files of an unlisted version are never read
+	if err := r.writeHeader(h); err != nil {
+		return err
+	}
+	if r.index != nil {
+		r.index.removeVersions(unitID, versionID)
+	}
+	if err := os.Remove(r.versionPath(unitID, versionID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return r.removeSidecars(unitID, versionID+".")
+}
+
+// removeSidecars deletes the unit's sidecar files starting with prefix.
+func (r *UnitRepo) removeSidecars(unitID, prefix string) error {
+	dir := filepath.Join(r.unitDir(unitID), "sidecars")
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
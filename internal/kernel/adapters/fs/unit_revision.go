@@ -0,0 +1,28 @@
+package fs
+
+import "digiemu-core/internal/kernel/domain"
+
+// SaveUnitRevision implements ports.UnitMetadataRepository: the unit header
+// gets the new title and description and keeps the earlier revisions.
+// Saving a revision again is a no-op.
+func (r *UnitRepo) SaveUnitRevision(unitID string, rev domain.UnitRevision) error {
+	return r.updateHeader(unitID, func(h *UnitHeader) error {
+		hist, added, err := domain.AppendUnitRevision(unitFromHeader(*h), rev)
+		if err != nil || !added {
+			return err
+		}
+		h.Title = rev.Title
+		h.Description = rev.Description
+		h.Revisions = make([]UnitRevisionRecord, 0, len(hist))
+		for _, x := range hist {
+			h.Revisions = append(h.Revisions, UnitRevisionRecord{
+				Revision:    x.Revision,
+				Title:       x.Title,
+				Description: x.Description,
+				AtUnix:      x.AtUnix,
+				ActorID:     x.ActorID,
+			})
+		}
+		return nil
+	})
+}
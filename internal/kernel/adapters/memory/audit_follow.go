@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: ports.AuditFollower; the cursor is an index into Events.
+
+func (l *AuditLog) CursorAfter(afterEventID string) (ports.AuditCursor, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if afterEventID == "" {
+		return ports.AuditCursor{Offset: int64(len(l.Events))}, nil
+	}
+	for i, ev := range l.Events {
+		if ev.ID == afterEventID {
+			return ports.AuditCursor{Offset: int64(i + 1)}, nil
+		}
+	}
+	return ports.AuditCursor{}, domain.ErrAuditEventNotFound
+}
+
+func (l *AuditLog) Poll(cur ports.AuditCursor, max int) ([]domain.AuditEvent, ports.AuditCursor, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if max <= 0 {
+		max = ports.DefaultAuditPollMax
+	}
+	if cur.Offset >= int64(len(l.Events)) {
+		return nil, cur, nil
+	}
+	end := min(cur.Offset+int64(max), int64(len(l.Events)))
+	out := append([]domain.AuditEvent{}, l.Events[cur.Offset:end]...)
+	return out, ports.AuditCursor{Offset: end}, nil
+}
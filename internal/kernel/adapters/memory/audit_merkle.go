@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+type AuditMerkleStore struct {
+	mu     sync.RWMutex
+	Leaves []domain.MerkleLeaf
+	Heads  []domain.TreeHead
+}
+
+func NewAuditMerkleStore() *AuditMerkleStore {
+	return &AuditMerkleStore{Leaves: []domain.MerkleLeaf{}, Heads: []domain.TreeHead{}}
+}
+
+func (s *AuditMerkleStore) ListLeaves() ([]domain.MerkleLeaf, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]domain.MerkleLeaf, len(s.Leaves))
+	copy(out, s.Leaves)
+	return out, nil
+}
+
+func (s *AuditMerkleStore) AppendLeaves(leaves []domain.MerkleLeaf) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := int64(len(s.Leaves))
+	for _, l := range leaves {
+		if l.Index != next {
+			return fmt.Errorf("%w: leaf index %d, expected %d", domain.ErrMerkleLeafMismatch, l.Index, next)
+		}
+		next++
+	}
+	s.Leaves = append(s.Leaves, leaves...)
+	return nil
+}
+
+func (s *AuditMerkleStore) ListTreeHeads() ([]domain.TreeHead, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]domain.TreeHead, len(s.Heads))
+	copy(out, s.Heads)
+	return out, nil
+}
+
+func (s *AuditMerkleStore) AppendTreeHead(th domain.TreeHead) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Heads = append(s.Heads, th)
+	return nil
+}
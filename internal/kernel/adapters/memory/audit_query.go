@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: ports.AuditQuery; the cursor is an index into Events.
+
+func (l *AuditLog) QueryAudit(f ports.AuditQueryFilter, cur ports.AuditCursor, limit int) ([]domain.AuditEvent, ports.AuditCursor, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	out := []domain.AuditEvent{}
+	for i := cur.Offset; i < int64(len(l.Events)); i++ {
+		ev := l.Events[i]
+		if !matchAuditQuery(ev, f) {
+			continue
+		}
+		if len(out) == limit {
+			return out, ports.AuditCursor{Offset: i}, true, nil
+		}
+		out = append(out, ev)
+	}
+	return out, ports.AuditCursor{}, false, nil
+}
+
+func matchAuditQuery(ev domain.AuditEvent, f ports.AuditQueryFilter) bool {
+	if f.FromUnix != 0 && ev.AtUnix < f.FromUnix {
+		return false
+	}
+	if f.ToUnix != 0 && ev.AtUnix > f.ToUnix {
+		return false
+	}
+	if f.VersionID != "" && ev.VersionID != f.VersionID {
+		return false
+	}
+	return anyOf(f.ActorIDs, ev.ActorID) && anyOf(f.Types, domain.CanonicalAuditEventType(ev.Type)) && anyOf(f.UnitIDs, ev.UnitID)
+}
+
+// anyOf reports whether s is in set; an empty set matches everything.
+func anyOf(set []string, s string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	for _, v := range set {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -24,7 +24,7 @@ func (t *AuditTail) Tail(in ports.AuditTailRequest) ([]domain.AuditEvent, error)
 
 	out := make([]domain.AuditEvent, 0, n)
 	for _, ev := range t.Log.Events {
-		if in.Type != "" && ev.Type != in.Type {
+		if in.Type != "" && domain.CanonicalAuditEventType(ev.Type) != domain.CanonicalAuditEventType(in.Type) {
 			continue
 		}
 		if in.UnitID != "" && ev.UnitID != in.UnitID {
@@ -17,6 +17,18 @@ func NewAuditLog() *AuditLog {
 func (l *AuditLog) Append(ev domain.AuditEvent) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+
+	// v0.6: seal into the hash chain after the last chained event
+	var head domain.AuditChainHead
+	for i := len(l.Events) - 1; i >= 0; i-- {
+		if l.Events[i].IsChained() {
+			head = domain.AuditChainHead{Seq: l.Events[i].Seq, Hash: l.Events[i].Hash, EventID: l.Events[i].ID}
+			break
+		}
+	}
+	if err := domain.SealAuditEvent(&ev, head); err != nil {
+		return err
+	}
 	l.Events = append(l.Events, ev)
 	return nil
 }
@@ -32,3 +44,16 @@ func (l *AuditLog) Scan(fn func(ev domain.AuditEvent) error) error {
 	}
 	return nil
 }
+
+// ReadChainHead implements ports.AuditChainHeadReader.
+func (l *AuditLog) ReadChainHead() (domain.AuditChainHead, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for i := len(l.Events) - 1; i >= 0; i-- {
+		if l.Events[i].IsChained() {
+			ev := l.Events[i]
+			return domain.AuditChainHead{Seq: ev.Seq, Hash: ev.Hash, EventID: ev.ID}, true, nil
+		}
+	}
+	return domain.AuditChainHead{}, false, nil
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// SaveBranch implements ports.BranchRepository. Replacing a branch may only
+// move its head to a version of the branch built on the current head
+// (domain.ErrConflict otherwise), as for UpdateUnitHead.
+func (r *UnitRepo) SaveBranch(unitID string, b domain.Branch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.unitsByID[unitID]
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	bs := make([]domain.Branch, 0, len(u.Branches)+1)
+	for _, x := range u.Branches {
+		if x.Name != b.Name {
+			bs = append(bs, x)
+			continue
+		}
+		if x.BaseVersionID != b.BaseVersionID {
+			return fmt.Errorf("%w: %q", domain.ErrBranchAlreadyExists, b.Name)
+		}
+		if x.HeadVersionID != b.HeadVersionID {
+			if err := r.checkHeadMoveLocked(x.HeadVersionID, b.HeadVersionID, b.Name); err != nil {
+				return err
+			}
+		}
+	}
+	bs = append(bs, b)
+	sort.Slice(bs, func(i, j int) bool { return bs[i].Name < bs[j].Name })
+	u.Branches = bs
+	r.unitsByID[unitID] = u
+	return nil
+}
+
+// DeleteBranch implements ports.BranchRepository.
+func (r *UnitRepo) DeleteBranch(unitID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.unitsByID[unitID]
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	var bs []domain.Branch
+	for _, x := range u.Branches {
+		if x.Name != name {
+			bs = append(bs, x)
+		}
+	}
+	u.Branches = bs
+	r.unitsByID[unitID] = u
+	return nil
+}
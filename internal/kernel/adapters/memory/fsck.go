@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: CheckDataDir implements ports.DataDirChecker. The in-memory store
+// has no files or derived indexes, so only the lineage checks of the fsck
+// usecase apply; units are returned ordered by id.
+func (r *UnitRepo) CheckDataDir() (ports.DataDirCheck, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := ports.DataDirCheck{Units: []ports.FsckUnit{}, Findings: []ports.FsckFinding{}}
+	for _, u := range r.unitsByID {
+		vs := append([]domain.Version{}, r.versionsByUnitID[u.ID]...)
+		out.Units = append(out.Units, ports.FsckUnit{Unit: u, Versions: vs})
+	}
+	sort.Slice(out.Units, func(a, b int) bool { return out.Units[a].Unit.ID < out.Units[b].Unit.ID })
+	return out, nil
+}
+
+func (r *UnitRepo) RebuildIndex(path string) (ports.FsckRepair, error) {
+	return ports.FsckRepair{}, fmt.Errorf("no index at %s", path)
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+type IntentJournal struct {
+	mu      sync.Mutex
+	Pending map[string]domain.Intent
+}
+
+func NewIntentJournal() *IntentJournal {
+	return &IntentJournal{Pending: map[string]domain.Intent{}}
+}
+
+func (j *IntentJournal) Begin(in domain.Intent) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Pending[in.ID] = in
+	return nil
+}
+
+func (j *IntentJournal) Commit(intentID string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.Pending, intentID)
+	return nil
+}
+
+func (j *IntentJournal) Abort(intentID string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if in, ok := j.Pending[intentID]; ok {
+		in.Aborted = true
+		j.Pending[intentID] = in
+	}
+	return nil
+}
+
+func (j *IntentJournal) ListPending() ([]domain.Intent, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]domain.Intent, 0, len(j.Pending))
+	for _, in := range j.Pending {
+		out = append(out, in)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].AtUnix != out[b].AtUnix {
+			return out[a].AtUnix < out[b].AtUnix
+		}
+		return out[a].ID < out[b].ID
+	})
+	return out, nil
+}
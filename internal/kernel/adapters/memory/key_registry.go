@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"crypto/ed25519"
+	"sync"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+type KeyRegistry struct {
+	mu   sync.RWMutex
+	keys []domain.ActorKey
+}
+
+func NewKeyRegistry() *KeyRegistry {
+	return &KeyRegistry{keys: []domain.ActorKey{}}
+}
+
+func (r *KeyRegistry) RegisterKey(k domain.ActorKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.keys {
+		if existing.KeyID == k.KeyID {
+			return domain.ErrKeyAlreadyExists
+		}
+	}
+	r.keys = append(r.keys, k)
+	return nil
+}
+
+func (r *KeyRegistry) RevokeKey(keyID string, atUnix int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.keys {
+		if r.keys[i].KeyID == keyID {
+			if r.keys[i].RevokedAtUnix != 0 {
+				return domain.ErrKeyRevoked
+			}
+			r.keys[i].RevokedAtUnix = atUnix
+			return nil
+		}
+	}
+	return domain.ErrKeyNotFound
+}
+
+func (r *KeyRegistry) FindKey(keyID string) (domain.ActorKey, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.KeyID == keyID {
+			return k, true, nil
+		}
+	}
+	return domain.ActorKey{}, false, nil
+}
+
+func (r *KeyRegistry) ListKeys() ([]domain.ActorKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]domain.ActorKey, len(r.keys))
+	copy(out, r.keys)
+	return out, nil
+}
+
+// Keyring is an in-memory ports.AuditSigner (actorID -> private key).
+type Keyring struct {
+	mu   sync.RWMutex
+	Keys ports.ActorKeyRegistry
+	priv map[string]ed25519.PrivateKey
+}
+
+func NewKeyring(keys ports.ActorKeyRegistry) *Keyring {
+	return &Keyring{Keys: keys, priv: map[string]ed25519.PrivateKey{}}
+}
+
+func (k *Keyring) Add(actorID string, priv ed25519.PrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.priv[actorID] = priv
+}
+
+func (k *Keyring) SignAuditEvent(ev domain.AuditEvent) (domain.AuditEvent, bool, error) {
+	keyID, priv, err := k.usableKey(ev.ActorID)
+	if err != nil || priv == nil {
+		return ev, false, err
+	}
+	if err := domain.SignAuditEvent(&ev, keyID, priv); err != nil {
+		return ev, false, err
+	}
+	return ev, true, nil
+}
+
+// SignTreeHead implements ports.TreeHeadSigner with the key of domain.TreeHeadActorID.
+func (k *Keyring) SignTreeHead(th domain.TreeHead) (domain.TreeHead, bool, error) {
+	keyID, priv, err := k.usableKey(domain.TreeHeadActorID)
+	if err != nil || priv == nil {
+		return th, false, err
+	}
+	domain.SignTreeHead(&th, keyID, priv)
+	return th, true, nil
+}
+
+func (k *Keyring) usableKey(actorID string) (string, ed25519.PrivateKey, error) {
+	k.mu.RLock()
+	priv, ok := k.priv[actorID]
+	k.mu.RUnlock()
+	if !ok {
+		return "", nil, nil
+	}
+	keyID := domain.ActorKeyID(priv.Public().(ed25519.PublicKey))
+	reg, ok, err := k.Keys.FindKey(keyID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok || reg.RevokedAtUnix != 0 {
+		return "", nil, nil
+	}
+	return keyID, priv, nil
+}
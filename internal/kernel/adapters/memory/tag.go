@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// SaveTag implements ports.TagRepository. Saving a tag again is a no-op;
+// moving it fails.
+func (r *UnitRepo) SaveTag(unitID string, t domain.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.unitsByID[unitID]
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	if x, ok := u.FindTag(t.Name); ok {
+		if x.VersionID != t.VersionID {
+			return fmt.Errorf("%w: %q points to %s", domain.ErrTagAlreadyExists, t.Name, x.VersionID)
+		}
+		return nil
+	}
+	ts := make([]domain.Tag, 0, len(u.Tags)+1)
+	ts = append(ts, u.Tags...)
+	ts = append(ts, t)
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+	u.Tags = ts
+	r.unitsByID[unitID] = u
+	return nil
+}
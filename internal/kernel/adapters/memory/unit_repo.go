@@ -2,6 +2,7 @@ package memory
 
 import (
 	"digiemu-core/internal/kernel/domain"
+	"fmt"
 	"sync"
 )
 
@@ -99,6 +100,9 @@ func (r *UnitRepo) ListVersionsByUnitID(unitID string) ([]domain.Version, error)
 	return out, nil
 }
 
+// UpdateUnitHead moves the head of the main branch to headVersionID, which
+// must be a main version built on the current head (domain.ErrConflict
+// otherwise), as fs.UnitRepo does. Moving it to where it is is a no-op.
 func (r *UnitRepo) UpdateUnitHead(unitID, headVersionID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -107,11 +111,30 @@ func (r *UnitRepo) UpdateUnitHead(unitID, headVersionID string) error {
 	if !ok {
 		return domain.ErrUnitNotFound
 	}
+	if u.HeadVersionID == headVersionID {
+		return nil
+	}
+	if err := r.checkHeadMoveLocked(u.HeadVersionID, headVersionID, ""); err != nil {
+		return err
+	}
 	u.HeadVersionID = headVersionID
 	r.unitsByID[unitID] = u
 	return nil
 }
 
+// checkHeadMoveLocked fails with domain.ErrConflict unless the version to
+// becomes the head of branch by building on the head from.
+func (r *UnitRepo) checkHeadMoveLocked(from, to, branch string) error {
+	v, ok := r.versionsByID[to]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrVersionNotFound, to)
+	}
+	if v.Branch != branch || v.PrevVersionID != from {
+		return fmt.Errorf("%w: %s builds on %q, head is %q", domain.ErrConflict, to, v.PrevVersionID, from)
+	}
+	return nil
+}
+
 func (r *UnitRepo) SaveMeaning(unitID, versionID string, meaning domain.Meaning, meaningHash string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
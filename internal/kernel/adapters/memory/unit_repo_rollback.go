@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// DeleteUnit implements ports.UnitRepositoryRollback.
+func (r *UnitRepo) DeleteUnit(unitID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.unitsByID[unitID]
+	if !ok {
+		return nil
+	}
+	delete(r.unitsByID, unitID)
+	if r.unitsByKey[u.Key] == unitID {
+		delete(r.unitsByKey, u.Key)
+	}
+	for _, v := range r.versionsByUnitID[unitID] {
+		delete(r.versionsByID, v.ID)
+	}
+	delete(r.versionsByUnitID, unitID)
+	r.deleteSidecarsLocked(unitID + ".")
+	return nil
+}
+
+// DeleteVersion implements ports.UnitRepositoryRollback.
+func (r *UnitRepo) DeleteVersion(unitID, versionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.unitsByID[unitID]
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	vs := r.versionsByUnitID[unitID]
+	kept := make([]domain.Version, 0, len(vs))
+	for _, v := range vs {
+		if v.ID == versionID {
+			if u.HeadVersionID == versionID {
+				u.HeadVersionID = v.PrevVersionID
+			}
+			// v0.6: as well as the head of a branch
+			bs := make([]domain.Branch, len(u.Branches))
+			for i, b := range u.Branches {
+				if b.HeadVersionID == versionID {
+					b.HeadVersionID = v.PrevVersionID
+				}
+				bs[i] = b
+			}
+			u.Branches = bs
+			r.unitsByID[unitID] = u
+			continue
+		}
+		kept = append(kept, v)
+	}
+	r.versionsByUnitID[unitID] = kept
+	delete(r.versionsByID, versionID)
+	r.deleteSidecarsLocked(unitID + "." + versionID)
+	return nil
+}
+
+func (r *UnitRepo) deleteSidecarsLocked(prefix string) {
+	for k := range r.meanings {
+		if strings.HasPrefix(k, prefix) {
+			delete(r.meanings, k)
+		}
+	}
+	for k := range r.claimsets {
+		if strings.HasPrefix(k, prefix) {
+			delete(r.claimsets, k)
+		}
+	}
+	for k := range r.uncertainties {
+		if strings.HasPrefix(k, prefix) {
+			delete(r.uncertainties, k)
+		}
+	}
+}
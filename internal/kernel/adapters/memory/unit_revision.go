@@ -0,0 +1,24 @@
+package memory
+
+import "digiemu-core/internal/kernel/domain"
+
+// SaveUnitRevision implements ports.UnitMetadataRepository. Saving a
+// revision again is a no-op.
+func (r *UnitRepo) SaveUnitRevision(unitID string, rev domain.UnitRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.unitsByID[unitID]
+	if !ok {
+		return domain.ErrUnitNotFound
+	}
+	hist, added, err := domain.AppendUnitRevision(u, rev)
+	if err != nil || !added {
+		return err
+	}
+	u.Title = rev.Title
+	u.Description = rev.Description
+	u.Revisions = hist
+	r.unitsByID[unitID] = u
+	return nil
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// ActorKey is a registered Ed25519 public key that an actor signs audit
+// events with. Revoked keys stay in the registry so that old signatures
+// can still be checked.
+type ActorKey struct {
+	KeyID         string `json:"keyId"`
+	ActorID       string `json:"actorId"`
+	PublicKey     string `json:"publicKey"` // base64 (std) of the 32-byte key
+	CreatedAtUnix int64  `json:"createdAtUnix"`
+	RevokedAtUnix int64  `json:"revokedAtUnix,omitempty"`
+}
+
+// NewActorKey validates the encoded public key and derives its KeyID.
+func NewActorKey(actorID, publicKeyB64 string, atUnix int64) (ActorKey, error) {
+	actorID = strings.TrimSpace(actorID)
+	if actorID == "" {
+		return ActorKey{}, ErrInvalidKeyActorID
+	}
+	pub, err := DecodePublicKey(publicKeyB64)
+	if err != nil {
+		return ActorKey{}, err
+	}
+	return ActorKey{
+		KeyID:         ActorKeyID(pub),
+		ActorID:       actorID,
+		PublicKey:     base64.StdEncoding.EncodeToString(pub),
+		CreatedAtUnix: atUnix,
+	}, nil
+}
+
+// ActorKeyID derives a stable key id from the public key bytes.
+func ActorKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return "key_" + hex.EncodeToString(sum[:16])
+}
+
+func DecodePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// RevokedAt reports whether the key was already revoked at atUnix.
+// Signatures made in the second of the revocation itself stay valid.
+func (k ActorKey) RevokedAt(atUnix int64) bool {
+	return k.RevokedAtUnix != 0 && atUnix > k.RevokedAtUnix
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// v0.6: external anchors of the audit log.
+
+const AuditAnchorSchema = "digiemu.audit.anchor.v1"
+
+// AuditAnchor commits to the first TreeSize events of the log (the Merkle
+// root over them, as in a tree head) and is stored outside the data dir.
+// Receipt fields are filled in by the sink that stored it: a local anchor
+// file has none, an RFC 3161 anchor carries the TSA's timestamp token.
+type AuditAnchor struct {
+	Schema      string `json:"schema"`
+	Sink        string `json:"sink"`
+	TreeSize    int64  `json:"treeSize"`
+	RootHash    string `json:"rootHash"`
+	LastEventID string `json:"lastEventId,omitempty"`
+	Digest      string `json:"digest"`
+	AtUnix      int64  `json:"atUnix"`
+
+	TSA         string `json:"tsa,omitempty"`
+	Token       string `json:"token,omitempty"` // base64 DER TimeStampToken
+	GenTimeUnix int64  `json:"genTimeUnix,omitempty"`
+}
+
+// AuditAnchorDigest is the SHA-256 that gets anchored for a tree of size
+// treeSize with root rootHash (hex). Like TreeHeadSigningBytes it is
+// line-based, so anchors can be checked without digiemu.
+func AuditAnchorDigest(treeSize int64, rootHash string) []byte {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%d\n%s\n", AuditAnchorSchema, treeSize, rootHash)))
+	return sum[:]
+}
+
+// NewAuditAnchor returns the anchor of a tree head, ready to hand to a sink.
+func NewAuditAnchor(treeSize int64, rootHash, lastEventID string, atUnix int64) AuditAnchor {
+	return AuditAnchor{
+		Schema:      AuditAnchorSchema,
+		TreeSize:    treeSize,
+		RootHash:    rootHash,
+		LastEventID: lastEventID,
+		Digest:      hex.EncodeToString(AuditAnchorDigest(treeSize, rootHash)),
+		AtUnix:      atUnix,
+	}
+}
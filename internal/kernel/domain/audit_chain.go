@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// AuditChainGenesisType marks the start of the hash chain in a log that
+// already contained unchained (pre-v0.6) events.
+const AuditChainGenesisType = "audit.chain.genesis"
+
+// AuditChainGenesisData records the legacy prefix the chain starts after.
+// LegacyHash folds the event hashes of all legacy events (see FoldLegacyHash).
+type AuditChainGenesisData struct {
+	LegacyEvents int64  `json:"legacyEvents"`
+	LegacyHash   string `json:"legacyHash,omitempty"`
+}
+
+// AuditChainHead identifies the last chained event of a log.
+type AuditChainHead struct {
+	Seq     int64  `json:"seq"`
+	Hash    string `json:"hash"`
+	EventID string `json:"eventId,omitempty"`
+}
+
+// CanonicalAuditEventJSON renders the event as JSON with sorted object keys,
+// so struct payloads and payloads decoded from NDJSON hash identically.
+func CanonicalAuditEventJSON(ev AuditEvent) ([]byte, error) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalJSON(b)
+}
+
+// ComputeAuditEventHash returns the hex sha256 over the canonical event with
+// its own Hash field cleared.
+func ComputeAuditEventHash(ev AuditEvent) (string, error) {
+	ev.Hash = ""
+	b, err := CanonicalAuditEventJSON(ev)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SealAuditEvent links ev to prev (zero value for the first chained event)
+// and sets Seq, PrevHash and Hash.
+func SealAuditEvent(ev *AuditEvent, prev AuditChainHead) error {
+	ev.Seq = prev.Seq + 1
+	ev.PrevHash = prev.Hash
+	h, err := ComputeAuditEventHash(*ev)
+	if err != nil {
+		return err
+	}
+	ev.Hash = h
+	return nil
+}
+
+// IsChained reports whether the event was written as part of the hash chain.
+func (ev AuditEvent) IsChained() bool {
+	return ev.Seq > 0 || ev.Hash != ""
+}
+
+// FoldLegacyHash extends the running legacy digest with one unchained event.
+func FoldLegacyHash(acc string, ev AuditEvent) (string, error) {
+	h, err := ComputeAuditEventHash(ev)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(acc + "\n" + h))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -12,17 +12,94 @@ type AuditEvent struct {
 	VersionID string `json:"versionId,omitempty"`
 
 	Data any `json:"data,omitempty"`
+
+	// v0.6: hash chain (tamper-evident ordering). Seq starts at 1; PrevHash is
+	// the Hash of the preceding chained event ("" for the first one).
+	Seq      int64  `json:"seq,omitempty"`
+	PrevHash string `json:"prevHash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+
+	// v0.6: optional Ed25519 signature by the actor's registered key
+	KeyID string `json:"keyId,omitempty"`
+	Sig   string `json:"sig,omitempty"`
 }
 
 type UnitCreatedData struct {
-	Key   string `json:"key"`
-	Title string `json:"title"`
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"` // v0.6: metadata revision 1
+	TenantID    string `json:"tenantId,omitempty"`    // v0.6
 }
 
 type VersionCreatedData struct {
 	PrevVersionID string `json:"prevVersionId,omitempty"`
 	ContentHash   string `json:"contentHash"`
 	Label         string `json:"label"`
+	Branch        string `json:"branch,omitempty"` // v0.6; "" for MainBranch
+}
+
+// VersionRevertedData is the payload of version.reverted, which records a
+// new version made from an earlier one. It stands in for the version.created
+// event of the new version and, for each sidecar carried over, for its
+// MEANING_SET / CLAIM_SET / UNCERTAINTY_SET event.
+type VersionRevertedData struct {
+	PrevVersionID   string `json:"prevVersionId,omitempty"`
+	ContentHash     string `json:"contentHash"`
+	Label           string `json:"label"`
+	SourceVersionID string `json:"sourceVersionId"`
+	MeaningHash     string `json:"meaningHash,omitempty"`
+	ClaimSetHash    string `json:"claimSetHash,omitempty"`
+	UncertaintyHash string `json:"uncertaintyHash,omitempty"`
+	Branch          string `json:"branch,omitempty"`
+}
+
+// VersionMergedData is the payload of version.merged, which records the
+// merge version of Branch (its PrevVersionID) and SourceBranch (its
+// MergeParentID). It stands in for the version.created event of the merge
+// version; MergeBaseVersionID is the common ancestor the merge started from.
+type VersionMergedData struct {
+	PrevVersionID      string `json:"prevVersionId,omitempty"`
+	MergeParentID      string `json:"mergeParentId"`
+	MergeBaseVersionID string `json:"mergeBaseVersionId,omitempty"`
+	ContentHash        string `json:"contentHash"`
+	Label              string `json:"label"`
+	Branch             string `json:"branch,omitempty"`
+	SourceBranch       string `json:"sourceBranch"`
+	Resolved           bool   `json:"resolved,omitempty"` // conflicts resolved by the caller
+}
+
+// BranchCreatedData is the payload of branch.created; the event's VersionID
+// is the version the branch starts from.
+type BranchCreatedData struct {
+	Branch        string `json:"branch"`
+	BaseVersionID string `json:"baseVersionId"`
+}
+
+// BranchDeletedData is the payload of branch.deleted. The versions of the
+// branch stay in the history.
+type BranchDeletedData struct {
+	Branch        string `json:"branch"`
+	HeadVersionID string `json:"headVersionId,omitempty"`
+}
+
+// TagCreatedData is the payload of TAG_CREATED; the event's VersionID is the
+// tagged version and ContentHash its content hash at tagging time.
+type TagCreatedData struct {
+	Tag         string `json:"tag"`
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+// UnitUpdatedData is the payload of unit.updated, which records metadata
+// revision Revision of a unit: Before is the metadata it replaced.
+type UnitUpdatedData struct {
+	Revision int          `json:"revision"`
+	Before   UnitMetadata `json:"before"`
+	After    UnitMetadata `json:"after"`
+}
+
+type UnitMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
 type MeaningSetData struct {
@@ -56,3 +133,14 @@ type UncertaintySetData struct {
 	UncertaintyHash string `json:"uncertainty_hash"`
 	UncertaintyPath string `json:"uncertainty_path,omitempty"`
 }
+
+type KeyRegisteredData struct {
+	KeyID     string `json:"key_id"`
+	ActorID   string `json:"actor_id"`
+	PublicKey string `json:"public_key"`
+}
+
+type KeyRevokedData struct {
+	KeyID   string `json:"key_id"`
+	ActorID string `json:"actor_id"`
+}
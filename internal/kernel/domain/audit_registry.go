@@ -0,0 +1,271 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// v0.6: registry of audit event types. Every type the kernel writes maps to
+// its payload struct and a validator, and readers decode Data into that
+// struct (NormalizeAuditPayload) instead of handling map and struct forms.
+//
+// Type names are the strings already in the logs and stay as they are: they
+// are covered by event hashes and signatures. The historic mix of spellings
+// ("unit.created" vs "MEANING_SET") is bridged by aliases; lookups and
+// filters accept either form.
+
+// Audit event types written by the kernel (see also AuditChainGenesisType,
+// AuditFsckRepaired, AuditIntentRolledForward and AuditIntentRolledBack).
+const (
+	AuditUnitCreated      = "unit.created"
+	AuditVersionCreated   = "version.created"
+	AuditVersionReverted  = "version.reverted"
+	AuditVersionMerged    = "version.merged"
+	AuditBranchCreated    = "branch.created"
+	AuditBranchDeleted    = "branch.deleted"
+	AuditMeaningSet       = "MEANING_SET"
+	AuditClaimSet         = "CLAIM_SET"
+	AuditClaimRelationSet = "CLAIM_RELATION_SET"
+	AuditUncertaintySet   = "UNCERTAINTY_SET"
+	AuditKeyRegistered    = "KEY_REGISTERED"
+	AuditKeyRevoked       = "KEY_REVOKED"
+	AuditAccessDenied     = "ACCESS_DENIED"
+	AuditTagCreated       = "TAG_CREATED"
+	AuditUnitUpdated      = "unit.updated"
+)
+
+// AuditEventType is a registered audit event type.
+type AuditEventType struct {
+	Name    string   // as written to the log
+	Aliases []string // other accepted spellings
+
+	decode func(ev AuditEvent) (p any, exact bool, err error)
+}
+
+var (
+	auditEventTypes  = map[string]*AuditEventType{} // by name
+	auditEventLookup = map[string]*AuditEventType{} // by name and alias
+)
+
+// RegisterAuditEventType registers an event type whose payload is T (stored
+// in AuditEvent.Data as a T value). validate may be nil. Types are
+// registered from init functions; a name or alias taken twice panics.
+func RegisterAuditEventType[T any](name string, aliases []string, validate func(ev AuditEvent, p T) error) {
+	t := &AuditEventType{Name: name, Aliases: aliases}
+	t.decode = func(ev AuditEvent) (any, bool, error) {
+		p, decoded, err := decodePayload[T](ev.Data)
+		if !decoded {
+			return nil, false, fmt.Errorf("%w: %s: %v", ErrInvalidAuditPayload, name, err)
+		}
+		exact := err == nil
+		if exact && validate != nil {
+			err = validate(ev, p)
+		}
+		if err != nil {
+			return p, exact, fmt.Errorf("%w: %s: %v", ErrInvalidAuditPayload, name, err)
+		}
+		return p, true, nil
+	}
+	for _, n := range append([]string{name}, aliases...) {
+		if _, dup := auditEventLookup[n]; dup {
+			panic("audit event type registered twice: " + n)
+		}
+		auditEventLookup[n] = t
+	}
+	auditEventTypes[name] = t
+}
+
+// LookupAuditEventType resolves a type name or alias.
+func LookupAuditEventType(name string) (AuditEventType, bool) {
+	t, ok := auditEventLookup[name]
+	if !ok {
+		return AuditEventType{}, false
+	}
+	return *t, true
+}
+
+// CanonicalAuditEventType returns the registered name for name or one of its
+// aliases, and name itself for unknown types.
+func CanonicalAuditEventType(name string) string {
+	if t, ok := auditEventLookup[name]; ok {
+		return t.Name
+	}
+	return name
+}
+
+// AuditEventTypes lists the registered types by name.
+func AuditEventTypes() []AuditEventType {
+	out := make([]AuditEventType, 0, len(auditEventTypes))
+	for _, t := range auditEventTypes {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// DecodeAuditPayload returns the typed payload of ev. Errors wrap
+// ErrUnknownAuditEventType or ErrInvalidAuditPayload; a payload that decodes
+// but is not valid (unknown or missing fields, failed validation) is
+// returned together with the error.
+func DecodeAuditPayload(ev AuditEvent) (any, error) {
+	t, ok := auditEventLookup[ev.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAuditEventType, ev.Type)
+	}
+	p, _, err := t.decode(ev)
+	return p, err
+}
+
+// NormalizeAuditPayload replaces ev.Data by its typed payload if that renders
+// exactly like Data, so the event still hashes and verifies as written.
+// Otherwise Data is left as it was. The error is DecodeAuditPayload's.
+func NormalizeAuditPayload(ev *AuditEvent) error {
+	t, ok := auditEventLookup[ev.Type]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownAuditEventType, ev.Type)
+	}
+	p, exact, err := t.decode(*ev)
+	if p != nil && exact {
+		ev.Data = p
+	}
+	return err
+}
+
+// decodePayload converts data (a T, or JSON decoded generically) into a T.
+// decoded is false if data is not a T at all. A non-nil error with
+// decoded=true means the T does not render like data (unknown fields, or
+// fields T renders differently such as missing ones without omitempty), so
+// replacing data by it would change the event hash.
+func decodePayload[T any](data any) (p T, decoded bool, err error) {
+	switch d := data.(type) {
+	case T:
+		return d, true, nil
+	case *T:
+		if d != nil {
+			return *d, true, nil
+		}
+	}
+	if data == nil {
+		return p, false, errors.New("payload missing")
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return p, false, err
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return p, false, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var strict T
+	if err := dec.Decode(&strict); err != nil {
+		return p, true, err
+	}
+	typed, err := json.Marshal(p)
+	if err != nil {
+		return p, true, err
+	}
+	a, err := canonicalJSON(raw)
+	if err != nil {
+		return p, true, err
+	}
+	b, err := canonicalJSON(typed)
+	if err != nil {
+		return p, true, err
+	}
+	if !bytes.Equal(a, b) {
+		return p, true, errors.New("payload fields differ from the registered schema")
+	}
+	return p, true, nil
+}
+
+// canonicalJSON re-renders JSON with sorted object keys and numbers kept as
+// written.
+func canonicalJSON(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var x any
+	if err := dec.Decode(&x); err != nil {
+		return nil, err
+	}
+	return json.Marshal(x)
+}
+
+// requireFields takes (name, value) pairs and reports the first empty value.
+func requireFields(fields ...string) error {
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			return fmt.Errorf("%s missing", fields[i])
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterAuditEventType(AuditUnitCreated, []string{"UNIT_CREATED"}, func(ev AuditEvent, p UnitCreatedData) error {
+		return requireFields("unitId", ev.UnitID, "key", p.Key)
+	})
+	RegisterAuditEventType(AuditVersionCreated, []string{"VERSION_CREATED"}, func(ev AuditEvent, p VersionCreatedData) error {
+		return requireFields("versionId", ev.VersionID, "contentHash", p.ContentHash)
+	})
+	RegisterAuditEventType(AuditVersionReverted, []string{"VERSION_REVERTED"}, func(ev AuditEvent, p VersionRevertedData) error {
+		return requireFields("versionId", ev.VersionID, "contentHash", p.ContentHash, "sourceVersionId", p.SourceVersionID)
+	})
+	RegisterAuditEventType(AuditVersionMerged, []string{"VERSION_MERGED"}, func(ev AuditEvent, p VersionMergedData) error {
+		return requireFields("versionId", ev.VersionID, "contentHash", p.ContentHash, "mergeParentId", p.MergeParentID, "sourceBranch", p.SourceBranch)
+	})
+	RegisterAuditEventType(AuditBranchCreated, []string{"BRANCH_CREATED"}, func(ev AuditEvent, p BranchCreatedData) error {
+		return requireFields("unitId", ev.UnitID, "branch", p.Branch, "baseVersionId", p.BaseVersionID)
+	})
+	RegisterAuditEventType(AuditBranchDeleted, []string{"BRANCH_DELETED"}, func(ev AuditEvent, p BranchDeletedData) error {
+		return requireFields("unitId", ev.UnitID, "branch", p.Branch)
+	})
+	RegisterAuditEventType(AuditMeaningSet, []string{"meaning.set"}, func(ev AuditEvent, p MeaningSetData) error {
+		return requireFields("versionId", ev.VersionID, "meaning_hash", p.MeaningHash)
+	})
+	RegisterAuditEventType(AuditClaimSet, []string{"claim.set"}, func(ev AuditEvent, p ClaimSetData) error {
+		return requireFields("versionId", ev.VersionID, "claimset_hash", p.ClaimSetHash)
+	})
+	RegisterAuditEventType(AuditClaimRelationSet, []string{"claim_relation.set"}, func(ev AuditEvent, p ClaimRelationSetData) error {
+		return requireFields("type", p.Type, "from_claim_id", p.FromClaimID, "to_claim_id", p.ToClaimID)
+	})
+	RegisterAuditEventType(AuditUncertaintySet, []string{"uncertainty.set"}, func(ev AuditEvent, p UncertaintySetData) error {
+		return requireFields("versionId", ev.VersionID, "uncertainty_hash", p.UncertaintyHash)
+	})
+	RegisterAuditEventType(AuditKeyRegistered, []string{"key.registered"}, func(ev AuditEvent, p KeyRegisteredData) error {
+		return requireFields("key_id", p.KeyID, "actor_id", p.ActorID, "public_key", p.PublicKey)
+	})
+	RegisterAuditEventType(AuditKeyRevoked, []string{"key.revoked"}, func(ev AuditEvent, p KeyRevokedData) error {
+		return requireFields("key_id", p.KeyID, "actor_id", p.ActorID)
+	})
+	RegisterAuditEventType(AuditAccessDenied, []string{"access.denied"}, func(ev AuditEvent, p AccessDeniedData) error {
+		return requireFields("action", p.Action, "unit_key", p.UnitKey, "required_role", p.RequiredRole)
+	})
+	RegisterAuditEventType(AuditTagCreated, []string{"tag.created"}, func(ev AuditEvent, p TagCreatedData) error {
+		return requireFields("unitId", ev.UnitID, "versionId", ev.VersionID, "tag", p.Tag)
+	})
+	RegisterAuditEventType(AuditUnitUpdated, []string{"UNIT_UPDATED"}, func(ev AuditEvent, p UnitUpdatedData) error {
+		if p.Revision < 2 {
+			return errors.New("revision must be at least 2")
+		}
+		return requireFields("unitId", ev.UnitID, "after.title", p.After.Title)
+	})
+	RegisterAuditEventType(AuditChainGenesisType, []string{"AUDIT_CHAIN_GENESIS"}, func(ev AuditEvent, p AuditChainGenesisData) error {
+		if p.LegacyEvents < 0 {
+			return errors.New("legacyEvents negative")
+		}
+		return nil
+	})
+	RegisterAuditEventType(AuditFsckRepaired, []string{"FSCK_REPAIRED"}, func(ev AuditEvent, p FsckRepairData) error {
+		return requireFields("kind", p.Kind, "path", p.Path)
+	})
+	RegisterAuditEventType(AuditIntentRolledForward, []string{"INTENT_ROLLED_FORWARD"}, validateIntentRecovery)
+	RegisterAuditEventType(AuditIntentRolledBack, []string{"INTENT_ROLLED_BACK"}, validateIntentRecovery)
+}
+
+func validateIntentRecovery(ev AuditEvent, p IntentRecoveryData) error {
+	return requireFields("intent_id", p.IntentID, "op", p.Op, "event_id", p.EventID)
+}
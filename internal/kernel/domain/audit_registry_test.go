@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// roundTrip renders ev as it would be written and reads it back generically.
+func roundTrip(t *testing.T, ev AuditEvent) AuditEvent {
+	t.Helper()
+	b, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out AuditEvent
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestAuditRegistry_NamesAndAliases(t *testing.T) {
+	for alias, name := range map[string]string{
+		"UNIT_CREATED":       AuditUnitCreated,
+		"meaning.set":        AuditMeaningSet,
+		"claim_relation.set": AuditClaimRelationSet,
+		AuditKeyRevoked:      AuditKeyRevoked,
+	} {
+		if got := CanonicalAuditEventType(alias); got != name {
+			t.Fatalf("CanonicalAuditEventType(%q) = %q, want %q", alias, got, name)
+		}
+	}
+	if got := CanonicalAuditEventType("custom.thing"); got != "custom.thing" {
+		t.Fatalf("unknown types keep their name, got %q", got)
+	}
+	if _, ok := LookupAuditEventType("custom.thing"); ok {
+		t.Fatal("unknown type resolved")
+	}
+	types := AuditEventTypes()
+	for i := 1; i < len(types); i++ {
+		if types[i-1].Name >= types[i].Name {
+			t.Fatalf("types not sorted: %q before %q", types[i-1].Name, types[i].Name)
+		}
+	}
+}
+
+func TestAuditRegistry_DecodeAndNormalize(t *testing.T) {
+	ev := roundTrip(t, AuditEvent{
+		Type:      AuditVersionCreated,
+		UnitID:    "u1",
+		VersionID: "v1",
+		Data:      VersionCreatedData{ContentHash: "abc", Label: "first"},
+	})
+	before, err := CanonicalAuditEventJSON(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NormalizeAuditPayload(&ev); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := ev.Data.(VersionCreatedData)
+	if !ok || d.ContentHash != "abc" || d.Label != "first" {
+		t.Fatalf("payload not typed: %#v", ev.Data)
+	}
+	after, err := CanonicalAuditEventJSON(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("normalizing changed the hash input:\n%s\n%s", before, after)
+	}
+
+	// an unknown field: decoded for reading, but Data is kept as written
+	ev = roundTrip(t, AuditEvent{Type: "VERSION_CREATED", VersionID: "v1", Data: map[string]any{"contentHash": "abc", "label": "x", "extra": 1}})
+	p, err := DecodeAuditPayload(ev)
+	if !errors.Is(err, ErrInvalidAuditPayload) {
+		t.Fatalf("expected ErrInvalidAuditPayload, got %v", err)
+	}
+	if d, ok := p.(VersionCreatedData); !ok || d.ContentHash != "abc" {
+		t.Fatalf("payload not returned with the error: %#v", p)
+	}
+	if err := NormalizeAuditPayload(&ev); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, ok := ev.Data.(map[string]any); !ok {
+		t.Fatalf("inexact payload replaced: %#v", ev.Data)
+	}
+
+	// validation and missing payloads
+	ev = roundTrip(t, AuditEvent{Type: AuditUnitCreated, UnitID: "u1", Data: UnitCreatedData{Title: "no key"}})
+	if _, err := DecodeAuditPayload(ev); !errors.Is(err, ErrInvalidAuditPayload) {
+		t.Fatalf("missing key: expected ErrInvalidAuditPayload, got %v", err)
+	}
+	if _, err := DecodeAuditPayload(AuditEvent{Type: AuditUnitCreated, UnitID: "u1"}); !errors.Is(err, ErrInvalidAuditPayload) {
+		t.Fatalf("missing payload: expected ErrInvalidAuditPayload, got %v", err)
+	}
+	if _, err := DecodeAuditPayload(AuditEvent{Type: "custom.thing"}); !errors.Is(err, ErrUnknownAuditEventType) {
+		t.Fatalf("expected ErrUnknownAuditEventType, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+)
+
+// AuditEventSigningBytes returns the bytes an actor signs: the canonical
+// event without chain fields (assigned later by the log) and without Sig.
+// KeyID is included so a signature cannot be moved to another key.
+func AuditEventSigningBytes(ev AuditEvent) ([]byte, error) {
+	ev.Seq = 0
+	ev.PrevHash = ""
+	ev.Hash = ""
+	ev.Sig = ""
+	return CanonicalAuditEventJSON(ev)
+}
+
+// SignAuditEvent sets KeyID and Sig on ev using priv.
+func SignAuditEvent(ev *AuditEvent, keyID string, priv ed25519.PrivateKey) error {
+	ev.KeyID = keyID
+	b, err := AuditEventSigningBytes(*ev)
+	if err != nil {
+		return err
+	}
+	ev.Sig = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, b))
+	return nil
+}
+
+// VerifyAuditEventSignature checks ev.Sig against pub.
+func VerifyAuditEventSignature(ev AuditEvent, pub ed25519.PublicKey) bool {
+	sig, err := base64.StdEncoding.DecodeString(ev.Sig)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	b, err := AuditEventSigningBytes(ev)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, b, sig)
+}
@@ -0,0 +1,70 @@
+package domain
+
+import "fmt"
+
+// v0.6: branches. Every unit has MainBranch, whose head is Unit.HeadVersionID;
+// named branches keep their own head, so parallel lines of work (a legal
+// review next to main edits) do not move each other's head. A version
+// records the branch it was created on; merges join two branches with a
+// version that has two parents.
+const MainBranch = "main"
+
+// Branch is a named line of versions of a unit.
+type Branch struct {
+	Name          string
+	HeadVersionID string
+	BaseVersionID string // the version the branch was created from
+	CreatedAtUnix int64
+	ActorID       string
+}
+
+// ValidateBranchName accepts names of 1-64 lowercase letters, digits, dots,
+// dashes and underscores that start with a letter or digit (names appear in
+// URL paths). MainBranch exists on every unit and is not created.
+func ValidateBranchName(name string) error {
+	if len(name) < 1 || len(name) > 64 || name == MainBranch || !isBranchStart(name[0]) {
+		return fmt.Errorf("%w: %q", ErrInvalidBranchName, name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isBranchStart(c) && c != '.' && c != '-' && c != '_' {
+			return fmt.Errorf("%w: %q", ErrInvalidBranchName, name)
+		}
+	}
+	return nil
+}
+
+func isBranchStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+// BranchKey is the value stored in Version.Branch for a branch name:
+// MainBranch is stored as "".
+func BranchKey(name string) string {
+	if name == MainBranch {
+		return ""
+	}
+	return name
+}
+
+// BranchName is the inverse of BranchKey.
+func BranchName(key string) string {
+	if key == "" {
+		return MainBranch
+	}
+	return key
+}
+
+// FindBranch returns the named branch of u; MainBranch is reported with the
+// unit's head.
+func (u Unit) FindBranch(name string) (Branch, bool) {
+	if name == "" || name == MainBranch {
+		return Branch{Name: MainBranch, HeadVersionID: u.HeadVersionID}, true
+	}
+	for _, b := range u.Branches {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Branch{}, false
+}
@@ -0,0 +1,242 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// v0.6: line diffs between version contents.
+
+// DiffHunk is one hunk of a unified diff. Starts are 1-based; a side with no
+// lines starts at the line before the hunk (0 at the top), as in diff -u.
+// Lines carry their prefix: " " context, "-" removed, "+" added.
+type DiffHunk struct {
+	OldStart int      `json:"oldStart"`
+	OldLines int      `json:"oldLines"`
+	NewStart int      `json:"newStart"`
+	NewLines int      `json:"newLines"`
+	Lines    []string `json:"lines"`
+}
+
+// diffMaxEdits bounds the edit search; past it the differing middle of the
+// two texts is reported as removed and added as a whole.
+const diffMaxEdits = 1000
+
+// DiffLines returns the hunks of a minimal line diff (Myers) from a to b,
+// with up to context unchanged lines around each change.
+func DiffLines(a, b []string, context int) []DiffHunk {
+	if context < 0 {
+		context = 0
+	}
+	ops := diffOps(a, b)
+
+	var hunks []DiffHunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// a hunk runs from context lines before the first change up to
+		// context lines after the last change followed by more than
+		// 2*context unchanged lines
+		start := max(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		h := DiffHunk{OldStart: ops[start].oldLine, NewStart: ops[start].newLine}
+		for _, op := range ops[start:end] {
+			h.Lines = append(h.Lines, string(op.kind)+op.text)
+			if op.kind != '+' {
+				h.OldLines++
+			}
+			if op.kind != '-' {
+				h.NewLines++
+			}
+		}
+		if h.OldLines > 0 {
+			h.OldStart++
+		}
+		if h.NewLines > 0 {
+			h.NewStart++
+		}
+		hunks = append(hunks, h)
+		i = end
+	}
+	return hunks
+}
+
+// FormatUnifiedDiff renders hunks as a unified diff between the named sides.
+func FormatUnifiedDiff(oldName, newName string, hunks []DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+		for _, l := range h.Lines {
+			sb.WriteString(l)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// SplitLines splits text into lines for DiffLines; "" has no lines.
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffOp is one line of the edit script; oldLine/newLine count the lines
+// of a and b before it.
+type diffOp struct {
+	kind    byte // ' ', '-', '+'
+	text    string
+	oldLine int
+	newLine int
+}
+
+func diffOps(a, b []string) []diffOp {
+	// common prefix and suffix need no search
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	kinds := make([]byte, 0, len(a)+len(b))
+	for range pre {
+		kinds = append(kinds, ' ')
+	}
+	kinds = append(kinds, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for range suf {
+		kinds = append(kinds, ' ')
+	}
+
+	ops := make([]diffOp, 0, len(kinds))
+	x, y := 0, 0
+	for _, k := range kinds {
+		op := diffOp{kind: k, oldLine: x, newLine: y}
+		switch k {
+		case ' ':
+			op.text = a[x]
+			x++
+			y++
+		case '-':
+			op.text = a[x]
+			x++
+		case '+':
+			op.text = b[y]
+			y++
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// myers returns the shortest edit script from a to b as a sequence of
+// ' ' (keep), '-' (delete from a) and '+' (insert from b).
+func myers(a, b []string) []byte {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+	limit := min(n+m, diffMaxEdits)
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int // v as it was before each round d
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // down: insertion
+			} else {
+				x = v[off+k-1] + 1 // right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return replaceAll(n, m)
+	}
+
+	var rev []byte
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, ' ')
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				rev = append(rev, '+')
+			} else {
+				rev = append(rev, '-')
+			}
+		}
+		x, y = prevX, prevY
+	}
+	out := make([]byte, len(rev))
+	for i, k := range rev {
+		out[len(rev)-1-i] = k
+	}
+	return out
+}
+
+func replaceAll(n, m int) []byte {
+	out := make([]byte, 0, n+m)
+	for range n {
+		out = append(out, '-')
+	}
+	for range m {
+		out = append(out, '+')
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines_Unified(t *testing.T) {
+	a := SplitLines("one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve")
+	b := SplitLines("zero\none\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten\ntwelve\nthirteen")
+
+	// as diff -u prints it
+	want := `--- a
++++ b
+@@ -1,6 +1,7 @@
++zero
+ one
+ two
+-three
++THREE
+ four
+ five
+ six
+@@ -8,5 +9,5 @@
+ eight
+ nine
+ ten
+-eleven
+ twelve
++thirteen
+`
+	if got := FormatUnifiedDiff("a", "b", DiffLines(a, b, 3)); got != want {
+		t.Fatalf("unified diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	// with more context the two hunks merge
+	if hs := DiffLines(a, b, 4); len(hs) != 1 || hs[0].OldLines != 12 || hs[0].NewLines != 13 {
+		t.Fatalf("merged hunks %+v", hs)
+	}
+}
+
+func TestDiffLines_Edges(t *testing.T) {
+	if hs := DiffLines(SplitLines("x\ny"), SplitLines("x\ny"), 3); len(hs) != 0 {
+		t.Fatalf("equal texts: %+v", hs)
+	}
+	hs := DiffLines(nil, SplitLines("x\ny"), 3)
+	if len(hs) != 1 || hs[0].OldStart != 0 || hs[0].OldLines != 0 || hs[0].NewStart != 1 || hs[0].NewLines != 2 {
+		t.Fatalf("from empty: %+v", hs)
+	}
+	if got := FormatUnifiedDiff("a", "b", hs); !strings.Contains(got, "@@ -0,0 +1,2 @@") {
+		t.Fatalf("from empty:\n%s", got)
+	}
+	hs = DiffLines(SplitLines("a\nb\nc"), SplitLines("a\nc"), 0)
+	if len(hs) != 1 || hs[0].OldStart != 2 || hs[0].NewStart != 1 || hs[0].NewLines != 0 || strings.Join(hs[0].Lines, ",") != "-b" {
+		t.Fatalf("deletion without context: %+v", hs)
+	}
+}
+
+func TestDiffLines_Minimal(t *testing.T) {
+	// the edit script is minimal and replays a into b
+	a := SplitLines("a\nb\nc\na\nb\nb\na")
+	b := SplitLines("c\nb\na\nb\na\nc")
+	ops := diffOps(a, b)
+	var edits int
+	var old, new []string
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			old, new = append(old, op.text), append(new, op.text)
+		case '-':
+			old = append(old, op.text)
+			edits++
+		case '+':
+			new = append(new, op.text)
+			edits++
+		}
+	}
+	if strings.Join(old, "") != strings.Join(a, "") || strings.Join(new, "") != strings.Join(b, "") {
+		t.Fatalf("script does not replay: %q -> %q", old, new)
+	}
+	if edits != 5 {
+		t.Fatalf("expected 5 edits, got %d", edits)
+	}
+
+	// past diffMaxEdits the differing middle is replaced as a whole
+	var x, y []string
+	for i := 0; i < diffMaxEdits; i++ {
+		x = append(x, fmt.Sprint("x", i))
+		y = append(y, fmt.Sprint("y", i))
+	}
+	hs := DiffLines(append([]string{"same"}, x...), append([]string{"same"}, y...), 1)
+	if len(hs) != 1 || hs[0].OldLines != diffMaxEdits+1 || hs[0].NewLines != diffMaxEdits+1 {
+		t.Fatalf("fallback hunk %+v", hs[0].OldLines)
+	}
+}
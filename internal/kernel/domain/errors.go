@@ -17,4 +17,62 @@ var (
 	ErrInvalidUncertaintyLevel      = errors.New("invalid uncertainty level")
 	ErrMissingClaimIDForUncertainty = errors.New("missing claim id for uncertainty applies_to scope=claim")
 	ErrInvalidAppliesToScope        = errors.New("invalid applies_to scope")
+
+	// actor key errors
+	ErrInvalidPublicKey  = errors.New("invalid ed25519 public key")
+	ErrKeyAlreadyExists  = errors.New("key already registered")
+	ErrKeyNotFound       = errors.New("key not found")
+	ErrKeyRevoked        = errors.New("key revoked")
+	ErrSigningKeyMissing = errors.New("no signing key for actor")
+	ErrInvalidKeyActorID = errors.New("invalid key actor id")
+
+	// merkle log errors
+	ErrMerkleProofInvalid  = errors.New("merkle proof invalid")
+	ErrMerkleTreeSize      = errors.New("merkle tree size out of range")
+	ErrMerkleLeafMismatch  = errors.New("merkle leaves do not match the audit log")
+	ErrAuditEventNotFound  = errors.New("audit event not found")
+	ErrTreeHeadSignature   = errors.New("tree head signature invalid")
+	ErrTreeHeadKeyMismatch = errors.New("tree head key does not match public key")
+
+	// v0.6: audit query errors
+	ErrInvalidAuditQuery  = errors.New("invalid audit query")
+	ErrInvalidAuditCursor = errors.New("invalid audit cursor")
+
+	// v0.6: audit event registry errors
+	ErrUnknownAuditEventType = errors.New("unknown audit event type")
+	ErrInvalidAuditPayload   = errors.New("invalid audit payload")
+
+	// v0.6: audit segment errors
+	ErrAuditSegmentMissing = errors.New("sealed audit segment missing")
+
+	// v0.6: audit anchor errors
+	ErrAuditAnchorMismatch = errors.New("audit log does not match its anchor")
+	ErrAnchorReceipt       = errors.New("anchor receipt invalid")
+
+	// v0.6: policy errors
+	ErrInvalidPolicy = errors.New("invalid policy")
+	ErrAccessDenied  = errors.New("access denied")
+
+	// v0.6: tenant errors
+	ErrInvalidTenantID     = errors.New("invalid tenant id")
+	ErrTenantNotFound      = errors.New("tenant not found")
+	ErrTenantAlreadyExists = errors.New("tenant already exists")
+	ErrTenantMismatch      = errors.New("unit belongs to another tenant")
+
+	// v0.6: branch errors
+	ErrInvalidBranchName   = errors.New("invalid branch name")
+	ErrBranchNotFound      = errors.New("branch not found")
+	ErrBranchAlreadyExists = errors.New("branch already exists")
+	ErrNothingToMerge      = errors.New("nothing to merge")
+	ErrMergeConflict       = errors.New("merge conflict")
+
+	// v0.6: tag errors
+	ErrInvalidTagName   = errors.New("invalid tag name")
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+
+	// v0.6: unit metadata errors
+	ErrUnitRevisionConflict = errors.New("conflict: base revision does not match current unit revision")
+	ErrUnitRevisionNotFound = errors.New("unit revision not found")
+	ErrNothingToUpdate      = errors.New("nothing to update")
 )
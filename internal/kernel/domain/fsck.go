@@ -0,0 +1,12 @@
+package domain
+
+// AuditFsckRepaired is appended for every repair made by fsck --repair.
+const AuditFsckRepaired = "fsck.repaired"
+
+// FsckRepairData is the payload of a fsck.repaired event.
+type FsckRepairData struct {
+	Kind     string `json:"kind"`     // what was repaired, e.g. "index_rebuilt"
+	Path     string `json:"path"`     // data-dir relative path of the rebuilt file
+	Entries  int    `json:"entries"`  // entries in the rebuilt file
+	Findings int    `json:"findings"` // findings that caused the repair
+}
@@ -0,0 +1,75 @@
+package domain
+
+const IntentSchema = "digiemu.intent.v1"
+
+// Intent operations.
+const (
+	IntentCreateUnit     = "create_unit"
+	IntentCreateVersion  = "create_version"
+	IntentSetMeaning     = "set_meaning"
+	IntentSetClaims      = "set_claims"
+	IntentSetUncertainty = "set_uncertainty"
+	IntentRevertVersion  = "revert_version" // v0.6
+	IntentCreateBranch   = "create_branch"  // v0.6
+	IntentDeleteBranch   = "delete_branch"  // v0.6
+	IntentCreateTag      = "create_tag"     // v0.6
+	IntentUpdateUnit     = "update_unit"    // v0.6
+)
+
+// Audit event types written by intent recovery.
+const (
+	AuditIntentRolledForward = "intent.rolled_forward"
+	AuditIntentRolledBack    = "intent.rolled_back"
+)
+
+// Intent is a write-ahead record of one state change together with the audit
+// event that commits it. It is journaled before the repository is touched
+// and removed once Event is in the audit log, so an interrupted operation
+// can be completed (rolled forward) or undone (rolled back) on startup.
+type Intent struct {
+	Schema string `json:"schema"`
+	ID     string `json:"id"`
+	Op     string `json:"op"`
+	AtUnix int64  `json:"atUnix"`
+
+	// create_unit
+	Unit *Unit `json:"unit,omitempty"`
+
+	// create_version and revert_version; PrevHeadVersionID is the head of
+	// the version's branch before the change (merge versions are created
+	// with create_version). A revert_version also carries the sidecars copied
+	// to the new version (Meaning, ClaimSet, Uncertainty), whose hashes are
+	// those in Version.
+	Version           *Version `json:"version,omitempty"`
+	PrevHeadVersionID string   `json:"prevHeadVersionId,omitempty"`
+
+	// create_branch / delete_branch
+	Branch *Branch `json:"branch,omitempty"`
+
+	// create_tag
+	Tag *Tag `json:"tag,omitempty"`
+
+	// update_unit: the new metadata revision of UnitID
+	UnitRevision *UnitRevision `json:"unitRevision,omitempty"`
+
+	// set_meaning / set_claims / set_uncertainty (and revert_version)
+	UnitID      string       `json:"unitId,omitempty"`
+	VersionID   string       `json:"versionId,omitempty"`
+	Meaning     *Meaning     `json:"meaning,omitempty"`
+	ClaimSet    *ClaimSet    `json:"claimSet,omitempty"`
+	Uncertainty *Uncertainty `json:"uncertainty,omitempty"`
+	SidecarHash string       `json:"sidecarHash,omitempty"`
+
+	Event AuditEvent `json:"event"`
+
+	// Aborted is set when the writer failed and reported the failure to
+	// its caller: recovery must undo the intent, never complete it.
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+type IntentRecoveryData struct {
+	IntentID string `json:"intent_id"`
+	Op       string `json:"op"`
+	EventID  string `json:"event_id"`
+	Reason   string `json:"reason,omitempty"`
+}
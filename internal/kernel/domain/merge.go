@@ -0,0 +1,158 @@
+package domain
+
+import "fmt"
+
+// v0.6: three-way line merge of version contents.
+
+// MergeConflict is a region both sides changed differently. BaseStart is
+// 1-based (for an insertion: the line before it, 0 at the top).
+type MergeConflict struct {
+	BaseStart int      `json:"baseStart"`
+	BaseLines int      `json:"baseLines"`
+	Base      []string `json:"base"`
+	Ours      []string `json:"ours"`
+	Theirs    []string `json:"theirs"`
+}
+
+// Conflict markers written into merged text, as diff3 and git do.
+const (
+	MergeMarkerOurs   = "<<<<<<<"
+	MergeMarkerSep    = "======="
+	MergeMarkerTheirs = ">>>>>>>"
+)
+
+// MergeLines merges the changes from base to ours and from base to theirs.
+// Changes to separate regions are combined; a region both sides changed is
+// taken once if they agree and is a conflict otherwise. Changes that touch
+// (one starts where the other ends) conflict as well. In the returned text
+// each conflict is marked up with the side names, so it can be edited and
+// submitted as the resolution.
+func MergeLines(base, ours, theirs []string, oursName, theirsName string) ([]string, []MergeConflict) {
+	a, b := mergeChunks(base, ours), mergeChunks(base, theirs)
+
+	var (
+		out       []string
+		conflicts []MergeConflict
+		pos       int // next base line to copy
+	)
+	for len(a) > 0 || len(b) > 0 {
+		// start a region with the earlier chunk, then absorb every chunk of
+		// either side that overlaps or touches it
+		var ra, rb []mergeChunk
+		start, end := 0, 0
+		take := func(side *[]mergeChunk, region *[]mergeChunk) {
+			c := (*side)[0]
+			*side = (*side)[1:]
+			*region = append(*region, c)
+			end = max(end, c.baseEnd)
+		}
+		if len(b) == 0 || (len(a) > 0 && a[0].baseStart <= b[0].baseStart) {
+			start, end = a[0].baseStart, a[0].baseEnd
+			take(&a, &ra)
+		} else {
+			start, end = b[0].baseStart, b[0].baseEnd
+			take(&b, &rb)
+		}
+		for {
+			if len(a) > 0 && a[0].baseStart <= end {
+				take(&a, &ra)
+			} else if len(b) > 0 && b[0].baseStart <= end {
+				take(&b, &rb)
+			} else {
+				break
+			}
+		}
+		out = append(out, base[pos:start]...)
+		pos = end
+
+		switch {
+		case len(rb) == 0:
+			out = append(out, applyChunks(base, ra, start, end)...)
+		case len(ra) == 0:
+			out = append(out, applyChunks(base, rb, start, end)...)
+		default:
+			o, t := applyChunks(base, ra, start, end), applyChunks(base, rb, start, end)
+			if equalLines(o, t) {
+				out = append(out, o...)
+				continue
+			}
+			c := MergeConflict{BaseStart: start, BaseLines: end - start, Base: append([]string{}, base[start:end]...), Ours: o, Theirs: t}
+			if c.BaseLines > 0 {
+				c.BaseStart++
+			}
+			conflicts = append(conflicts, c)
+			out = append(out, MergeMarkerOurs+" "+oursName)
+			out = append(out, o...)
+			out = append(out, MergeMarkerSep)
+			out = append(out, t...)
+			out = append(out, MergeMarkerTheirs+" "+theirsName)
+		}
+	}
+	out = append(out, base[pos:]...)
+	return out, conflicts
+}
+
+// mergeChunk replaces base[baseStart:baseEnd] with lines.
+type mergeChunk struct {
+	baseStart, baseEnd int
+	lines              []string
+}
+
+// mergeChunks returns the changes from base to side as chunks in base order.
+func mergeChunks(base, side []string) []mergeChunk {
+	ops := diffOps(base, side)
+	var out []mergeChunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		c := mergeChunk{baseStart: ops[i].oldLine, baseEnd: ops[i].oldLine}
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			if ops[i].kind == '-' {
+				c.baseEnd++
+			} else {
+				c.lines = append(c.lines, ops[i].text)
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// applyChunks returns base[start:end] with the chunks (all inside it) applied.
+func applyChunks(base []string, chunks []mergeChunk, start, end int) []string {
+	out := []string{}
+	pos := start
+	for _, c := range chunks {
+		out = append(out, base[pos:c.baseStart]...)
+		out = append(out, c.lines...)
+		pos = c.baseEnd
+	}
+	return append(out, base[pos:end]...)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeConflictError fails a merge whose sides conflict. Text is the merged
+// content with conflict markers.
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+	Text      string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%v: %d conflicting region(s)", ErrMergeConflict, len(e.Conflicts))
+}
+
+func (e *MergeConflictError) Unwrap() error { return ErrMergeConflict }
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMergeLines_Clean(t *testing.T) {
+	base := SplitLines("a\nb\nc\nd\ne")
+	ours := SplitLines("A\nb\nc\nd\ne")
+	theirs := SplitLines("a\nb\nc\nd\ne\nf")
+
+	got, conflicts := MergeLines(base, ours, theirs, "main", "review")
+	if len(conflicts) != 0 || strings.Join(got, ",") != "A,b,c,d,e,f" {
+		t.Fatalf("merge %q %+v", got, conflicts)
+	}
+
+	// the same change on both sides is taken once
+	got, conflicts = MergeLines(base, SplitLines("a\nB\nc\nd\ne"), SplitLines("a\nB\nc\nd\nE"), "main", "review")
+	if len(conflicts) != 0 || strings.Join(got, ",") != "a,B,c,d,E" {
+		t.Fatalf("identical change %q %+v", got, conflicts)
+	}
+
+	// one side unchanged
+	got, conflicts = MergeLines(base, base, theirs, "main", "review")
+	if len(conflicts) != 0 || strings.Join(got, ",") != strings.Join(theirs, ",") {
+		t.Fatalf("one-sided %q %+v", got, conflicts)
+	}
+}
+
+func TestMergeLines_Conflict(t *testing.T) {
+	base := SplitLines("a\nb\nc")
+	got, conflicts := MergeLines(base, SplitLines("a\nX\nc"), SplitLines("a\nY\nc"), "main", "review")
+	want := "a\n<<<<<<< main\nX\n=======\nY\n>>>>>>> review\nc"
+	if strings.Join(got, "\n") != want {
+		t.Fatalf("merged text:\n%s\nwant:\n%s", strings.Join(got, "\n"), want)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts %+v", conflicts)
+	}
+	c := conflicts[0]
+	if c.BaseStart != 2 || c.BaseLines != 1 || strings.Join(c.Base, "") != "b" || strings.Join(c.Ours, "") != "X" || strings.Join(c.Theirs, "") != "Y" {
+		t.Fatalf("conflict %+v", c)
+	}
+
+	// adjacent changes conflict too
+	if _, conflicts := MergeLines(base, SplitLines("A\nb\nc"), SplitLines("a\nB\nc"), "main", "review"); len(conflicts) != 1 {
+		t.Fatalf("adjacent changes: %+v", conflicts)
+	}
+
+	err := error(&MergeConflictError{Conflicts: conflicts})
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("MergeConflictError does not wrap ErrMergeConflict: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Merkle tree over the audit log, following RFC 6962 (section 2.1):
+// leaves are SHA-256(0x00 || data), interior nodes SHA-256(0x01 || left || right).
+// Leaf data is the canonical JSON of an audit event as stored in the log.
+
+// MerkleLeaf is one stored leaf; Index is the 0-based position in the log.
+type MerkleLeaf struct {
+	Index    int64  `json:"index"`
+	EventID  string `json:"eventId"`
+	LeafHash string `json:"leafHash"`
+}
+
+func MerkleLeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func MerkleNodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// AuditEventLeafHash returns the leaf hash of ev (hex).
+func AuditEventLeafHash(ev AuditEvent) (string, error) {
+	b, err := CanonicalAuditEventJSON(ev)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(MerkleLeafHash(b)), nil
+}
+
+// MerkleRoot computes MTH(D[n]) over leaf hashes. The empty tree hashes to SHA-256("").
+func MerkleRoot(leaves [][]byte) []byte {
+	n := len(leaves)
+	switch n {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+	k := splitPoint(n)
+	return MerkleNodeHash(MerkleRoot(leaves[:k]), MerkleRoot(leaves[k:]))
+}
+
+// MerkleInclusionPath returns PATH(m, D[n]) for leaf m.
+func MerkleInclusionPath(leaves [][]byte, m int) ([][]byte, error) {
+	if m < 0 || m >= len(leaves) {
+		return nil, ErrMerkleTreeSize
+	}
+	return inclusionPath(m, leaves), nil
+}
+
+func inclusionPath(m int, leaves [][]byte) [][]byte {
+	n := len(leaves)
+	if n <= 1 {
+		return [][]byte{}
+	}
+	k := splitPoint(n)
+	if m < k {
+		return append(inclusionPath(m, leaves[:k]), MerkleRoot(leaves[k:]))
+	}
+	return append(inclusionPath(m-k, leaves[k:]), MerkleRoot(leaves[:k]))
+}
+
+// MerkleConsistencyProof returns PROOF(m, D[n]) between the first m leaves
+// and all of leaves.
+func MerkleConsistencyProof(leaves [][]byte, m int) ([][]byte, error) {
+	if m < 0 || m > len(leaves) {
+		return nil, ErrMerkleTreeSize
+	}
+	if m == 0 || m == len(leaves) {
+		return [][]byte{}, nil
+	}
+	return subproof(m, leaves, true), nil
+}
+
+func subproof(m int, leaves [][]byte, complete bool) [][]byte {
+	n := len(leaves)
+	if m == n {
+		if complete {
+			return [][]byte{}
+		}
+		return [][]byte{MerkleRoot(leaves)}
+	}
+	k := splitPoint(n)
+	if m <= k {
+		return append(subproof(m, leaves[:k], complete), MerkleRoot(leaves[k:]))
+	}
+	return append(subproof(m-k, leaves[k:], false), MerkleRoot(leaves[:k]))
+}
+
+// VerifyMerkleInclusion checks an audit path (RFC 9162, 2.1.3.2).
+func VerifyMerkleInclusion(leafHash []byte, index, size int64, path [][]byte, root []byte) error {
+	if index < 0 || index >= size {
+		return ErrMerkleTreeSize
+	}
+	fn, sn := index, size-1
+	r := leafHash
+	for _, p := range path {
+		if sn == 0 {
+			return ErrMerkleProofInvalid
+		}
+		if fn&1 == 1 || fn == sn {
+			r = MerkleNodeHash(p, r)
+			if fn&1 == 0 {
+				for fn&1 == 0 && fn != 0 {
+					fn >>= 1
+					sn >>= 1
+				}
+			}
+		} else {
+			r = MerkleNodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(r, root) {
+		return ErrMerkleProofInvalid
+	}
+	return nil
+}
+
+// VerifyMerkleConsistency checks a consistency proof (RFC 9162, 2.1.4.2).
+func VerifyMerkleConsistency(oldSize, newSize int64, oldRoot, newRoot []byte, proof [][]byte) error {
+	if oldSize < 0 || oldSize > newSize {
+		return ErrMerkleTreeSize
+	}
+	if oldSize == newSize {
+		if len(proof) != 0 || !bytes.Equal(oldRoot, newRoot) {
+			return ErrMerkleProofInvalid
+		}
+		return nil
+	}
+	if oldSize == 0 {
+		// every tree extends the empty tree
+		if len(proof) != 0 {
+			return ErrMerkleProofInvalid
+		}
+		return nil
+	}
+	if len(proof) == 0 {
+		return ErrMerkleProofInvalid
+	}
+
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return ErrMerkleProofInvalid
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = MerkleNodeHash(c, fr)
+			sr = MerkleNodeHash(c, sr)
+			if fn&1 == 0 {
+				for fn&1 == 0 && fn != 0 {
+					fn >>= 1
+					sn >>= 1
+				}
+			}
+		} else {
+			sr = MerkleNodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(fr, oldRoot) || !bytes.Equal(sr, newRoot) {
+		return ErrMerkleProofInvalid
+	}
+	return nil
+}
+
+// splitPoint returns the largest power of two smaller than n (n > 1).
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// MerkleHexList encodes hashes as lowercase hex.
+func MerkleHexList(hs [][]byte) []string {
+	out := make([]string, len(hs))
+	for i, h := range hs {
+		out[i] = hex.EncodeToString(h)
+	}
+	return out
+}
+
+// ParseMerkleHexList decodes hex hashes; each must be 32 bytes.
+func ParseMerkleHexList(ss []string) ([][]byte, error) {
+	out := make([][]byte, len(ss))
+	for i, s := range ss {
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != sha256.Size {
+			return nil, ErrMerkleProofInvalid
+		}
+		out[i] = b
+	}
+	return out, nil
+}
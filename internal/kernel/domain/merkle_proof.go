@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	TreeHeadSchema         = "digiemu.merkle.sth.v1"
+	InclusionProofSchema   = "digiemu.merkle.inclusion.v1"
+	ConsistencyProofSchema = "digiemu.merkle.consistency.v1"
+)
+
+// TreeHeadActorID is the actor whose key signs tree heads. It is the same
+// actor the log uses for its own events (chain genesis).
+const TreeHeadActorID = "system"
+
+// TreeHead is a (signed) commitment to the first TreeSize events of the log.
+// KeyID/Sig are empty if no key was available for TreeHeadActorID.
+type TreeHead struct {
+	Schema   string `json:"schema"`
+	TreeSize int64  `json:"treeSize"`
+	RootHash string `json:"rootHash"`
+	AtUnix   int64  `json:"atUnix"`
+	KeyID    string `json:"keyId,omitempty"`
+	Sig      string `json:"sig,omitempty"`
+}
+
+// TreeHeadSigningBytes is a fixed, line-based encoding so that third parties
+// can verify heads without a JSON canonicalizer.
+func TreeHeadSigningBytes(th TreeHead) []byte {
+	return []byte(fmt.Sprintf("%s\n%d\n%s\n%d\n%s\n", TreeHeadSchema, th.TreeSize, th.RootHash, th.AtUnix, th.KeyID))
+}
+
+func SignTreeHead(th *TreeHead, keyID string, priv ed25519.PrivateKey) {
+	th.KeyID = keyID
+	th.Sig = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, TreeHeadSigningBytes(*th)))
+}
+
+// VerifyTreeHeadSignature checks th against pub, including that KeyID names pub.
+func VerifyTreeHeadSignature(th TreeHead, pub ed25519.PublicKey) error {
+	if th.KeyID != ActorKeyID(pub) {
+		return ErrTreeHeadKeyMismatch
+	}
+	sig, err := base64.StdEncoding.DecodeString(th.Sig)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return ErrTreeHeadSignature
+	}
+	if !ed25519.Verify(pub, TreeHeadSigningBytes(th), sig) {
+		return ErrTreeHeadSignature
+	}
+	return nil
+}
+
+// InclusionProof shows that Event is leaf LeafIndex of the tree committed to
+// by TreeHead. It is self-contained and can be checked offline.
+type InclusionProof struct {
+	Schema    string   `json:"schema"`
+	EventID   string   `json:"eventId"`
+	Event     string   `json:"event"` // canonical event JSON, verbatim leaf data
+	LeafIndex int64    `json:"leafIndex"`
+	LeafHash  string   `json:"leafHash"`
+	AuditPath []string `json:"auditPath"`
+	TreeHead  TreeHead `json:"treeHead"`
+}
+
+// ConsistencyProof shows that the tree of OldSize leaves is a prefix of the
+// tree of NewSize leaves. Heads are included when the log published them.
+type ConsistencyProof struct {
+	Schema      string    `json:"schema"`
+	OldSize     int64     `json:"oldSize"`
+	NewSize     int64     `json:"newSize"`
+	OldRoot     string    `json:"oldRoot"`
+	NewRoot     string    `json:"newRoot"`
+	Proof       []string  `json:"proof"`
+	OldTreeHead *TreeHead `json:"oldTreeHead,omitempty"`
+	NewTreeHead *TreeHead `json:"newTreeHead,omitempty"`
+}
+
+// Verify recomputes the leaf from Event and checks the path against the head root.
+// Signatures are checked separately (VerifyTreeHeadSignature).
+func (p InclusionProof) Verify() error {
+	if p.Schema != InclusionProofSchema {
+		return fmt.Errorf("%w: schema %q", ErrMerkleProofInvalid, p.Schema)
+	}
+	leaf := MerkleLeafHash([]byte(p.Event))
+	if hex.EncodeToString(leaf) != p.LeafHash {
+		return fmt.Errorf("%w: leaf hash does not match event", ErrMerkleProofInvalid)
+	}
+	var ev AuditEvent
+	if err := json.Unmarshal([]byte(p.Event), &ev); err != nil || ev.ID != p.EventID {
+		return fmt.Errorf("%w: event id does not match", ErrMerkleProofInvalid)
+	}
+	root, err := hex.DecodeString(p.TreeHead.RootHash)
+	if err != nil {
+		return ErrMerkleProofInvalid
+	}
+	path, err := ParseMerkleHexList(p.AuditPath)
+	if err != nil {
+		return err
+	}
+	return VerifyMerkleInclusion(leaf, p.LeafIndex, p.TreeHead.TreeSize, path, root)
+}
+
+// Verify checks the proof against OldRoot/NewRoot and that any included
+// heads commit to the same sizes and roots.
+func (p ConsistencyProof) Verify() error {
+	if p.Schema != ConsistencyProofSchema {
+		return fmt.Errorf("%w: schema %q", ErrMerkleProofInvalid, p.Schema)
+	}
+	if h := p.OldTreeHead; h != nil && (h.TreeSize != p.OldSize || h.RootHash != p.OldRoot) {
+		return fmt.Errorf("%w: old tree head does not match", ErrMerkleProofInvalid)
+	}
+	if h := p.NewTreeHead; h != nil && (h.TreeSize != p.NewSize || h.RootHash != p.NewRoot) {
+		return fmt.Errorf("%w: new tree head does not match", ErrMerkleProofInvalid)
+	}
+	roots, err := ParseMerkleHexList([]string{p.OldRoot, p.NewRoot})
+	if err != nil {
+		return err
+	}
+	proof, err := ParseMerkleHexList(p.Proof)
+	if err != nil {
+		return err
+	}
+	return VerifyMerkleConsistency(p.OldSize, p.NewSize, roots[0], roots[1], proof)
+}
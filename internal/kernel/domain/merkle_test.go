@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func testLeaves(n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = MerkleLeafHash([]byte(fmt.Sprintf("leaf-%d", i)))
+	}
+	return out
+}
+
+func TestMerkleRoot_KnownVectors(t *testing.T) {
+	// RFC 6962 / certificate-transparency reference values
+	if got := hex.EncodeToString(MerkleRoot(nil)); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatalf("empty root: %s", got)
+	}
+	if got := hex.EncodeToString(MerkleLeafHash(nil)); got != "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d" {
+		t.Fatalf("empty leaf: %s", got)
+	}
+}
+
+func TestMerkle_InclusionAllLeaves(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		leaves := testLeaves(n)
+		root := MerkleRoot(leaves)
+		for m := 0; m < n; m++ {
+			path, err := MerkleInclusionPath(leaves, m)
+			if err != nil {
+				t.Fatalf("n=%d m=%d: %v", n, m, err)
+			}
+			if err := VerifyMerkleInclusion(leaves[m], int64(m), int64(n), path, root); err != nil {
+				t.Fatalf("n=%d m=%d: expected valid proof, got %v", n, m, err)
+			}
+			// wrong index must fail
+			if n > 1 {
+				if err := VerifyMerkleInclusion(leaves[m], int64((m+1)%n), int64(n), path, root); err == nil {
+					t.Fatalf("n=%d m=%d: expected failure for wrong index", n, m)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkle_ConsistencyAllSizes(t *testing.T) {
+	all := testLeaves(33)
+	for n := 1; n <= len(all); n++ {
+		newRoot := MerkleRoot(all[:n])
+		for m := 0; m <= n; m++ {
+			oldRoot := MerkleRoot(all[:m])
+			proof, err := MerkleConsistencyProof(all[:n], m)
+			if err != nil {
+				t.Fatalf("m=%d n=%d: %v", m, n, err)
+			}
+			if err := VerifyMerkleConsistency(int64(m), int64(n), oldRoot, newRoot, proof); err != nil {
+				t.Fatalf("m=%d n=%d: expected valid proof, got %v", m, n, err)
+			}
+			if m > 0 && m < n {
+				forked := MerkleRoot(append(append([][]byte{}, all[:m-1]...), MerkleLeafHash([]byte("forged"))))
+				if err := VerifyMerkleConsistency(int64(m), int64(n), forked, newRoot, proof); err == nil {
+					t.Fatalf("m=%d n=%d: expected failure for rewritten prefix", m, n)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkle_TamperedPathFails(t *testing.T) {
+	leaves := testLeaves(7)
+	root := MerkleRoot(leaves)
+	path, _ := MerkleInclusionPath(leaves, 3)
+	path[1] = MerkleLeafHash([]byte("x"))
+	if err := VerifyMerkleInclusion(leaves[3], 3, 7, path, root); err != ErrMerkleProofInvalid {
+		t.Fatalf("expected ErrMerkleProofInvalid, got %v", err)
+	}
+}
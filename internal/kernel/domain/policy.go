@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// v0.6: role-based access to units by key prefix.
+
+const PolicySchema = "digiemu.policy.v1"
+
+// Roles, from least to most privileged. Each role includes the ones before it.
+const (
+	RoleReader   = "reader"   // no writes
+	RoleAuthor   = "author"   // create and update units, create versions and branches, set meanings
+	RoleReviewer = "reviewer" // also set claims and uncertainty, merge and delete branches, tag versions
+	RoleAdmin    = "admin"    // everything
+)
+
+var roleRank = map[string]int{RoleReader: 1, RoleAuthor: 2, RoleReviewer: 3, RoleAdmin: 4}
+
+// Actions the policy guards, with the role each needs.
+const (
+	ActionCreateUnit     = "unit.create"
+	ActionCreateVersion  = "version.create"
+	ActionSetMeaning     = "meaning.set"
+	ActionSetClaims      = "claims.set"
+	ActionSetUncertainty = "uncertainty.set"
+	ActionCreateBranch   = "branch.create"
+	ActionDeleteBranch   = "branch.delete"
+	ActionMergeBranch    = "branch.merge"
+	ActionCreateTag      = "tag.create"
+	ActionUpdateUnit     = "unit.update"
+)
+
+var actionRoles = map[string]string{
+	ActionCreateUnit:     RoleAuthor,
+	ActionCreateVersion:  RoleAuthor,
+	ActionSetMeaning:     RoleAuthor,
+	ActionSetClaims:      RoleReviewer,
+	ActionSetUncertainty: RoleReviewer,
+	ActionCreateBranch:   RoleAuthor,
+	ActionDeleteBranch:   RoleReviewer,
+	ActionMergeBranch:    RoleReviewer,
+	ActionCreateTag:      RoleReviewer,
+	ActionUpdateUnit:     RoleAuthor,
+}
+
+// ActionRole returns the role action needs (admin for unknown actions).
+func ActionRole(action string) string {
+	if r, ok := actionRoles[action]; ok {
+		return r
+	}
+	return RoleAdmin
+}
+
+// RoleIncludes reports whether role grants everything required does.
+func RoleIncludes(role, required string) bool {
+	return roleRank[role] != 0 && roleRank[role] >= roleRank[required]
+}
+
+// PolicyGrant gives Actor ("*" for every actor) Role on the units whose key
+// starts with Prefix ("" for all units).
+type PolicyGrant struct {
+	Actor  string `json:"actor"`
+	Role   string `json:"role"`
+	Prefix string `json:"prefix"`
+}
+
+type Policy struct {
+	Schema string        `json:"schema"`
+	Grants []PolicyGrant `json:"grants"`
+}
+
+func (p Policy) Validate() error {
+	if p.Schema != PolicySchema {
+		return fmt.Errorf("%w: schema %q", ErrInvalidPolicy, p.Schema)
+	}
+	for i, g := range p.Grants {
+		if strings.TrimSpace(g.Actor) == "" {
+			return fmt.Errorf("%w: grant %d: actor missing", ErrInvalidPolicy, i)
+		}
+		if roleRank[g.Role] == 0 {
+			return fmt.Errorf("%w: grant %d: unknown role %q", ErrInvalidPolicy, i, g.Role)
+		}
+	}
+	return nil
+}
+
+// RoleOf returns the role of actor on the unit with key unitKey: the grant
+// with the longest matching prefix decides, so a narrower grant can lower a
+// broader one ("author" on "" but "reader" on "legal-"). Among grants with
+// the same prefix, the highest role wins. ok=false if no grant matches.
+func (p Policy) RoleOf(actor, unitKey string) (role string, ok bool) {
+	best := -1
+	for _, g := range p.Grants {
+		if (g.Actor != actor && g.Actor != "*") || !strings.HasPrefix(unitKey, g.Prefix) {
+			continue
+		}
+		if n := len(g.Prefix); n > best || (n == best && roleRank[g.Role] > roleRank[role]) {
+			best, role = n, g.Role
+		}
+	}
+	return role, best >= 0
+}
+
+// AccessDeniedData is the payload of ACCESS_DENIED: ActorID of the event
+// tried Action on UnitKey and holds Role ("" if no grant matched).
+type AccessDeniedData struct {
+	Action       string `json:"action"`
+	UnitKey      string `json:"unit_key"`
+	RequiredRole string `json:"required_role"`
+	Role         string `json:"role,omitempty"`
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicy_RoleOf(t *testing.T) {
+	p := Policy{Schema: PolicySchema, Grants: []PolicyGrant{
+		{Actor: "*", Role: RoleReader, Prefix: ""},
+		{Actor: "alice", Role: RoleAuthor, Prefix: ""},
+		{Actor: "alice", Role: RoleReader, Prefix: "legal-"},
+		{Actor: "bob", Role: RoleAuthor, Prefix: "legal-"},
+		{Actor: "bob", Role: RoleReviewer, Prefix: "legal-"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ actor, key, want string }{
+		{"alice", "bau-reglement", RoleAuthor},
+		{"alice", "legal-contract", RoleReader}, // narrower grant lowers the role
+		{"bob", "legal-contract", RoleReviewer}, // same prefix: highest role
+		{"bob", "bau-reglement", RoleReader},
+		{"carol", "legal-contract", RoleReader},
+	} {
+		if got, _ := p.RoleOf(c.actor, c.key); got != c.want {
+			t.Fatalf("RoleOf(%s, %s) = %q, want %q", c.actor, c.key, got, c.want)
+		}
+	}
+	if _, ok := (Policy{Schema: PolicySchema}).RoleOf("alice", "abc"); ok {
+		t.Fatal("empty policy matched")
+	}
+
+	if !RoleIncludes(RoleAdmin, ActionRole("unknown.action")) || RoleIncludes(RoleReviewer, ActionRole("unknown.action")) {
+		t.Fatal("unknown actions need admin")
+	}
+	if RoleIncludes(RoleAuthor, ActionRole(ActionSetClaims)) || !RoleIncludes(RoleAuthor, ActionRole(ActionSetMeaning)) {
+		t.Fatal("author may set meanings but not claims")
+	}
+	if RoleIncludes("", RoleReader) {
+		t.Fatal("no role includes reader")
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	for _, p := range []Policy{
+		{Schema: "other"},
+		{Schema: PolicySchema, Grants: []PolicyGrant{{Actor: "", Role: RoleAdmin}}},
+		{Schema: PolicySchema, Grants: []PolicyGrant{{Actor: "alice", Role: "owner"}}},
+	} {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPolicy) {
+			t.Fatalf("Validate(%+v) = %v, want ErrInvalidPolicy", p, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// v0.6: release tags. A tag gives a version of a unit a readable, immutable
+// name ("2026-Q3", "approved"): it is created once and never moved or
+// removed, so consumers can pin to it. Tag names are unique per unit.
+
+// Tag names a version of a unit.
+type Tag struct {
+	Name          string
+	VersionID     string
+	CreatedAtUnix int64
+	ActorID       string
+}
+
+// ValidateTagName accepts names of 1-64 letters, digits, dots, dashes and
+// underscores that start with a letter or digit (names appear in URL paths
+// and query strings). Names that look like version ids ("ver_...") are
+// rejected, so a version reference is never ambiguous.
+func ValidateTagName(name string) error {
+	if len(name) < 1 || len(name) > 64 || !isTagStart(name[0]) || strings.HasPrefix(name, "ver_") {
+		return fmt.Errorf("%w: %q", ErrInvalidTagName, name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isTagStart(c) && c != '.' && c != '-' && c != '_' {
+			return fmt.Errorf("%w: %q", ErrInvalidTagName, name)
+		}
+	}
+	return nil
+}
+
+func isTagStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// FindTag returns the named tag of u.
+func (u Unit) FindTag(name string) (Tag, bool) {
+	for _, t := range u.Tags {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
+// ResolveVersionRef returns the version a read path's version reference
+// names: the version of the tag ref, or ref itself (a version id or "").
+func (u Unit) ResolveVersionRef(ref string) string {
+	if t, ok := u.FindTag(ref); ok {
+		return t.VersionID
+	}
+	return ref
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateTagName(t *testing.T) {
+	for _, name := range []string{"2026-Q3", "approved", "v1.0.0", "release_2"} {
+		if err := ValidateTagName(name); err != nil {
+			t.Fatalf("ValidateTagName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-x", ".x", "a b", "a/b", "ver_0123", strings.Repeat("a", 65)} {
+		if err := ValidateTagName(name); !errors.Is(err, ErrInvalidTagName) {
+			t.Fatalf("ValidateTagName(%q) = %v, want ErrInvalidTagName", name, err)
+		}
+	}
+}
+
+func TestUnit_ResolveVersionRef(t *testing.T) {
+	u := Unit{Tags: []Tag{{Name: "approved", VersionID: "ver_1"}}}
+	for ref, want := range map[string]string{"approved": "ver_1", "ver_2": "ver_2", "": "", "other": "other"} {
+		if got := u.ResolveVersionRef(ref); got != want {
+			t.Fatalf("ResolveVersionRef(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
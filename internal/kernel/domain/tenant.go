@@ -0,0 +1,25 @@
+package domain
+
+import "fmt"
+
+// v0.6: tenants. Every unit belongs to one tenant, and tenants share
+// nothing: each has its own units, indexes, audit log, keys and policy.
+// DefaultTenant is the data dir as it was before tenants existed.
+const DefaultTenant = ""
+
+// ValidateTenantID accepts DefaultTenant and ids of 3-63 lowercase letters,
+// digits and dashes that start with a letter or digit (ids name directories).
+func ValidateTenantID(id string) error {
+	if id == DefaultTenant {
+		return nil
+	}
+	if len(id) < 3 || len(id) > 63 || id[0] == '-' {
+		return fmt.Errorf("%w: %q", ErrInvalidTenantID, id)
+	}
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return fmt.Errorf("%w: %q", ErrInvalidTenantID, id)
+		}
+	}
+	return nil
+}
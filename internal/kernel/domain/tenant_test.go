@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTenantID(t *testing.T) {
+	for _, id := range []string{DefaultTenant, "acme", "acme-2", "123"} {
+		if err := ValidateTenantID(id); err != nil {
+			t.Fatalf("ValidateTenantID(%q) = %v", id, err)
+		}
+	}
+	for _, id := range []string{"ab", "-acme", "Acme", "ac/me", "..", "acme.x", string(make([]byte, 64))} {
+		if err := ValidateTenantID(id); !errors.Is(err, ErrInvalidTenantID) {
+			t.Fatalf("ValidateTenantID(%q) = %v, want ErrInvalidTenantID", id, err)
+		}
+	}
+}
@@ -9,7 +9,26 @@ type Unit struct {
 	Description string
 
 	// v0.2: tracks current "head" version for optimistic locking and lineage
+	// (v0.6: the head of MainBranch)
 	HeadVersionID string
+
+	// v0.6: named branches besides MainBranch, sorted by name
+	Branches []Branch
+
+	// v0.6: immutable release tags, sorted by name
+	Tags []Tag
+
+	// v0.6: metadata history, oldest first; empty until the first update
+	// (see History)
+	Revisions []UnitRevision
+
+	// v0.6: owning tenant (DefaultTenant for single-tenant data dirs)
+	TenantID string
+
+	// v0.6: when and by whom the unit was created; the time and author of
+	// metadata revision 1 (zero for units created before they were kept)
+	CreatedAtUnix int64
+	ActorID       string
 }
 
 func NewUnit(key, title, description string) (Unit, error) {
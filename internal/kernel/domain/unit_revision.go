@@ -0,0 +1,70 @@
+package domain
+
+import "fmt"
+
+// v0.6: unit metadata history. Title and Description of a unit can change
+// after creation; every change adds a numbered revision and the earlier ones
+// are kept. Revision 1 is the metadata the unit was created with, so a unit
+// that was never updated has no stored history and is at revision 1.
+
+// UnitRevision is one state of a unit's metadata. AtUnix and ActorID tell
+// who made the change; for revision 1 they are the unit's creation.
+type UnitRevision struct {
+	Revision    int
+	Title       string
+	Description string
+	AtUnix      int64
+	ActorID     string
+}
+
+// Revision returns the current metadata revision of u.
+func (u Unit) Revision() int {
+	if n := len(u.Revisions); n > 0 {
+		return u.Revisions[n-1].Revision
+	}
+	return 1
+}
+
+// History returns every metadata revision of u, oldest first. For a unit
+// that was never updated it is revision 1 made from the current metadata.
+// A revision 1 stored without its time and actor gets the unit's creation.
+func (u Unit) History() []UnitRevision {
+	if len(u.Revisions) == 0 {
+		return []UnitRevision{{Revision: 1, Title: u.Title, Description: u.Description, AtUnix: u.CreatedAtUnix, ActorID: u.ActorID}}
+	}
+	if first := u.Revisions[0]; first.Revision == 1 && first.AtUnix == 0 && first.ActorID == "" && (u.CreatedAtUnix != 0 || u.ActorID != "") {
+		out := append([]UnitRevision{}, u.Revisions...)
+		out[0].AtUnix, out[0].ActorID = u.CreatedAtUnix, u.ActorID
+		return out
+	}
+	return u.Revisions
+}
+
+// FindRevision returns revision n of u's metadata.
+func (u Unit) FindRevision(n int) (UnitRevision, bool) {
+	for _, r := range u.History() {
+		if r.Revision == n {
+			return r, true
+		}
+	}
+	return UnitRevision{}, false
+}
+
+// AppendUnitRevision returns the history of u with r added. The bool is
+// false when r is already in it (nothing to store); a revision that does
+// not directly follow the current one fails with ErrUnitRevisionConflict.
+func AppendUnitRevision(u Unit, r UnitRevision) ([]UnitRevision, bool, error) {
+	if x, ok := u.FindRevision(r.Revision); ok {
+		if x != r {
+			return nil, false, fmt.Errorf("%w: revision %d already exists", ErrUnitRevisionConflict, r.Revision)
+		}
+		return u.History(), false, nil
+	}
+	if cur := u.Revision(); r.Revision != cur+1 {
+		return nil, false, fmt.Errorf("%w: revision %d does not follow %d", ErrUnitRevisionConflict, r.Revision, cur)
+	}
+	hist := u.History()
+	out := make([]UnitRevision, 0, len(hist)+1)
+	out = append(out, hist...)
+	return append(out, r), true, nil
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAppendUnitRevision(t *testing.T) {
+	u := Unit{Title: "Doc", Description: "first"}
+	if u.Revision() != 1 || len(u.History()) != 1 || u.History()[0].Title != "Doc" {
+		t.Fatalf("never-updated unit: revision %d, history %+v", u.Revision(), u.History())
+	}
+
+	r2 := UnitRevision{Revision: 2, Title: "Doc two", AtUnix: 10, ActorID: "e"}
+	hist, added, err := AppendUnitRevision(u, r2)
+	if err != nil || !added || len(hist) != 2 || hist[0].Description != "first" || hist[1] != r2 {
+		t.Fatalf("append: %+v %v %v", hist, added, err)
+	}
+	u.Revisions = hist
+
+	if _, added, err := AppendUnitRevision(u, r2); err != nil || added {
+		t.Fatalf("appending again: added=%v err=%v, want a no-op", added, err)
+	}
+	other := r2
+	other.Title = "Other"
+	if _, _, err := AppendUnitRevision(u, other); !errors.Is(err, ErrUnitRevisionConflict) {
+		t.Fatalf("expected ErrUnitRevisionConflict for a taken revision, got %v", err)
+	}
+	if _, _, err := AppendUnitRevision(u, UnitRevision{Revision: 4, Title: "Gap"}); !errors.Is(err, ErrUnitRevisionConflict) {
+		t.Fatalf("expected ErrUnitRevisionConflict for a gap, got %v", err)
+	}
+}
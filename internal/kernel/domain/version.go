@@ -16,6 +16,15 @@ type Version struct {
 	MeaningHash     string
 	ClaimSetHash    string
 	UncertaintyHash string
+
+	// v0.6: branch the version was created on ("" for MainBranch) and, for
+	// a merge version, the head of the merged branch (its second parent)
+	Branch        string
+	MergeParentID string
+
+	// v0.6: the unit's metadata revision when the version was made (0 if
+	// not recorded); snapshots of the version use that metadata
+	UnitRevision int
 }
 
 func NewVersion(unitID, label, content string) (Version, error) {
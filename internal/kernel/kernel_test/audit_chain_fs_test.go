@@ -0,0 +1,184 @@
+package kernel_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// seedChainFS creates one unit with three versions and returns the data dir.
+func seedChainFS(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := memory.FakeClock{Now: 1700000000}
+
+	cu := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "chain", Title: "Chain", ActorID: "t"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	for _, c := range []string{"one", "two", "three"} {
+		if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "chain", Label: c, Content: c, ActorID: "t"}); err != nil {
+			t.Fatalf("create version: %v", err)
+		}
+	}
+	return dir
+}
+
+func verifyChainFS(t *testing.T, dir string) ports.VerifyAuditResponse {
+	t.Helper()
+	uc := usecases.VerifyAudit{
+		Repo:      fsrepo.NewUnitRepo(dir),
+		Audit:     fsrepo.NewAuditReader(dir),
+		ChainHead: fsrepo.NewAuditChainHead(dir),
+		Segments:  fsrepo.NewAuditSegmentChecker(dir),
+	}
+	out, err := uc.VerifyAudit(ports.VerifyAuditRequest{Chain: true})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	return out
+}
+
+func readAuditLines(t *testing.T, dir string) [][]byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, "audit.ndjson"))
+	if err != nil {
+		t.Fatalf("read audit: %v", err)
+	}
+	return bytes.Split(bytes.TrimRight(b, "\n"), []byte("\n"))
+}
+
+func writeAuditLines(t *testing.T, dir string, lines [][]byte) {
+	t.Helper()
+	b := append(bytes.Join(lines, []byte("\n")), '\n')
+	if err := os.WriteFile(filepath.Join(dir, "audit.ndjson"), b, 0o644); err != nil {
+		t.Fatalf("write audit: %v", err)
+	}
+}
+
+func hasChainBreak(out ports.VerifyAuditResponse, kind string) bool {
+	for _, cb := range out.ChainBreaks {
+		if cb.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuditChain_FS_Ok(t *testing.T) {
+	dir := seedChainFS(t)
+	out := verifyChainFS(t, dir)
+	if !out.Ok || out.ChainLength != 4 {
+		t.Fatalf("expected ok chain of 4, got ok=%v len=%d breaks=%+v", out.Ok, out.ChainLength, out.ChainBreaks)
+	}
+}
+
+func TestAuditChain_FS_DeletedLineIsGap(t *testing.T) {
+	dir := seedChainFS(t)
+	lines := readAuditLines(t, dir)
+	writeAuditLines(t, dir, append(lines[:1:1], lines[2:]...))
+
+	out := verifyChainFS(t, dir)
+	if out.Ok || !hasChainBreak(out, ports.ChainBreakGap) {
+		t.Fatalf("expected gap, got %+v", out.ChainBreaks)
+	}
+}
+
+func TestAuditChain_FS_SwappedLinesAreReorder(t *testing.T) {
+	dir := seedChainFS(t)
+	lines := readAuditLines(t, dir)
+	lines[1], lines[2] = lines[2], lines[1]
+	writeAuditLines(t, dir, lines)
+
+	out := verifyChainFS(t, dir)
+	if !hasChainBreak(out, ports.ChainBreakReorder) {
+		t.Fatalf("expected reorder, got %+v", out.ChainBreaks)
+	}
+	if hasChainBreak(out, ports.ChainBreakGap) {
+		t.Fatalf("reordered events must not also be reported as gap: %+v", out.ChainBreaks)
+	}
+}
+
+func TestAuditChain_FS_EditedLine(t *testing.T) {
+	dir := seedChainFS(t)
+	lines := readAuditLines(t, dir)
+	lines[2] = bytes.Replace(lines[2], []byte(`"actorId":"t"`), []byte(`"actorId":"mallory"`), 1)
+	writeAuditLines(t, dir, lines)
+
+	out := verifyChainFS(t, dir)
+	if !hasChainBreak(out, ports.ChainBreakEdited) {
+		t.Fatalf("expected edited, got %+v", out.ChainBreaks)
+	}
+	if out.ChainBreaks[0].Position != 3 {
+		t.Fatalf("expected break at position 3, got %d", out.ChainBreaks[0].Position)
+	}
+}
+
+func TestAuditChain_FS_TruncatedTail(t *testing.T) {
+	dir := seedChainFS(t)
+	lines := readAuditLines(t, dir)
+	writeAuditLines(t, dir, lines[:len(lines)-1])
+
+	out := verifyChainFS(t, dir)
+	if !hasChainBreak(out, ports.ChainBreakTruncated) {
+		t.Fatalf("expected truncated, got %+v", out.ChainBreaks)
+	}
+}
+
+func TestAuditChain_FS_LegacyLogGetsGenesis(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	clock := memory.FakeClock{Now: 1700000000}
+
+	// a unit created by a pre-chain writer: plain NDJSON without seq/hash
+	u, _ := domain.NewUnit("legacy", "Legacy", "")
+	if err := repo.SaveUnit(u); err != nil {
+		t.Fatalf("save unit: %v", err)
+	}
+	legacy := domain.AuditEvent{
+		Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "unit.created",
+		AtUnix: 1690000000, ActorID: "old", UnitID: u.ID,
+		Data: domain.UnitCreatedData{Key: u.Key, Title: u.Title},
+	}
+	b, _ := json.Marshal(legacy)
+	writeAuditLines(t, dir, [][]byte{b})
+
+	cv := usecases.CreateVersion{Repo: repo, Audit: fsrepo.NewAuditLog(dir), Clock: clock}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "legacy", Label: "v1", Content: "x", ActorID: "t"}); err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+
+	lines := readAuditLines(t, dir)
+	if len(lines) != 3 {
+		t.Fatalf("expected legacy + genesis + event, got %d lines", len(lines))
+	}
+	var g domain.AuditEvent
+	_ = json.Unmarshal(lines[1], &g)
+	if g.Type != domain.AuditChainGenesisType || g.Seq != 1 {
+		t.Fatalf("expected genesis at seq 1, got type=%s seq=%d", g.Type, g.Seq)
+	}
+
+	out := verifyChainFS(t, dir)
+	if !out.Ok || out.LegacyEvents != 1 {
+		t.Fatalf("expected ok with 1 legacy event, got ok=%v legacy=%d breaks=%+v", out.Ok, out.LegacyEvents, out.ChainBreaks)
+	}
+
+	// editing the legacy prefix is caught by the genesis digest
+	lines[0] = bytes.Replace(lines[0], []byte(`"actorId":"old"`), []byte(`"actorId":"new"`), 1)
+	writeAuditLines(t, dir, lines)
+	out = verifyChainFS(t, dir)
+	if !hasChainBreak(out, ports.ChainBreakEdited) {
+		t.Fatalf("expected edited legacy prefix, got %+v", out.ChainBreaks)
+	}
+}
@@ -0,0 +1,171 @@
+package kernel_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func merkleFS(dir string, signer ports.TreeHeadSigner) usecases.AuditMerkle {
+	return usecases.AuditMerkle{
+		Audit:  fsrepo.NewAuditReader(dir),
+		Merkle: fsrepo.NewAuditMerkleStore(dir),
+		Clock:  memory.FakeClock{Now: 1700000100},
+		Signer: signer,
+	}
+}
+
+// addLogKey registers a key for domain.TreeHeadActorID, writes it to a key
+// file outside dir and returns its public key and a signer of the file.
+func addLogKey(t *testing.T, dir string) (ed25519.PublicKey, ports.TreeHeadSigner) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	keys := fsrepo.NewKeyRegistry(dir)
+	k, err := domain.NewActorKey(domain.TreeHeadActorID, base64.StdEncoding.EncodeToString(pub), 1700000000)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	if err := keys.RegisterKey(k); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	kf, err := fsrepo.WriteKeyFile(filepath.Join(t.TempDir(), "system.key"), domain.TreeHeadActorID, priv)
+	if err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	return pub, fsrepo.KeySigner{Key: kf, Keys: keys}
+}
+
+func auditEventIDs(t *testing.T, dir string) []string {
+	t.Helper()
+	var ids []string
+	for _, line := range readAuditLines(t, dir) {
+		var ev domain.AuditEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func TestAuditMerkle_FS_InclusionProofs(t *testing.T) {
+	dir := seedChainFS(t)
+	pub, signer := addLogKey(t, dir)
+	uc := merkleFS(dir, signer)
+
+	for i, id := range auditEventIDs(t, dir) {
+		p, err := uc.InclusionProof(ports.InclusionProofRequest{EventID: id})
+		if err != nil {
+			t.Fatalf("proof %s: %v", id, err)
+		}
+		if p.LeafIndex != int64(i) || p.TreeHead.TreeSize != 4 {
+			t.Fatalf("unexpected proof position: index=%d size=%d", p.LeafIndex, p.TreeHead.TreeSize)
+		}
+
+		// round-trip through JSON: the proof must be checkable on its own
+		b, _ := json.Marshal(p)
+		var offline domain.InclusionProof
+		if err := json.Unmarshal(b, &offline); err != nil {
+			t.Fatalf("decode proof: %v", err)
+		}
+		if err := offline.Verify(); err != nil {
+			t.Fatalf("verify %s: %v", id, err)
+		}
+		if err := domain.VerifyTreeHeadSignature(offline.TreeHead, pub); err != nil {
+			t.Fatalf("tree head signature: %v", err)
+		}
+	}
+
+	heads, _ := fsrepo.NewAuditMerkleStore(dir).ListTreeHeads()
+	if len(heads) != 1 {
+		t.Fatalf("expected one published head for an unchanged log, got %d", len(heads))
+	}
+}
+
+func TestAuditMerkle_FS_ConsistencyAfterGrowth(t *testing.T) {
+	dir := seedChainFS(t)
+	uc := merkleFS(dir, nil)
+
+	first, err := uc.PublishTreeHead()
+	if err != nil || !first.Published {
+		t.Fatalf("publish: %+v %v", first, err)
+	}
+
+	cv := usecases.CreateVersion{Repo: fsrepo.NewUnitRepo(dir), Audit: fsrepo.NewAuditLog(dir), Clock: memory.FakeClock{Now: 1700000200}}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "chain", Label: "four", Content: "four", ActorID: "t"}); err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+
+	p, err := uc.ConsistencyProof(ports.ConsistencyProofRequest{OldSize: first.TreeHead.TreeSize})
+	if err != nil {
+		t.Fatalf("consistency: %v", err)
+	}
+	if p.NewSize != 5 || p.OldTreeHead == nil || p.NewTreeHead == nil {
+		t.Fatalf("expected published heads for 4 and 5, got %+v", p)
+	}
+	if p.OldRoot != first.TreeHead.RootHash {
+		t.Fatalf("old root changed: %s != %s", p.OldRoot, first.TreeHead.RootHash)
+	}
+	if err := p.Verify(); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if p.OldTreeHead.Sig != "" {
+		t.Fatalf("expected unsigned heads without a log key")
+	}
+}
+
+func TestAuditMerkle_FS_RewrittenLogIsDetected(t *testing.T) {
+	dir := seedChainFS(t)
+	uc := merkleFS(dir, nil)
+	if _, err := uc.PublishTreeHead(); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	lines := readAuditLines(t, dir)
+	lines[1] = bytes.Replace(lines[1], []byte(`"actorId":"t"`), []byte(`"actorId":"mallory"`), 1)
+	writeAuditLines(t, dir, lines)
+
+	if _, err := uc.PublishTreeHead(); !errors.Is(err, domain.ErrMerkleLeafMismatch) {
+		t.Fatalf("expected ErrMerkleLeafMismatch for edited event, got %v", err)
+	}
+
+	writeAuditLines(t, dir, lines[:2])
+	if _, err := uc.ConsistencyProof(ports.ConsistencyProofRequest{OldSize: 1}); !errors.Is(err, domain.ErrMerkleLeafMismatch) {
+		t.Fatalf("expected ErrMerkleLeafMismatch for truncated log, got %v", err)
+	}
+}
+
+func TestAuditMerkle_FS_TamperedProofFails(t *testing.T) {
+	dir := seedChainFS(t)
+	ids := auditEventIDs(t, dir)
+
+	p, err := merkleFS(dir, nil).InclusionProof(ports.InclusionProofRequest{EventID: ids[2]})
+	if err != nil {
+		t.Fatalf("proof: %v", err)
+	}
+	p.Event = string(bytes.Replace([]byte(p.Event), []byte(`"actorId":"t"`), []byte(`"actorId":"x"`), 1))
+	if err := p.Verify(); !errors.Is(err, domain.ErrMerkleProofInvalid) {
+		t.Fatalf("expected invalid proof for edited event, got %v", err)
+	}
+
+	if _, err := merkleFS(dir, nil).InclusionProof(ports.InclusionProofRequest{EventID: "evt_missing"}); err != domain.ErrAuditEventNotFound {
+		t.Fatalf("expected ErrAuditEventNotFound, got %v", err)
+	}
+	if _, err := merkleFS(dir, nil).InclusionProof(ports.InclusionProofRequest{EventID: ids[3], TreeSize: 2}); !errors.Is(err, domain.ErrMerkleTreeSize) {
+		t.Fatalf("expected ErrMerkleTreeSize for event outside the tree, got %v", err)
+	}
+}
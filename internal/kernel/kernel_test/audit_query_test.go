@@ -0,0 +1,129 @@
+package kernel_test
+
+import (
+	"errors"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+type auditQueryBackend struct {
+	repo  ports.UnitRepository
+	audit ports.AuditLog
+	query ports.AuditQuery
+}
+
+func auditQueryBackends(t *testing.T) map[string]auditQueryBackend {
+	dir := t.TempDir()
+	memLog := memory.NewAuditLog()
+	return map[string]auditQueryBackend{
+		"memory": {repo: memory.NewUnitRepo(), audit: memLog, query: memLog},
+		"fs":     {repo: fsrepo.NewUnitRepo(dir), audit: fsrepo.NewAuditLog(dir), query: fsrepo.NewAuditReader(dir)},
+	}
+}
+
+// seedAuditQuery creates three units (alice at 1000 and 1020, bob at 1010)
+// and one version per unit at 2000..2002, and returns the unit ids by key.
+func seedAuditQuery(t *testing.T, b auditQueryBackend) map[string]string {
+	t.Helper()
+	ids := map[string]string{}
+	for i, key := range []string{"dept-a", "dept-b", "other-c"} {
+		actor := "alice"
+		if i == 1 {
+			actor = "bob"
+		}
+		uc := usecases.CreateUnit{Repo: b.repo, Audit: b.audit, Clock: memory.FakeClock{Now: int64(1000 + 10*i)}}
+		out, err := uc.CreateUnit(ports.CreateUnitRequest{Key: key, Title: "Unit " + key, ActorID: actor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[key] = out.UnitID
+	}
+	for i, key := range []string{"dept-a", "dept-b", "other-c"} {
+		uc := usecases.CreateVersion{Repo: b.repo, Audit: b.audit, Clock: memory.FakeClock{Now: int64(2000 + i)}}
+		if _, err := uc.CreateVersion(ports.CreateVersionRequest{UnitKey: key, Label: "v1", Content: "content", ActorID: "carol"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ids
+}
+
+func TestQueryAudit_FiltersAndPages(t *testing.T) {
+	for name, b := range auditQueryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ids := seedAuditQuery(t, b)
+			uc := usecases.QueryAudit{Audit: b.query, Repo: b.repo}
+
+			// actors and a time range
+			out, err := uc.QueryAudit(ports.AuditQueryRequest{AuditQueryFilter: ports.AuditQueryFilter{
+				ActorIDs: []string{"alice", "bob"}, FromUnix: 1005, ToUnix: 1020,
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out.Events) != 2 || out.Events[0].UnitID != ids["dept-b"] || out.Events[1].UnitID != ids["other-c"] || out.NextCursor != "" {
+				t.Fatalf("actor/time query: %+v", out)
+			}
+
+			// several types on units with a key prefix, paged by one
+			in := ports.AuditQueryRequest{
+				AuditQueryFilter: ports.AuditQueryFilter{Types: []string{"version.created", "unit.created"}},
+				UnitKeyPrefix:    "dept-",
+				Limit:            1,
+			}
+			var got []domain.AuditEvent
+			for page := 0; ; page++ {
+				if page > 4 {
+					t.Fatal("paging does not terminate")
+				}
+				out, err := uc.QueryAudit(in)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, out.Events...)
+				if out.NextCursor == "" {
+					break
+				}
+				in.Cursor = out.NextCursor
+			}
+			if len(got) != 4 {
+				t.Fatalf("expected 4 events for dept-*, got %d", len(got))
+			}
+			for _, ev := range got {
+				if ev.UnitID != ids["dept-a"] && ev.UnitID != ids["dept-b"] {
+					t.Fatalf("event of unit %s outside the prefix", ev.UnitID)
+				}
+			}
+			if got[2].Type != "version.created" || got[2].UnitID != ids["dept-a"] {
+				t.Fatalf("events out of log order: %+v", got)
+			}
+
+			// a cursor only continues the query it came from
+			first, err := uc.QueryAudit(ports.AuditQueryRequest{Limit: 1})
+			if err != nil || first.NextCursor == "" {
+				t.Fatalf("first page: %+v %v", first, err)
+			}
+			_, err = uc.QueryAudit(ports.AuditQueryRequest{AuditQueryFilter: ports.AuditQueryFilter{ActorIDs: []string{"bob"}}, Cursor: first.NextCursor})
+			if !errors.Is(err, domain.ErrInvalidAuditCursor) {
+				t.Fatalf("expected ErrInvalidAuditCursor, got %v", err)
+			}
+			if _, err := uc.QueryAudit(ports.AuditQueryRequest{Cursor: "not-a-cursor"}); !errors.Is(err, domain.ErrInvalidAuditCursor) {
+				t.Fatalf("expected ErrInvalidAuditCursor for garbage, got %v", err)
+			}
+			_, err = uc.QueryAudit(ports.AuditQueryRequest{AuditQueryFilter: ports.AuditQueryFilter{FromUnix: 10, ToUnix: 5}})
+			if !errors.Is(err, domain.ErrInvalidAuditQuery) {
+				t.Fatalf("expected ErrInvalidAuditQuery, got %v", err)
+			}
+
+			// unknown prefix: empty page
+			out, err = uc.QueryAudit(ports.AuditQueryRequest{UnitKeyPrefix: "nope"})
+			if err != nil || len(out.Events) != 0 || out.NextCursor != "" {
+				t.Fatalf("unknown prefix: %+v %v", out, err)
+			}
+		})
+	}
+}
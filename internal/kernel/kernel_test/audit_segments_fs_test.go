@@ -0,0 +1,62 @@
+package kernel_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// seedSegmentsFS seeds a chained log and seals it into a segment.
+func seedSegmentsFS(t *testing.T) (string, []fsrepo.AuditSegment) {
+	t.Helper()
+	dir := seedChainFS(t)
+	log := fsrepo.NewAuditLog(dir)
+	if _, _, err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	_, segs, err := fsrepo.ReadAuditSegments(dir)
+	if err != nil || len(segs) != 1 {
+		t.Fatalf("segments: %+v %v", segs, err)
+	}
+	return dir, segs
+}
+
+func TestAuditSegments_FS_VerifyAcrossSegments(t *testing.T) {
+	dir, _ := seedSegmentsFS(t)
+	out := verifyChainFS(t, dir)
+	if !out.Ok || !out.SegmentsChecked || out.SealedSegments != 1 || out.ChainLength != 4 || out.TotalVersions != 3 {
+		t.Fatalf("expected a clean rotated log, got %+v", out)
+	}
+}
+
+func TestAuditSegments_FS_AlteredSegment(t *testing.T) {
+	dir, segs := seedSegmentsFS(t)
+	p := filepath.Join(dir, filepath.FromSlash(segs[0].File))
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := b[:bytes.IndexByte(b, '\n')+1]
+	if err := os.WriteFile(p, append(b, first...), 0o644); err != nil { // replayed event
+		t.Fatal(err)
+	}
+	out := verifyChainFS(t, dir)
+	if out.Ok || len(out.SegmentFindings) != 1 || out.SegmentFindings[0].Kind != ports.SegmentAltered {
+		t.Fatalf("expected altered segment, got %+v", out.SegmentFindings)
+	}
+}
+
+func TestAuditSegments_FS_MissingSegment(t *testing.T) {
+	dir, segs := seedSegmentsFS(t)
+	if err := os.Remove(filepath.Join(dir, filepath.FromSlash(segs[0].File))); err != nil {
+		t.Fatal(err)
+	}
+	out := verifyChainFS(t, dir)
+	if out.Ok || len(out.SegmentFindings) != 1 || out.SegmentFindings[0].Kind != ports.SegmentMissing || out.SegmentFindings[0].Segment != 1 {
+		t.Fatalf("expected missing segment, got %+v", out.SegmentFindings)
+	}
+}
@@ -0,0 +1,236 @@
+package kernel_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+type signingFixture struct {
+	repo    *memory.UnitRepo
+	log     *memory.AuditLog
+	keys    *memory.KeyRegistry
+	keyring *memory.Keyring
+	audit   usecases.SignedAuditLog
+	clock   memory.FakeClock
+	priv    map[string]ed25519.PrivateKey
+}
+
+// newSigningFixture registers one self-signed key per actor.
+func newSigningFixture(t *testing.T, actors ...string) signingFixture {
+	t.Helper()
+	f := signingFixture{
+		repo:  memory.NewUnitRepo(),
+		log:   memory.NewAuditLog(),
+		keys:  memory.NewKeyRegistry(),
+		clock: memory.FakeClock{Now: 1700000000},
+		priv:  map[string]ed25519.PrivateKey{},
+	}
+	f.keyring = memory.NewKeyring(f.keys)
+	f.audit = usecases.SignedAuditLog{Inner: f.log, Signer: f.keyring}
+
+	reg := usecases.RegisterKey{Keys: f.keys, Audit: f.audit, Clock: f.clock}
+	for _, a := range actors {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		f.keyring.Add(a, priv)
+		f.priv[a] = priv
+		if _, err := reg.RegisterKey(ports.RegisterKeyRequest{
+			ActorID:   a,
+			PublicKey: base64.StdEncoding.EncodeToString(pub),
+			ByActorID: a,
+		}); err != nil {
+			t.Fatalf("register key: %v", err)
+		}
+	}
+	return f
+}
+
+func (f signingFixture) verify(t *testing.T) ports.VerifyAuditResponse {
+	t.Helper()
+	uc := usecases.VerifyAudit{Repo: f.repo, Audit: f.log, Keys: f.keys}
+	out, err := uc.VerifyAudit(ports.VerifyAuditRequest{Signatures: true, Chain: true})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	return out
+}
+
+func signatureKinds(out ports.VerifyAuditResponse) map[string]int {
+	m := map[string]int{}
+	for _, f := range out.SignatureFindings {
+		m[f.Kind]++
+	}
+	return m
+}
+
+func TestAuditSigning_AllSigned_Ok(t *testing.T) {
+	f := newSigningFixture(t, "alice")
+
+	cu := usecases.CreateUnit{Repo: f.repo, Audit: f.audit, Clock: f.clock}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "alice"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	out := f.verify(t)
+	if !out.Ok || !out.SignaturesChecked {
+		t.Fatalf("expected ok, got findings %+v breaks %+v", out.SignatureFindings, out.ChainBreaks)
+	}
+	if out.SignedEvents != 2 {
+		t.Fatalf("expected 2 signed events, got %d", out.SignedEvents)
+	}
+}
+
+func TestAuditSigning_FindingKinds(t *testing.T) {
+	f := newSigningFixture(t, "alice")
+	aliceKeyID := domain.ActorKeyID(f.priv["alice"].Public().(ed25519.PublicKey))
+	_, stranger, _ := ed25519.GenerateKey(rand.Reader)
+
+	// unsigned: bob has no key
+	cu := usecases.CreateUnit{Repo: f.repo, Audit: f.audit, Clock: f.clock}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "bob"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	// bad signature: claims alice's key but signed with another one
+	bad := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "NOTE", AtUnix: f.clock.Now, ActorID: "alice"}
+	if err := domain.SignAuditEvent(&bad, aliceKeyID, stranger); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// unknown key
+	unknown := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "NOTE", AtUnix: f.clock.Now, ActorID: "alice"}
+	if err := domain.SignAuditEvent(&unknown, domain.ActorKeyID(stranger.Public().(ed25519.PublicKey)), stranger); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// actor mismatch: alice's key used for bob
+	mismatch := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "NOTE", AtUnix: f.clock.Now, ActorID: "bob"}
+	if err := domain.SignAuditEvent(&mismatch, aliceKeyID, f.priv["alice"]); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	for _, ev := range []domain.AuditEvent{bad, unknown, mismatch} {
+		if err := f.log.Append(ev); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	out := f.verify(t)
+	if out.Ok {
+		t.Fatalf("expected findings")
+	}
+	if len(out.ChainBreaks) != 0 {
+		t.Fatalf("signatures must not affect the chain, got %+v", out.ChainBreaks)
+	}
+	got := signatureKinds(out)
+	want := map[string]int{
+		ports.SignatureUnsigned:      1,
+		ports.SignatureBad:           1,
+		ports.SignatureUnknownKey:    1,
+		ports.SignatureActorMismatch: 1,
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Fatalf("expected %d %s findings, got %+v", n, k, got)
+		}
+	}
+}
+
+func TestAuditSigning_TamperedEventFailsSignature(t *testing.T) {
+	f := newSigningFixture(t, "alice")
+	cu := usecases.CreateUnit{Repo: f.repo, Audit: f.audit, Clock: f.clock}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "alice"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	// rewrite the event and reseal the chain: only the signature can catch it
+	f.log.Events[len(f.log.Events)-1].AtUnix++
+	evs := f.log.Events
+	f.log.Events = nil
+	for _, ev := range evs {
+		ev.Seq, ev.PrevHash, ev.Hash = 0, "", ""
+		if err := f.log.Append(ev); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	out := f.verify(t)
+	if len(out.ChainBreaks) != 0 {
+		t.Fatalf("expected resealed chain to verify, got %+v", out.ChainBreaks)
+	}
+	if got := signatureKinds(out); got[ports.SignatureBad] != 1 {
+		t.Fatalf("expected 1 bad_signature, got %+v", got)
+	}
+}
+
+func TestAuditSigning_RevokedKey(t *testing.T) {
+	f := newSigningFixture(t, "alice")
+	aliceKeyID := domain.ActorKeyID(f.priv["alice"].Public().(ed25519.PublicKey))
+
+	revoke := usecases.RevokeKey{Keys: f.keys, Audit: f.audit, Clock: f.clock}
+	if _, err := revoke.RevokeKey(ports.RevokeKeyRequest{KeyID: aliceKeyID, ByActorID: "alice"}); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := revoke.RevokeKey(ports.RevokeKeyRequest{KeyID: aliceKeyID, ByActorID: "alice"}); err != domain.ErrKeyRevoked {
+		t.Fatalf("expected ErrKeyRevoked, got %v", err)
+	}
+
+	last := f.log.Events[len(f.log.Events)-1]
+	if last.Type != "KEY_REVOKED" || last.KeyID != aliceKeyID {
+		t.Fatalf("expected revocation signed by the revoked key, got %+v", last)
+	}
+
+	// the keyring no longer signs with the revoked key
+	later := usecases.CreateUnit{Repo: f.repo, Audit: f.audit, Clock: memory.FakeClock{Now: f.clock.Now + 10}}
+	if _, err := later.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "alice"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	if f.log.Events[len(f.log.Events)-1].Sig != "" {
+		t.Fatalf("expected revoked key not to be used")
+	}
+
+	// a signature made with the stolen key after revocation is flagged
+	forged := domain.AuditEvent{Schema: "digiemu.audit.v1", ID: domain.NewID("evt"), Type: "NOTE", AtUnix: f.clock.Now + 20, ActorID: "alice"}
+	if err := domain.SignAuditEvent(&forged, aliceKeyID, f.priv["alice"]); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := f.log.Append(forged); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	got := signatureKinds(f.verify(t))
+	if got[ports.SignatureRevokedKey] != 1 || got[ports.SignatureUnsigned] != 1 || len(got) != 2 {
+		t.Fatalf("unexpected findings: %+v", got)
+	}
+}
+
+func TestAuditSigning_Required(t *testing.T) {
+	f := newSigningFixture(t)
+	strict := usecases.SignedAuditLog{Inner: f.log, Signer: f.keyring, Required: true}
+
+	cu := usecases.CreateUnit{Repo: f.repo, Audit: strict, Clock: f.clock}
+	_, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "nokey"})
+	if err != domain.ErrSigningKeyMissing {
+		t.Fatalf("expected ErrSigningKeyMissing, got %v", err)
+	}
+	if len(f.log.Events) != 0 {
+		t.Fatalf("expected nothing appended, got %d", len(f.log.Events))
+	}
+}
+
+func TestAuditSigning_VerifyWithoutRegistry(t *testing.T) {
+	f := newSigningFixture(t)
+	uc := usecases.VerifyAudit{Repo: f.repo, Audit: f.log}
+	if _, err := uc.VerifyAudit(ports.VerifyAuditRequest{Signatures: true}); err == nil {
+		t.Fatalf("expected error without key registry")
+	}
+}
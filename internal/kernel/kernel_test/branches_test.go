@@ -0,0 +1,262 @@
+package kernel_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// Integration test: branch -> parallel versions -> merge (clean and
+// resolved) -> delete -> VerifyAudit (fs)
+func TestBranches_FS_MergeAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := memory.FakeClock{Now: 1700000000}
+	verify := func() ports.VerifyAuditResponse {
+		t.Helper()
+		res, err := usecases.VerifyAudit{Repo: repo, Audit: fsrepo.NewAuditReader(dir)}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		return res
+	}
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	create := func(branch, label, content string) string {
+		t.Helper()
+		out, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Branch: branch, Label: label, Content: content, ActorID: "u"})
+		if err != nil {
+			t.Fatalf("create %s on %q: %v", label, branch, err)
+		}
+		return out.VersionID
+	}
+	v1 := create("", "v1", "a\nb\nc")
+
+	cb := usecases.CreateBranch{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := cb.CreateBranch(ports.CreateBranchRequest{UnitKey: "doc", Name: "Bad Name"}); !errors.Is(err, domain.ErrInvalidBranchName) {
+		t.Fatalf("expected ErrInvalidBranchName, got %v", err)
+	}
+	br, err := cb.CreateBranch(ports.CreateBranchRequest{UnitKey: "doc", Name: "review", ActorID: "r"})
+	if err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if br.Branch.BaseVersionID != v1 || br.Branch.HeadVersionID != v1 {
+		t.Fatalf("unexpected branch %+v", br)
+	}
+	if _, err := cb.CreateBranch(ports.CreateBranchRequest{UnitKey: "doc", Name: "review"}); !errors.Is(err, domain.ErrBranchAlreadyExists) {
+		t.Fatalf("expected ErrBranchAlreadyExists, got %v", err)
+	}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Branch: "nope", Label: "x", Content: "x"}); !errors.Is(err, domain.ErrBranchNotFound) {
+		t.Fatalf("expected ErrBranchNotFound, got %v", err)
+	}
+
+	// work on both branches does not move the other head
+	r1 := create("review", "r1", "a\nb\nc\nd")
+	m2 := create("", "m2", "A\nb\nc")
+	u, _, _ := repo.FindUnitByKey("doc")
+	if b, _ := u.FindBranch("review"); u.HeadVersionID != m2 || b.HeadVersionID != r1 {
+		t.Fatalf("heads main=%s review=%s", u.HeadVersionID, b.HeadVersionID)
+	}
+	if res := verify(); !res.Ok {
+		t.Fatalf("expected verify ok before merge, got %+v", res)
+	}
+
+	mb := usecases.MergeBranch{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := mb.MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "review", BaseVersionID: v1}); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	m, err := mb.MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "review", BaseVersionID: m2, ActorID: "r"})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if m.PrevVersionID != m2 || m.MergeParentID != r1 || m.MergeBaseVersionID != v1 || m.Label != "merge review" || m.Branch != domain.MainBranch {
+		t.Fatalf("unexpected merge %+v", m)
+	}
+	mv, _, _ := repo.FindVersionByID(m.VersionID)
+	if mv.Content != "A\nb\nc\nd" || mv.MergeParentID != r1 {
+		t.Fatalf("unexpected merge version %+v", mv)
+	}
+	if _, err := mb.MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "review"}); !errors.Is(err, domain.ErrNothingToMerge) {
+		t.Fatalf("expected ErrNothingToMerge, got %v", err)
+	}
+
+	// conflicting edits fail with the marked-up text until resolved
+	// (merge base r1: the first merge brought it into main)
+	create("review", "r2", "a\nb\nc\nreview")
+	create("", "m3", "A\nb\nc\nmain")
+	_, err = mb.MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "review"})
+	var conflict *domain.MergeConflictError
+	if !errors.As(err, &conflict) || len(conflict.Conflicts) != 1 {
+		t.Fatalf("expected a merge conflict, got %v", err)
+	}
+	if want := "A\nb\nc\n<<<<<<< main\nmain\n=======\nreview\n>>>>>>> review"; conflict.Text != want {
+		t.Fatalf("conflict text:\n%s\nwant:\n%s", conflict.Text, want)
+	}
+	resolved, err := mb.MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "review", Content: "A\nb\nc\nboth", ActorID: "r"})
+	if err != nil || !resolved.Resolved {
+		t.Fatalf("resolved merge %+v: %v", resolved, err)
+	}
+
+	list, err := usecases.ListBranches{Repo: repo}.ListBranches(ports.ListBranchesRequest{UnitKey: "doc"})
+	if err != nil || len(list.Branches) != 2 || list.Branches[0].Name != domain.MainBranch || list.Branches[0].HeadVersionID != resolved.VersionID || list.Branches[1].Name != "review" {
+		t.Fatalf("list branches %+v: %v", list, err)
+	}
+
+	db := usecases.DeleteBranch{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := db.DeleteBranch(ports.DeleteBranchRequest{UnitKey: "doc", Name: domain.MainBranch}); !errors.Is(err, domain.ErrInvalidBranchName) {
+		t.Fatalf("expected ErrInvalidBranchName, got %v", err)
+	}
+	if _, err := db.DeleteBranch(ports.DeleteBranchRequest{UnitKey: "doc", Name: "review", ActorID: "r"}); err != nil {
+		t.Fatalf("delete branch: %v", err)
+	}
+	if _, err := db.DeleteBranch(ports.DeleteBranchRequest{UnitKey: "doc", Name: "review"}); !errors.Is(err, domain.ErrBranchNotFound) {
+		t.Fatalf("expected ErrBranchNotFound, got %v", err)
+	}
+	if res := verify(); !res.Ok {
+		t.Fatalf("expected verify ok, got %+v", res)
+	}
+
+	// a branch written to the repo without its event is reported
+	u, _, _ = repo.FindUnitByKey("doc")
+	if err := repo.SaveBranch(u.ID, domain.Branch{Name: "sneaky", HeadVersionID: v1, BaseVersionID: v1}); err != nil {
+		t.Fatal(err)
+	}
+	res := verify()
+	if res.Ok || len(res.Missing) != 1 || res.Missing[0].EventType != domain.AuditBranchCreated || res.Missing[0].Branch != "sneaky" {
+		t.Fatalf("expected missing branch.created, got %+v", res)
+	}
+}
+
+// fsck accepts branches that leave main at their base and merges with two
+// parents; a branch that leaves anywhere else is a fork (fs)
+func TestBranches_FS_Fsck(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := memory.FakeClock{Now: 1700000000}
+	fsck := func() ports.FsckResponse {
+		t.Helper()
+		out, err := usecases.Fsck{Storage: fsrepo.NewDataDirChecker(dir)}.Fsck(ports.FsckRequest{})
+		if err != nil {
+			t.Fatalf("fsck: %v", err)
+		}
+		return out
+	}
+
+	cu, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	create := func(branch, label, content string) string {
+		t.Helper()
+		out, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Branch: branch, Label: label, Content: content, ActorID: "u"})
+		if err != nil {
+			t.Fatalf("create %s on %q: %v", label, branch, err)
+		}
+		return out.VersionID
+	}
+	v1 := create("", "v1", "a\nb")
+	cb := usecases.CreateBranch{Repo: repo, Audit: audit, Clock: clock}
+	for _, name := range []string{"review", "empty"} {
+		if _, err := cb.CreateBranch(ports.CreateBranchRequest{UnitKey: "doc", Name: name, ActorID: "u"}); err != nil {
+			t.Fatalf("create branch: %v", err)
+		}
+	}
+	create("review", "r1", "a\nb\nr")
+	create("", "m2", "A\nb")
+	if out := fsck(); !out.Ok || out.Versions != 3 {
+		t.Fatalf("expected a clean fsck with one version per branch on v1, got %+v", out)
+	}
+
+	if _, err := (usecases.MergeBranch{Repo: repo, Audit: audit, Clock: clock}).MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "review", ActorID: "u"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	create("review", "r2", "a\nb\nr2")
+	if out := fsck(); !out.Ok {
+		t.Fatalf("expected a clean fsck after the merge, got %+v", out)
+	}
+	if _, err := (usecases.DeleteBranch{Repo: repo, Audit: audit, Clock: clock}).DeleteBranch(ports.DeleteBranchRequest{UnitKey: "doc", Name: "empty", ActorID: "u"}); err != nil {
+		t.Fatalf("delete branch: %v", err)
+	}
+	if out := fsck(); !out.Ok {
+		t.Fatalf("expected a clean fsck after deleting a branch, got %+v", out)
+	}
+
+	// the header now claims review was branched from m2
+	hp := filepath.Join(dir, "units", cu.UnitID+".json")
+	b, err := os.ReadFile(hp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _, _ := repo.FindUnitByKey("doc")
+	edited := strings.Replace(string(b), `"base_version_id": "`+v1+`"`, `"base_version_id": "`+u.HeadVersionID+`"`, 1)
+	if edited == string(b) {
+		t.Fatalf("branch base not found in header:\n%s", b)
+	}
+	if err := os.WriteFile(hp, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := fsck()
+	if out.Ok || len(out.Findings) != 1 || out.Findings[0].Kind != ports.FsckLineageFork || out.Findings[0].VersionID != v1 {
+		t.Fatalf("expected a fork at v1, got %+v", out)
+	}
+}
+
+func TestBranches_RecoverIntents(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "a\nb", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// branch written, branch.created lost
+	crashedBranch := usecases.CreateBranch{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: j}
+	crash(t, func() {
+		_, _ = crashedBranch.CreateBranch(ports.CreateBranchRequest{UnitKey: "doc", Name: "feat", ActorID: "u"})
+	})
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Op != domain.IntentCreateBranch || out.Recovered[0].Action != ports.IntentActionRolledForward {
+		t.Fatalf("unexpected recovery %+v", out)
+	}
+
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Branch: "feat", Label: "f1", Content: "a\nb\nc", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// merge version written and main head moved, version.merged lost
+	crashedMerge := usecases.MergeBranch{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: j}
+	crash(t, func() {
+		_, _ = crashedMerge.MergeBranch(ports.MergeBranchRequest{UnitKey: "doc", Source: "feat", ActorID: "u"})
+	})
+	out = recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Op != domain.IntentCreateVersion || out.Recovered[0].Action != ports.IntentActionRolledForward {
+		t.Fatalf("unexpected recovery %+v", out)
+	}
+	if countType(audit.Events, domain.AuditBranchCreated) != 1 || countType(audit.Events, domain.AuditVersionMerged) != 1 {
+		t.Fatalf("expected one branch.created and one version.merged event")
+	}
+	res, err := usecases.VerifyAudit{Repo: repo, Audit: audit}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true})
+	if err != nil || !res.Ok {
+		t.Fatalf("verify %+v: %v", res, err)
+	}
+}
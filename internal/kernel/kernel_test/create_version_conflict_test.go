@@ -1,11 +1,16 @@
 package kernel_test
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
 	"digiemu-core/internal/kernel/adapters/memory"
 	"digiemu-core/internal/kernel/domain"
 	"digiemu-core/internal/kernel/ports"
 	"digiemu-core/internal/kernel/usecases"
-	"testing"
 )
 
 func TestCreateVersion_ConflictOnBaseVersion(t *testing.T) {
@@ -39,3 +44,106 @@ func TestCreateVersion_ConflictOnBaseVersion(t *testing.T) {
 		t.Fatalf("expected ErrConflict, got %v", err)
 	}
 }
+
+// Two repos on one data dir (two processes) race to create versions: every
+// loser gets ErrConflict, its version is undone, and it retries; the
+// lineage stays one chain.
+func TestCreateVersion_FS_TwoWritersKeepLinearLineage(t *testing.T) {
+	dir := t.TempDir()
+	clock := memory.FakeClock{Now: 1700000000}
+	if _, err := (usecases.CreateUnit{Repo: fsrepo.NewUnitRepo(dir), Audit: fsrepo.NewAuditLog(dir), Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "test"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	if _, err := (usecases.CreateUnit{Repo: fsrepo.NewUnitRepo(dir), Audit: fsrepo.NewAuditLog(dir), Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Again", ActorID: "test"}); !errors.Is(err, domain.ErrUnitAlreadyExists) {
+		t.Fatalf("expected ErrUnitAlreadyExists from a second repo, got %v", err)
+	}
+
+	const writers, perWriter = 2, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		uc := usecases.CreateVersion{Repo: fsrepo.NewUnitRepo(dir), Audit: fsrepo.NewAuditLog(dir), Clock: clock, Journal: fsrepo.NewIntentJournal(dir)}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; {
+				_, err := uc.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: "v", Content: fmt.Sprintf("%d-%d", w, i), ActorID: "test"})
+				if errors.Is(err, domain.ErrConflict) {
+					continue
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+				i++
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	out, err := usecases.Fsck{Storage: fsrepo.NewDataDirChecker(dir)}.Fsck(ports.FsckRequest{})
+	if err != nil {
+		t.Fatalf("fsck: %v", err)
+	}
+	if !out.Ok || out.Versions != writers*perWriter {
+		t.Fatalf("expected a clean linear history of %d versions, got %+v", writers*perWriter, out)
+	}
+}
+
+// Both repos swap the head under their lock: of two versions saved on one
+// head only the first becomes the head, the second is refused.
+func TestUnitRepo_HeadMovesOnlyFromTheHeadItBuildsOn(t *testing.T) {
+	for name, repo := range map[string]interface {
+		ports.UnitRepository
+		ports.BranchRepository
+	}{
+		"memory": memory.NewUnitRepo(),
+		"fs":     fsrepo.NewUnitRepo(t.TempDir()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := repo.SaveUnit(domain.Unit{ID: "unit_1", Key: "abc", Title: "Title"}); err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range []domain.Version{
+				{ID: "ver_1", UnitID: "unit_1", Content: "one"},
+				{ID: "ver_2", UnitID: "unit_1", Content: "two"},
+				{ID: "ver_3", UnitID: "unit_1", Content: "three", PrevVersionID: "ver_1", Branch: "draft"},
+				{ID: "ver_4", UnitID: "unit_1", Content: "four", PrevVersionID: "ver_1", Branch: "draft"},
+			} {
+				if err := repo.SaveVersion(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := repo.UpdateUnitHead("unit_1", "ver_1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.UpdateUnitHead("unit_1", "ver_2"); !errors.Is(err, domain.ErrConflict) {
+				t.Fatalf("expected ErrConflict for a version built on the old head, got %v", err)
+			}
+			if err := repo.UpdateUnitHead("unit_1", "ver_x"); !errors.Is(err, domain.ErrVersionNotFound) {
+				t.Fatalf("expected ErrVersionNotFound, got %v", err)
+			}
+
+			draft := domain.Branch{Name: "draft", BaseVersionID: "ver_1", HeadVersionID: "ver_1"}
+			if err := repo.SaveBranch("unit_1", draft); err != nil {
+				t.Fatal(err)
+			}
+			draft.HeadVersionID = "ver_3"
+			if err := repo.SaveBranch("unit_1", draft); err != nil {
+				t.Fatal(err)
+			}
+			draft.HeadVersionID = "ver_4"
+			if err := repo.SaveBranch("unit_1", draft); !errors.Is(err, domain.ErrConflict) {
+				t.Fatalf("expected ErrConflict for a branch version built on the old head, got %v", err)
+			}
+			if u, _, _ := repo.FindUnitByID("unit_1"); u.HeadVersionID != "ver_1" {
+				t.Fatalf("head moved to %s", u.HeadVersionID)
+			}
+		})
+	}
+}
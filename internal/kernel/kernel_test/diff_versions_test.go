@@ -0,0 +1,95 @@
+package kernel_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func TestDiffVersions(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	clock := memory.FakeClock{Now: 1700000000}
+	units := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}
+	vers := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	diff := usecases.DiffVersions{Repo: repo}
+
+	if _, err := units.CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "a\nb\nc\n", ActorID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "a\nB\nc\nd\n", ActorID: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// defaults: head against its predecessor
+	out, err := diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "doc", Context: 3})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if out.From.VersionID != v1.VersionID || out.To.VersionID != v2.VersionID {
+		t.Fatalf("sides %s..%s", out.From.VersionID, out.To.VersionID)
+	}
+	if !out.ContentChanged || out.Added != 2 || out.Removed != 1 || len(out.Hunks) != 1 {
+		t.Fatalf("unexpected diff %+v", out)
+	}
+	if len(out.Metadata) != 2 || out.Metadata[0].Field != "label" || out.Metadata[1].Field != "actorId" {
+		t.Fatalf("metadata %+v", out.Metadata)
+	}
+	if u := out.Unified(); !strings.Contains(u, "-b\n+B\n") || !strings.HasPrefix(u, "--- "+v1.VersionID+" (v1)\n") {
+		t.Fatalf("unified:\n%s", u)
+	}
+
+	// the first version diffs against nothing
+	out, err = diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "doc", ToVersionID: v1.VersionID})
+	if err != nil {
+		t.Fatalf("diff first: %v", err)
+	}
+	if out.From.VersionID != "" || out.Added != 3 || out.Removed != 0 {
+		t.Fatalf("first version %+v", out)
+	}
+
+	// same version: nothing changed
+	out, err = diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "doc", FromVersionID: v2.VersionID, ToVersionID: v2.VersionID})
+	if err != nil || out.ContentChanged || len(out.Hunks) != 0 || len(out.Metadata) != 0 || out.Unified() != "" {
+		t.Fatalf("self diff %+v %v", out, err)
+	}
+
+	// either side may be a tag
+	tags := usecases.CreateTag{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := tags.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: "approved", VersionID: v1.VersionID, ActorID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	out, err = diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "doc", FromVersionID: v2.VersionID, ToVersionID: "approved"})
+	if err != nil || out.From.VersionID != v2.VersionID || out.To.VersionID != v1.VersionID {
+		t.Fatalf("diff to tag: %+v %v", out, err)
+	}
+	out, err = diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "doc", FromVersionID: "approved"})
+	if err != nil || out.From.VersionID != v1.VersionID || out.To.VersionID != v2.VersionID {
+		t.Fatalf("diff from tag: %+v %v", out, err)
+	}
+
+	// versions of another unit are not found through this one
+	if _, err := units.CreateUnit(ports.CreateUnitRequest{Key: "other", Title: "Other", ActorID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	ov, err := vers.CreateVersion(ports.CreateVersionRequest{UnitKey: "other", Label: "o1", Content: "x", ActorID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "doc", FromVersionID: ov.VersionID}); !errors.Is(err, domain.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+	if _, err := diff.DiffVersions(ports.DiffVersionsRequest{UnitKey: "nope"}); !errors.Is(err, domain.ErrUnitNotFound) {
+		t.Fatalf("expected ErrUnitNotFound, got %v", err)
+	}
+}
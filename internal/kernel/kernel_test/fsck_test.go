@@ -0,0 +1,131 @@
+package kernel_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+func TestFsck_RepairRebuildsIndexesAndAudits(t *testing.T) {
+	dir := seedChainFS(t)
+	for _, name := range []string{"units_by_key.json", "versions_by_id.json"} {
+		if err := os.WriteFile(filepath.Join(dir, "index", name), []byte("not json"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	headers, _ := filepath.Glob(filepath.Join(dir, "units", "*.json"))
+	if len(headers) != 1 {
+		t.Fatalf("expected one unit header, got %v", headers)
+	}
+	unitBefore, err := os.ReadFile(headers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := usecases.Fsck{Storage: fsrepo.NewDataDirChecker(dir)}
+	out, err := check.Fsck(ports.FsckRequest{})
+	if err != nil {
+		t.Fatalf("fsck: %v", err)
+	}
+	if out.Ok || len(out.Findings) != 2 || out.Units != 1 || out.Versions != 3 {
+		t.Fatalf("expected two index findings, got %+v", out)
+	}
+	if _, err := check.Fsck(ports.FsckRequest{Repair: true}); err != domain.ErrAuditNotConfigured {
+		t.Fatalf("expected ErrAuditNotConfigured, got %v", err)
+	}
+
+	audit := fsrepo.NewAuditLog(dir)
+	repair := usecases.Fsck{Storage: fsrepo.NewDataDirChecker(dir), Audit: audit, Clock: memory.FakeClock{Now: 1700000100}}
+	out, err = repair.Fsck(ports.FsckRequest{Repair: true, ActorID: "ops"})
+	if err != nil {
+		t.Fatalf("fsck repair: %v", err)
+	}
+	if !out.Ok || len(out.Findings) != 0 || len(out.Repairs) != 2 {
+		t.Fatalf("expected clean result after two repairs, got %+v", out)
+	}
+
+	var evs []domain.AuditEvent
+	if err := fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		evs = append(evs, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countType(evs, domain.AuditFsckRepaired); n != 2 {
+		t.Fatalf("expected 2 %s events, got %d", domain.AuditFsckRepaired, n)
+	}
+	last := evs[len(evs)-1]
+	if last.ActorID != "ops" || last.ID != out.Repairs[1].EventID {
+		t.Fatalf("unexpected repair event: %+v", last)
+	}
+
+	unitAfter, _ := os.ReadFile(headers[0])
+	if string(unitBefore) != string(unitAfter) {
+		t.Fatalf("repair modified primary data")
+	}
+	if v := verifyChainFS(t, dir); !v.Ok {
+		t.Fatalf("chain broken after repair: %+v", v)
+	}
+
+	again, err := check.Fsck(ports.FsckRequest{})
+	if err != nil || !again.Ok {
+		t.Fatalf("expected clean re-check, got %+v %v", again, err)
+	}
+}
+
+func TestFsck_LineageFindings(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	save := func(u domain.Unit, vs ...domain.Version) {
+		t.Helper()
+		if err := repo.SaveUnit(u); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range vs {
+			v.UnitID = u.ID
+			if err := repo.SaveVersion(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	save(domain.Unit{ID: "u_ok", Key: "ok", HeadVersionID: "a2"},
+		domain.Version{ID: "a1"}, domain.Version{ID: "a2", PrevVersionID: "a1"})
+	save(domain.Unit{ID: "u_fork", Key: "fork", HeadVersionID: "b3"},
+		domain.Version{ID: "b1"}, domain.Version{ID: "b2", PrevVersionID: "b1"}, domain.Version{ID: "b3", PrevVersionID: "b1"})
+	save(domain.Unit{ID: "u_cycle", Key: "cycle", HeadVersionID: "c1"},
+		domain.Version{ID: "c1", PrevVersionID: "c2"}, domain.Version{ID: "c2", PrevVersionID: "c1"})
+	save(domain.Unit{ID: "u_head", Key: "head"},
+		domain.Version{ID: "d1"}, domain.Version{ID: "d2", PrevVersionID: "gone"}, domain.Version{ID: "d3"})
+
+	out, err := usecases.Fsck{Storage: repo}.Fsck(ports.FsckRequest{})
+	if err != nil {
+		t.Fatalf("fsck: %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range out.Findings {
+		got[f.Kind] = f.UnitID
+	}
+	want := map[string]string{
+		ports.FsckLineageFork:       "u_fork",
+		ports.FsckLineageCycle:      "u_cycle",
+		ports.FsckHeadMissing:       "u_head",
+		ports.FsckPrevDangling:      "u_head",
+		ports.FsckLineageMultiRoots: "u_head",
+	}
+	if len(out.Findings) != len(want) {
+		t.Fatalf("unexpected findings: %+v", out.Findings)
+	}
+	for kind, unitID := range want {
+		if got[kind] != unitID {
+			t.Fatalf("expected %s on %s, got %+v", kind, unitID, out.Findings)
+		}
+	}
+	if out.Ok || out.Units != 4 || out.Versions != 10 {
+		t.Fatalf("unexpected summary: %+v", out)
+	}
+}
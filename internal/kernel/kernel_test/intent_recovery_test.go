@@ -0,0 +1,482 @@
+package kernel_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+var errCrash = errors.New("crash")
+
+// crashingAudit panics on every append, simulating a crash of the process
+// after the state writes: the intent is neither committed nor aborted.
+type crashingAudit struct{}
+
+func (crashingAudit) Append(domain.AuditEvent) error { panic(errCrash) }
+
+// failingAudit fails every append; the writer sees the error.
+type failingAudit struct{}
+
+func (failingAudit) Append(domain.AuditEvent) error { return errCrash }
+
+// crash runs fn, which must crash in crashingAudit.
+func crash(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		if r := recover(); r != errCrash {
+			t.Fatalf("expected crash, got %v", r)
+		}
+	}()
+	fn()
+}
+
+// stickyJournal never commits, simulating a crash right after the audit append.
+type stickyJournal struct{ *memory.IntentJournal }
+
+func (stickyJournal) Commit(string) error { return nil }
+
+func recoverMem(t *testing.T, repo ports.UnitRepository, audit *memory.AuditLog, j ports.IntentJournal) ports.RecoverIntentsResponse {
+	t.Helper()
+	uc := usecases.RecoverIntents{Repo: repo, Audit: audit, Reader: audit, Journal: j, Clock: memory.FakeClock{Now: 1700000100}}
+	out, err := uc.RecoverIntents()
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	return out
+}
+
+func countType(evs []domain.AuditEvent, typ string) int {
+	n := 0
+	for _, ev := range evs {
+		if ev.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestIntent_CommittedOnSuccess(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	cu := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock, Journal: j}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock, Journal: j}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: "v1", Content: "one", ActorID: "t"}); err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+	if len(j.Pending) != 0 {
+		t.Fatalf("expected no pending intents, got %d", len(j.Pending))
+	}
+	if out := recoverMem(t, repo, audit, j); len(out.Recovered) != 0 {
+		t.Fatalf("expected nothing to recover, got %+v", out.Recovered)
+	}
+}
+
+func TestIntent_RollForwardMissingEvent(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	cu := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock, Journal: j}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	// state written, audit append "crashed"
+	crashed := usecases.CreateVersion{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: j}
+	crash(t, func() {
+		_, _ = crashed.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: "v1", Content: "one", ActorID: "t"})
+	})
+	if len(j.Pending) != 1 {
+		t.Fatalf("expected 1 pending intent, got %d", len(j.Pending))
+	}
+
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Action != ports.IntentActionRolledForward {
+		t.Fatalf("expected roll forward, got %+v", out.Recovered)
+	}
+	if countType(audit.Events, "version.created") != 1 || countType(audit.Events, domain.AuditIntentRolledForward) != 1 {
+		t.Fatalf("expected version.created and recovery event, got %+v", audit.Events)
+	}
+	if len(j.Pending) != 0 {
+		t.Fatalf("expected journal to be empty after recovery")
+	}
+
+	v := usecases.VerifyAudit{Repo: repo, Audit: audit, ChainHead: audit}
+	res, err := v.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Chain: true})
+	if err != nil || !res.Ok {
+		t.Fatalf("expected consistent state after recovery, got %+v %v", res, err)
+	}
+}
+
+func TestIntent_EventAlreadyLogged(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := stickyJournal{memory.NewIntentJournal()}
+	clock := memory.FakeClock{Now: 1700000000}
+
+	cu := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock, Journal: j}
+	if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	out := recoverMem(t, repo, audit, j.IntentJournal)
+	if len(out.Recovered) != 1 || out.Recovered[0].Reason != "event already in audit log" {
+		t.Fatalf("unexpected recovery: %+v", out.Recovered)
+	}
+	if countType(audit.Events, "unit.created") != 1 {
+		t.Fatalf("event must not be appended twice")
+	}
+}
+
+func TestIntent_RollBackOrphanVersion(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	cu := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock, Journal: j}
+	unit, err := cu.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	// a create_version that saved its version but never moved the head
+	orphan := domain.Version{ID: domain.NewID("ver"), UnitID: unit.UnitID, Label: "orphan", Content: "x"}
+	if err := j.Begin(domain.Intent{
+		Schema: domain.IntentSchema, ID: domain.NewID("int"), Op: domain.IntentCreateVersion, AtUnix: 1,
+		Version: &orphan,
+		Event:   domain.AuditEvent{ID: domain.NewID("evt"), Type: "version.created", UnitID: unit.UnitID, VersionID: orphan.ID},
+	}); err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := repo.SaveVersion(orphan); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// meanwhile another version moved the head
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: "v1", Content: "one", ActorID: "t"}); err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Action != ports.IntentActionRolledBack {
+		t.Fatalf("expected roll back, got %+v", out.Recovered)
+	}
+	if _, ok, _ := repo.FindVersionByID(orphan.ID); ok {
+		t.Fatalf("expected orphan version to be removed")
+	}
+	if countType(audit.Events, domain.AuditIntentRolledBack) != 1 {
+		t.Fatalf("expected recovery to be audited")
+	}
+}
+
+func TestIntent_FS_RecoverSetMeaning(t *testing.T) {
+	dir := seedChainFS(t)
+	repo := fsrepo.NewUnitRepo(dir)
+	journal := fsrepo.NewIntentJournal(dir)
+	clock := memory.FakeClock{Now: 1700000050}
+
+	crashed := usecases.SetMeaning{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: journal}
+	crash(t, func() {
+		_, _ = crashed.SetMeaning(ports.SetMeaningRequest{
+			UnitKey:     "chain",
+			MeaningJSON: []byte(`{"schema_version":"meaning/v1","title":"T","purpose":"P"}`),
+			ActorID:     "t",
+		})
+	})
+	// the crashed process is gone, and with it the lock of its intent
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	journal = fsrepo.NewIntentJournal(dir)
+
+	pending, err := journal.ListPending()
+	if err != nil || len(pending) != 1 || pending[0].Op != domain.IntentSetMeaning {
+		t.Fatalf("expected pending set_meaning intent, got %+v %v", pending, err)
+	}
+
+	uc := usecases.RecoverIntents{
+		Repo: repo, Audit: fsrepo.NewAuditLog(dir), Reader: fsrepo.NewAuditReader(dir),
+		Journal: journal, Clock: clock,
+	}
+	out, err := uc.RecoverIntents()
+	if err != nil || len(out.Recovered) != 1 {
+		t.Fatalf("recover: %+v %v", out, err)
+	}
+	if pending, _ := journal.ListPending(); len(pending) != 0 {
+		t.Fatalf("expected empty journal, got %d", len(pending))
+	}
+
+	res := verifyChainFS(t, dir)
+	if !res.Ok {
+		t.Fatalf("expected intact chain after recovery, got %+v", res.ChainBreaks)
+	}
+}
+
+func TestIntent_FailedWriteIsRolledBackNotRecovered(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	unit, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	failed := usecases.CreateVersion{Repo: repo, Audit: failingAudit{}, Clock: clock, Journal: j}
+	if _, err := failed.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: "v1", Content: "one", ActorID: "t"}); err != errCrash {
+		t.Fatalf("expected the append error, got %v", err)
+	}
+	if len(j.Pending) != 0 {
+		t.Fatalf("expected the failed intent to be removed, got %+v", j.Pending)
+	}
+	if u, _, _ := repo.FindUnitByKey("abc"); u.HeadVersionID != "" {
+		t.Fatalf("failed version left as head %s", u.HeadVersionID)
+	}
+	if vs, _ := repo.ListVersionsByUnitID(unit.UnitID); len(vs) != 0 {
+		t.Fatalf("failed version left behind: %+v", vs)
+	}
+	if out := recoverMem(t, repo, audit, j); len(out.Recovered) != 0 {
+		t.Fatalf("a failed write must not be recovered, got %+v", out.Recovered)
+	}
+}
+
+func TestIntent_AbortedIntentIsRolledBack(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	unit, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	// a writer that could not undo its version and aborted the intent
+	v := domain.Version{ID: domain.NewID("ver"), UnitID: unit.UnitID, Label: "v1", Content: "x"}
+	in := domain.Intent{
+		Schema: domain.IntentSchema, ID: domain.NewID("int"), Op: domain.IntentCreateVersion, AtUnix: 1,
+		Version: &v,
+		Event:   domain.AuditEvent{ID: domain.NewID("evt"), Type: "version.created", UnitID: unit.UnitID, VersionID: v.ID},
+	}
+	if err := j.Begin(in); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveVersion(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateUnitHead(unit.UnitID, v.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Abort(in.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the head still is the version, yet recovery must not complete it
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Action != ports.IntentActionRolledBack {
+		t.Fatalf("expected roll back, got %+v", out.Recovered)
+	}
+	if countType(audit.Events, "version.created") != 0 {
+		t.Fatalf("aborted event was appended")
+	}
+	if _, ok, _ := repo.FindVersionByID(v.ID); ok {
+		t.Fatalf("expected aborted version to be removed")
+	}
+}
+
+// Recovery started by another process (or tenant open) while a write is in
+// flight waits for it instead of taking its intent for abandoned.
+func TestIntent_FS_RecoveryWaitsForLiveWriter(t *testing.T) {
+	dir := seedChainFS(t)
+	writer := fsrepo.NewIntentJournal(dir)
+	in := domain.Intent{
+		Schema: domain.IntentSchema, ID: domain.NewID("int"), Op: domain.IntentSetMeaning, AtUnix: 1,
+		Event: domain.AuditEvent{ID: domain.NewID("evt")},
+	}
+	if err := writer.Begin(in); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan ports.RecoverIntentsResponse)
+	errs := make(chan error, 1)
+	go func() {
+		uc := usecases.RecoverIntents{
+			Repo: fsrepo.NewUnitRepo(dir), Audit: fsrepo.NewAuditLog(dir), Reader: fsrepo.NewAuditReader(dir),
+			Journal: fsrepo.NewIntentJournal(dir), Clock: memory.FakeClock{Now: 1700000100},
+		}
+		out, err := uc.RecoverIntents()
+		if err != nil {
+			errs <- err
+			return
+		}
+		done <- out
+	}()
+
+	select {
+	case out := <-done:
+		t.Fatalf("recovery did not wait for the writer: %+v", out)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := writer.Commit(in.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case out := <-done:
+		if len(out.Recovered) != 0 {
+			t.Fatalf("committed intent was recovered: %+v", out.Recovered)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("recovery still blocked after commit")
+	}
+}
+
+// Writes without an undo (sidecars, branches, tags, unit metadata) that
+// fail on the audit append stay in the journal, aborted, and recovery
+// completes them with their event instead of leaving them unlogged.
+func TestIntent_FailedWriteWithoutUndoIsRolledForward(t *testing.T) {
+	title := "Title two"
+	for _, tc := range []struct {
+		name  string
+		write func(uc base, versionID string) error
+		check func(repo *memory.UnitRepo, unitID, versionID string) bool
+	}{
+		{
+			name: "set meaning",
+			write: func(uc base, versionID string) error {
+				_, err := usecases.SetMeaning(uc).SetMeaning(ports.SetMeaningRequest{UnitKey: "abc", MeaningJSON: []byte(`{"schema_version":"meaning/v1","title":"T","purpose":"P"}`), ActorID: "t"})
+				return err
+			},
+			check: func(repo *memory.UnitRepo, unitID, versionID string) bool {
+				_, ok, _ := repo.LoadMeaning(unitID, versionID)
+				return ok
+			},
+		},
+		{
+			name: "set claims",
+			write: func(uc base, versionID string) error {
+				_, err := usecases.SetClaims(uc).SetClaims(ports.SetClaimsRequest{UnitKey: "abc", BodyBytes: []byte(`{"schema_version":"claimset/v0","version_id":"` + versionID + `","claims":[{"id":"c1","text":"A"}]}`), ActorID: "t"})
+				return err
+			},
+			check: func(repo *memory.UnitRepo, unitID, versionID string) bool {
+				_, ok, _ := repo.LoadClaimSet(unitID, versionID)
+				return ok
+			},
+		},
+		{
+			name: "set uncertainty",
+			write: func(uc base, versionID string) error {
+				_, err := usecases.SetUncertainty(uc).SetUncertainty(ports.SetUncertaintyRequest{UnitKey: "abc", BodyBytes: []byte(`{"schema_version":"uncertainty/v0","id":"u1","type":"empirical","level":"low","applies_to":{"scope":"version"}}`), ActorID: "t"})
+				return err
+			},
+			check: func(repo *memory.UnitRepo, unitID, versionID string) bool {
+				_, ok, _ := repo.LoadUncertainty(unitID, versionID)
+				return ok
+			},
+		},
+		{
+			name: "create branch",
+			write: func(uc base, versionID string) error {
+				_, err := usecases.CreateBranch(uc).CreateBranch(ports.CreateBranchRequest{UnitKey: "abc", Name: "draft", ActorID: "t"})
+				return err
+			},
+			check: func(repo *memory.UnitRepo, unitID, _ string) bool {
+				u, _, _ := repo.FindUnitByID(unitID)
+				_, ok := u.FindBranch("draft")
+				return ok
+			},
+		},
+		{
+			name: "create tag",
+			write: func(uc base, versionID string) error {
+				_, err := usecases.CreateTag(uc).CreateTag(ports.CreateTagRequest{UnitKey: "abc", Name: "stable", ActorID: "t"})
+				return err
+			},
+			check: func(repo *memory.UnitRepo, unitID, versionID string) bool {
+				u, _, _ := repo.FindUnitByID(unitID)
+				tag, ok := u.FindTag("stable")
+				return ok && tag.VersionID == versionID
+			},
+		},
+		{
+			name: "update unit",
+			write: func(uc base, versionID string) error {
+				_, err := usecases.UpdateUnit(uc).UpdateUnit(ports.UpdateUnitRequest{UnitKey: "abc", Title: &title, ActorID: "t"})
+				return err
+			},
+			check: func(repo *memory.UnitRepo, unitID, _ string) bool {
+				u, _, _ := repo.FindUnitByID(unitID)
+				return u.Title == title && u.Revision() == 2
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := memory.NewUnitRepo()
+			audit := memory.NewAuditLog()
+			j := memory.NewIntentJournal()
+			clock := memory.FakeClock{Now: 1700000000}
+
+			unit, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "t"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			v1, err := (usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}).CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: "v1", Content: "one", ActorID: "t"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tc.write(base{Repo: repo, Audit: failingAudit{}, Clock: clock, Journal: j}, v1.VersionID); err != errCrash {
+				t.Fatalf("expected the append error, got %v", err)
+			}
+			pending, _ := j.ListPending()
+			if len(pending) != 1 || !pending[0].Aborted {
+				t.Fatalf("expected the failed intent to stay for recovery, aborted; got %+v", pending)
+			}
+			in := pending[0]
+
+			out := recoverMem(t, repo, audit, j)
+			if len(out.Recovered) != 1 || out.Recovered[0].Action != ports.IntentActionRolledForward {
+				t.Fatalf("expected roll forward, got %+v", out.Recovered)
+			}
+			logged := false
+			for _, ev := range audit.Events {
+				logged = logged || ev.ID == in.Event.ID
+			}
+			if !logged {
+				t.Fatalf("event %s of the recovered write not in the log", in.Event.ID)
+			}
+			if !tc.check(repo, unit.UnitID, v1.VersionID) {
+				t.Fatalf("write not in the repository after recovery")
+			}
+		})
+	}
+}
+
+// base holds the fields the write usecases share, so a test case can be
+// given any of them.
+type base struct {
+	Repo    ports.UnitRepository
+	Audit   ports.AuditLog
+	Clock   ports.Clock
+	Journal ports.IntentJournal
+}
@@ -0,0 +1,169 @@
+package kernel_test
+
+import (
+	"errors"
+	"testing"
+
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+type staticPolicy struct {
+	p  domain.Policy
+	ok bool
+}
+
+func (s staticPolicy) LoadPolicy() (domain.Policy, bool, error) { return s.p, s.ok, nil }
+
+func TestPolicyGuard_DeniesAndRecords(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	policy := domain.Policy{Schema: domain.PolicySchema, Grants: []domain.PolicyGrant{
+		{Actor: "alice", Role: domain.RoleAuthor, Prefix: ""},
+		{Actor: "rita", Role: domain.RoleReviewer, Prefix: "legal-"},
+	}}
+	g := usecases.PolicyGuard{
+		Units:       usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock},
+		Vers:        usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock},
+		Claims:      usecases.SetClaims{Repo: repo, Audit: audit, Clock: clock},
+		Uncertainty: usecases.SetUncertainty{Repo: repo, Audit: audit, Clock: clock},
+		Policy:      staticPolicy{p: policy, ok: true},
+		Repo:        repo,
+		Audit:       audit,
+		Clock:       clock,
+	}
+
+	unit, err := g.CreateUnit(ports.CreateUnitRequest{Key: "legal-contract", Title: "Contract", ActorID: "alice"})
+	if err != nil {
+		t.Fatalf("author create unit: %v", err)
+	}
+	if _, err := g.CreateVersion(ports.CreateVersionRequest{UnitKey: "legal-contract", Label: "v1", Content: "x", ActorID: "alice"}); err != nil {
+		t.Fatalf("author create version: %v", err)
+	}
+	before := len(audit.Events)
+
+	// rita holds no role outside legal-, and authors may not set claims
+	_, err = g.CreateUnit(ports.CreateUnitRequest{Key: "bau-plan", Title: "Plan", ActorID: "rita"})
+	if !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("expected ErrAccessDenied, got %v", err)
+	}
+	_, err = g.SetClaims(ports.SetClaimsRequest{UnitKey: unit.UnitID, BodyBytes: []byte(`{"claims":[]}`), ActorID: "alice"})
+	if !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("expected ErrAccessDenied, got %v", err)
+	}
+	_, err = g.CreateVersion(ports.CreateVersionRequest{UnitKey: "legal-contract", Label: "v2", Content: "y", ActorID: "mallory"})
+	if !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("expected ErrAccessDenied, got %v", err)
+	}
+	if _, ok, _ := repo.FindUnitByKey("bau-plan"); ok {
+		t.Fatal("denied unit was created")
+	}
+
+	denied := audit.Events[before:]
+	if len(denied) != 3 {
+		t.Fatalf("expected 3 ACCESS_DENIED events, got %d", len(denied))
+	}
+	for _, ev := range denied {
+		if ev.Type != domain.AuditAccessDenied {
+			t.Fatalf("unexpected audit type: %s", ev.Type)
+		}
+	}
+	d, ok := denied[1].Data.(domain.AccessDeniedData)
+	if !ok {
+		t.Fatalf("unexpected payload %T", denied[1].Data)
+	}
+	if denied[1].ActorID != "alice" || denied[1].UnitID != unit.UnitID || d.UnitKey != "legal-contract" ||
+		d.Action != domain.ActionSetClaims || d.RequiredRole != domain.RoleReviewer || d.Role != domain.RoleAuthor {
+		t.Fatalf("unexpected denial: %+v %+v", denied[1], d)
+	}
+	if d, _ := denied[2].Data.(domain.AccessDeniedData); d.Role != "" {
+		t.Fatalf("actor without grant has role %q", d.Role)
+	}
+
+	// reviewers may set uncertainty on their prefix
+	uJSON := []byte(`{"schema_version":"uncertainty/v0","id":"u1","type":"empirical","level":"low","applies_to":{"scope":"version"}}`)
+	if _, err := g.SetUncertainty(ports.SetUncertaintyRequest{UnitKey: "legal-contract", BodyBytes: uJSON, ActorID: "rita"}); err != nil {
+		t.Fatalf("reviewer denied: %v", err)
+	}
+}
+
+func TestPolicyGuard_NoPolicyAllows(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	g := usecases.PolicyGuard{
+		Units:  usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock},
+		Policy: staticPolicy{},
+		Repo:   repo,
+		Audit:  audit,
+		Clock:  clock,
+	}
+	if _, err := g.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "anyone"}); err != nil {
+		t.Fatalf("expected nil err, got %v", err)
+	}
+}
+
+// Carrying sidecars over in a revert writes them, so it needs the roles of
+// the sidecar writes too.
+func TestPolicyGuard_RevertWithSidecarsNeedsSidecarRoles(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	policy := domain.Policy{Schema: domain.PolicySchema, Grants: []domain.PolicyGrant{
+		{Actor: "alice", Role: domain.RoleAuthor, Prefix: ""},
+		{Actor: "rita", Role: domain.RoleReviewer, Prefix: ""},
+	}}
+	g := usecases.PolicyGuard{
+		Units:  usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock},
+		Vers:   usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock},
+		Revert: usecases.RevertVersion{Repo: repo, Audit: audit, Clock: clock},
+		Claims: usecases.SetClaims{Repo: repo, Audit: audit, Clock: clock},
+		Policy: staticPolicy{p: policy, ok: true},
+		Repo:   repo,
+		Audit:  audit,
+		Clock:  clock,
+	}
+
+	if _, err := g.CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := g.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "one", ActorID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csJSON := []byte(`{"schema_version":"claimset/v0","version_id":"` + v1.VersionID + `","claims":[{"id":"cl1","text":"A"}]}`)
+	if _, err := g.SetClaims(ports.SetClaimsRequest{UnitKey: "doc", BodyBytes: csJSON, ActorID: "rita"}); err != nil {
+		t.Fatalf("reviewer set claims: %v", err)
+	}
+	if _, err := g.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "two", ActorID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	before := len(audit.Events)
+
+	_, err = g.RevertVersion(ports.RevertVersionRequest{UnitKey: "doc", SourceVersionID: v1.VersionID, CarrySidecars: true, ActorID: "alice"})
+	if !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("author revert with sidecars: expected ErrAccessDenied, got %v", err)
+	}
+	if vs, _ := repo.ListVersionsByUnitID(v1.UnitID); len(vs) != 2 {
+		t.Fatalf("denied revert created a version: %d versions", len(vs))
+	}
+	if denied := audit.Events[before:]; len(denied) != 1 || denied[0].Type != domain.AuditAccessDenied {
+		t.Fatalf("expected one ACCESS_DENIED event, got %+v", denied)
+	} else if d, _ := denied[0].Data.(domain.AccessDeniedData); d.Action != domain.ActionSetClaims {
+		t.Fatalf("denied action %q", d.Action)
+	}
+
+	// the content alone is an author's write; the sidecars a reviewer's
+	if _, err := g.RevertVersion(ports.RevertVersionRequest{UnitKey: "doc", SourceVersionID: v1.VersionID, ActorID: "alice"}); err != nil {
+		t.Fatalf("author revert: %v", err)
+	}
+	if _, err := g.RevertVersion(ports.RevertVersionRequest{UnitKey: "doc", SourceVersionID: v1.VersionID, CarrySidecars: true, ActorID: "rita"}); err != nil {
+		t.Fatalf("reviewer revert with sidecars: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package kernel_test
+
+import (
+	"errors"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// Integration test: revert with sidecars -> VerifyAudit (fs)
+func TestRevertVersion_FS_CarriesSidecarsAndVerifies(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := memory.FakeClock{Now: 1700000000}
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"}); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	v1, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first", ActorID: "u"})
+	if err != nil {
+		t.Fatalf("create v1: %v", err)
+	}
+	if _, err := (usecases.SetMeaning{Repo: repo, Audit: audit, Clock: clock}).SetMeaning(ports.SetMeaningRequest{
+		UnitKey: "doc", VersionID: v1.VersionID, MeaningJSON: []byte(`{"schema_version":"meaning/v1","title":"M"}`), ActorID: "u",
+	}); err != nil {
+		t.Fatalf("set meaning: %v", err)
+	}
+	csJSON := []byte(`{"schema_version":"claimset/v0","version_id":"` + v1.VersionID + `","claims":[{"id":"cl1","text":"A"}]}`)
+	if _, err := (usecases.SetClaims{Repo: repo, Audit: audit, Clock: clock}).SetClaims(ports.SetClaimsRequest{
+		UnitKey: "doc", VersionID: v1.VersionID, BodyBytes: csJSON, ActorID: "u",
+	}); err != nil {
+		t.Fatalf("set claims: %v", err)
+	}
+	uJSON := []byte(`{"schema_version":"uncertainty/v0","id":"u1","type":"empirical","level":"low","applies_to":{"scope":"version"}}`)
+	if _, err := (usecases.SetUncertainty{Repo: repo, Audit: audit, Clock: clock}).SetUncertainty(ports.SetUncertaintyRequest{
+		UnitKey: "doc", VersionID: v1.VersionID, BodyBytes: uJSON, ActorID: "u",
+	}); err != nil {
+		t.Fatalf("set uncertainty: %v", err)
+	}
+	v2, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "second", ActorID: "u"})
+	if err != nil {
+		t.Fatalf("create v2: %v", err)
+	}
+
+	rv := usecases.RevertVersion{Repo: repo, Audit: audit, Clock: clock}
+
+	// optimistic locking against a stale head
+	_, err = rv.RevertVersion(ports.RevertVersionRequest{UnitKey: "doc", SourceVersionID: v1.VersionID, BaseVersionID: v1.VersionID, ActorID: "r"})
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	out, err := rv.RevertVersion(ports.RevertVersionRequest{UnitKey: "doc", SourceVersionID: v1.VersionID, CarrySidecars: true, BaseVersionID: v2.VersionID, ActorID: "r"})
+	if err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	if out.PrevVersionID != v2.VersionID || out.SourceVersionID != v1.VersionID || out.Label != "v1" {
+		t.Fatalf("unexpected revert %+v", out)
+	}
+	v3, ok, err := repo.FindVersionByID(out.VersionID)
+	if err != nil || !ok {
+		t.Fatalf("find reverted version: %v %v", ok, err)
+	}
+	if v3.Content != "first" || v3.PrevVersionID != v2.VersionID || v3.MeaningHash == "" || v3.ClaimSetHash == "" || v3.UncertaintyHash == "" {
+		t.Fatalf("unexpected reverted version %+v", v3)
+	}
+	u, _, _ := repo.FindUnitByKey("doc")
+	if u.HeadVersionID != v3.ID {
+		t.Fatalf("head %s, want %s", u.HeadVersionID, v3.ID)
+	}
+	cs, ok, err := repo.LoadClaimSet(u.ID, v3.ID)
+	if err != nil || !ok || cs.VersionID != v3.ID {
+		t.Fatalf("carried claimset %+v %v %v", cs, ok, err)
+	}
+
+	// the reverted event names the source and stands in for version.created
+	var last domain.AuditEvent
+	if err := fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error { last = ev; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := last.Data.(domain.VersionRevertedData)
+	if last.Type != domain.AuditVersionReverted || last.VersionID != v3.ID || !ok || d.SourceVersionID != v1.VersionID {
+		t.Fatalf("unexpected last event %+v", last)
+	}
+	res, err := usecases.VerifyAudit{Repo: repo, Audit: fsrepo.NewAuditReader(dir)}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !res.Ok {
+		t.Fatalf("expected verify ok, got %+v", res)
+	}
+}
+
+func TestRevertVersion_Errors(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	clock := memory.FakeClock{Now: 1700000000}
+	cu := usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	for _, key := range []string{"one", "two"} {
+		if _, err := cu.CreateUnit(ports.CreateUnitRequest{Key: key, Title: key, ActorID: "u"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "two", Label: "v1", Content: "x", ActorID: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv := usecases.RevertVersion{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := rv.RevertVersion(ports.RevertVersionRequest{UnitKey: "one", SourceVersionID: other.VersionID}); !errors.Is(err, domain.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+	if _, err := rv.RevertVersion(ports.RevertVersionRequest{UnitKey: "nope", SourceVersionID: other.VersionID}); !errors.Is(err, domain.ErrUnitNotFound) {
+		t.Fatalf("expected ErrUnitNotFound, got %v", err)
+	}
+}
+
+func TestRevertVersion_RecoverIntent(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	v1, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first", ActorID: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "second", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+
+	crashed := usecases.RevertVersion{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: j}
+	crash(t, func() {
+		_, _ = crashed.RevertVersion(ports.RevertVersionRequest{UnitKey: "doc", SourceVersionID: v1.VersionID, ActorID: "u"})
+	})
+
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Op != domain.IntentRevertVersion || out.Recovered[0].Action != ports.IntentActionRolledForward {
+		t.Fatalf("unexpected recovery %+v", out)
+	}
+	if countType(audit.Events, domain.AuditVersionReverted) != 1 {
+		t.Fatalf("expected one version.reverted event")
+	}
+}
@@ -42,7 +42,7 @@ func TestSetClaims_TamperDetection_FS(t *testing.T) {
 	}
 
 	// locate sidecar file and tamper it
-	sidecar := filepath.Join(dir, "units", cv.UnitID+"."+cv.VersionID+".claimset.json")
+	sidecar := filepath.Join(dir, "units", cv.UnitID, "sidecars", cv.VersionID+".claimset.json")
 	if _, err := ioutil.ReadFile(sidecar); err != nil {
 		t.Fatalf("read sidecar: %v", err)
 	}
@@ -42,7 +42,7 @@ func TestSetMeaning_TamperDetection_FS(t *testing.T) {
 	}
 
 	// locate sidecar file and tamper it
-	sidecar := filepath.Join(dir, "units", cv.UnitID+"."+cv.VersionID+".meaning.json")
+	sidecar := filepath.Join(dir, "units", cv.UnitID, "sidecars", cv.VersionID+".meaning.json")
 	if _, err := ioutil.ReadFile(sidecar); err != nil {
 		t.Fatalf("read sidecar: %v", err)
 	}
@@ -0,0 +1,146 @@
+package kernel_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// Integration test: tag -> immutable -> export as of the tag -> VerifyAudit,
+// then a tag moved behind the log's back (fs)
+func TestTags_FS_ImmutableAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	clock := memory.FakeClock{Now: 1700000000}
+	verify := func() ports.VerifyAuditResponse {
+		t.Helper()
+		res, err := usecases.VerifyAudit{Repo: repo, Audit: fsrepo.NewAuditReader(dir)}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		return res
+	}
+
+	cu, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	ct := usecases.CreateTag{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := ct.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: "empty"}); !errors.Is(err, domain.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound without versions, got %v", err)
+	}
+
+	cv := usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}
+	v1, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first", ActorID: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := ct.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: "2026-Q3", ActorID: "r"})
+	if err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+	if tag.Tag.VersionID != v1.VersionID || tag.Tag.ActorID != "r" {
+		t.Fatalf("unexpected tag %+v", tag)
+	}
+	v2, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v2", Content: "second", ActorID: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tags never move
+	if _, err := ct.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: "2026-Q3", VersionID: v2.VersionID}); !errors.Is(err, domain.ErrTagAlreadyExists) {
+		t.Fatalf("expected ErrTagAlreadyExists, got %v", err)
+	}
+	if err := repo.SaveTag(cu.UnitID, domain.Tag{Name: "2026-Q3", VersionID: v2.VersionID}); !errors.Is(err, domain.ErrTagAlreadyExists) {
+		t.Fatalf("repo moved a tag: %v", err)
+	}
+	for _, bad := range []string{"", "ver_abc", "-x", "a b"} {
+		if _, err := ct.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: bad}); !errors.Is(err, domain.ErrInvalidTagName) {
+			t.Fatalf("tag %q: expected ErrInvalidTagName, got %v", bad, err)
+		}
+	}
+	if _, err := ct.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: "approved", VersionID: v2.VersionID, ActorID: "r"}); err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+
+	list, err := usecases.ListTags{Repo: repo}.ListTags(ports.ListTagsRequest{UnitKey: "doc"})
+	if err != nil || len(list.Tags) != 2 || list.Tags[0].Name != "2026-Q3" || list.Tags[1].Name != "approved" {
+		t.Fatalf("list tags %+v: %v", list, err)
+	}
+
+	// the export as of a tag ends at the tagged version
+	snap, err := usecases.ExportUnitSnapshot{Repo: repo}.ExportUnitSnapshot(ports.ExportUnitSnapshotRequest{UnitKey: "doc", VersionID: "2026-Q3"})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if snap.Unit.HeadVersionID != v1.VersionID || len(snap.Versions) != 1 || snap.Versions[0].ID != v1.VersionID {
+		t.Fatalf("unexpected export %+v", snap)
+	}
+	if _, err := (usecases.ExportUnitSnapshot{Repo: repo}).ExportUnitSnapshot(ports.ExportUnitSnapshotRequest{UnitKey: "doc", VersionID: "nope"}); !errors.Is(err, domain.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+
+	if res := verify(); !res.Ok {
+		t.Fatalf("expected verify ok, got %+v", res)
+	}
+
+	// move the tag in the unit header
+	hp := filepath.Join(dir, "units", cu.UnitID+".json")
+	b, err := os.ReadFile(hp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := strings.Replace(string(b), `"version_id": "`+v1.VersionID+`"`, `"version_id": "`+v2.VersionID+`"`, 1)
+	if moved == string(b) {
+		t.Fatalf("tag not found in header:\n%s", b)
+	}
+	if err := os.WriteFile(hp, []byte(moved), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := verify()
+	if res.Ok || len(res.TagMismatches) != 1 {
+		t.Fatalf("expected a tag mismatch, got %+v", res)
+	}
+	if m := res.TagMismatches[0]; m.Tag != "2026-Q3" || m.RepoVersionID != v2.VersionID || m.EventVersionID != v1.VersionID {
+		t.Fatalf("unexpected tag mismatch %+v", m)
+	}
+}
+
+func TestCreateTag_RecoverIntent(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}).CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+
+	crashed := usecases.CreateTag{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: j}
+	crash(t, func() {
+		_, _ = crashed.CreateTag(ports.CreateTagRequest{UnitKey: "doc", Name: "approved", ActorID: "u"})
+	})
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Op != domain.IntentCreateTag || out.Recovered[0].Action != ports.IntentActionRolledForward {
+		t.Fatalf("unexpected recovery %+v", out)
+	}
+	if countType(audit.Events, domain.AuditTagCreated) != 1 {
+		t.Fatalf("expected one TAG_CREATED event")
+	}
+	res, err := usecases.VerifyAudit{Repo: repo, Audit: audit}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+	if err != nil || !res.Ok {
+		t.Fatalf("verify %+v: %v", res, err)
+	}
+}
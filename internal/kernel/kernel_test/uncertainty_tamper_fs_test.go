@@ -42,7 +42,7 @@ func TestUncertainty_Tamper_FS(t *testing.T) {
 	}
 
 	// tamper the sidecar (use actual unit ID)
-	side := filepath.Join(dir, "units", outU.UnitID+"."+outV.VersionID+".uncertainty.json")
+	side := filepath.Join(dir, "units", outU.UnitID, "sidecars", outV.VersionID+".uncertainty.json")
 	if err := ioutil.WriteFile(side, []byte("{}"), 0o644); err != nil {
 		t.Fatalf("tamper write: %v", err)
 	}
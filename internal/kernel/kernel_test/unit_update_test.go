@@ -0,0 +1,243 @@
+package kernel_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// Integration test: update -> optimistic lock -> history -> export as of a
+// version and a revision -> VerifyAudit, then metadata changed behind the
+// log's back (fs)
+func TestUpdateUnit_FS_HistoryExportAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	repo := fsrepo.NewUnitRepo(dir)
+	audit := fsrepo.NewAuditLog(dir)
+	t0 := memory.FakeClock{Now: 1700000000}
+	t1 := memory.FakeClock{Now: 1700000100}
+	verify := func() ports.VerifyAuditResponse {
+		t.Helper()
+		res, err := usecases.VerifyAudit{Repo: repo, Audit: fsrepo.NewAuditReader(dir)}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		return res
+	}
+	export := func(in ports.ExportUnitSnapshotRequest) ports.ExportUnitSnapshotResponse {
+		t.Helper()
+		in.UnitKey = "doc"
+		snap, err := usecases.ExportUnitSnapshot{Repo: repo}.ExportUnitSnapshot(in)
+		if err != nil {
+			t.Fatalf("export: %v", err)
+		}
+		return snap
+	}
+	str := func(s string) *string { return &s }
+
+	cu, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: t0}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	v1, err := (usecases.CreateVersion{Repo: repo, Audit: audit, Clock: t0}).CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "first", ActorID: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := export(ports.ExportUnitSnapshotRequest{})
+	if before.Revision != 0 || len(before.Revisions) != 0 {
+		t.Fatalf("never-updated unit exported revisions: %+v", before)
+	}
+
+	uu := usecases.UpdateUnit{Repo: repo, Audit: audit, Clock: t1}
+	up, err := uu.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: str(" Doc two "), Description: str("second"), BaseRevision: 1, ActorID: "e"})
+	if err != nil {
+		t.Fatalf("update unit: %v", err)
+	}
+	if up.UnitID != cu.UnitID || up.Revision.Revision != 2 || up.Revision.Title != "Doc two" || up.Revision.ActorID != "e" {
+		t.Fatalf("unexpected update %+v", up)
+	}
+	if _, err := uu.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: str("Doc three"), BaseRevision: 1}); !errors.Is(err, domain.ErrUnitRevisionConflict) {
+		t.Fatalf("expected ErrUnitRevisionConflict for a stale revision, got %v", err)
+	}
+	if _, err := uu.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: str("Doc two")}); !errors.Is(err, domain.ErrNothingToUpdate) {
+		t.Fatalf("expected ErrNothingToUpdate, got %v", err)
+	}
+	if _, err := uu.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: str("x")}); !errors.Is(err, domain.ErrInvalidUnitTitle) {
+		t.Fatalf("expected ErrInvalidUnitTitle, got %v", err)
+	}
+
+	u, _, _ := repo.FindUnitByKey("doc")
+	if u.Title != "Doc two" || u.Description != "second" || u.Revision() != 2 {
+		t.Fatalf("unit not updated: %+v", u)
+	}
+	hist, err := usecases.ListUnitRevisions{Repo: repo}.ListUnitRevisions(ports.ListUnitRevisionsRequest{UnitKey: "doc"})
+	if err != nil || len(hist.Revisions) != 2 || hist.Revisions[0].Title != "Doc" || hist.Revisions[1].Description != "second" {
+		t.Fatalf("history %+v: %v", hist, err)
+	}
+	if r1 := hist.Revisions[0]; r1.ActorID != "u" || r1.AtUnix != t0.Now {
+		t.Fatalf("revision 1 is not the unit's creation: %+v", r1)
+	}
+	// units stored before their creation was kept: the actor of revision 1
+	// comes from the unit.created event
+	hp := filepath.Join(dir, "units", cu.UnitID+".json")
+	legacy, err := os.ReadFile(hp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hp, []byte(strings.ReplaceAll(string(legacy), `"actor_id": "u"`, `"actor_id": ""`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if u, _, _ := repo.FindUnitByKey("doc"); u.ActorID != "" || u.History()[0].ActorID != "" {
+		t.Fatalf("header still names the creator: %+v", u)
+	}
+	hist, err = usecases.ListUnitRevisions{Repo: repo, Audit: fsrepo.NewAuditByUnitReader(dir)}.ListUnitRevisions(ports.ListUnitRevisionsRequest{UnitKey: "doc"})
+	if err != nil || hist.Revisions[0].ActorID != "u" || hist.Revisions[0].AtUnix != t0.Now {
+		t.Fatalf("revision 1 not filled from the audit log: %+v: %v", hist, err)
+	}
+	if err := os.WriteFile(hp, legacy, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var updated []domain.AuditEvent
+	if err := fsrepo.NewAuditReader(dir).Scan(func(ev domain.AuditEvent) error {
+		if ev.Type == domain.AuditUnitUpdated {
+			updated = append(updated, ev)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 || updated[0].ActorID != "e" {
+		t.Fatalf("expected one unit.updated event, got %+v", updated)
+	}
+
+	// the current snapshot carries the history; the snapshot as of v1 is the
+	// one taken before the update
+	cur := export(ports.ExportUnitSnapshotRequest{})
+	if cur.Unit.Title != "Doc two" || cur.Revision != 2 || len(cur.Revisions) != 2 || cur.SnapshotHash == before.SnapshotHash {
+		t.Fatalf("unexpected current snapshot %+v", cur)
+	}
+	if again := export(ports.ExportUnitSnapshotRequest{}); again.SnapshotHash != cur.SnapshotHash {
+		t.Fatalf("snapshot hash not reproducible")
+	}
+	if old := export(ports.ExportUnitSnapshotRequest{VersionID: v1.VersionID}); old.SnapshotHash != before.SnapshotHash || old.Unit.Title != "Doc" {
+		t.Fatalf("snapshot as of v1 changed with the update: %+v", old)
+	}
+	if r1 := export(ports.ExportUnitSnapshotRequest{Revision: 1}); r1.SnapshotHash != before.SnapshotHash {
+		t.Fatalf("snapshot of revision 1 changed with the update: %+v", r1)
+	}
+	if _, err := (usecases.ExportUnitSnapshot{Repo: repo}).ExportUnitSnapshot(ports.ExportUnitSnapshotRequest{UnitKey: "doc", Revision: 9}); !errors.Is(err, domain.ErrUnitRevisionNotFound) {
+		t.Fatalf("expected ErrUnitRevisionNotFound, got %v", err)
+	}
+
+	if res := verify(); !res.Ok {
+		t.Fatalf("verify: %+v", res)
+	}
+
+	// retitling the unit in its header without an event
+	b, err := os.ReadFile(hp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.ReplaceAll(string(b), `"title": "Doc two"`, `"title": "Doc 2"`)
+	if edited == string(b) {
+		t.Fatalf("title not found in header:\n%s", b)
+	}
+	if err := os.WriteFile(hp, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := verify()
+	if res.Ok || len(res.RevisionMismatches) != 1 {
+		t.Fatalf("expected a revision mismatch, got %+v", res)
+	}
+	if m := res.RevisionMismatches[0]; m.Revision != 2 || m.RepoTitle != "Doc 2" || m.EventTitle != "Doc two" {
+		t.Fatalf("unexpected revision mismatch %+v", m)
+	}
+}
+
+func TestUpdateUnit_RecoverIntent(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	j := memory.NewIntentJournal()
+	clock := memory.FakeClock{Now: 1700000000}
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "Renamed"
+	crashed := usecases.UpdateUnit{Repo: repo, Audit: crashingAudit{}, Clock: clock, Journal: j}
+	crash(t, func() {
+		_, _ = crashed.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: &title, ActorID: "u"})
+	})
+	out := recoverMem(t, repo, audit, j)
+	if len(out.Recovered) != 1 || out.Recovered[0].Op != domain.IntentUpdateUnit || out.Recovered[0].Action != ports.IntentActionRolledForward {
+		t.Fatalf("unexpected recovery %+v", out)
+	}
+	if countType(audit.Events, domain.AuditUnitUpdated) != 1 {
+		t.Fatalf("expected one unit.updated event")
+	}
+	if u, _, _ := repo.FindUnitByKey("doc"); u.Title != "Renamed" || u.Revision() != 2 {
+		t.Fatalf("unit not updated: %+v", u)
+	}
+	res, err := usecases.VerifyAudit{Repo: repo, Audit: audit}.VerifyAudit(ports.VerifyAuditRequest{StrictHash: true, Payloads: true})
+	if err != nil || !res.Ok {
+		t.Fatalf("verify %+v: %v", res, err)
+	}
+}
+
+// A version and the updates around it made within one second: the snapshot
+// of the version takes the metadata revision the version was made at, and
+// revision 1 is the unit's creation with its description.
+func TestUpdateUnit_SnapshotMetadataByRevisionWithinOneSecond(t *testing.T) {
+	repo := memory.NewUnitRepo()
+	audit := memory.NewAuditLog()
+	clock := memory.FakeClock{Now: 1700000000}
+	str := func(s string) *string { return &s }
+
+	if _, err := (usecases.CreateUnit{Repo: repo, Audit: audit, Clock: clock}).CreateUnit(ports.CreateUnitRequest{Key: "doc", Title: "Doc", Description: "first", ActorID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	uu := usecases.UpdateUnit{Repo: repo, Audit: audit, Clock: clock}
+	if _, err := uu.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: str("Doc two"), ActorID: "e"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := (usecases.CreateVersion{Repo: repo, Audit: audit, Clock: clock}).CreateVersion(ports.CreateVersionRequest{UnitKey: "doc", Label: "v1", Content: "x", ActorID: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uu.UpdateUnit(ports.UpdateUnitRequest{UnitKey: "doc", Title: str("Doc three"), ActorID: "e"}); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := usecases.ExportUnitSnapshot{Repo: repo}.ExportUnitSnapshot(ports.ExportUnitSnapshotRequest{UnitKey: "doc", VersionID: v.VersionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Unit.Title != "Doc two" {
+		t.Fatalf("snapshot of the version took title %q, want the one it was made at", snap.Unit.Title)
+	}
+
+	hist, err := usecases.ListUnitRevisions{Repo: repo}.ListUnitRevisions(ports.ListUnitRevisionsRequest{UnitKey: "doc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1 := hist.Revisions[0]; r1.Description != "first" || r1.ActorID != "u" || r1.AtUnix != clock.Now {
+		t.Fatalf("unexpected revision 1 %+v", r1)
+	}
+	for _, ev := range audit.Events {
+		if ev.Type != domain.AuditUnitCreated {
+			continue
+		}
+		if d, ok := ev.Data.(domain.UnitCreatedData); !ok || d.Description != "first" {
+			t.Fatalf("unit.created does not carry the description: %+v", ev.Data)
+		}
+	}
+}
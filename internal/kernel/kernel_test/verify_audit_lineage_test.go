@@ -0,0 +1,178 @@
+package kernel_test
+
+import (
+	"testing"
+
+	"digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+type lineageFixture struct {
+	repo  *memory.UnitRepo
+	audit *memory.AuditLog
+	unit  string
+	v1    string
+	v2    string
+}
+
+// seedLineageMem creates unit "abc" with two versions and a claimset with
+// one CONTRADICTS relation on the second version.
+func seedLineageMem(t *testing.T) lineageFixture {
+	t.Helper()
+	f := lineageFixture{repo: memory.NewUnitRepo(), audit: memory.NewAuditLog()}
+	clock := memory.FakeClock{Now: 1700000000}
+
+	cu, err := usecases.CreateUnit{Repo: f.repo, Audit: f.audit, Clock: clock}.CreateUnit(ports.CreateUnitRequest{Key: "abc", Title: "Title", ActorID: "u"})
+	if err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	f.unit = cu.UnitID
+	cv := usecases.CreateVersion{Repo: f.repo, Audit: f.audit, Clock: clock}
+	for i, c := range []string{"one", "two"} {
+		out, err := cv.CreateVersion(ports.CreateVersionRequest{UnitKey: "abc", Label: c, Content: c, ActorID: "u"})
+		if err != nil {
+			t.Fatalf("create version: %v", err)
+		}
+		if i == 0 {
+			f.v1 = out.VersionID
+		} else {
+			f.v2 = out.VersionID
+		}
+	}
+	cs := []byte(`{"schema_version":"claimset/v0","version_id":"` + f.v2 + `","claims":[{"id":"c1","text":"A"},{"id":"c2","text":"B"}],` +
+		`"relations":[{"type":"CONTRADICTS","from_claim_id":"c1","to_claim_id":"c2"}]}`)
+	sc := usecases.SetClaims{Repo: f.repo, Audit: f.audit, Clock: clock}
+	if _, err := sc.SetClaims(ports.SetClaimsRequest{UnitKey: "abc", VersionID: f.v2, BodyBytes: cs, ActorID: "u"}); err != nil {
+		t.Fatalf("set claims: %v", err)
+	}
+	return f
+}
+
+func (f lineageFixture) appendEvent(t *testing.T, ev domain.AuditEvent) {
+	t.Helper()
+	ev.Schema = "digiemu.audit.v1"
+	ev.ID = domain.NewID("evt")
+	if ev.AtUnix == 0 {
+		ev.AtUnix = 1700000000
+	}
+	if err := f.audit.Append(ev); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f lineageFixture) verify(t *testing.T, unitKey string) ports.VerifyAuditResponse {
+	t.Helper()
+	out, err := usecases.VerifyAudit{Repo: f.repo, Audit: memory.NewAuditReader(f.audit)}.VerifyAudit(ports.VerifyAuditRequest{UnitKey: unitKey})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	return out
+}
+
+func TestVerifyAudit_LineageClean(t *testing.T) {
+	f := seedLineageMem(t)
+	f.appendEvent(t, domain.AuditEvent{
+		Type: "CLAIM_RELATION_SET", UnitID: f.unit, VersionID: f.v2,
+		Data: domain.ClaimRelationSetData{Type: "CONTRADICTS", FromClaimID: "c1", ToClaimID: "c2"},
+	})
+	if out := f.verify(t, ""); !out.Ok {
+		t.Fatalf("expected ok, got %+v", out)
+	}
+}
+
+func TestVerifyAudit_OrphanEvents(t *testing.T) {
+	f := seedLineageMem(t)
+	f.appendEvent(t, domain.AuditEvent{Type: "unit.created", UnitID: "unit_gone"})
+	f.appendEvent(t, domain.AuditEvent{Type: "version.created", UnitID: f.unit, VersionID: "ver_gone"})
+	f.appendEvent(t, domain.AuditEvent{Type: "MEANING_SET", VersionID: "ver_nowhere"})
+	f.appendEvent(t, domain.AuditEvent{
+		Type: "CLAIM_RELATION_SET", UnitID: f.unit, VersionID: f.v2,
+		Data: domain.ClaimRelationSetData{Type: "CONTRADICTS", FromClaimID: "c2", ToClaimID: "c1"},
+	})
+	f.appendEvent(t, domain.AuditEvent{
+		Type: "CLAIM_RELATION_SET", UnitID: f.unit, VersionID: f.v1,
+		Data: domain.ClaimRelationSetData{Type: "CONTRADICTS", FromClaimID: "c1", ToClaimID: "c2"},
+	})
+
+	out := f.verify(t, "")
+	if out.Ok || len(out.OrphanEvents) != 5 {
+		t.Fatalf("expected 5 orphan events, got %+v", out.OrphanEvents)
+	}
+	if o := out.OrphanEvents[0]; o.UnitID != "unit_gone" || o.Position != 5 {
+		t.Fatalf("unexpected first orphan: %+v", o)
+	}
+
+	// scoped to one unit, events about unknown units are not attributed to it
+	scoped := f.verify(t, "abc")
+	if len(scoped.OrphanEvents) != 3 {
+		t.Fatalf("expected 3 orphans in scope, got %+v", scoped.OrphanEvents)
+	}
+}
+
+func TestVerifyAudit_LineageAndHeadMismatch(t *testing.T) {
+	f := seedLineageMem(t)
+	// a third version whose event names another predecessor, head not moved
+	v3 := domain.Version{ID: "ver_3", UnitID: f.unit, Label: "three", Content: "three", PrevVersionID: f.v2}
+	if err := f.repo.SaveVersion(v3); err != nil {
+		t.Fatal(err)
+	}
+	f.appendEvent(t, domain.AuditEvent{
+		Type: "version.created", UnitID: f.unit, VersionID: v3.ID,
+		Data: domain.VersionCreatedData{PrevVersionID: f.v1, Label: "three"},
+	})
+
+	out := f.verify(t, "")
+	if len(out.LineageMismatches) != 1 || out.LineageMismatches[0].EventPrevVersionID != f.v1 || out.LineageMismatches[0].RepoPrevVersionID != f.v2 {
+		t.Fatalf("expected lineage mismatch, got %+v", out.LineageMismatches)
+	}
+	if len(out.HeadMismatches) != 1 || out.HeadMismatches[0].HeadVersionID != f.v2 || out.HeadMismatches[0].LastVersionID != v3.ID {
+		t.Fatalf("expected head mismatch, got %+v", out.HeadMismatches)
+	}
+	if out.Ok {
+		t.Fatalf("expected findings")
+	}
+}
+
+func TestVerifyAudit_TimeOrder(t *testing.T) {
+	f := seedLineageMem(t)
+	f.appendEvent(t, domain.AuditEvent{Type: "MEANING_SET", UnitID: f.unit, VersionID: f.v1, AtUnix: 1600000000})
+
+	out := f.verify(t, "")
+	if len(out.TimeOrderViolations) != 1 {
+		t.Fatalf("expected one time order violation, got %+v", out.TimeOrderViolations)
+	}
+	if tv := out.TimeOrderViolations[0]; tv.AtUnix != 1600000000 || tv.PrevAtUnix != 1700000000 || tv.UnitID != f.unit {
+		t.Fatalf("unexpected violation: %+v", tv)
+	}
+}
+
+func TestVerifyAudit_Payloads(t *testing.T) {
+	f := seedLineageMem(t)
+	f.appendEvent(t, domain.AuditEvent{Type: "custom.note", UnitID: f.unit, Data: map[string]any{"text": "hi"}})
+	f.appendEvent(t, domain.AuditEvent{
+		Type: "claim_relation.set", UnitID: f.unit, VersionID: f.v2,
+		Data: map[string]any{"type": "CONTRADICTS", "from_claim_id": "c1"},
+	})
+
+	// opt-in: without Payloads the events are only checked for lineage
+	if out := f.verify(t, ""); len(out.PayloadIssues) != 0 || out.PayloadsChecked {
+		t.Fatalf("payloads checked without being asked: %+v", out)
+	}
+
+	uc := usecases.VerifyAudit{Repo: f.repo, Audit: memory.NewAuditReader(f.audit)}
+	out, err := uc.VerifyAudit(ports.VerifyAuditRequest{Payloads: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.PayloadsChecked || out.Ok || len(out.PayloadIssues) != 2 {
+		t.Fatalf("expected 2 payload issues, got %+v", out.PayloadIssues)
+	}
+	if pi := out.PayloadIssues[0]; pi.Kind != ports.PayloadUnknownType || pi.EventType != "custom.note" || pi.Position != 5 {
+		t.Fatalf("unexpected first issue: %+v", pi)
+	}
+	if pi := out.PayloadIssues[1]; pi.Kind != ports.PayloadMalformed || pi.VersionID != f.v2 {
+		t.Fatalf("unexpected second issue: %+v", pi)
+	}
+}
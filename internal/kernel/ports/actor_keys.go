@@ -0,0 +1,47 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// ActorKeyRegistry stores actor public keys. Keys are never deleted;
+// revocation only sets RevokedAtUnix.
+type ActorKeyRegistry interface {
+	RegisterKey(k domain.ActorKey) error
+	RevokeKey(keyID string, atUnix int64) error
+	FindKey(keyID string) (domain.ActorKey, bool, error)
+	ListKeys() ([]domain.ActorKey, error)
+}
+
+// AuditSigner signs an event on behalf of ev.ActorID.
+// ok=false if no usable key is available for that actor.
+type AuditSigner interface {
+	SignAuditEvent(ev domain.AuditEvent) (signed domain.AuditEvent, ok bool, err error)
+}
+
+type RegisterKeyRequest struct {
+	ActorID   string // owner of the key
+	PublicKey string // base64 ed25519 public key
+	ByActorID string // who registers it (audit)
+}
+
+type RegisterKeyResponse struct {
+	KeyID   string
+	ActorID string
+}
+
+type RevokeKeyRequest struct {
+	KeyID     string
+	ByActorID string
+}
+
+type RevokeKeyResponse struct {
+	KeyID         string
+	RevokedAtUnix int64
+}
+
+type RegisterKeyUsecase interface {
+	RegisterKey(in RegisterKeyRequest) (RegisterKeyResponse, error)
+}
+
+type RevokeKeyUsecase interface {
+	RevokeKey(in RevokeKeyRequest) (RevokeKeyResponse, error)
+}
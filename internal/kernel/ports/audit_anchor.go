@@ -0,0 +1,65 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: anchoring digests of the audit log outside the data dir, so that
+// rewriting history needs access to the anchor as well.
+
+// Anchor is a place anchors are stored: an append-only file on separate
+// storage, a timestamping authority, ...
+type Anchor interface {
+	// Name identifies the sink in anchors and findings ("file", "rfc3161").
+	Name() string
+
+	// Anchor stores a and returns it as stored, with the sink's receipt.
+	Anchor(a domain.AuditAnchor) (domain.AuditAnchor, error)
+
+	// ListAnchors returns the stored anchors, oldest first.
+	ListAnchors() ([]domain.AuditAnchor, error)
+
+	// CheckAnchor verifies the receipt of a stored anchor (that the sink
+	// really recorded a.Digest). Whether the digest matches the log is the
+	// caller's business.
+	CheckAnchor(a domain.AuditAnchor) error
+}
+
+type AnchorAuditResponse struct {
+	// one per sink, in the order of the usecase's anchors
+	Anchors []domain.AuditAnchor
+	// Anchored[i] is false if the sink's latest anchor already covered the
+	// whole log and Anchors[i] is that anchor
+	Anchored []bool
+}
+
+// Anchor finding kinds reported by anchor verification.
+const (
+	AnchorMismatch       = "mismatch"        // the log's first TreeSize events have another root
+	AnchorBeyondLog      = "beyond_log"      // the anchor covers more events than the log has
+	AnchorReceiptInvalid = "receipt_invalid" // the sink's receipt does not verify
+	AnchorMalformed      = "malformed"       // schema or digest of the stored anchor is wrong
+)
+
+type AnchorFinding struct {
+	Kind     string
+	Sink     string
+	TreeSize int64
+	AtUnix   int64
+	Detail   string
+}
+
+type VerifyAnchorsResponse struct {
+	Events int64 // events in the log
+
+	Checked  int // anchors checked over all sinks
+	Findings []AnchorFinding
+
+	// latest anchor that matched the log (zero if none)
+	Latest domain.AuditAnchor
+
+	Ok bool
+}
+
+type AnchorAuditUsecase interface {
+	AnchorAudit() (AnchorAuditResponse, error)
+	VerifyAnchors() (VerifyAnchorsResponse, error)
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// AuditChainHeadReader returns the chain head recorded at append time.
+// ok=false if no head has been recorded yet.
+type AuditChainHeadReader interface {
+	ReadChainHead() (domain.AuditChainHead, bool, error)
+}
@@ -0,0 +1,55 @@
+package ports
+
+import (
+	"context"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// v0.6: exporting the audit log to external log systems (SIEM ingestion).
+
+// AuditEventEncoder renders one audit event as a record of an external
+// format (CloudEvents, RFC 5424 syslog, OTLP-JSON, ...).
+type AuditEventEncoder interface {
+	EncodeAuditEvent(ev domain.AuditEvent) ([]byte, error)
+}
+
+// AuditSink delivers encoded records, in order. Send returns once the
+// records are handed off (written, or sent to the listener).
+type AuditSink interface {
+	Send(records [][]byte) error
+}
+
+// AuditExportCheckpoint is how far an export got.
+type AuditExportCheckpoint struct {
+	Format        string `json:"format"`
+	LastEventID   string `json:"lastEventId"`
+	Forwarded     int64  `json:"forwarded"` // events sent over all runs
+	UpdatedAtUnix int64  `json:"updatedAtUnix"`
+}
+
+// AuditExportCheckpointStore persists the checkpoint of one export.
+// ok=false if none was saved yet.
+type AuditExportCheckpointStore interface {
+	LoadCheckpoint() (AuditExportCheckpoint, bool, error)
+	SaveCheckpoint(c AuditExportCheckpoint) error
+}
+
+type ForwardAuditRequest struct {
+	// Format is recorded in the checkpoint; resuming a checkpoint written
+	// for another format is refused.
+	Format string
+
+	// Follow keeps forwarding new events until the context is done;
+	// otherwise the call returns once the log is drained.
+	Follow bool
+}
+
+type ForwardAuditResponse struct {
+	Forwarded   int64 // events sent by this call
+	LastEventID string
+}
+
+type ForwardAuditUsecase interface {
+	ForwardAudit(ctx context.Context, in ForwardAuditRequest) (ForwardAuditResponse, error)
+}
@@ -0,0 +1,56 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"digiemu-core/internal/kernel/domain"
+)
+
+// AuditCursor is a position in the audit log, between two events. Its value
+// is adapter specific (byte offset for fs, event index for memory).
+type AuditCursor struct {
+	Offset int64
+}
+
+// AuditFollower reads events as they are appended, possibly by other
+// processes.
+type AuditFollower interface {
+	// CursorAfter returns the position after the event afterEventID, or the
+	// current end of the log if afterEventID is empty. Unknown ids yield
+	// domain.ErrAuditEventNotFound.
+	CursorAfter(afterEventID string) (AuditCursor, error)
+
+	// Poll returns up to max complete events appended after cur and the
+	// cursor after them; max <= 0 means DefaultAuditPollMax. A full batch
+	// means more may follow, so callers poll again before waiting. It does
+	// not block.
+	Poll(cur AuditCursor, max int) ([]domain.AuditEvent, AuditCursor, error)
+}
+
+type AuditFollowRequest struct {
+	// Resume after this event (e.g. SSE Last-Event-ID); empty follows from
+	// the current end of the log.
+	AfterEventID string
+
+	// Optional filters, as for AuditTailRequest
+	Type      string
+	UnitID    string
+	VersionID string
+}
+
+type FollowAuditUsecase interface {
+	// StartFollow resolves where a follow starts, so that an unknown
+	// AfterEventID is reported before anything is streamed.
+	StartFollow(in AuditFollowRequest) (AuditCursor, error)
+
+	// FollowAudit calls fn for every matching event after cur until ctx is
+	// done (returning nil) or fn or the log fails.
+	FollowAudit(ctx context.Context, in AuditFollowRequest, cur AuditCursor, fn func(ev domain.AuditEvent) error) error
+}
+
+// DefaultAuditFollowInterval is how often followers poll the log.
+const DefaultAuditFollowInterval = 250 * time.Millisecond
+
+// DefaultAuditPollMax bounds one Poll when the caller passes no max.
+const DefaultAuditPollMax = 500
@@ -0,0 +1,47 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// AuditMerkleStore persists the Merkle leaves of the audit log (one per
+// event, in AuditLogReader.Scan order) and the published tree heads.
+// Both are append-only.
+type AuditMerkleStore interface {
+	ListLeaves() ([]domain.MerkleLeaf, error)
+	AppendLeaves(leaves []domain.MerkleLeaf) error
+	ListTreeHeads() ([]domain.TreeHead, error)
+	AppendTreeHead(th domain.TreeHead) error
+}
+
+// TreeHeadSigner signs tree heads with the key of domain.TreeHeadActorID.
+// ok=false if no usable key is available.
+type TreeHeadSigner interface {
+	SignTreeHead(th domain.TreeHead) (signed domain.TreeHead, ok bool, err error)
+}
+
+type PublishTreeHeadResponse struct {
+	TreeHead domain.TreeHead
+	// Published is false if the latest head already covered the whole log.
+	Published bool
+}
+
+type InclusionProofRequest struct {
+	EventID  string
+	TreeSize int64 // 0 = latest tree head
+}
+
+type ConsistencyProofRequest struct {
+	OldSize int64
+	NewSize int64 // 0 = latest tree head
+}
+
+type PublishTreeHeadUsecase interface {
+	PublishTreeHead() (PublishTreeHeadResponse, error)
+}
+
+type InclusionProofUsecase interface {
+	InclusionProof(in InclusionProofRequest) (domain.InclusionProof, error)
+}
+
+type ConsistencyProofUsecase interface {
+	ConsistencyProof(in ConsistencyProofRequest) (domain.ConsistencyProof, error)
+}
@@ -0,0 +1,54 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: audit queries select events by time range, actors, types and units
+// and page through the matches in log order.
+
+// AuditQueryFilter selects audit events. Empty fields do not filter; a list
+// matches any of its values. Types are registered names (the usecase
+// resolves aliases, see domain.CanonicalAuditEventType).
+type AuditQueryFilter struct {
+	FromUnix  int64 // inclusive; 0 = unbounded
+	ToUnix    int64 // inclusive; 0 = unbounded
+	ActorIDs  []string
+	Types     []string
+	UnitIDs   []string
+	VersionID string
+}
+
+// AuditQuery reads the events matching a filter, starting at a cursor.
+type AuditQuery interface {
+	// QueryAudit returns up to limit matching events at or after cur. If
+	// more match, next is the position of the first of them and more is true.
+	QueryAudit(f AuditQueryFilter, cur AuditCursor, limit int) (evs []domain.AuditEvent, next AuditCursor, more bool, err error)
+}
+
+type AuditQueryRequest struct {
+	AuditQueryFilter
+
+	// Optional: only units whose key starts with this prefix (combined with
+	// UnitIDs if both are set)
+	UnitKeyPrefix string
+
+	// Page size (DefaultAuditQueryLimit if 0, at most MaxAuditQueryLimit)
+	Limit int
+
+	// Opaque NextCursor of the previous page; empty for the first page. A
+	// cursor is only valid for the query it was returned for.
+	Cursor string
+}
+
+type AuditQueryResponse struct {
+	Events     []domain.AuditEvent `json:"events"`
+	NextCursor string              `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+type AuditQueryUsecase interface {
+	QueryAudit(in AuditQueryRequest) (AuditQueryResponse, error)
+}
+
+const (
+	DefaultAuditQueryLimit = 100
+	MaxAuditQueryLimit     = 1000
+)
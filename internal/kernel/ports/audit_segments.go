@@ -0,0 +1,25 @@
+package ports
+
+// v0.6: sealed segments of a rotated audit log.
+
+// Segment finding kinds reported by AuditSegmentChecker.
+const (
+	SegmentMissing  = "missing"  // sealed segment file is gone
+	SegmentAltered  = "altered"  // size or hash differs from the manifest
+	SegmentUnlinked = "unlinked" // does not continue the previous segment
+	SegmentUnlisted = "unlisted" // segment file the manifest does not know
+)
+
+type AuditSegmentFinding struct {
+	Kind    string
+	Segment int // number in the manifest, 0 for unlisted files
+	File    string
+	Detail  string
+}
+
+// AuditSegmentChecker checks the sealed segments of the audit log against
+// the hashes they were sealed with. sealed is the number of segments the
+// manifest lists.
+type AuditSegmentChecker interface {
+	CheckAuditSegments() (findings []AuditSegmentFinding, sealed int, err error)
+}
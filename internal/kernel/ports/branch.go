@@ -0,0 +1,97 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: branches and merges (see domain.MainBranch).
+
+// BranchRepository is implemented by unit repositories that store named
+// branches. Implementations MUST only persist data and MUST NOT emit audit
+// events; the branches come back in domain.Unit.Branches, sorted by name.
+type BranchRepository interface {
+	// SaveBranch creates the branch or replaces the one with its name.
+	SaveBranch(unitID string, b domain.Branch) error
+	// DeleteBranch removes the branch; removing a missing branch is a no-op.
+	DeleteBranch(unitID, name string) error
+}
+
+type CreateBranchRequest struct {
+	UnitKey       string // key or id
+	Name          string
+	FromVersionID string // optional; default: the head of FromBranch
+	FromBranch    string // optional; default: domain.MainBranch
+	ActorID       string
+}
+
+type DeleteBranchRequest struct {
+	UnitKey string // key or id
+	Name    string
+	ActorID string
+}
+
+type ListBranchesRequest struct {
+	UnitKey string // key or id
+}
+
+// BranchInfo describes a branch; MainBranch has no base.
+type BranchInfo struct {
+	Name          string `json:"name"`
+	HeadVersionID string `json:"headVersionId,omitempty"`
+	BaseVersionID string `json:"baseVersionId,omitempty"`
+	CreatedAtUnix int64  `json:"createdAtUnix,omitempty"`
+	ActorID       string `json:"actorId,omitempty"`
+}
+
+type BranchResponse struct {
+	UnitID string     `json:"unitId"`
+	Branch BranchInfo `json:"branch"`
+}
+
+type ListBranchesResponse struct {
+	UnitID   string       `json:"unitId"`
+	UnitKey  string       `json:"unitKey"`
+	Branches []BranchInfo `json:"branches"` // MainBranch first
+}
+
+// MergeBranchRequest merges the head of Source into Target with a new
+// version whose parents are both heads. Content, if set, is the merged
+// content to record (a resolution of the conflicts of an earlier attempt);
+// otherwise the three-way merge result is used and conflicts fail the merge
+// with a *domain.MergeConflictError.
+type MergeBranchRequest struct {
+	UnitKey       string // key or id
+	Source        string
+	Target        string // optional; default: domain.MainBranch
+	Label         string // optional; default: "merge <source>"
+	Content       string
+	BaseVersionID string // optional optimistic locking on the target head
+	ActorID       string
+}
+
+type MergeBranchResponse struct {
+	VersionID          string `json:"versionId"`
+	UnitID             string `json:"unitId"`
+	Branch             string `json:"branch"`
+	SourceBranch       string `json:"sourceBranch"`
+	PrevVersionID      string `json:"prevVersionId,omitempty"`
+	MergeParentID      string `json:"mergeParentId"`
+	MergeBaseVersionID string `json:"mergeBaseVersionId,omitempty"`
+	Label              string `json:"label"`
+	ContentHash        string `json:"contentHash"`
+	Resolved           bool   `json:"resolved,omitempty"`
+}
+
+type CreateBranchUsecase interface {
+	CreateBranch(in CreateBranchRequest) (BranchResponse, error)
+}
+
+type DeleteBranchUsecase interface {
+	DeleteBranch(in DeleteBranchRequest) (BranchResponse, error)
+}
+
+type ListBranchesUsecase interface {
+	ListBranches(in ListBranchesRequest) (ListBranchesResponse, error)
+}
+
+type MergeBranchUsecase interface {
+	MergeBranch(in MergeBranchRequest) (MergeBranchResponse, error)
+}
@@ -8,6 +8,7 @@ type CreateUnitRequest struct {
 	Title       string
 	Description string
 	ActorID     string // v0.2: strict audit
+	TenantID    string // v0.6: must be the tenant of the repo
 }
 
 type CreateUnitResponse struct {
@@ -24,6 +25,9 @@ type CreateVersionRequest struct {
 	// v0.2
 	BaseVersionID string // optional optimistic locking; "" = no check
 	ActorID       string // strict audit
+
+	// v0.6: branch to create the version on; "" = domain.MainBranch
+	Branch string
 }
 
 type CreateVersionResponse struct {
@@ -33,6 +37,30 @@ type CreateVersionResponse struct {
 	Content   string
 }
 
+// v0.6: RevertVersionRequest makes an earlier version's content current
+// again as a new version on top of the head.
+type RevertVersionRequest struct {
+	UnitKey         string // key or id
+	SourceVersionID string // the version to revert to
+	Label           string // optional; default: the source version's label
+	CarrySidecars   bool   // copy the source's meaning, claims and uncertainty
+	BaseVersionID   string // optional optimistic locking; "" = no check
+	ActorID         string
+	Branch          string // "" = domain.MainBranch
+}
+
+type RevertVersionResponse struct {
+	VersionID       string
+	UnitID          string
+	SourceVersionID string
+	PrevVersionID   string
+	Label           string
+	ContentHash     string
+	MeaningHash     string
+	ClaimSetHash    string
+	UncertaintyHash string
+}
+
 type SetMeaningRequest struct {
 	UnitKey     string
 	VersionID   string // optional; empty means use head
@@ -5,6 +5,15 @@ import "digiemu-core/internal/kernel/domain"
 type ExportUnitSnapshotRequest struct {
 	UnitKey      string
 	IncludeAudit bool
+
+	// v0.6: optional version id or tag; the snapshot is then the unit as of
+	// that version: the version and its ancestors, with it as the head
+	VersionID string
+
+	// v0.6: optional metadata revision the unit's title and description are
+	// taken from. Default: the revision in effect when VersionID was created,
+	// else the current one.
+	Revision int
 }
 
 // ExportUnitSnapshotResponse is a stable snapshot of a unit.
@@ -15,6 +24,12 @@ type ExportUnitSnapshotResponse struct {
 	Versions []VersionDTO        `json:"versions"`
 	Audit    []domain.AuditEvent `json:"audit,omitempty"`
 
+	// v0.6: metadata revision of Unit and the revisions up to it; both are
+	// left out for revision 1, so snapshots of never-updated units keep
+	// their hash
+	Revision  int                `json:"revision,omitempty"`
+	Revisions []UnitRevisionInfo `json:"revisions,omitempty"`
+
 	// v0.2.5: deterministic hashes for signing/archiving
 	SnapshotHash string `json:"snapshotHash"`
 	AuditHash    string `json:"auditHash,omitempty"`
@@ -0,0 +1,90 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+const FsckReportSchema = "digiemu.fsck.v1"
+
+// Fsck finding kinds.
+const (
+	FsckLayoutOutdated    = "layout_outdated"    // data dir not migrated to the current layout
+	FsckTempFile          = "temp_file"          // leftover *.tmp of an interrupted write
+	FsckIntentPending     = "intent_pending"     // journaled operation not recovered yet
+	FsckUnitUnreadable    = "unit_unreadable"    // unit file cannot be parsed
+	FsckUnitMismatch      = "unit_mismatch"      // unit id does not match its file name
+	FsckUnitOrphan        = "unit_orphan"        // unit dir without a unit file
+	FsckUnitForeign       = "unit_foreign"       // v0.6: unit file of another tenant
+	FsckDuplicateKey      = "duplicate_key"      // several units share a key
+	FsckIndexInvalid      = "index_invalid"      // index file missing, corrupt or of another schema
+	FsckIndexDangling     = "index_dangling"     // index entry points to a missing unit/version
+	FsckIndexMismatch     = "index_mismatch"     // index entry maps to the wrong unit
+	FsckIndexMissing      = "index_missing"      // unit/version absent from the index
+	FsckVersionMissing    = "version_missing"    // listed version has no file
+	FsckVersionUnreadable = "version_unreadable" // version (or its hashes) file cannot be parsed
+	FsckVersionMismatch   = "version_mismatch"   // version file ids disagree with its location
+	FsckVersionOrphan     = "version_orphan"     // version file not listed by its unit
+	FsckHeadMissing       = "head_missing"       // unit has versions but no head
+	FsckHeadDangling      = "head_dangling"      // head is not a version of the unit
+	FsckPrevDangling      = "prev_dangling"      // PrevVersionID is not a version of the unit
+	FsckLineageFork       = "lineage_fork"       // two versions share a predecessor
+	FsckLineageCycle      = "lineage_cycle"      // PrevVersionID chain loops
+	FsckLineageMultiRoots = "lineage_multi_root" // more than one version without predecessor
+	FsckSidecarMissing    = "sidecar_missing"    // hash recorded but sidecar file missing
+	FsckSidecarOrphan     = "sidecar_orphan"     // sidecar without version or recorded hash
+)
+
+type FsckFinding struct {
+	Kind      string `json:"kind"`
+	UnitID    string `json:"unitId,omitempty"`
+	VersionID string `json:"versionId,omitempty"`
+	Path      string `json:"path,omitempty"` // data-dir relative
+	Detail    string `json:"detail,omitempty"`
+
+	// Repairable findings concern derived data (indexes) that fsck --repair
+	// regenerates from primary data.
+	Repairable bool `json:"repairable"`
+}
+
+type FsckRepair struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+	EventID string `json:"eventId,omitempty"`
+}
+
+// FsckUnit is a unit as found on storage, with its versions in stored order.
+type FsckUnit struct {
+	Unit     domain.Unit
+	Versions []domain.Version
+}
+
+// DataDirCheck is the storage-level part of a fsck run.
+type DataDirCheck struct {
+	Units    []FsckUnit // readable units, for the lineage checks
+	Findings []FsckFinding
+}
+
+// DataDirChecker inspects a storage backend without modifying it.
+type DataDirChecker interface {
+	CheckDataDir() (DataDirCheck, error)
+	// RebuildIndex regenerates the derived index at path (as reported in a
+	// Repairable finding) from primary data.
+	RebuildIndex(path string) (FsckRepair, error)
+}
+
+type FsckRequest struct {
+	Repair  bool
+	ActorID string // actor of the fsck.repaired events (default "system")
+}
+
+type FsckResponse struct {
+	Schema   string        `json:"schema"`
+	Ok       bool          `json:"ok"`
+	Units    int           `json:"units"`
+	Versions int           `json:"versions"`
+	Findings []FsckFinding `json:"findings"` // after repairs
+	Repairs  []FsckRepair  `json:"repairs"`
+}
+
+type FsckUsecase interface {
+	Fsck(in FsckRequest) (FsckResponse, error)
+}
@@ -0,0 +1,52 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// IntentJournal is the write-ahead journal for state changes. An intent is
+// written (durably) by Begin before the repository is touched, and removed
+// by Commit after its audit event was appended. Abort keeps it, marked as
+// failed, when the writer could not undo its partial writes itself.
+type IntentJournal interface {
+	Begin(in domain.Intent) error
+	Commit(intentID string) error
+	Abort(intentID string) error
+	// ListPending returns uncommitted intents, oldest first.
+	ListPending() ([]domain.Intent, error)
+}
+
+// IntentJournalLocker is implemented by journals shared between processes.
+// Every intent holds a shared lock from Begin until Commit or Abort;
+// LockRecovery takes it exclusively, so it waits for the intents in flight
+// and the pending intents it then sees were left by writers that are gone.
+type IntentJournalLocker interface {
+	LockRecovery() (unlock func(), err error)
+}
+
+// UnitRepositoryRollback is implemented by repositories that can undo a
+// partially applied create_unit / create_version intent. Only intent
+// recovery uses it; regular usecases never delete.
+type UnitRepositoryRollback interface {
+	DeleteUnit(unitID string) error
+	DeleteVersion(unitID, versionID string) error
+}
+
+const (
+	IntentActionRolledForward = "rolled_forward"
+	IntentActionRolledBack    = "rolled_back"
+)
+
+type IntentRecovery struct {
+	IntentID string
+	Op       string
+	EventID  string
+	Action   string
+	Reason   string
+}
+
+type RecoverIntentsResponse struct {
+	Recovered []IntentRecovery
+}
+
+type RecoverIntentsUsecase interface {
+	RecoverIntents() (RecoverIntentsResponse, error)
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: access policy for the write usecases (see usecases.PolicyGuard).
+
+// PolicyStore loads the access policy. ok=false if none is configured, in
+// which case nothing is enforced.
+type PolicyStore interface {
+	LoadPolicy() (domain.Policy, bool, error)
+}
@@ -10,6 +10,7 @@ type UnitDTO struct {
 	Title         string
 	Description   string
 	HeadVersionID string
+	TenantID      string `json:"TenantID,omitempty"` // v0.6
 }
 
 type GetUnitResponse struct {
@@ -48,6 +49,8 @@ type VersionDTO struct {
 	ContentHash   string
 	CreatedAtUnix int64
 	ActorID       string
+	Branch        string `json:"Branch,omitempty"`        // v0.6
+	MergeParentID string `json:"MergeParentID,omitempty"` // v0.6
 }
 
 type ListVersionsResponse struct {
@@ -0,0 +1,53 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: immutable release tags (see domain.Tag).
+
+// TagRepository is implemented by unit repositories that store tags.
+// Implementations MUST only persist data and MUST NOT emit audit events;
+// saving a tag again is a no-op and saving it with another version fails
+// with domain.ErrTagAlreadyExists. Tags come back in domain.Unit.Tags.
+type TagRepository interface {
+	SaveTag(unitID string, t domain.Tag) error
+}
+
+// CreateTagRequest tags VersionID, or else the head of Branch (default
+// domain.MainBranch).
+type CreateTagRequest struct {
+	UnitKey   string // key or id
+	Name      string
+	VersionID string
+	Branch    string
+	ActorID   string
+}
+
+type ListTagsRequest struct {
+	UnitKey string // key or id
+}
+
+type TagInfo struct {
+	Name          string `json:"name"`
+	VersionID     string `json:"versionId"`
+	CreatedAtUnix int64  `json:"createdAtUnix,omitempty"`
+	ActorID       string `json:"actorId,omitempty"`
+}
+
+type TagResponse struct {
+	UnitID string  `json:"unitId"`
+	Tag    TagInfo `json:"tag"`
+}
+
+type ListTagsResponse struct {
+	UnitID  string    `json:"unitId"`
+	UnitKey string    `json:"unitKey"`
+	Tags    []TagInfo `json:"tags"` // sorted by name
+}
+
+type CreateTagUsecase interface {
+	CreateTag(in CreateTagRequest) (TagResponse, error)
+}
+
+type ListTagsUsecase interface {
+	ListTags(in ListTagsRequest) (ListTagsResponse, error)
+}
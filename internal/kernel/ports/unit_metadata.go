@@ -0,0 +1,57 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: unit metadata updates (see domain.UnitRevision).
+
+// UnitMetadataRepository is implemented by unit repositories that can change
+// a unit's title and description. SaveUnitRevision makes rev the unit's
+// current metadata and keeps the earlier revisions (in domain.Unit.Revisions).
+// Implementations MUST only persist data and MUST NOT emit audit events;
+// saving a revision again is a no-op and a revision that does not follow
+// the current one fails with domain.ErrUnitRevisionConflict.
+type UnitMetadataRepository interface {
+	SaveUnitRevision(unitID string, rev domain.UnitRevision) error
+}
+
+// UpdateUnitRequest changes the fields that are set. BaseRevision is the
+// revision the caller read (optimistic lock; 0 skips the check).
+type UpdateUnitRequest struct {
+	UnitKey      string // key or id
+	Title        *string
+	Description  *string
+	BaseRevision int
+	ActorID      string
+}
+
+type ListUnitRevisionsRequest struct {
+	UnitKey string // key or id
+}
+
+type UnitRevisionInfo struct {
+	Revision    int    `json:"revision"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	AtUnix      int64  `json:"atUnix,omitempty"`
+	ActorID     string `json:"actorId,omitempty"`
+}
+
+type UpdateUnitResponse struct {
+	UnitID   string           `json:"unitId"`
+	UnitKey  string           `json:"unitKey"`
+	Revision UnitRevisionInfo `json:"revision"`
+}
+
+type ListUnitRevisionsResponse struct {
+	UnitID    string             `json:"unitId"`
+	UnitKey   string             `json:"unitKey"`
+	Revisions []UnitRevisionInfo `json:"revisions"` // oldest first
+}
+
+type UpdateUnitUsecase interface {
+	UpdateUnit(in UpdateUnitRequest) (UpdateUnitResponse, error)
+}
+
+type ListUnitRevisionsUsecase interface {
+	ListUnitRevisions(in ListUnitRevisionsRequest) (ListUnitRevisionsResponse, error)
+}
@@ -10,6 +10,11 @@ type CreateVersionUsecase interface {
 	CreateVersion(req CreateVersionRequest) (CreateVersionResponse, error)
 }
 
+// v0.6
+type RevertVersionUsecase interface {
+	RevertVersion(req RevertVersionRequest) (RevertVersionResponse, error)
+}
+
 type SetMeaningUsecase interface {
 	SetMeaning(req SetMeaningRequest) (SetMeaningResponse, error)
 }
@@ -0,0 +1,89 @@
+package ports
+
+// v0.6: machine-readable form of VerifyAuditResponse, shared by
+// `digiemu audit verify --format json` and GET /v1/audit/verify.
+
+const VerifyAuditReportSchema = "digiemu.audit.verify_report.v1"
+
+// Finding severities. Both make the report not Ok; warnings flag events that
+// are suspicious rather than provably inconsistent.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding categories (one per VerifyAuditResponse finding list).
+const (
+	FindingMissing   = "missing"
+	FindingDuplicate = "duplicate"
+	FindingHash      = "hash_mismatch"
+	FindingOrphan    = "orphan_event"
+	FindingLineage   = "lineage_mismatch"
+	FindingHead      = "head_mismatch"
+	FindingTag       = "tag_mismatch"
+	FindingRevision  = "revision_mismatch"
+	FindingTimeOrder = "time_order"
+	FindingChain     = "chain_break"
+	FindingSignature = "signature"
+	FindingPayload   = "payload"
+	FindingSegment   = "segment"
+)
+
+type VerifyFinding struct {
+	Category  string `json:"category"`
+	Kind      string `json:"kind,omitempty"` // chain break / signature / payload / segment kind, or the event type
+	Severity  string `json:"severity"`
+	UnitID    string `json:"unitId,omitempty"`
+	VersionID string `json:"versionId,omitempty"`
+	EventID   string `json:"eventId,omitempty"`
+	Position  int64  `json:"position,omitempty"`
+	Seq       int64  `json:"seq,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+	Actual    string `json:"actual,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type VerifyAuditReportRequest struct {
+	UnitKey    string `json:"unitKey,omitempty"`
+	StrictHash bool   `json:"strictHash"`
+	Chain      bool   `json:"chain"`
+	Signatures bool   `json:"signatures"`
+	Payloads   bool   `json:"payloads"`
+}
+
+type VerifyAuditSummary struct {
+	Units             int   `json:"units"`
+	Versions          int   `json:"versions"`
+	ChainChecked      bool  `json:"chainChecked"`
+	ChainLength       int64 `json:"chainLength"`
+	LegacyEvents      int64 `json:"legacyEvents"`
+	SignaturesChecked bool  `json:"signaturesChecked"`
+	SignedEvents      int64 `json:"signedEvents"`
+	PayloadsChecked   bool  `json:"payloadsChecked"`
+	SegmentsChecked   bool  `json:"segmentsChecked"`
+	SealedSegments    int   `json:"sealedSegments"`
+	Errors            int   `json:"errors"`
+	Warnings          int   `json:"warnings"`
+}
+
+type VerifyAuditReport struct {
+	Schema          string                   `json:"schema"`
+	VerifierVersion string                   `json:"verifierVersion"`
+	GeneratedAtUnix int64                    `json:"generatedAtUnix,omitempty"`
+	Fingerprint     string                   `json:"fingerprint,omitempty"` // data dir state that was verified
+	TenantID        string                   `json:"tenantId,omitempty"`    // v0.6: tenant whose data was verified
+	Request         VerifyAuditReportRequest `json:"request"`
+	Ok              bool                     `json:"ok"`
+	Summary         VerifyAuditSummary       `json:"summary"`
+	Findings        []VerifyFinding          `json:"findings"`
+}
+
+// DataDirFingerprinter identifies the primary data a verification ran on,
+// so that two reports can be told apart by their input.
+type DataDirFingerprinter interface {
+	Fingerprint() (string, error)
+}
+
+type VerifyAuditReportUsecase interface {
+	VerifyAuditReport(in VerifyAuditRequest) (VerifyAuditReport, error)
+}
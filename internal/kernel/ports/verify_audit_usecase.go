@@ -6,12 +6,25 @@ type VerifyAuditRequest struct {
 
 	// If true, verify ContentHash matches the audit event payload for version.created.
 	StrictHash bool
+
+	// v0.6: if true, also verify the audit hash chain (seq/prevHash/hash).
+	Chain bool
+
+	// v0.6: if true, verify Ed25519 signatures against the key registry.
+	Signatures bool
+
+	// v0.6: if true, report events of unregistered types and payloads that
+	// do not match their registered type (see domain.RegisterAuditEventType).
+	Payloads bool
 }
 
 type MissingAudit struct {
 	UnitID    string
 	VersionID string // empty for unit.created checks
 	EventType string
+	Branch    string // v0.6: branch.created / branch.deleted checks
+	Tag       string // v0.6: TAG_CREATED checks
+	Revision  int    // v0.6: unit.updated checks
 }
 
 type DuplicateAudit struct {
@@ -26,6 +39,127 @@ type HashMismatch struct {
 	EventHash    string
 }
 
+// Chain break kinds reported by chain verification.
+const (
+	ChainBreakGap       = "gap"       // one or more sequence numbers are missing
+	ChainBreakReorder   = "reorder"   // event appears after a later sequence number
+	ChainBreakEdited    = "edited"    // stored hash or prevHash does not match content
+	ChainBreakUnchained = "unchained" // event without seq/hash after the chain started
+	ChainBreakTruncated = "truncated" // log ends before (or starts after) the recorded chain
+)
+
+type ChainBreak struct {
+	Kind     string
+	Position int64 // 1-based position of the event in log order (0 = end of log)
+	Seq      int64
+	EventID  string
+	Detail   string
+}
+
+// Signature finding kinds reported by signature verification.
+const (
+	SignatureUnsigned      = "unsigned"       // event carries no signature
+	SignatureBad           = "bad_signature"  // signature does not verify
+	SignatureUnknownKey    = "unknown_key"    // keyId is not in the registry
+	SignatureRevokedKey    = "revoked_key"    // signed after the key was revoked
+	SignatureActorMismatch = "actor_mismatch" // key belongs to another actor
+)
+
+type SignatureFinding struct {
+	Kind      string
+	Position  int64
+	EventID   string
+	EventType string
+	ActorID   string
+	KeyID     string
+}
+
+// OrphanEvent is an event about a unit, version or claim relation the repo
+// does not have.
+type OrphanEvent struct {
+	Position  int64
+	EventID   string
+	EventType string
+	UnitID    string
+	VersionID string
+	Detail    string
+}
+
+// LineageMismatch: the version.created (or version.reverted /
+// version.merged) event records other parents than the stored version.
+type LineageMismatch struct {
+	UnitID             string
+	VersionID          string
+	RepoPrevVersionID  string
+	EventPrevVersionID string
+
+	// v0.6: second parent of merge versions
+	RepoMergeParentID  string
+	EventMergeParentID string
+}
+
+// HeadMismatch: the unit head is not the last version of the unit (of its
+// main branch), or the head of a named branch has a successor on the
+// branch (LastVersionID) or is unknown.
+type HeadMismatch struct {
+	UnitID        string
+	HeadVersionID string
+	LastVersionID string
+	Branch        string // v0.6: "" for the unit head
+}
+
+// TagMismatch: a TAG_CREATED event names another version than the repo's
+// tag of that name (RepoVersionID is empty if the repo has no such tag).
+// Tags never move, so either the repo or the log was changed.
+type TagMismatch struct {
+	UnitID         string
+	Tag            string
+	RepoVersionID  string
+	EventVersionID string
+	EventID        string
+}
+
+// UnitRevisionMismatch: a unit.created or unit.updated event records other
+// metadata for a revision than the repo's history (Repo* are empty if the
+// repo has no such revision). A unit.created event only carries the title.
+type UnitRevisionMismatch struct {
+	UnitID           string
+	Revision         int
+	RepoTitle        string
+	EventTitle       string
+	RepoDescription  string
+	EventDescription string
+	EventID          string
+}
+
+// TimeOrderViolation: an event about a unit is dated earlier than the
+// preceding event about the same unit.
+type TimeOrderViolation struct {
+	Position    int64
+	EventID     string
+	EventType   string
+	UnitID      string
+	AtUnix      int64
+	PrevEventID string
+	PrevAtUnix  int64
+}
+
+// Payload issue kinds reported by payload verification.
+const (
+	PayloadUnknownType = "unknown_type" // event type is not registered
+	PayloadMalformed   = "malformed"    // payload does not decode or validate
+)
+
+type PayloadIssue struct {
+	Kind      string
+	Position  int64
+	EventID   string
+	EventType string
+	UnitID    string
+	VersionID string
+	Detail    string
+}
+
 type VerifyAuditResponse struct {
 	TotalUnits    int
 	TotalVersions int
@@ -34,6 +168,35 @@ type VerifyAuditResponse struct {
 	Duplicates     []DuplicateAudit
 	HashMismatches []HashMismatch
 
+	// v0.6: consistency of the events with the repo's lineage
+	OrphanEvents        []OrphanEvent
+	LineageMismatches   []LineageMismatch
+	HeadMismatches      []HeadMismatch
+	TimeOrderViolations []TimeOrderViolation
+	TagMismatches       []TagMismatch
+	RevisionMismatches  []UnitRevisionMismatch
+
+	// v0.6: chain verification (only populated if Chain=true)
+	ChainChecked bool
+	ChainLength  int64 // number of chained events
+	LegacyEvents int64 // unchained events before the genesis point
+	ChainBreaks  []ChainBreak
+
+	// v0.6: signature verification (only populated if Signatures=true)
+	SignaturesChecked bool
+	SignedEvents      int64
+	SignatureFindings []SignatureFinding
+
+	// v0.6: payload verification (only populated if Payloads=true)
+	PayloadsChecked bool
+	PayloadIssues   []PayloadIssue
+
+	// v0.6: sealed segment check (only populated if the usecase has a
+	// segment checker); a missing segment stops verification there
+	SegmentsChecked bool
+	SealedSegments  int
+	SegmentFindings []AuditSegmentFinding
+
 	Ok bool
 }
 
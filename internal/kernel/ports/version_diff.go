@@ -0,0 +1,63 @@
+package ports
+
+import "digiemu-core/internal/kernel/domain"
+
+// v0.6: differences between two versions of a unit.
+
+type DiffVersionsRequest struct {
+	UnitKey       string // key or id
+	FromVersionID string // version id or tag; default: the predecessor of To (empty content for the first version)
+	ToVersionID   string // version id or tag; default: the head
+	Context       int    // unchanged lines around each change
+}
+
+// DiffVersionSide names one side of a diff.
+type DiffVersionSide struct {
+	VersionID       string `json:"versionId,omitempty"` // "" for the empty side before the first version
+	Label           string `json:"label,omitempty"`
+	ActorID         string `json:"actorId,omitempty"`
+	CreatedAtUnix   int64  `json:"createdAtUnix,omitempty"`
+	ContentHash     string `json:"contentHash,omitempty"`
+	MeaningHash     string `json:"meaningHash,omitempty"`
+	ClaimSetHash    string `json:"claimSetHash,omitempty"`
+	UncertaintyHash string `json:"uncertaintyHash,omitempty"`
+}
+
+// DiffFieldChange is a changed metadata field ("label", "actorId").
+type DiffFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type DiffVersionsResponse struct {
+	UnitID  string          `json:"unitId"`
+	UnitKey string          `json:"unitKey"`
+	From    DiffVersionSide `json:"from"`
+	To      DiffVersionSide `json:"to"`
+
+	ContentChanged bool              `json:"contentChanged"`
+	Added          int               `json:"added"`
+	Removed        int               `json:"removed"`
+	Hunks          []domain.DiffHunk `json:"hunks"`
+
+	Metadata           []DiffFieldChange `json:"metadata"`
+	MeaningChanged     bool              `json:"meaningChanged"`
+	ClaimSetChanged    bool              `json:"claimSetChanged"`
+	UncertaintyChanged bool              `json:"uncertaintyChanged"`
+}
+
+// Unified renders the content diff (empty if the content is unchanged).
+func (r DiffVersionsResponse) Unified() string {
+	name := func(s DiffVersionSide) string {
+		if s.VersionID == "" {
+			return "/dev/null"
+		}
+		return s.VersionID + " (" + s.Label + ")"
+	}
+	return domain.FormatUnifiedDiff(name(r.From), name(r.To), r.Hunks)
+}
+
+type DiffVersionsUsecase interface {
+	DiffVersions(in DiffVersionsRequest) (DiffVersionsResponse, error)
+}
@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// AnchorAudit anchors the Merkle root over the whole audit log in every
+// sink of Anchors, and verifies the log against the anchors stored there.
+//
+// Roots are computed from the log itself, not taken from the Merkle store,
+// so verification does not trust anything inside the data dir.
+type AnchorAudit struct {
+	Audit   ports.AuditLogReader
+	Anchors []ports.Anchor
+	Clock   ports.Clock
+}
+
+// AnchorAudit stores an anchor for the current log in each sink, unless the
+// sink's latest anchor already covers it. A sink whose latest anchor does
+// not match the log is refused: anchoring on top of a rewritten log would
+// bless the rewrite.
+func (uc AnchorAudit) AnchorAudit() (ports.AnchorAuditResponse, error) {
+	if uc.Clock == nil {
+		return ports.AnchorAuditResponse{}, domain.ErrClockNotConfigured
+	}
+	if len(uc.Anchors) == 0 {
+		return ports.AnchorAuditResponse{}, fmt.Errorf("no anchor configured")
+	}
+	leaves, lastEventID, err := uc.leaves()
+	if err != nil {
+		return ports.AnchorAuditResponse{}, err
+	}
+	if len(leaves) == 0 {
+		return ports.AnchorAuditResponse{}, fmt.Errorf("nothing to anchor: audit log is empty")
+	}
+	size := int64(len(leaves))
+	root := hex.EncodeToString(domain.MerkleRoot(leaves))
+
+	var out ports.AnchorAuditResponse
+	for _, sink := range uc.Anchors {
+		stored, err := sink.ListAnchors()
+		if err != nil {
+			return out, fmt.Errorf("anchor %s: %w", sink.Name(), err)
+		}
+		if len(stored) > 0 {
+			latest := stored[len(stored)-1]
+			if latest.TreeSize > size {
+				return out, fmt.Errorf("anchor %s: %w: latest anchor covers %d events, log has %d", sink.Name(), domain.ErrAuditAnchorMismatch, latest.TreeSize, size)
+			}
+			if hex.EncodeToString(domain.MerkleRoot(leaves[:latest.TreeSize])) != latest.RootHash {
+				return out, fmt.Errorf("anchor %s: %w: root of the first %d events differs", sink.Name(), domain.ErrAuditAnchorMismatch, latest.TreeSize)
+			}
+			if latest.TreeSize == size {
+				out.Anchors = append(out.Anchors, latest)
+				out.Anchored = append(out.Anchored, false)
+				continue
+			}
+		}
+
+		a, err := sink.Anchor(domain.NewAuditAnchor(size, root, lastEventID, uc.Clock.NowUnix()))
+		if err != nil {
+			return out, fmt.Errorf("anchor %s: %w", sink.Name(), err)
+		}
+		out.Anchors = append(out.Anchors, a)
+		out.Anchored = append(out.Anchored, true)
+	}
+	return out, nil
+}
+
+// VerifyAnchors checks every stored anchor: its digest, its receipt and
+// that the first TreeSize events of the log still have its root.
+func (uc AnchorAudit) VerifyAnchors() (ports.VerifyAnchorsResponse, error) {
+	if len(uc.Anchors) == 0 {
+		return ports.VerifyAnchorsResponse{}, fmt.Errorf("no anchor configured")
+	}
+	leaves, _, err := uc.leaves()
+	if err != nil {
+		return ports.VerifyAnchorsResponse{}, err
+	}
+
+	out := ports.VerifyAnchorsResponse{Events: int64(len(leaves))}
+	for _, sink := range uc.Anchors {
+		stored, err := sink.ListAnchors()
+		if err != nil {
+			return out, fmt.Errorf("anchor %s: %w", sink.Name(), err)
+		}
+		for _, a := range stored {
+			out.Checked++
+			finding := func(kind, detail string) {
+				out.Findings = append(out.Findings, ports.AnchorFinding{
+					Kind: kind, Sink: sink.Name(), TreeSize: a.TreeSize, AtUnix: a.AtUnix, Detail: detail,
+				})
+			}
+
+			if a.Schema != domain.AuditAnchorSchema || a.TreeSize <= 0 ||
+				a.Digest != hex.EncodeToString(domain.AuditAnchorDigest(a.TreeSize, a.RootHash)) {
+				finding(ports.AnchorMalformed, "schema, size or digest does not match the anchored root")
+				continue
+			}
+			if err := sink.CheckAnchor(a); err != nil {
+				finding(ports.AnchorReceiptInvalid, err.Error())
+				continue
+			}
+			if a.TreeSize > out.Events {
+				finding(ports.AnchorBeyondLog, fmt.Sprintf("anchored %d events, log has %d", a.TreeSize, out.Events))
+				continue
+			}
+			if root := hex.EncodeToString(domain.MerkleRoot(leaves[:a.TreeSize])); root != a.RootHash {
+				finding(ports.AnchorMismatch, fmt.Sprintf("anchored root %s, log has %s", a.RootHash, root))
+				continue
+			}
+
+			if a.TreeSize > out.Latest.TreeSize || (a.TreeSize == out.Latest.TreeSize && a.AtUnix > out.Latest.AtUnix) {
+				out.Latest = a
+			}
+		}
+	}
+	out.Ok = len(out.Findings) == 0
+	return out, nil
+}
+
+// leaves returns the Merkle leaf hashes of the log and the id of its last event.
+func (uc AnchorAudit) leaves() ([][]byte, string, error) {
+	if uc.Audit == nil {
+		return nil, "", fmt.Errorf("audit reader not configured")
+	}
+	var (
+		leaves [][]byte
+		last   string
+	)
+	err := uc.Audit.Scan(func(ev domain.AuditEvent) error {
+		leaf, err := domain.AuditEventLeafHash(ev)
+		if err != nil {
+			return err
+		}
+		b, _ := hex.DecodeString(leaf)
+		leaves = append(leaves, b)
+		last = ev.ID
+		return nil
+	})
+	return leaves, last, err
+}
@@ -0,0 +1,250 @@
+package usecases
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// AuditMerkle maintains the Merkle tree over the audit log (RFC 6962) and
+// produces signed tree heads plus inclusion / consistency proofs.
+//
+// Leaves follow AuditLogReader.Scan order. Stored leaves are compared with
+// the log on every call, so a rewritten or truncated log is reported instead
+// of silently producing a new tree.
+type AuditMerkle struct {
+	Audit  ports.AuditLogReader
+	Merkle ports.AuditMerkleStore
+	Clock  ports.Clock
+
+	// optional: unsigned heads are published if nil or no key is available
+	Signer ports.TreeHeadSigner
+}
+
+// PublishTreeHead syncs the leaves and publishes a head for the whole log,
+// unless the latest head already covers it.
+func (uc AuditMerkle) PublishTreeHead() (ports.PublishTreeHeadResponse, error) {
+	leaves, err := uc.syncLeaves(nil)
+	if err != nil {
+		return ports.PublishTreeHeadResponse{}, err
+	}
+	return uc.publish(leaves)
+}
+
+func (uc AuditMerkle) InclusionProof(in ports.InclusionProofRequest) (domain.InclusionProof, error) {
+	var (
+		index = int64(-1)
+		event string
+	)
+	leaves, err := uc.syncLeaves(func(i int64, ev domain.AuditEvent) error {
+		if index >= 0 || ev.ID != in.EventID {
+			return nil
+		}
+		b, err := domain.CanonicalAuditEventJSON(ev)
+		if err != nil {
+			return err
+		}
+		index, event = i, string(b)
+		return nil
+	})
+	if err != nil {
+		return domain.InclusionProof{}, err
+	}
+	if index < 0 {
+		return domain.InclusionProof{}, domain.ErrAuditEventNotFound
+	}
+
+	head, err := uc.headForSize(leaves, in.TreeSize, in.TreeSize == 0)
+	if err != nil {
+		return domain.InclusionProof{}, err
+	}
+	if index >= head.TreeSize {
+		return domain.InclusionProof{}, fmt.Errorf("%w: event %d not in tree of size %d", domain.ErrMerkleTreeSize, index, head.TreeSize)
+	}
+
+	path, err := domain.MerkleInclusionPath(leaves[:head.TreeSize], int(index))
+	if err != nil {
+		return domain.InclusionProof{}, err
+	}
+	return domain.InclusionProof{
+		Schema:    domain.InclusionProofSchema,
+		EventID:   in.EventID,
+		Event:     event,
+		LeafIndex: index,
+		LeafHash:  hex.EncodeToString(leaves[index]),
+		AuditPath: domain.MerkleHexList(path),
+		TreeHead:  head,
+	}, nil
+}
+
+func (uc AuditMerkle) ConsistencyProof(in ports.ConsistencyProofRequest) (domain.ConsistencyProof, error) {
+	leaves, err := uc.syncLeaves(nil)
+	if err != nil {
+		return domain.ConsistencyProof{}, err
+	}
+
+	newHead, err := uc.headForSize(leaves, in.NewSize, in.NewSize == 0)
+	if err != nil {
+		return domain.ConsistencyProof{}, err
+	}
+	if in.OldSize < 0 || in.OldSize > newHead.TreeSize {
+		return domain.ConsistencyProof{}, fmt.Errorf("%w: old size %d, new size %d", domain.ErrMerkleTreeSize, in.OldSize, newHead.TreeSize)
+	}
+	oldHead, err := uc.headForSize(leaves, in.OldSize, false)
+	if err != nil {
+		return domain.ConsistencyProof{}, err
+	}
+
+	proof, err := domain.MerkleConsistencyProof(leaves[:newHead.TreeSize], int(in.OldSize))
+	if err != nil {
+		return domain.ConsistencyProof{}, err
+	}
+
+	out := domain.ConsistencyProof{
+		Schema:  domain.ConsistencyProofSchema,
+		OldSize: oldHead.TreeSize,
+		NewSize: newHead.TreeSize,
+		OldRoot: oldHead.RootHash,
+		NewRoot: newHead.RootHash,
+		Proof:   domain.MerkleHexList(proof),
+	}
+	if oldHead.AtUnix != 0 {
+		out.OldTreeHead = &oldHead
+	}
+	if newHead.AtUnix != 0 {
+		out.NewTreeHead = &newHead
+	}
+	return out, nil
+}
+
+// syncLeaves compares stored leaves with the log and appends leaves for new
+// events. visit (optional) sees every event with its leaf index.
+func (uc AuditMerkle) syncLeaves(visit func(i int64, ev domain.AuditEvent) error) ([][]byte, error) {
+	if uc.Audit == nil {
+		return nil, fmt.Errorf("audit reader not configured")
+	}
+	if uc.Merkle == nil {
+		return nil, fmt.Errorf("merkle store not configured")
+	}
+
+	stored, err := uc.Merkle.ListLeaves()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		i      int64
+		hashes [][]byte
+		added  []domain.MerkleLeaf
+	)
+	err = uc.Audit.Scan(func(ev domain.AuditEvent) error {
+		leaf, err := domain.AuditEventLeafHash(ev)
+		if err != nil {
+			return err
+		}
+		if i < int64(len(stored)) {
+			if stored[i].LeafHash != leaf || stored[i].EventID != ev.ID {
+				return fmt.Errorf("%w: leaf %d (event %s)", domain.ErrMerkleLeafMismatch, i, ev.ID)
+			}
+		} else {
+			added = append(added, domain.MerkleLeaf{Index: i, EventID: ev.ID, LeafHash: leaf})
+		}
+		b, _ := hex.DecodeString(leaf)
+		hashes = append(hashes, b)
+
+		if visit != nil {
+			if err := visit(i, ev); err != nil {
+				return err
+			}
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(stored)) > i {
+		return nil, fmt.Errorf("%w: log has %d events, %d leaves stored", domain.ErrMerkleLeafMismatch, i, len(stored))
+	}
+
+	if len(added) > 0 {
+		if err := uc.Merkle.AppendLeaves(added); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+func (uc AuditMerkle) publish(leaves [][]byte) (ports.PublishTreeHeadResponse, error) {
+	if uc.Clock == nil {
+		return ports.PublishTreeHeadResponse{}, domain.ErrClockNotConfigured
+	}
+
+	root := hex.EncodeToString(domain.MerkleRoot(leaves))
+	size := int64(len(leaves))
+
+	heads, err := uc.Merkle.ListTreeHeads()
+	if err != nil {
+		return ports.PublishTreeHeadResponse{}, err
+	}
+	if len(heads) > 0 {
+		latest := heads[len(heads)-1]
+		if latest.TreeSize == size && latest.RootHash == root {
+			return ports.PublishTreeHeadResponse{TreeHead: latest}, nil
+		}
+		if latest.TreeSize >= size {
+			return ports.PublishTreeHeadResponse{}, fmt.Errorf("%w: latest tree head has size %d, log has %d", domain.ErrMerkleLeafMismatch, latest.TreeSize, size)
+		}
+	}
+
+	th := domain.TreeHead{
+		Schema:   domain.TreeHeadSchema,
+		TreeSize: size,
+		RootHash: root,
+		AtUnix:   uc.Clock.NowUnix(),
+	}
+	if uc.Signer != nil {
+		signed, ok, err := uc.Signer.SignTreeHead(th)
+		if err != nil {
+			return ports.PublishTreeHeadResponse{}, err
+		}
+		if ok {
+			th = signed
+		}
+	}
+	if err := uc.Merkle.AppendTreeHead(th); err != nil {
+		return ports.PublishTreeHeadResponse{}, err
+	}
+	return ports.PublishTreeHeadResponse{TreeHead: th, Published: true}, nil
+}
+
+// headForSize returns the published head for size; latest=true publishes
+// (or reuses) the head for the whole log instead. Sizes without a published
+// head get an unsigned head (AtUnix=0).
+func (uc AuditMerkle) headForSize(leaves [][]byte, size int64, latest bool) (domain.TreeHead, error) {
+	if latest {
+		out, err := uc.publish(leaves)
+		return out.TreeHead, err
+	}
+	if size < 0 || size > int64(len(leaves)) {
+		return domain.TreeHead{}, fmt.Errorf("%w: size %d, log has %d events", domain.ErrMerkleTreeSize, size, len(leaves))
+	}
+
+	root := hex.EncodeToString(domain.MerkleRoot(leaves[:size]))
+	heads, err := uc.Merkle.ListTreeHeads()
+	if err != nil {
+		return domain.TreeHead{}, err
+	}
+	for i := len(heads) - 1; i >= 0; i-- {
+		if heads[i].TreeSize != size {
+			continue
+		}
+		if heads[i].RootHash != root {
+			return domain.TreeHead{}, fmt.Errorf("%w: published head for size %d has a different root", domain.ErrMerkleLeafMismatch, size)
+		}
+		return heads[i], nil
+	}
+	return domain.TreeHead{Schema: domain.TreeHeadSchema, TreeSize: size, RootHash: root}, nil
+}
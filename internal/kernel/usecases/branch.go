@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// v0.6: helpers for branch-aware usecases (see domain.MainBranch).
+
+// branchRepo returns repo as a ports.BranchRepository.
+func branchRepo(repo ports.UnitRepository) (ports.BranchRepository, error) {
+	br, ok := repo.(ports.BranchRepository)
+	if !ok {
+		return nil, fmt.Errorf("repository does not store branches")
+	}
+	return br, nil
+}
+
+// findUnit resolves a unit by key, then by id.
+func findUnit(repo ports.UnitRepository, keyOrID string) (domain.Unit, error) {
+	u, ok, err := repo.FindUnitByKey(keyOrID)
+	if err != nil {
+		return domain.Unit{}, err
+	}
+	if !ok {
+		if u, ok, err = repo.FindUnitByID(keyOrID); err != nil {
+			return domain.Unit{}, err
+		}
+	}
+	if !ok {
+		return domain.Unit{}, domain.ErrUnitNotFound
+	}
+	return u, nil
+}
+
+// branchHead returns the head of the named branch of u ("" = MainBranch).
+func branchHead(u domain.Unit, name string) (string, error) {
+	b, ok := u.FindBranch(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", domain.ErrBranchNotFound, name)
+	}
+	return b.HeadVersionID, nil
+}
+
+func branchInfo(b domain.Branch) ports.BranchInfo {
+	return ports.BranchInfo{
+		Name:          b.Name,
+		HeadVersionID: b.HeadVersionID,
+		BaseVersionID: b.BaseVersionID,
+		CreatedAtUnix: b.CreatedAtUnix,
+		ActorID:       b.ActorID,
+	}
+}
+
+// ancestors returns id and every version reachable from it through
+// PrevVersionID and MergeParentID, with their distance from id.
+func ancestors(byID map[string]domain.Version, id string) map[string]int {
+	out := map[string]int{}
+	queue := []string{id}
+	out[id] = 0
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		v, ok := byID[cur]
+		if !ok {
+			continue
+		}
+		for _, p := range []string{v.PrevVersionID, v.MergeParentID} {
+			if _, seen := out[p]; p != "" && !seen {
+				out[p] = out[cur] + 1
+				queue = append(queue, p)
+			}
+		}
+	}
+	return out
+}
+
+// mergeBase returns the common ancestor of a and b closest to both
+// ("" if the two have none).
+func mergeBase(byID map[string]domain.Version, a, b string) string {
+	da, db := ancestors(byID, a), ancestors(byID, b)
+	best, bestDist := "", -1
+	for id, d := range da {
+		if e, ok := db[id]; ok {
+			// ties go to the smaller id, so the base is deterministic
+			if dist := d + e; bestDist < 0 || dist < bestDist || (dist == bestDist && id < best) {
+				best, bestDist = id, dist
+			}
+		}
+	}
+	return best
+}
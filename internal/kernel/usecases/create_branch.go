@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// CreateBranch starts a named branch of a unit at an existing version and
+// records a branch.created event.
+type CreateBranch struct {
+	Repo  ports.UnitRepository // must also implement ports.BranchRepository
+	Audit ports.AuditLog
+	Clock ports.Clock
+
+	// optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
+}
+
+func (uc CreateBranch) CreateBranch(in ports.CreateBranchRequest) (ports.BranchResponse, error) {
+	if uc.Repo == nil {
+		return ports.BranchResponse{}, domain.ErrUnitNotFound
+	}
+	if uc.Audit == nil {
+		return ports.BranchResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.BranchResponse{}, domain.ErrClockNotConfigured
+	}
+	if _, err := branchRepo(uc.Repo); err != nil {
+		return ports.BranchResponse{}, err
+	}
+	if err := domain.ValidateBranchName(in.Name); err != nil {
+		return ports.BranchResponse{}, err
+	}
+
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.BranchResponse{}, err
+	}
+	if _, exists := unit.FindBranch(in.Name); exists {
+		return ports.BranchResponse{}, fmt.Errorf("%w: %q", domain.ErrBranchAlreadyExists, in.Name)
+	}
+
+	base := in.FromVersionID
+	if base == "" {
+		if base, err = branchHead(unit, in.FromBranch); err != nil {
+			return ports.BranchResponse{}, err
+		}
+	}
+	if base == "" {
+		return ports.BranchResponse{}, domain.ErrVersionNotFound // no version to branch from
+	}
+	v, ok, err := uc.Repo.FindVersionByID(base)
+	if err != nil {
+		return ports.BranchResponse{}, err
+	}
+	if !ok || v.UnitID != unit.ID {
+		return ports.BranchResponse{}, domain.ErrVersionNotFound
+	}
+
+	b := domain.Branch{
+		Name:          in.Name,
+		HeadVersionID: base,
+		BaseVersionID: base,
+		CreatedAtUnix: uc.Clock.NowUnix(),
+		ActorID:       actorOrUnknown(in.ActorID),
+	}
+	ev := domain.AuditEvent{
+		Schema:    "digiemu.audit.v1",
+		ID:        domain.NewID("evt"),
+		Type:      domain.AuditBranchCreated,
+		AtUnix:    b.CreatedAtUnix,
+		ActorID:   b.ActorID,
+		UnitID:    unit.ID,
+		VersionID: base,
+		Data:      domain.BranchCreatedData{Branch: b.Name, BaseVersionID: base},
+	}
+	intent := domain.Intent{Op: domain.IntentCreateBranch, UnitID: unit.ID, Branch: &b, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
+		return ports.BranchResponse{}, err
+	}
+	return ports.BranchResponse{UnitID: unit.ID, Branch: branchInfo(b)}, nil
+}
@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// CreateTag gives a version of a unit an immutable name and records a
+// TAG_CREATED event. A tag that exists is never moved.
+type CreateTag struct {
+	Repo  ports.UnitRepository // must also implement ports.TagRepository
+	Audit ports.AuditLog
+	Clock ports.Clock
+
+	// optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
+}
+
+func (uc CreateTag) CreateTag(in ports.CreateTagRequest) (ports.TagResponse, error) {
+	if uc.Repo == nil {
+		return ports.TagResponse{}, domain.ErrUnitNotFound
+	}
+	if uc.Audit == nil {
+		return ports.TagResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.TagResponse{}, domain.ErrClockNotConfigured
+	}
+	if _, err := tagRepo(uc.Repo); err != nil {
+		return ports.TagResponse{}, err
+	}
+	if err := domain.ValidateTagName(in.Name); err != nil {
+		return ports.TagResponse{}, err
+	}
+
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.TagResponse{}, err
+	}
+	if t, exists := unit.FindTag(in.Name); exists {
+		return ports.TagResponse{}, fmt.Errorf("%w: %q points to %s", domain.ErrTagAlreadyExists, in.Name, t.VersionID)
+	}
+
+	verID := in.VersionID
+	if verID == "" {
+		if verID, err = branchHead(unit, in.Branch); err != nil {
+			return ports.TagResponse{}, err
+		}
+	}
+	if verID == "" {
+		return ports.TagResponse{}, domain.ErrVersionNotFound // nothing to tag yet
+	}
+	v, ok, err := uc.Repo.FindVersionByID(verID)
+	if err != nil {
+		return ports.TagResponse{}, err
+	}
+	if !ok || v.UnitID != unit.ID {
+		return ports.TagResponse{}, domain.ErrVersionNotFound
+	}
+
+	t := domain.Tag{
+		Name:          in.Name,
+		VersionID:     v.ID,
+		CreatedAtUnix: uc.Clock.NowUnix(),
+		ActorID:       actorOrUnknown(in.ActorID),
+	}
+	ev := domain.AuditEvent{
+		Schema:    "digiemu.audit.v1",
+		ID:        domain.NewID("evt"),
+		Type:      domain.AuditTagCreated,
+		AtUnix:    t.CreatedAtUnix,
+		ActorID:   t.ActorID,
+		UnitID:    unit.ID,
+		VersionID: v.ID,
+		Data:      domain.TagCreatedData{Tag: t.Name, ContentHash: v.ContentHash},
+	}
+	intent := domain.Intent{Op: domain.IntentCreateTag, UnitID: unit.ID, Tag: &t, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
+		return ports.TagResponse{}, err
+	}
+	return ports.TagResponse{UnitID: unit.ID, Tag: tagInfo(t)}, nil
+}
+
+// tagRepo returns repo as a ports.TagRepository.
+func tagRepo(repo ports.UnitRepository) (ports.TagRepository, error) {
+	tr, ok := repo.(ports.TagRepository)
+	if !ok {
+		return nil, fmt.Errorf("repository does not store tags")
+	}
+	return tr, nil
+}
+
+func tagInfo(t domain.Tag) ports.TagInfo {
+	return ports.TagInfo{Name: t.Name, VersionID: t.VersionID, CreatedAtUnix: t.CreatedAtUnix, ActorID: t.ActorID}
+}
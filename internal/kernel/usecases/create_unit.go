@@ -10,6 +10,9 @@ type CreateUnit struct {
 	Repo  ports.UnitRepository
 	Audit ports.AuditLog
 	Clock ports.Clock
+
+	// v0.6: optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
 }
 
 // CreateUnit implements ports.CreateUnitUsecase (strict audit).
@@ -25,6 +28,10 @@ func (uc CreateUnit) CreateUnit(in ports.CreateUnitRequest) (ports.CreateUnitRes
 	if err != nil {
 		return ports.CreateUnitResponse{}, err
 	}
+	if err := domain.ValidateTenantID(in.TenantID); err != nil {
+		return ports.CreateUnitResponse{}, err
+	}
+	u.TenantID = in.TenantID
 
 	exists, err := uc.Repo.ExistsByKey(u.Key)
 	if err != nil {
@@ -34,23 +41,23 @@ func (uc CreateUnit) CreateUnit(in ports.CreateUnitRequest) (ports.CreateUnitRes
 		return ports.CreateUnitResponse{}, domain.ErrUnitAlreadyExists
 	}
 
-	if err := uc.Repo.SaveUnit(u); err != nil {
-		return ports.CreateUnitResponse{}, err
-	}
-
 	ev := domain.AuditEvent{
 		Schema:  "digiemu.audit.v1",
 		ID:      domain.NewID("evt"),
-		Type:    "unit.created",
+		Type:    domain.AuditUnitCreated,
 		AtUnix:  uc.Clock.NowUnix(),
 		ActorID: actorOrUnknown(in.ActorID),
 		UnitID:  u.ID,
 		Data: domain.UnitCreatedData{
-			Key:   u.Key,
-			Title: u.Title,
+			Key:         u.Key,
+			Title:       u.Title,
+			Description: u.Description,
+			TenantID:    u.TenantID,
 		},
 	}
-	if err := uc.Audit.Append(ev); err != nil {
+	u.CreatedAtUnix, u.ActorID = ev.AtUnix, ev.ActorID
+	intent := domain.Intent{Op: domain.IntentCreateUnit, Unit: &u, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
 		return ports.CreateUnitResponse{}, err
 	}
 
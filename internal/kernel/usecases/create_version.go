@@ -13,6 +13,9 @@ type CreateVersion struct {
 	Repo  ports.UnitRepository
 	Audit ports.AuditLog
 	Clock ports.Clock
+
+	// v0.6: optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
 }
 
 // CreateVersion implements ports.CreateVersionUsecase (strict audit).
@@ -32,8 +35,14 @@ func (uc CreateVersion) CreateVersion(in ports.CreateVersionRequest) (ports.Crea
 		return ports.CreateVersionResponse{}, domain.ErrUnitNotFound
 	}
 
+	// v0.6: versions go on top of the head of their branch
+	head, err := branchHead(unit, in.Branch)
+	if err != nil {
+		return ports.CreateVersionResponse{}, err
+	}
+
 	// optimistic locking (optional)
-	if in.BaseVersionID != "" && in.BaseVersionID != unit.HeadVersionID {
+	if in.BaseVersionID != "" && in.BaseVersionID != head {
 		return ports.CreateVersionResponse{}, domain.ErrConflict
 	}
 
@@ -42,28 +51,19 @@ func (uc CreateVersion) CreateVersion(in ports.CreateVersionRequest) (ports.Crea
 		return ports.CreateVersionResponse{}, err
 	}
 
-	v.PrevVersionID = unit.HeadVersionID
+	v.PrevVersionID = head
+	v.Branch = domain.BranchKey(in.Branch)
 	v.ActorID = actorOrUnknown(in.ActorID)
 	v.CreatedAtUnix = uc.Clock.NowUnix()
+	v.UnitRevision = unit.Revision()
 
-	// deterministic hash (content is already trimmed by domain.NewVersion)
-	canonical := v.UnitID + "\n" + v.PrevVersionID + "\n" + v.Label + "\n" + v.Content
-	sum := sha256.Sum256([]byte(canonical))
-	v.ContentHash = hex.EncodeToString(sum[:])
-
-	// state first
-	if err := uc.Repo.SaveVersion(v); err != nil {
-		return ports.CreateVersionResponse{}, err
-	}
-	if err := uc.Repo.UpdateUnitHead(unit.ID, v.ID); err != nil {
-		return ports.CreateVersionResponse{}, err
-	}
+	v.ContentHash = versionContentHash(v)
 
 	// strict audit: no "success" without journal entry
 	ev := domain.AuditEvent{
 		Schema:    "digiemu.audit.v1",
 		ID:        domain.NewID("evt"),
-		Type:      "version.created",
+		Type:      domain.AuditVersionCreated,
 		AtUnix:    v.CreatedAtUnix,
 		ActorID:   v.ActorID,
 		UnitID:    v.UnitID,
@@ -72,9 +72,12 @@ func (uc CreateVersion) CreateVersion(in ports.CreateVersionRequest) (ports.Crea
 			PrevVersionID: v.PrevVersionID,
 			ContentHash:   v.ContentHash,
 			Label:         v.Label,
+			Branch:        v.Branch,
 		},
 	}
-	if err := uc.Audit.Append(ev); err != nil {
+	// state first (SaveVersion + UpdateUnitHead), audit last
+	intent := domain.Intent{Op: domain.IntentCreateVersion, Version: &v, PrevHeadVersionID: head, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
 		return ports.CreateVersionResponse{}, err
 	}
 
@@ -85,3 +88,15 @@ func (uc CreateVersion) CreateVersion(in ports.CreateVersionRequest) (ports.Crea
 		Content:   v.Content,
 	}, nil
 }
+
+// versionContentHash is the deterministic hash of a version's lineage, label
+// and content (content is already trimmed by domain.NewVersion). The second
+// parent of a merge version is appended, so other hashes stay as they were.
+func versionContentHash(v domain.Version) string {
+	canonical := v.UnitID + "\n" + v.PrevVersionID + "\n" + v.Label + "\n" + v.Content
+	if v.MergeParentID != "" {
+		canonical += "\n" + v.MergeParentID
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// DeleteBranch removes a named branch and records a branch.deleted event.
+// The versions of the branch stay in the unit's history.
+type DeleteBranch struct {
+	Repo  ports.UnitRepository // must also implement ports.BranchRepository
+	Audit ports.AuditLog
+	Clock ports.Clock
+
+	// optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
+}
+
+func (uc DeleteBranch) DeleteBranch(in ports.DeleteBranchRequest) (ports.BranchResponse, error) {
+	if uc.Repo == nil {
+		return ports.BranchResponse{}, domain.ErrUnitNotFound
+	}
+	if uc.Audit == nil {
+		return ports.BranchResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.BranchResponse{}, domain.ErrClockNotConfigured
+	}
+	if _, err := branchRepo(uc.Repo); err != nil {
+		return ports.BranchResponse{}, err
+	}
+	if in.Name == "" || in.Name == domain.MainBranch {
+		return ports.BranchResponse{}, fmt.Errorf("%w: %q cannot be deleted", domain.ErrInvalidBranchName, in.Name)
+	}
+
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.BranchResponse{}, err
+	}
+	b, ok := unit.FindBranch(in.Name)
+	if !ok {
+		return ports.BranchResponse{}, fmt.Errorf("%w: %q", domain.ErrBranchNotFound, in.Name)
+	}
+
+	ev := domain.AuditEvent{
+		Schema:    "digiemu.audit.v1",
+		ID:        domain.NewID("evt"),
+		Type:      domain.AuditBranchDeleted,
+		AtUnix:    uc.Clock.NowUnix(),
+		ActorID:   actorOrUnknown(in.ActorID),
+		UnitID:    unit.ID,
+		VersionID: b.HeadVersionID,
+		Data:      domain.BranchDeletedData{Branch: b.Name, HeadVersionID: b.HeadVersionID},
+	}
+	intent := domain.Intent{Op: domain.IntentDeleteBranch, UnitID: unit.ID, Branch: &b, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
+		return ports.BranchResponse{}, err
+	}
+	return ports.BranchResponse{UnitID: unit.ID, Branch: branchInfo(b)}, nil
+}
@@ -0,0 +1,102 @@
+package usecases
+
+import (
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// DiffVersions compares two versions of a unit: a line diff of the content,
+// the changed metadata and which sidecar hashes differ.
+type DiffVersions struct {
+	Repo ports.UnitRepository
+}
+
+func (uc DiffVersions) DiffVersions(in ports.DiffVersionsRequest) (ports.DiffVersionsResponse, error) {
+	u, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.DiffVersionsResponse{}, err
+	}
+
+	// v0.6: either side may name a tag instead of a version id
+	toID := u.ResolveVersionRef(in.ToVersionID)
+	if toID == "" {
+		toID = u.HeadVersionID
+	}
+	to, err := uc.unitVersion(u, toID)
+	if err != nil {
+		return ports.DiffVersionsResponse{}, err
+	}
+	var from domain.Version // empty before the first version
+	fromID := u.ResolveVersionRef(in.FromVersionID)
+	if fromID == "" {
+		fromID = to.PrevVersionID
+	}
+	if fromID != "" {
+		if from, err = uc.unitVersion(u, fromID); err != nil {
+			return ports.DiffVersionsResponse{}, err
+		}
+	}
+
+	out := ports.DiffVersionsResponse{
+		UnitID:             u.ID,
+		UnitKey:            u.Key,
+		From:               diffSide(from),
+		To:                 diffSide(to),
+		ContentChanged:     from.Content != to.Content,
+		Hunks:              domain.DiffLines(domain.SplitLines(from.Content), domain.SplitLines(to.Content), in.Context),
+		Metadata:           []ports.DiffFieldChange{},
+		MeaningChanged:     from.MeaningHash != to.MeaningHash,
+		ClaimSetChanged:    from.ClaimSetHash != to.ClaimSetHash,
+		UncertaintyChanged: from.UncertaintyHash != to.UncertaintyHash,
+	}
+	if out.Hunks == nil {
+		out.Hunks = []domain.DiffHunk{}
+	}
+	for _, h := range out.Hunks {
+		for _, l := range h.Lines {
+			switch {
+			case strings.HasPrefix(l, "+"):
+				out.Added++
+			case strings.HasPrefix(l, "-"):
+				out.Removed++
+			}
+		}
+	}
+	if from.Label != to.Label {
+		out.Metadata = append(out.Metadata, ports.DiffFieldChange{Field: "label", From: from.Label, To: to.Label})
+	}
+	if from.ActorID != to.ActorID {
+		out.Metadata = append(out.Metadata, ports.DiffFieldChange{Field: "actorId", From: from.ActorID, To: to.ActorID})
+	}
+	return out, nil
+}
+
+// unitVersion loads a version of u; versions of other units are not found.
+func (uc DiffVersions) unitVersion(u domain.Unit, versionID string) (domain.Version, error) {
+	if versionID == "" {
+		return domain.Version{}, domain.ErrVersionNotFound
+	}
+	v, ok, err := uc.Repo.FindVersionByID(versionID)
+	if err != nil {
+		return domain.Version{}, err
+	}
+	if !ok || v.UnitID != u.ID {
+		return domain.Version{}, domain.ErrVersionNotFound
+	}
+	return v, nil
+}
+
+func diffSide(v domain.Version) ports.DiffVersionSide {
+	return ports.DiffVersionSide{
+		VersionID:       v.ID,
+		Label:           v.Label,
+		ActorID:         v.ActorID,
+		CreatedAtUnix:   v.CreatedAtUnix,
+		ContentHash:     v.ContentHash,
+		MeaningHash:     v.MeaningHash,
+		ClaimSetHash:    v.ClaimSetHash,
+		UncertaintyHash: v.UncertaintyHash,
+	}
+}
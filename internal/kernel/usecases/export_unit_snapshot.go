@@ -24,6 +24,37 @@ func (uc ExportUnitSnapshot) ExportUnitSnapshot(in ports.ExportUnitSnapshotReque
 		return ports.ExportUnitSnapshotResponse{}, err
 	}
 
+	head := u.HeadVersionID
+	meta, _ := u.FindRevision(u.Revision())
+	if in.VersionID != "" {
+		head = u.ResolveVersionRef(in.VersionID)
+		byID := make(map[string]domain.Version, len(vs))
+		for _, v := range vs {
+			byID[v.ID] = v
+		}
+		hv, ok := byID[head]
+		if !ok {
+			return ports.ExportUnitSnapshotResponse{}, domain.ErrVersionNotFound
+		}
+		// the metadata as it was when the version was made, so that the
+		// snapshot of a version does not change with later updates
+		meta = metadataAt(u, hv)
+		keep := ancestors(byID, head)
+		kept := vs[:0:0]
+		for _, v := range vs {
+			if _, ok := keep[v.ID]; ok {
+				kept = append(kept, v)
+			}
+		}
+		vs = kept
+	}
+
+	if in.Revision != 0 {
+		if meta, ok = u.FindRevision(in.Revision); !ok {
+			return ports.ExportUnitSnapshotResponse{}, domain.ErrUnitRevisionNotFound
+		}
+	}
+
 	outVers := make([]ports.VersionDTO, 0, len(vs))
 	for _, v := range vs {
 		outVers = append(outVers, toVersionDTO(v))
@@ -33,15 +64,23 @@ func (uc ExportUnitSnapshot) ExportUnitSnapshot(in ports.ExportUnitSnapshotReque
 		Unit: ports.UnitDTO{
 			ID:            u.ID,
 			Key:           u.Key,
-			Title:         u.Title,
-			Description:   u.Description,
-			HeadVersionID: u.HeadVersionID,
+			Title:         meta.Title,
+			Description:   meta.Description,
+			HeadVersionID: head,
+			TenantID:      u.TenantID,
 		},
 		Versions: outVers,
 	}
+	if meta.Revision > 1 {
+		resp.Revision = meta.Revision
+		for _, r := range u.History()[:meta.Revision] {
+			resp.Revisions = append(resp.Revisions, unitRevisionInfo(r))
+		}
+	}
 
 	// v0.2.5: snapshot hash over unit + versions (canonical, deterministic)
-	resp.SnapshotHash = sha256HexFromLines(snapshotCanonicalLines(resp.Unit, resp.Versions))
+	// (v0.6: and the metadata revisions)
+	resp.SnapshotHash = sha256HexFromLines(snapshotCanonicalLines(resp.Unit, resp.Revisions, resp.Versions))
 
 	if in.IncludeAudit {
 		if uc.Audit == nil {
@@ -63,3 +102,20 @@ func (uc ExportUnitSnapshot) ExportUnitSnapshot(in ports.ExportUnitSnapshotReque
 
 	return resp, nil
 }
+
+// metadataAt returns the metadata revision of u that was current when v was
+// made. Versions record it; versions made before they did keep the old
+// guess, the last revision made no later than the version's second.
+func metadataAt(u domain.Unit, v domain.Version) domain.UnitRevision {
+	if r, ok := u.FindRevision(v.UnitRevision); ok {
+		return r
+	}
+	hist := u.History()
+	meta := hist[0]
+	for _, r := range hist[1:] {
+		if r.AtUnix <= v.CreatedAtUnix {
+			meta = r
+		}
+	}
+	return meta
+}
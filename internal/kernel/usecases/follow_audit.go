@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// FollowAudit streams audit events as they are appended by polling the log.
+type FollowAudit struct {
+	Audit ports.AuditFollower
+
+	// optional; ports.DefaultAuditFollowInterval if zero
+	Interval time.Duration
+}
+
+func (uc FollowAudit) StartFollow(in ports.AuditFollowRequest) (ports.AuditCursor, error) {
+	if uc.Audit == nil {
+		return ports.AuditCursor{}, fmt.Errorf("audit follower not configured")
+	}
+	return uc.Audit.CursorAfter(in.AfterEventID)
+}
+
+func (uc FollowAudit) FollowAudit(ctx context.Context, in ports.AuditFollowRequest, cur ports.AuditCursor, fn func(ev domain.AuditEvent) error) error {
+	if uc.Audit == nil {
+		return fmt.Errorf("audit follower not configured")
+	}
+	interval := uc.Interval
+	if interval <= 0 {
+		interval = ports.DefaultAuditFollowInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		evs, next, err := uc.Audit.Poll(cur, ports.DefaultAuditPollMax)
+		if err != nil {
+			return err
+		}
+		cur = next
+		for _, ev := range evs {
+			if !matchFollowFilters(ev, in) {
+				continue
+			}
+			if err := fn(ev); err != nil {
+				return err
+			}
+		}
+		if len(evs) == ports.DefaultAuditPollMax && ctx.Err() == nil {
+			continue // more may be waiting
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+func matchFollowFilters(ev domain.AuditEvent, in ports.AuditFollowRequest) bool {
+	if in.Type != "" && domain.CanonicalAuditEventType(ev.Type) != domain.CanonicalAuditEventType(in.Type) {
+		return false
+	}
+	if in.UnitID != "" && ev.UnitID != in.UnitID {
+		return false
+	}
+	if in.VersionID != "" && ev.VersionID != in.VersionID {
+		return false
+	}
+	return true
+}
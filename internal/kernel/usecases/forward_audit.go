@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// forwardAuditBatch bounds how many events are sent between two checkpoints.
+const forwardAuditBatch = 500
+
+// ForwardAudit encodes audit events and hands them to Sink, from the start
+// of the log (the zero cursor) or after the event recorded in Checkpoint.
+// The checkpoint is saved after every batch the sink accepted, so delivery
+// is at-least-once: a crash in between repeats that batch on the next run.
+type ForwardAudit struct {
+	Audit   ports.AuditFollower
+	Encoder ports.AuditEventEncoder
+	Sink    ports.AuditSink
+
+	// optional; without it every run starts at the beginning of the log
+	Checkpoint ports.AuditExportCheckpointStore
+	Clock      ports.Clock
+
+	// optional; ports.DefaultAuditFollowInterval if zero
+	Interval time.Duration
+}
+
+func (uc ForwardAudit) ForwardAudit(ctx context.Context, in ports.ForwardAuditRequest) (ports.ForwardAuditResponse, error) {
+	if uc.Audit == nil {
+		return ports.ForwardAuditResponse{}, fmt.Errorf("audit follower not configured")
+	}
+	if uc.Encoder == nil || uc.Sink == nil {
+		return ports.ForwardAuditResponse{}, fmt.Errorf("audit export encoder or sink not configured")
+	}
+
+	cp := ports.AuditExportCheckpoint{Format: in.Format}
+	var cur ports.AuditCursor
+	if uc.Checkpoint != nil {
+		saved, ok, err := uc.Checkpoint.LoadCheckpoint()
+		if err != nil {
+			return ports.ForwardAuditResponse{}, err
+		}
+		if ok {
+			if saved.Format != in.Format {
+				return ports.ForwardAuditResponse{}, fmt.Errorf("checkpoint was written for format %q, not %q", saved.Format, in.Format)
+			}
+			cp = saved
+			if cp.LastEventID != "" {
+				if cur, err = uc.Audit.CursorAfter(cp.LastEventID); err != nil {
+					return ports.ForwardAuditResponse{}, fmt.Errorf("resume after %s: %w", cp.LastEventID, err)
+				}
+			}
+		}
+	}
+
+	interval := uc.Interval
+	if interval <= 0 {
+		interval = ports.DefaultAuditFollowInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	out := ports.ForwardAuditResponse{LastEventID: cp.LastEventID}
+	for {
+		evs, next, err := uc.Audit.Poll(cur, forwardAuditBatch)
+		if err != nil {
+			return out, err
+		}
+		if len(evs) > 0 {
+			if err := uc.send(evs, &cp); err != nil {
+				return out, err
+			}
+			out.Forwarded += int64(len(evs))
+			out.LastEventID = cp.LastEventID
+		}
+		cur = next
+		if len(evs) == forwardAuditBatch && ctx.Err() == nil {
+			continue // more may be waiting
+		}
+
+		if !in.Follow {
+			return out, nil
+		}
+		select {
+		case <-ctx.Done():
+			return out, nil
+		case <-t.C:
+		}
+	}
+}
+
+// send encodes and sends one batch, then advances and saves cp.
+func (uc ForwardAudit) send(evs []domain.AuditEvent, cp *ports.AuditExportCheckpoint) error {
+	records := make([][]byte, 0, len(evs))
+	for _, ev := range evs {
+		rec, err := uc.Encoder.EncodeAuditEvent(ev)
+		if err != nil {
+			return fmt.Errorf("encode event %s: %w", ev.ID, err)
+		}
+		records = append(records, rec)
+	}
+	if err := uc.Sink.Send(records); err != nil {
+		return err
+	}
+	cp.LastEventID = evs[len(evs)-1].ID
+	cp.Forwarded += int64(len(evs))
+	if uc.Checkpoint == nil {
+		return nil
+	}
+	if uc.Clock != nil {
+		cp.UpdatedAtUnix = uc.Clock.NowUnix()
+	}
+	return uc.Checkpoint.SaveCheckpoint(*cp)
+}
@@ -0,0 +1,269 @@
+package usecases
+
+import (
+	"fmt"
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// Fsck checks the consistency of a data directory: storage-level findings
+// come from the DataDirChecker, lineage findings (heads and PrevVersionID
+// chains) are derived here from the versions it returns.
+//
+// With Repair=true every derived index that has repairable findings is
+// rebuilt from primary data and a fsck.repaired event is appended per
+// rebuild. Primary history (units, versions, sidecars, audit log) is never
+// modified. The response reports the findings of a re-check after repairs.
+type Fsck struct {
+	Storage ports.DataDirChecker
+	Audit   ports.AuditLog // required only if Repair=true
+	Clock   ports.Clock    // required only if Repair=true
+}
+
+func (uc Fsck) Fsck(in ports.FsckRequest) (ports.FsckResponse, error) {
+	if uc.Storage == nil {
+		return ports.FsckResponse{}, fmt.Errorf("data dir checker not configured")
+	}
+	if in.Repair {
+		if uc.Audit == nil {
+			return ports.FsckResponse{}, domain.ErrAuditNotConfigured
+		}
+		if uc.Clock == nil {
+			return ports.FsckResponse{}, domain.ErrClockNotConfigured
+		}
+	}
+
+	out := ports.FsckResponse{Schema: ports.FsckReportSchema, Repairs: []ports.FsckRepair{}}
+	findings, err := uc.check(&out)
+	if err != nil {
+		return ports.FsckResponse{}, err
+	}
+
+	if in.Repair {
+		// one rebuild per index, in the order the findings name them
+		var paths []string
+		counts := map[string]int{}
+		for _, f := range findings {
+			if !f.Repairable {
+				continue
+			}
+			if counts[f.Path] == 0 {
+				paths = append(paths, f.Path)
+			}
+			counts[f.Path]++
+		}
+		for _, p := range paths {
+			rep, err := uc.Storage.RebuildIndex(p)
+			if err != nil {
+				return ports.FsckResponse{}, fmt.Errorf("rebuild %s: %w", p, err)
+			}
+			ev := domain.AuditEvent{
+				Schema:  "digiemu.audit.v1",
+				ID:      domain.NewID("evt"),
+				Type:    domain.AuditFsckRepaired,
+				AtUnix:  uc.Clock.NowUnix(),
+				ActorID: actorOrSystem(in.ActorID),
+				Data: domain.FsckRepairData{
+					Kind:     rep.Kind,
+					Path:     rep.Path,
+					Entries:  rep.Entries,
+					Findings: counts[p],
+				},
+			}
+			if err := uc.Audit.Append(ev); err != nil {
+				return ports.FsckResponse{}, err
+			}
+			rep.EventID = ev.ID
+			out.Repairs = append(out.Repairs, rep)
+		}
+		if len(paths) > 0 {
+			if findings, err = uc.check(&out); err != nil {
+				return ports.FsckResponse{}, err
+			}
+		}
+	}
+
+	out.Findings = findings
+	out.Ok = len(findings) == 0
+	return out, nil
+}
+
+func (uc Fsck) check(out *ports.FsckResponse) ([]ports.FsckFinding, error) {
+	res, err := uc.Storage.CheckDataDir()
+	if err != nil {
+		return nil, err
+	}
+	findings := append([]ports.FsckFinding{}, res.Findings...)
+	out.Units, out.Versions = len(res.Units), 0
+	for _, u := range res.Units {
+		out.Versions += len(u.Versions)
+		findings = append(findings, checkLineage(u)...)
+	}
+	return findings, nil
+}
+
+// checkLineage verifies the version graph of a unit. Versions are grouped by
+// their branch; each branch is one linear chain: every PrevVersionID (and
+// MergeParentID) resolves within the unit, no version has two successors on
+// its branch, and a named branch leaves another branch only once, at its
+// BaseVersionID. Main has a single root, every head is a version of its
+// branch (or a branch's base while it has no versions), and there is no
+// cycle.
+func checkLineage(u ports.FsckUnit) []ports.FsckFinding {
+	unitID := u.Unit.ID
+	var out []ports.FsckFinding
+	add := func(kind, versionID, detail string) {
+		out = append(out, ports.FsckFinding{Kind: kind, UnitID: unitID, VersionID: versionID, Detail: detail})
+	}
+
+	byID := make(map[string]domain.Version, len(u.Versions))
+	for _, v := range u.Versions {
+		byID[v.ID] = v
+	}
+	branches := map[string]domain.Branch{}
+	for _, b := range u.Unit.Branches {
+		branches[b.Name] = b
+	}
+
+	switch {
+	case u.Unit.HeadVersionID == "" && len(u.Versions) > 0:
+		add(ports.FsckHeadMissing, "", fmt.Sprintf("unit has %d versions but no head", len(u.Versions)))
+	case u.Unit.HeadVersionID != "":
+		if v, ok := byID[u.Unit.HeadVersionID]; !ok {
+			add(ports.FsckHeadDangling, u.Unit.HeadVersionID, "head is not a version of the unit")
+		} else if v.Branch != "" {
+			add(ports.FsckHeadDangling, v.ID, "head is a version of branch "+v.Branch)
+		}
+	}
+	for _, b := range u.Unit.Branches {
+		if b.BaseVersionID != "" {
+			if _, ok := byID[b.BaseVersionID]; !ok {
+				add(ports.FsckPrevDangling, b.BaseVersionID, "base of branch "+b.Name+" not found")
+			}
+		}
+		if b.HeadVersionID == "" || b.HeadVersionID == b.BaseVersionID {
+			continue
+		}
+		if v, ok := byID[b.HeadVersionID]; !ok {
+			add(ports.FsckHeadDangling, b.HeadVersionID, "head of branch "+b.Name+" is not a version of the unit")
+		} else if v.Branch != b.Name {
+			add(ports.FsckHeadDangling, v.ID, "head of branch "+b.Name+" is a version of "+domain.BranchName(v.Branch))
+		}
+	}
+
+	// successors[branch][prev]: versions of branch building on prev; a
+	// version building on another branch (or on nothing) enters its branch
+	successors := map[string]map[string][]string{}
+	entries := map[string][]string{}
+	for _, v := range u.Versions {
+		if v.MergeParentID != "" {
+			if _, ok := byID[v.MergeParentID]; !ok {
+				add(ports.FsckPrevDangling, v.ID, "merge parent "+v.MergeParentID+" not found")
+			}
+		}
+		if v.PrevVersionID == "" {
+			entries[v.Branch] = append(entries[v.Branch], v.ID)
+			continue
+		}
+		p, ok := byID[v.PrevVersionID]
+		if !ok {
+			add(ports.FsckPrevDangling, v.ID, "prev version "+v.PrevVersionID+" not found")
+			continue
+		}
+		if p.Branch != v.Branch {
+			entries[v.Branch] = append(entries[v.Branch], v.ID)
+			continue
+		}
+		if successors[v.Branch] == nil {
+			successors[v.Branch] = map[string][]string{}
+		}
+		successors[v.Branch][v.PrevVersionID] = append(successors[v.Branch][v.PrevVersionID], v.ID)
+	}
+
+	names := make([]string, 0, len(entries)+len(successors))
+	for name := range entries {
+		names = append(names, name)
+	}
+	for name := range successors {
+		if _, ok := entries[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ids := entries[name]
+		if name == "" {
+			// main starts at the unit's first version and never leaves itself
+			var roots []string
+			for _, id := range ids {
+				if p := byID[id].PrevVersionID; p != "" {
+					add(ports.FsckLineageFork, p, fmt.Sprintf("main version %s builds on branch %s", id, byID[p].Branch))
+					continue
+				}
+				roots = append(roots, id)
+			}
+			if len(roots) > 1 {
+				add(ports.FsckLineageMultiRoots, "", fmt.Sprintf("versions without predecessor: %v", roots))
+			}
+		} else {
+			b, known := branches[name]
+			for _, id := range ids {
+				// a deleted branch has no record left to say where it started
+				if p := byID[id].PrevVersionID; known && p != b.BaseVersionID {
+					add(ports.FsckLineageFork, p, fmt.Sprintf("branch %s leaves at %s, its base is %q", name, id, b.BaseVersionID))
+				}
+			}
+			if len(ids) > 1 {
+				add(ports.FsckLineageFork, byID[ids[0]].PrevVersionID, fmt.Sprintf("branch %s starts %d times: %v", name, len(ids), ids))
+			}
+		}
+
+		succ := successors[name]
+		prevs := make([]string, 0, len(succ))
+		for p := range succ {
+			prevs = append(prevs, p)
+		}
+		sort.Strings(prevs)
+		for _, p := range prevs {
+			if s := succ[p]; len(s) > 1 {
+				add(ports.FsckLineageFork, p, fmt.Sprintf("version has %d successors: %v", len(s), s))
+			}
+		}
+	}
+
+	// cycles: follow PrevVersionID from every version; each cycle is
+	// reported once, at its smallest version id
+	reported := map[string]bool{}
+	for _, v := range u.Versions {
+		seen := map[string]bool{}
+		id := v.ID
+		for id != "" && !seen[id] {
+			seen[id] = true
+			id = byID[id].PrevVersionID
+		}
+		if id == "" {
+			continue
+		}
+		// id is on a cycle; find its smallest member
+		min := id
+		for c := byID[id].PrevVersionID; c != id; c = byID[c].PrevVersionID {
+			if c < min {
+				min = c
+			}
+		}
+		if !reported[min] {
+			reported[min] = true
+			add(ports.FsckLineageCycle, min, "prev version chain loops")
+		}
+	}
+	return out
+}
+
+func actorOrSystem(s string) string {
+	if s == "" {
+		return "system"
+	}
+	return s
+}
@@ -24,6 +24,7 @@ func (uc GetUnit) GetUnit(in ports.GetUnitRequest) (ports.GetUnitResponse, error
 			Title:         u.Title,
 			Description:   u.Description,
 			HeadVersionID: u.HeadVersionID,
+			TenantID:      u.TenantID,
 		},
 	}, nil
 }
@@ -0,0 +1,249 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// runIntent applies a state change and appends its audit event. With a
+// journal configured the intent is written first, so a crash between the
+// repository writes and the audit append can be recovered (RecoverIntents),
+// and a write that fails is undone before the error is returned (see
+// abortIntent). Without a journal it behaves like the previous "state
+// first, audit last".
+func runIntent(j ports.IntentJournal, repo ports.UnitRepository, audit ports.AuditLog, in domain.Intent) error {
+	if j != nil {
+		in.Schema = domain.IntentSchema
+		in.ID = domain.NewID("int")
+		in.AtUnix = in.Event.AtUnix
+		if err := j.Begin(in); err != nil {
+			return err
+		}
+	}
+
+	if err := applyIntent(repo, in); err != nil {
+		return abortIntent(j, repo, in, err)
+	}
+	if err := audit.Append(in.Event); err != nil {
+		return abortIntent(j, repo, in, err)
+	}
+
+	if j != nil {
+		// The appended event is the commit point. A leftover journal entry
+		// is cleaned up by RecoverIntents and must not fail the operation.
+		_ = j.Commit(in.ID)
+	}
+	return nil
+}
+
+// abortIntent handles a journaled intent whose apply or audit append failed
+// with cause. Units and versions are undone now and the journal entry
+// removed, so the write the caller was told failed never shows up later;
+// when that is not possible (the undo fails, or a new version was already
+// built upon) the entry stays, marked aborted, for RecoverIntents.
+// Sidecar, branch, tag and unit metadata writes have no undo: their entry
+// is always left aborted, and RecoverIntents completes them with their
+// event rather than keep a change the log does not have.
+func abortIntent(j ports.IntentJournal, repo ports.UnitRepository, in domain.Intent, cause error) error {
+	if j == nil {
+		return cause
+	}
+	undo := canRollBack(in.Op)
+	if undo && in.Version != nil {
+		built, err := versionBuiltUpon(repo, in.Version.UnitID, in.Version.ID)
+		undo = err == nil && !built
+	}
+	if undo && rollBackIntent(repo, in) == nil {
+		_ = j.Commit(in.ID)
+		return cause
+	}
+	_ = j.Abort(in.ID)
+	return cause
+}
+
+// versionBuiltUpon reports whether another version of the unit builds on
+// versionID.
+func versionBuiltUpon(repo ports.UnitRepository, unitID, versionID string) (bool, error) {
+	vs, err := repo.ListVersionsByUnitID(unitID)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range vs {
+		if v.PrevVersionID == versionID || v.MergeParentID == versionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// canRollBack reports whether rollBackIntent undoes the writes of op.
+func canRollBack(op string) bool {
+	switch op {
+	case domain.IntentCreateUnit, domain.IntentCreateVersion, domain.IntentRevertVersion:
+		return true
+	}
+	return false
+}
+
+// rollBackIntent removes what a partially applied intent may have written.
+// Sidecar, branch, tag and unit metadata intents are only rolled back when
+// their version or unit is gone (or the tag name or revision is taken), so
+// there is nothing left to undo for them.
+func rollBackIntent(repo ports.UnitRepository, in domain.Intent) error {
+	switch in.Op {
+	case domain.IntentCreateUnit:
+		if in.Unit == nil {
+			return fmt.Errorf("intent %s: missing unit", in.ID)
+		}
+		u, found, err := repo.FindUnitByID(in.Unit.ID)
+		if err != nil || !found {
+			return err
+		}
+		rb, ok := repo.(ports.UnitRepositoryRollback)
+		if !ok {
+			return fmt.Errorf("repository cannot roll back unit %s", u.ID)
+		}
+		return rb.DeleteUnit(u.ID)
+
+	case domain.IntentCreateVersion, domain.IntentRevertVersion:
+		if in.Version == nil {
+			return fmt.Errorf("intent %s: missing version", in.ID)
+		}
+		if _, found, err := repo.FindVersionByID(in.Version.ID); err != nil || !found {
+			return err
+		}
+		rb, ok := repo.(ports.UnitRepositoryRollback)
+		if !ok {
+			return fmt.Errorf("repository cannot roll back version %s", in.Version.ID)
+		}
+		return rb.DeleteVersion(in.Version.UnitID, in.Version.ID)
+	}
+	return nil
+}
+
+// applyIntent performs the repository writes of in. It is idempotent so
+// that recovery can re-run a partially applied intent.
+func applyIntent(repo ports.UnitRepository, in domain.Intent) error {
+	switch in.Op {
+	case domain.IntentCreateUnit:
+		if in.Unit == nil {
+			return fmt.Errorf("intent %s: missing unit", in.ID)
+		}
+		if _, ok, err := repo.FindUnitByID(in.Unit.ID); err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+		return repo.SaveUnit(*in.Unit)
+
+	case domain.IntentCreateVersion, domain.IntentRevertVersion:
+		if in.Version == nil {
+			return fmt.Errorf("intent %s: missing version", in.ID)
+		}
+		v := *in.Version
+		if _, ok, err := repo.FindVersionByID(v.ID); err != nil {
+			return err
+		} else if !ok {
+			if err := repo.SaveVersion(v); err != nil {
+				return err
+			}
+		}
+		// carried-over sidecars go in before the head moves to the version
+		if in.Meaning != nil {
+			if err := repo.SaveMeaning(v.UnitID, v.ID, *in.Meaning, v.MeaningHash); err != nil {
+				return err
+			}
+		}
+		if in.ClaimSet != nil {
+			if err := repo.SaveClaimSet(v.UnitID, v.ID, *in.ClaimSet, v.ClaimSetHash); err != nil {
+				return err
+			}
+		}
+		if in.Uncertainty != nil {
+			if err := repo.SaveUncertainty(v.UnitID, v.ID, *in.Uncertainty, v.UncertaintyHash); err != nil {
+				return err
+			}
+		}
+		u, ok, err := repo.FindUnitByID(v.UnitID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrUnitNotFound
+		}
+		if v.Branch == "" {
+			if u.HeadVersionID == v.ID {
+				return nil
+			}
+			return repo.UpdateUnitHead(v.UnitID, v.ID)
+		}
+		// v0.6: versions of a named branch move that branch's head
+		b, ok := u.FindBranch(v.Branch)
+		if !ok {
+			return fmt.Errorf("%w: %q", domain.ErrBranchNotFound, v.Branch)
+		}
+		if b.HeadVersionID == v.ID {
+			return nil
+		}
+		br, err := branchRepo(repo)
+		if err != nil {
+			return err
+		}
+		b.HeadVersionID = v.ID
+		return br.SaveBranch(u.ID, b)
+
+	case domain.IntentCreateBranch, domain.IntentDeleteBranch:
+		if in.Branch == nil {
+			return fmt.Errorf("intent %s: missing branch", in.ID)
+		}
+		br, err := branchRepo(repo)
+		if err != nil {
+			return err
+		}
+		if in.Op == domain.IntentCreateBranch {
+			return br.SaveBranch(in.UnitID, *in.Branch)
+		}
+		return br.DeleteBranch(in.UnitID, in.Branch.Name)
+
+	case domain.IntentCreateTag:
+		if in.Tag == nil {
+			return fmt.Errorf("intent %s: missing tag", in.ID)
+		}
+		tr, err := tagRepo(repo)
+		if err != nil {
+			return err
+		}
+		return tr.SaveTag(in.UnitID, *in.Tag)
+
+	case domain.IntentUpdateUnit:
+		if in.UnitRevision == nil {
+			return fmt.Errorf("intent %s: missing unit revision", in.ID)
+		}
+		mr, err := unitMetadataRepo(repo)
+		if err != nil {
+			return err
+		}
+		return mr.SaveUnitRevision(in.UnitID, *in.UnitRevision)
+
+	case domain.IntentSetMeaning:
+		if in.Meaning == nil {
+			return fmt.Errorf("intent %s: missing meaning", in.ID)
+		}
+		return repo.SaveMeaning(in.UnitID, in.VersionID, *in.Meaning, in.SidecarHash)
+
+	case domain.IntentSetClaims:
+		if in.ClaimSet == nil {
+			return fmt.Errorf("intent %s: missing claimset", in.ID)
+		}
+		return repo.SaveClaimSet(in.UnitID, in.VersionID, *in.ClaimSet, in.SidecarHash)
+
+	case domain.IntentSetUncertainty:
+		if in.Uncertainty == nil {
+			return fmt.Errorf("intent %s: missing uncertainty", in.ID)
+		}
+		return repo.SaveUncertainty(in.UnitID, in.VersionID, *in.Uncertainty, in.SidecarHash)
+	}
+	return fmt.Errorf("intent %s: unknown op %q", in.ID, in.Op)
+}
@@ -0,0 +1,27 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// ListBranches lists the branches of a unit, MainBranch first.
+type ListBranches struct {
+	Repo ports.UnitRepository
+}
+
+func (uc ListBranches) ListBranches(in ports.ListBranchesRequest) (ports.ListBranchesResponse, error) {
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.ListBranchesResponse{}, err
+	}
+	out := ports.ListBranchesResponse{
+		UnitID:   unit.ID,
+		UnitKey:  unit.Key,
+		Branches: []ports.BranchInfo{{Name: domain.MainBranch, HeadVersionID: unit.HeadVersionID}},
+	}
+	for _, b := range unit.Branches {
+		out.Branches = append(out.Branches, branchInfo(b))
+	}
+	return out, nil
+}
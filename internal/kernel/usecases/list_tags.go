@@ -0,0 +1,20 @@
+package usecases
+
+import "digiemu-core/internal/kernel/ports"
+
+// ListTags lists the tags of a unit by name.
+type ListTags struct {
+	Repo ports.UnitRepository
+}
+
+func (uc ListTags) ListTags(in ports.ListTagsRequest) (ports.ListTagsResponse, error) {
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.ListTagsResponse{}, err
+	}
+	out := ports.ListTagsResponse{UnitID: unit.ID, UnitKey: unit.Key, Tags: []ports.TagInfo{}}
+	for _, t := range unit.Tags {
+		out.Tags = append(out.Tags, tagInfo(t))
+	}
+	return out, nil
+}
@@ -0,0 +1,43 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// ListUnitRevisions lists the metadata revisions of a unit, oldest first.
+type ListUnitRevisions struct {
+	Repo ports.UnitRepository
+
+	// optional; units stored before their creation was kept get the actor
+	// and time of revision 1 from their unit.created event
+	Audit ports.AuditLogByUnitReader
+}
+
+func (uc ListUnitRevisions) ListUnitRevisions(in ports.ListUnitRevisionsRequest) (ports.ListUnitRevisionsResponse, error) {
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.ListUnitRevisionsResponse{}, err
+	}
+	hist := append([]domain.UnitRevision{}, unit.History()...)
+	if first := &hist[0]; first.Revision == 1 && first.ActorID == "" && uc.Audit != nil {
+		events, err := uc.Audit.ListByUnitID(unit.ID)
+		if err != nil {
+			return ports.ListUnitRevisionsResponse{}, err
+		}
+		for _, ev := range events {
+			if ev.Type == domain.AuditUnitCreated {
+				first.ActorID = ev.ActorID
+				if first.AtUnix == 0 {
+					first.AtUnix = ev.AtUnix
+				}
+				break
+			}
+		}
+	}
+	out := ports.ListUnitRevisionsResponse{UnitID: unit.ID, UnitKey: unit.Key}
+	for _, r := range hist {
+		out.Revisions = append(out.Revisions, unitRevisionInfo(r))
+	}
+	return out, nil
+}
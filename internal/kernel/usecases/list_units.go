@@ -28,6 +28,7 @@ func (uc ListUnits) ListUnits(in ports.ListUnitsRequest) (ports.ListUnitsRespons
 			Title:         u.Title,
 			Description:   u.Description,
 			HeadVersionID: u.HeadVersionID,
+			TenantID:      u.TenantID,
 		})
 	}
 	return ports.ListUnitsResponse{Units: out}, nil
@@ -50,5 +50,7 @@ func toVersionDTO(v domain.Version) ports.VersionDTO {
 		ContentHash:   v.ContentHash,
 		CreatedAtUnix: v.CreatedAtUnix,
 		ActorID:       v.ActorID,
+		Branch:        v.Branch,
+		MergeParentID: v.MergeParentID,
 	}
 }
@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// MergeBranch merges the head of one branch into another with a three-way
+// line merge from their common ancestor. The merge version goes on top of
+// the target head, has the source head as its second parent and is
+// recorded as version.merged.
+type MergeBranch struct {
+	Repo  ports.UnitRepository // must also implement ports.BranchRepository
+	Audit ports.AuditLog
+	Clock ports.Clock
+
+	// optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
+}
+
+func (uc MergeBranch) MergeBranch(in ports.MergeBranchRequest) (ports.MergeBranchResponse, error) {
+	if uc.Repo == nil {
+		return ports.MergeBranchResponse{}, domain.ErrUnitNotFound
+	}
+	if uc.Audit == nil {
+		return ports.MergeBranchResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.MergeBranchResponse{}, domain.ErrClockNotConfigured
+	}
+	if _, err := branchRepo(uc.Repo); err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+
+	source, target := domain.BranchName(in.Source), domain.BranchName(in.Target)
+	if source == target {
+		return ports.MergeBranchResponse{}, fmt.Errorf("%w: cannot merge %q into itself", domain.ErrInvalidBranchName, source)
+	}
+
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+	theirsID, err := branchHead(unit, source)
+	if err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+	oursID, err := branchHead(unit, target)
+	if err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+
+	// optimistic locking (optional)
+	if in.BaseVersionID != "" && in.BaseVersionID != oursID {
+		return ports.MergeBranchResponse{}, domain.ErrConflict
+	}
+
+	vs, err := uc.Repo.ListVersionsByUnitID(unit.ID)
+	if err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+	byID := make(map[string]domain.Version, len(vs))
+	for _, v := range vs {
+		byID[v.ID] = v
+	}
+	if theirsID == "" {
+		return ports.MergeBranchResponse{}, fmt.Errorf("%w: %q has no versions", domain.ErrNothingToMerge, source)
+	}
+	if _, merged := ancestors(byID, oursID)[theirsID]; merged {
+		return ports.MergeBranchResponse{}, fmt.Errorf("%w: %q is already in %q", domain.ErrNothingToMerge, source, target)
+	}
+
+	baseID := mergeBase(byID, oursID, theirsID)
+	content := strings.TrimSpace(in.Content)
+	resolved := content != ""
+	if !resolved {
+		lines, conflicts := domain.MergeLines(
+			domain.SplitLines(byID[baseID].Content),
+			domain.SplitLines(byID[oursID].Content),
+			domain.SplitLines(byID[theirsID].Content),
+			target, source,
+		)
+		content = strings.Join(lines, "\n")
+		if len(conflicts) > 0 {
+			return ports.MergeBranchResponse{}, &domain.MergeConflictError{Conflicts: conflicts, Text: content}
+		}
+	}
+
+	label := in.Label
+	if strings.TrimSpace(label) == "" {
+		label = "merge " + source
+	}
+	v, err := domain.NewVersion(unit.ID, label, content)
+	if err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+	v.PrevVersionID = oursID
+	v.MergeParentID = theirsID
+	v.Branch = domain.BranchKey(target)
+	v.ActorID = actorOrUnknown(in.ActorID)
+	v.CreatedAtUnix = uc.Clock.NowUnix()
+	v.UnitRevision = unit.Revision()
+	v.ContentHash = versionContentHash(v)
+
+	ev := domain.AuditEvent{
+		Schema:    "digiemu.audit.v1",
+		ID:        domain.NewID("evt"),
+		Type:      domain.AuditVersionMerged,
+		AtUnix:    v.CreatedAtUnix,
+		ActorID:   v.ActorID,
+		UnitID:    v.UnitID,
+		VersionID: v.ID,
+		Data: domain.VersionMergedData{
+			PrevVersionID:      v.PrevVersionID,
+			MergeParentID:      v.MergeParentID,
+			MergeBaseVersionID: baseID,
+			ContentHash:        v.ContentHash,
+			Label:              v.Label,
+			Branch:             v.Branch,
+			SourceBranch:       source,
+			Resolved:           resolved,
+		},
+	}
+	intent := domain.Intent{Op: domain.IntentCreateVersion, Version: &v, PrevHeadVersionID: oursID, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+
+	return ports.MergeBranchResponse{
+		VersionID:          v.ID,
+		UnitID:             v.UnitID,
+		Branch:             target,
+		SourceBranch:       source,
+		PrevVersionID:      v.PrevVersionID,
+		MergeParentID:      v.MergeParentID,
+		MergeBaseVersionID: baseID,
+		Label:              v.Label,
+		ContentHash:        v.ContentHash,
+		Resolved:           resolved,
+	}, nil
+}
@@ -0,0 +1,221 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// PolicyGuard checks the actor's role on the unit key (see domain.Policy)
+// before it hands a write to the inner usecase. Denied attempts are
+// recorded as ACCESS_DENIED events and fail with domain.ErrAccessDenied.
+//
+// It implements the write usecase interfaces, so one guard can stand in
+// for all of them; an inner usecase that is nil is not configured.
+type PolicyGuard struct {
+	Units       ports.CreateUnitUsecase
+	Vers        ports.CreateVersionUsecase
+	Revert      ports.RevertVersionUsecase // version.create, plus the sidecar roles with CarrySidecars
+	Meaning     ports.SetMeaningUsecase
+	Claims      ports.SetClaimsUsecase
+	Uncertainty ports.SetUncertaintyUsecase
+	Branches    ports.CreateBranchUsecase
+	DelBranch   ports.DeleteBranchUsecase
+	Merge       ports.MergeBranchUsecase
+	Tags        ports.CreateTagUsecase
+	UnitUpdate  ports.UpdateUnitUsecase
+
+	Policy ports.PolicyStore
+	Repo   ports.UnitRepository // resolves unit ids to keys
+	Audit  ports.AuditLog
+	Clock  ports.Clock
+}
+
+func (g PolicyGuard) CreateUnit(in ports.CreateUnitRequest) (ports.CreateUnitResponse, error) {
+	if g.Units == nil {
+		return ports.CreateUnitResponse{}, fmt.Errorf("create unit not configured")
+	}
+	// the key the unit would get; invalid input is left to the usecase
+	key := in.Key
+	if u, err := domain.NewUnit(in.Key, in.Title, in.Description); err == nil {
+		key = u.Key
+	}
+	if err := g.authorize(in.ActorID, domain.ActionCreateUnit, key, ""); err != nil {
+		return ports.CreateUnitResponse{}, err
+	}
+	return g.Units.CreateUnit(in)
+}
+
+func (g PolicyGuard) CreateVersion(in ports.CreateVersionRequest) (ports.CreateVersionResponse, error) {
+	if g.Vers == nil {
+		return ports.CreateVersionResponse{}, fmt.Errorf("create version not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionCreateVersion, in.UnitKey); err != nil {
+		return ports.CreateVersionResponse{}, err
+	}
+	return g.Vers.CreateVersion(in)
+}
+
+func (g PolicyGuard) RevertVersion(in ports.RevertVersionRequest) (ports.RevertVersionResponse, error) {
+	if g.Revert == nil {
+		return ports.RevertVersionResponse{}, fmt.Errorf("revert version not configured")
+	}
+	actions := []string{domain.ActionCreateVersion}
+	if in.CarrySidecars {
+		// the copied sidecars are writes of their own
+		actions = append(actions, domain.ActionSetMeaning, domain.ActionSetClaims, domain.ActionSetUncertainty)
+	}
+	for _, action := range actions {
+		if err := g.authorizeUnit(in.ActorID, action, in.UnitKey); err != nil {
+			return ports.RevertVersionResponse{}, err
+		}
+	}
+	return g.Revert.RevertVersion(in)
+}
+
+func (g PolicyGuard) SetMeaning(in ports.SetMeaningRequest) (ports.SetMeaningResponse, error) {
+	if g.Meaning == nil {
+		return ports.SetMeaningResponse{}, fmt.Errorf("set meaning not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionSetMeaning, in.UnitKey); err != nil {
+		return ports.SetMeaningResponse{}, err
+	}
+	return g.Meaning.SetMeaning(in)
+}
+
+func (g PolicyGuard) SetClaims(in ports.SetClaimsRequest) (ports.SetClaimsResponse, error) {
+	if g.Claims == nil {
+		return ports.SetClaimsResponse{}, fmt.Errorf("set claims not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionSetClaims, in.UnitKey); err != nil {
+		return ports.SetClaimsResponse{}, err
+	}
+	return g.Claims.SetClaims(in)
+}
+
+func (g PolicyGuard) SetUncertainty(in ports.SetUncertaintyRequest) (ports.SetUncertaintyResponse, error) {
+	if g.Uncertainty == nil {
+		return ports.SetUncertaintyResponse{}, fmt.Errorf("set uncertainty not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionSetUncertainty, in.UnitKey); err != nil {
+		return ports.SetUncertaintyResponse{}, err
+	}
+	return g.Uncertainty.SetUncertainty(in)
+}
+
+func (g PolicyGuard) CreateBranch(in ports.CreateBranchRequest) (ports.BranchResponse, error) {
+	if g.Branches == nil {
+		return ports.BranchResponse{}, fmt.Errorf("create branch not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionCreateBranch, in.UnitKey); err != nil {
+		return ports.BranchResponse{}, err
+	}
+	return g.Branches.CreateBranch(in)
+}
+
+func (g PolicyGuard) DeleteBranch(in ports.DeleteBranchRequest) (ports.BranchResponse, error) {
+	if g.DelBranch == nil {
+		return ports.BranchResponse{}, fmt.Errorf("delete branch not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionDeleteBranch, in.UnitKey); err != nil {
+		return ports.BranchResponse{}, err
+	}
+	return g.DelBranch.DeleteBranch(in)
+}
+
+func (g PolicyGuard) MergeBranch(in ports.MergeBranchRequest) (ports.MergeBranchResponse, error) {
+	if g.Merge == nil {
+		return ports.MergeBranchResponse{}, fmt.Errorf("merge branch not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionMergeBranch, in.UnitKey); err != nil {
+		return ports.MergeBranchResponse{}, err
+	}
+	return g.Merge.MergeBranch(in)
+}
+
+func (g PolicyGuard) CreateTag(in ports.CreateTagRequest) (ports.TagResponse, error) {
+	if g.Tags == nil {
+		return ports.TagResponse{}, fmt.Errorf("create tag not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionCreateTag, in.UnitKey); err != nil {
+		return ports.TagResponse{}, err
+	}
+	return g.Tags.CreateTag(in)
+}
+
+func (g PolicyGuard) UpdateUnit(in ports.UpdateUnitRequest) (ports.UpdateUnitResponse, error) {
+	if g.UnitUpdate == nil {
+		return ports.UpdateUnitResponse{}, fmt.Errorf("update unit not configured")
+	}
+	if err := g.authorizeUnit(in.ActorID, domain.ActionUpdateUnit, in.UnitKey); err != nil {
+		return ports.UpdateUnitResponse{}, err
+	}
+	return g.UnitUpdate.UpdateUnit(in)
+}
+
+// authorizeUnit authorizes an action on an existing unit, named by key or
+// id. A unit that does not exist is checked under the given name, so the
+// not-found error only reaches actors who may write there.
+func (g PolicyGuard) authorizeUnit(actor, action, keyOrID string) error {
+	if g.Repo == nil {
+		return fmt.Errorf("policy guard: repo not configured")
+	}
+	u, ok, err := g.Repo.FindUnitByKey(keyOrID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if u, ok, err = g.Repo.FindUnitByID(keyOrID); err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return g.authorize(actor, action, keyOrID, "")
+	}
+	return g.authorize(actor, action, u.Key, u.ID)
+}
+
+func (g PolicyGuard) authorize(actor, action, unitKey, unitID string) error {
+	if g.Policy == nil {
+		return fmt.Errorf("policy guard: policy not configured")
+	}
+	p, ok, err := g.Policy.LoadPolicy()
+	if err != nil || !ok {
+		return err
+	}
+
+	actor = actorOrUnknown(actor)
+	required := domain.ActionRole(action)
+	role, _ := p.RoleOf(actor, unitKey)
+	if domain.RoleIncludes(role, required) {
+		return nil
+	}
+
+	denied := fmt.Errorf("%w: %s needs role %s on %q", domain.ErrAccessDenied, action, required, unitKey)
+	if g.Audit == nil {
+		return errors.Join(denied, domain.ErrAuditNotConfigured)
+	}
+	if g.Clock == nil {
+		return errors.Join(denied, domain.ErrClockNotConfigured)
+	}
+	ev := domain.AuditEvent{
+		Schema:  "digiemu.audit.v1",
+		ID:      domain.NewID("evt"),
+		Type:    domain.AuditAccessDenied,
+		AtUnix:  g.Clock.NowUnix(),
+		ActorID: actor,
+		UnitID:  unitID,
+		Data: domain.AccessDeniedData{
+			Action:       action,
+			UnitKey:      unitKey,
+			RequiredRole: required,
+			Role:         role,
+		},
+	}
+	if err := g.Audit.Append(ev); err != nil {
+		return errors.Join(denied, err)
+	}
+	return denied
+}
@@ -0,0 +1,174 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// QueryAudit pages through the audit events matching a query. UnitKeyPrefix
+// is resolved to unit ids via Repo (required only for it); the cursor handed
+// out wraps the adapter position together with a hash of the query, so it
+// cannot be replayed against a different one.
+type QueryAudit struct {
+	Audit ports.AuditQuery
+	Repo  ports.UnitRepository
+}
+
+func (uc QueryAudit) QueryAudit(in ports.AuditQueryRequest) (ports.AuditQueryResponse, error) {
+	if uc.Audit == nil {
+		return ports.AuditQueryResponse{}, fmt.Errorf("audit query not configured")
+	}
+	f := in.AuditQueryFilter
+	if f.FromUnix < 0 || f.ToUnix < 0 {
+		return ports.AuditQueryResponse{}, fmt.Errorf("%w: negative time bound", domain.ErrInvalidAuditQuery)
+	}
+	if f.ToUnix != 0 && f.FromUnix > f.ToUnix {
+		return ports.AuditQueryResponse{}, fmt.Errorf("%w: from %d is after to %d", domain.ErrInvalidAuditQuery, f.FromUnix, f.ToUnix)
+	}
+	if in.Limit < 0 {
+		return ports.AuditQueryResponse{}, fmt.Errorf("%w: negative limit", domain.ErrInvalidAuditQuery)
+	}
+	limit := in.Limit
+	if limit == 0 {
+		limit = ports.DefaultAuditQueryLimit
+	}
+	if limit > ports.MaxAuditQueryLimit {
+		limit = ports.MaxAuditQueryLimit
+	}
+
+	f.ActorIDs = normalizeSet(f.ActorIDs)
+	types := make([]string, len(f.Types))
+	for i, t := range f.Types {
+		types[i] = domain.CanonicalAuditEventType(strings.TrimSpace(t)) // aliases match too
+	}
+	f.Types = normalizeSet(types)
+	f.UnitIDs = normalizeSet(f.UnitIDs)
+	f.VersionID = strings.TrimSpace(f.VersionID)
+	queryHash, err := auditQueryHash(f, in.UnitKeyPrefix)
+	if err != nil {
+		return ports.AuditQueryResponse{}, err
+	}
+
+	var cur ports.AuditCursor
+	if in.Cursor != "" {
+		if cur, err = decodeAuditQueryCursor(in.Cursor, queryHash); err != nil {
+			return ports.AuditQueryResponse{}, err
+		}
+	}
+
+	if in.UnitKeyPrefix != "" {
+		ids, err := uc.unitIDsByKeyPrefix(in.UnitKeyPrefix, f.UnitIDs)
+		if err != nil {
+			return ports.AuditQueryResponse{}, err
+		}
+		if len(ids) == 0 {
+			return ports.AuditQueryResponse{Events: []domain.AuditEvent{}}, nil
+		}
+		f.UnitIDs = ids
+	}
+
+	evs, next, more, err := uc.Audit.QueryAudit(f, cur, limit)
+	if err != nil {
+		return ports.AuditQueryResponse{}, err
+	}
+	out := ports.AuditQueryResponse{Events: evs}
+	if out.Events == nil {
+		out.Events = []domain.AuditEvent{}
+	}
+	if more {
+		if out.NextCursor, err = encodeAuditQueryCursor(next, queryHash); err != nil {
+			return ports.AuditQueryResponse{}, err
+		}
+	}
+	return out, nil
+}
+
+// unitIDsByKeyPrefix returns the ids of the units whose key starts with
+// prefix, restricted to within if that is not empty.
+func (uc QueryAudit) unitIDsByKeyPrefix(prefix string, within []string) ([]string, error) {
+	if uc.Repo == nil {
+		return nil, fmt.Errorf("unit repository not configured")
+	}
+	units, err := uc.Repo.ListUnits()
+	if err != nil {
+		return nil, err
+	}
+	allowed := map[string]bool{}
+	for _, id := range within {
+		allowed[id] = true
+	}
+	var ids []string
+	for _, u := range units {
+		if strings.HasPrefix(u.Key, prefix) && (len(within) == 0 || allowed[u.ID]) {
+			ids = append(ids, u.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// normalizeSet trims, dedupes and sorts a filter list, so that equal
+// queries hash equally.
+func normalizeSet(in []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func auditQueryHash(f ports.AuditQueryFilter, unitKeyPrefix string) (string, error) {
+	b, err := json.Marshal(struct {
+		F ports.AuditQueryFilter
+		P string
+	}{f, unitKeyPrefix})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// auditQueryCursor is the payload of the opaque cursor.
+type auditQueryCursor struct {
+	Offset int64  `json:"o"`
+	Query  string `json:"q"`
+}
+
+func encodeAuditQueryCursor(cur ports.AuditCursor, queryHash string) (string, error) {
+	b, err := json.Marshal(auditQueryCursor{Offset: cur.Offset, Query: queryHash})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeAuditQueryCursor(s, queryHash string) (ports.AuditCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ports.AuditCursor{}, domain.ErrInvalidAuditCursor
+	}
+	var c auditQueryCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Offset < 0 {
+		return ports.AuditCursor{}, domain.ErrInvalidAuditCursor
+	}
+	if c.Query != queryHash {
+		return ports.AuditCursor{}, fmt.Errorf("%w: cursor belongs to a different query", domain.ErrInvalidAuditCursor)
+	}
+	return ports.AuditCursor{Offset: c.Offset}, nil
+}
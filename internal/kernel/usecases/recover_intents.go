@@ -0,0 +1,263 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// RecoverIntents completes or undoes operations left in the intent journal
+// by a crash (or a failed write). Run it on startup, before serving writes.
+//
+//   - event already in the audit log: the operation committed; only the
+//     journal entry is left behind -> rolled forward (nothing to apply)
+//   - preconditions still hold: re-apply the (idempotent) state change and
+//     append the original event -> rolled forward
+//   - the new version is already the parent of later versions: append the
+//     original event only -> rolled forward
+//   - otherwise (unit key taken, head moved, version gone, or the writer
+//     aborted it): undo partial writes -> rolled back, the original event
+//     is never appended. Sidecar, branch, tag and unit metadata writes
+//     cannot be undone, so aborted ones are planned like the others and
+//     rolled forward while their preconditions hold.
+//
+// A journal shared between processes (ports.IntentJournalLocker) is locked
+// for the whole run, so intents whose writer is still running are waited
+// for instead of being taken for abandoned.
+//
+// Every recovery action is recorded as an intent.rolled_forward /
+// intent.rolled_back audit event by the "system" actor.
+type RecoverIntents struct {
+	Repo    ports.UnitRepository
+	Audit   ports.AuditLog
+	Reader  ports.AuditLogReader
+	Journal ports.IntentJournal
+	Clock   ports.Clock
+}
+
+func (uc RecoverIntents) RecoverIntents() (ports.RecoverIntentsResponse, error) {
+	out := ports.RecoverIntentsResponse{Recovered: []ports.IntentRecovery{}}
+	if uc.Journal == nil {
+		return out, nil
+	}
+	if uc.Audit == nil {
+		return out, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return out, domain.ErrClockNotConfigured
+	}
+	if uc.Reader == nil {
+		return out, fmt.Errorf("audit reader not configured")
+	}
+
+	if l, ok := uc.Journal.(ports.IntentJournalLocker); ok {
+		unlock, err := l.LockRecovery()
+		if err != nil {
+			return out, err
+		}
+		defer unlock()
+	}
+
+	pending, err := uc.Journal.ListPending()
+	if err != nil {
+		return out, err
+	}
+	if len(pending) == 0 {
+		return out, nil
+	}
+
+	// which intent events already made it into the log?
+	logged := map[string]bool{}
+	for _, in := range pending {
+		logged[in.Event.ID] = false
+	}
+	if err := uc.Reader.Scan(func(ev domain.AuditEvent) error {
+		if _, ok := logged[ev.ID]; ok {
+			logged[ev.ID] = true
+		}
+		return nil
+	}); err != nil {
+		return out, err
+	}
+
+	for _, in := range pending {
+		rec := ports.IntentRecovery{IntentID: in.ID, Op: in.Op, EventID: in.Event.ID}
+
+		if logged[in.Event.ID] {
+			rec.Action, rec.Reason = ports.IntentActionRolledForward, "event already in audit log"
+		} else {
+			step, reason, err := uc.plan(in)
+			if err != nil {
+				return out, fmt.Errorf("recover intent %s: %w", in.ID, err)
+			}
+			if step == recoverApply {
+				if err := applyIntent(uc.Repo, in); err != nil {
+					return out, fmt.Errorf("recover intent %s: %w", in.ID, err)
+				}
+			}
+			if step == recoverRollBack {
+				if err := rollBackIntent(uc.Repo, in); err != nil {
+					return out, fmt.Errorf("recover intent %s: %w", in.ID, err)
+				}
+				rec.Action = ports.IntentActionRolledBack
+			} else {
+				if err := uc.Audit.Append(in.Event); err != nil {
+					return out, fmt.Errorf("recover intent %s: %w", in.ID, err)
+				}
+				rec.Action = ports.IntentActionRolledForward
+			}
+			rec.Reason = reason
+		}
+
+		typ := domain.AuditIntentRolledForward
+		if rec.Action == ports.IntentActionRolledBack {
+			typ = domain.AuditIntentRolledBack
+		}
+		ev := domain.AuditEvent{
+			Schema:    "digiemu.audit.v1",
+			ID:        domain.NewID("evt"),
+			Type:      typ,
+			AtUnix:    uc.Clock.NowUnix(),
+			ActorID:   "system",
+			UnitID:    in.Event.UnitID,
+			VersionID: in.Event.VersionID,
+			Data: domain.IntentRecoveryData{
+				IntentID: in.ID,
+				Op:       in.Op,
+				EventID:  in.Event.ID,
+				Reason:   rec.Reason,
+			},
+		}
+		if err := uc.Audit.Append(ev); err != nil {
+			return out, err
+		}
+		if err := uc.Journal.Commit(in.ID); err != nil {
+			return out, err
+		}
+		out.Recovered = append(out.Recovered, rec)
+	}
+	return out, nil
+}
+
+type recoverStep int
+
+const (
+	recoverApply    recoverStep = iota // re-apply state, then append the event
+	recoverAppend                      // state is complete and built upon: append only
+	recoverRollBack                    // undo partial writes, drop the event
+)
+
+// plan decides how to recover in (whose event is not in the log yet).
+func (uc RecoverIntents) plan(in domain.Intent) (recoverStep, string, error) {
+	if in.Aborted && canRollBack(in.Op) {
+		// the writer reported the failure; only a version that others
+		// already build on has to stay (and so gets its event)
+		if in.Version != nil {
+			built, err := versionBuiltUpon(uc.Repo, in.Version.UnitID, in.Version.ID)
+			if err != nil {
+				return 0, "", err
+			}
+			if built {
+				return recoverAppend, "aborted, but version already built upon", nil
+			}
+		}
+		return recoverRollBack, "aborted by its writer", nil
+	}
+	switch in.Op {
+	case domain.IntentCreateUnit:
+		if in.Unit == nil {
+			return 0, "", fmt.Errorf("missing unit")
+		}
+		u, found, err := uc.Repo.FindUnitByKey(in.Unit.Key)
+		if err != nil {
+			return 0, "", err
+		}
+		if found && u.ID != in.Unit.ID {
+			return recoverRollBack, "unit key taken by " + u.ID, nil
+		}
+		return recoverApply, "re-applied", nil
+
+	case domain.IntentCreateVersion, domain.IntentRevertVersion:
+		if in.Version == nil {
+			return 0, "", fmt.Errorf("missing version")
+		}
+		u, found, err := uc.Repo.FindUnitByID(in.Version.UnitID)
+		if err != nil {
+			return 0, "", err
+		}
+		if !found {
+			return recoverRollBack, "unit not found", nil
+		}
+		b, ok := u.FindBranch(in.Version.Branch)
+		if !ok {
+			return recoverRollBack, "branch not found", nil
+		}
+		if b.HeadVersionID == in.PrevHeadVersionID || b.HeadVersionID == in.Version.ID {
+			return recoverApply, "re-applied", nil
+		}
+		// head moved: keep the version if later versions descend from it
+		built, err := versionBuiltUpon(uc.Repo, u.ID, in.Version.ID)
+		if err != nil {
+			return 0, "", err
+		}
+		if built {
+			return recoverAppend, "version already built upon", nil
+		}
+		return recoverRollBack, "unit head moved to " + b.HeadVersionID, nil
+
+	case domain.IntentCreateBranch, domain.IntentDeleteBranch:
+		if in.Branch == nil {
+			return 0, "", fmt.Errorf("missing branch")
+		}
+		if _, found, err := uc.Repo.FindUnitByID(in.UnitID); err != nil {
+			return 0, "", err
+		} else if !found {
+			return recoverRollBack, "unit not found", nil
+		}
+		return recoverApply, "re-applied", nil
+
+	case domain.IntentCreateTag:
+		if in.Tag == nil {
+			return 0, "", fmt.Errorf("missing tag")
+		}
+		u, found, err := uc.Repo.FindUnitByID(in.UnitID)
+		if err != nil {
+			return 0, "", err
+		}
+		if !found {
+			return recoverRollBack, "unit not found", nil
+		}
+		if t, ok := u.FindTag(in.Tag.Name); ok && t.VersionID != in.Tag.VersionID {
+			return recoverRollBack, "tag taken by " + t.VersionID, nil
+		}
+		return recoverApply, "re-applied", nil
+
+	case domain.IntentUpdateUnit:
+		if in.UnitRevision == nil {
+			return 0, "", fmt.Errorf("missing unit revision")
+		}
+		u, found, err := uc.Repo.FindUnitByID(in.UnitID)
+		if err != nil {
+			return 0, "", err
+		}
+		if !found {
+			return recoverRollBack, "unit not found", nil
+		}
+		if _, _, err := domain.AppendUnitRevision(u, *in.UnitRevision); err != nil {
+			return recoverRollBack, fmt.Sprintf("unit moved to revision %d", u.Revision()), nil
+		}
+		return recoverApply, "re-applied", nil
+
+	case domain.IntentSetMeaning, domain.IntentSetClaims, domain.IntentSetUncertainty:
+		v, found, err := uc.Repo.FindVersionByID(in.VersionID)
+		if err != nil {
+			return 0, "", err
+		}
+		if !found || v.UnitID != in.UnitID {
+			return recoverRollBack, "version not found", nil
+		}
+		return recoverApply, "re-applied", nil
+	}
+	return 0, "", fmt.Errorf("unknown op %q", in.Op)
+}
@@ -0,0 +1,54 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// RegisterKey adds an actor public key to the registry and emits KEY_REGISTERED.
+type RegisterKey struct {
+	Keys  ports.ActorKeyRegistry
+	Audit ports.AuditLog
+	Clock ports.Clock
+}
+
+func (uc RegisterKey) RegisterKey(in ports.RegisterKeyRequest) (ports.RegisterKeyResponse, error) {
+	if uc.Audit == nil {
+		return ports.RegisterKeyResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.RegisterKeyResponse{}, domain.ErrClockNotConfigured
+	}
+
+	k, err := domain.NewActorKey(in.ActorID, in.PublicKey, uc.Clock.NowUnix())
+	if err != nil {
+		return ports.RegisterKeyResponse{}, err
+	}
+	if _, exists, err := uc.Keys.FindKey(k.KeyID); err != nil {
+		return ports.RegisterKeyResponse{}, err
+	} else if exists {
+		return ports.RegisterKeyResponse{}, domain.ErrKeyAlreadyExists
+	}
+
+	if err := uc.Keys.RegisterKey(k); err != nil {
+		return ports.RegisterKeyResponse{}, err
+	}
+
+	ev := domain.AuditEvent{
+		Schema:  "digiemu.audit.v1",
+		ID:      domain.NewID("evt"),
+		Type:    domain.AuditKeyRegistered,
+		AtUnix:  k.CreatedAtUnix,
+		ActorID: actorOrUnknown(in.ByActorID),
+		Data: domain.KeyRegisteredData{
+			KeyID:     k.KeyID,
+			ActorID:   k.ActorID,
+			PublicKey: k.PublicKey,
+		},
+	}
+	if err := uc.Audit.Append(ev); err != nil {
+		return ports.RegisterKeyResponse{}, err
+	}
+
+	return ports.RegisterKeyResponse{KeyID: k.KeyID, ActorID: k.ActorID}, nil
+}
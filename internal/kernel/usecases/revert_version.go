@@ -0,0 +1,156 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// RevertVersion makes an earlier version current again without rewriting
+// history: it creates a new version with the source version's content on
+// top of the head and records a version.reverted event naming the source.
+// With CarrySidecars the source's meaning, claims and uncertainty are copied
+// to the new version in the same step.
+type RevertVersion struct {
+	Repo  ports.UnitRepository
+	Audit ports.AuditLog
+	Clock ports.Clock
+
+	// optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
+}
+
+func (uc RevertVersion) RevertVersion(in ports.RevertVersionRequest) (ports.RevertVersionResponse, error) {
+	if uc.Repo == nil {
+		return ports.RevertVersionResponse{}, domain.ErrUnitNotFound
+	}
+	if uc.Audit == nil {
+		return ports.RevertVersionResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.RevertVersionResponse{}, domain.ErrClockNotConfigured
+	}
+
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.RevertVersionResponse{}, err
+	}
+	head, err := branchHead(unit, in.Branch)
+	if err != nil {
+		return ports.RevertVersionResponse{}, err
+	}
+
+	// optimistic locking (optional)
+	if in.BaseVersionID != "" && in.BaseVersionID != head {
+		return ports.RevertVersionResponse{}, domain.ErrConflict
+	}
+
+	src, ok, err := uc.Repo.FindVersionByID(in.SourceVersionID)
+	if err != nil {
+		return ports.RevertVersionResponse{}, err
+	}
+	if !ok || src.UnitID != unit.ID {
+		return ports.RevertVersionResponse{}, domain.ErrVersionNotFound
+	}
+
+	label := in.Label
+	if label == "" {
+		label = src.Label
+	}
+	v, err := domain.NewVersion(unit.ID, label, src.Content)
+	if err != nil {
+		return ports.RevertVersionResponse{}, err
+	}
+	v.PrevVersionID = head
+	v.Branch = domain.BranchKey(in.Branch)
+	v.ActorID = actorOrUnknown(in.ActorID)
+	v.CreatedAtUnix = uc.Clock.NowUnix()
+	v.UnitRevision = unit.Revision()
+	v.ContentHash = versionContentHash(v)
+
+	intent := domain.Intent{Op: domain.IntentRevertVersion, PrevHeadVersionID: head}
+	if in.CarrySidecars {
+		if err := uc.carrySidecars(src, &v, &intent); err != nil {
+			return ports.RevertVersionResponse{}, err
+		}
+	}
+
+	intent.Version = &v
+	intent.Event = domain.AuditEvent{
+		Schema:    "digiemu.audit.v1",
+		ID:        domain.NewID("evt"),
+		Type:      domain.AuditVersionReverted,
+		AtUnix:    v.CreatedAtUnix,
+		ActorID:   v.ActorID,
+		UnitID:    v.UnitID,
+		VersionID: v.ID,
+		Data: domain.VersionRevertedData{
+			PrevVersionID:   v.PrevVersionID,
+			ContentHash:     v.ContentHash,
+			Label:           v.Label,
+			SourceVersionID: src.ID,
+			MeaningHash:     v.MeaningHash,
+			ClaimSetHash:    v.ClaimSetHash,
+			UncertaintyHash: v.UncertaintyHash,
+			Branch:          v.Branch,
+		},
+	}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
+		return ports.RevertVersionResponse{}, err
+	}
+
+	return ports.RevertVersionResponse{
+		VersionID:       v.ID,
+		UnitID:          v.UnitID,
+		SourceVersionID: src.ID,
+		PrevVersionID:   v.PrevVersionID,
+		Label:           v.Label,
+		ContentHash:     v.ContentHash,
+		MeaningHash:     v.MeaningHash,
+		ClaimSetHash:    v.ClaimSetHash,
+		UncertaintyHash: v.UncertaintyHash,
+	}, nil
+}
+
+// carrySidecars loads the sidecars src has into the intent and sets their
+// hashes on v. A claimset names its version, so the copy is re-pointed to v
+// and hashed anew; meaning and uncertainty keep their hashes.
+func (uc RevertVersion) carrySidecars(src domain.Version, v *domain.Version, intent *domain.Intent) error {
+	if src.MeaningHash != "" {
+		m, ok, err := uc.Repo.LoadMeaning(src.UnitID, src.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if v.MeaningHash, err = ComputeMeaningHash(m); err != nil {
+				return err
+			}
+			intent.Meaning = &m
+		}
+	}
+	if src.ClaimSetHash != "" {
+		cs, ok, err := uc.Repo.LoadClaimSet(src.UnitID, src.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			cs.VersionID = v.ID
+			if v.ClaimSetHash, err = ComputeClaimSetHashFromStruct(cs); err != nil {
+				return err
+			}
+			intent.ClaimSet = &cs
+		}
+	}
+	if src.UncertaintyHash != "" {
+		u, ok, err := uc.Repo.LoadUncertainty(src.UnitID, src.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if v.UncertaintyHash, err = ComputeUncertaintyHashFromStruct(u); err != nil {
+				return err
+			}
+			intent.Uncertainty = &u
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// RevokeKey marks a registered key as revoked and emits KEY_REVOKED.
+// Events signed before the revocation remain valid.
+type RevokeKey struct {
+	Keys  ports.ActorKeyRegistry
+	Audit ports.AuditLog
+	Clock ports.Clock
+}
+
+func (uc RevokeKey) RevokeKey(in ports.RevokeKeyRequest) (ports.RevokeKeyResponse, error) {
+	if uc.Audit == nil {
+		return ports.RevokeKeyResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.RevokeKeyResponse{}, domain.ErrClockNotConfigured
+	}
+
+	k, ok, err := uc.Keys.FindKey(in.KeyID)
+	if err != nil {
+		return ports.RevokeKeyResponse{}, err
+	}
+	if !ok {
+		return ports.RevokeKeyResponse{}, domain.ErrKeyNotFound
+	}
+	if k.RevokedAtUnix != 0 {
+		return ports.RevokeKeyResponse{}, domain.ErrKeyRevoked
+	}
+
+	now := uc.Clock.NowUnix()
+
+	// journal first: an actor revoking its own key can still sign the revocation
+	ev := domain.AuditEvent{
+		Schema:  "digiemu.audit.v1",
+		ID:      domain.NewID("evt"),
+		Type:    domain.AuditKeyRevoked,
+		AtUnix:  now,
+		ActorID: actorOrUnknown(in.ByActorID),
+		Data:    domain.KeyRevokedData{KeyID: k.KeyID, ActorID: k.ActorID},
+	}
+	if err := uc.Audit.Append(ev); err != nil {
+		return ports.RevokeKeyResponse{}, err
+	}
+	if err := uc.Keys.RevokeKey(k.KeyID, now); err != nil {
+		return ports.RevokeKeyResponse{}, err
+	}
+
+	return ports.RevokeKeyResponse{KeyID: k.KeyID, RevokedAtUnix: now}, nil
+}
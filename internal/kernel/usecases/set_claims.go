@@ -15,6 +15,9 @@ type SetClaims struct {
 	Repo  ports.UnitRepository
 	Audit ports.AuditLog
 	Clock ports.Clock
+
+	// v0.6: optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
 }
 
 func (uc SetClaims) SetClaims(in ports.SetClaimsRequest) (ports.SetClaimsResponse, error) {
@@ -80,16 +83,10 @@ func (uc SetClaims) SetClaims(in ports.SetClaimsRequest) (ports.SetClaimsRespons
 		return ports.SetClaimsResponse{}, err
 	}
 
-	// persist via repo (persistence-only)
-	if err := uc.Repo.SaveClaimSet(unit.ID, verID, cs, ch); err != nil {
-		return ports.SetClaimsResponse{}, err
-	}
-
-	// append audit event
 	ev := domain.AuditEvent{
 		Schema:    "digiemu.audit.v1",
 		ID:        domain.NewID("evt"),
-		Type:      "CLAIM_SET",
+		Type:      domain.AuditClaimSet,
 		AtUnix:    uc.Clock.NowUnix(),
 		ActorID:   in.ActorID,
 		UnitID:    unit.ID,
@@ -98,10 +95,12 @@ func (uc SetClaims) SetClaims(in ports.SetClaimsRequest) (ports.SetClaimsRespons
 			UnitID:       unit.ID,
 			VersionID:    verID,
 			ClaimSetHash: ch,
-			ClaimSetPath: unit.ID + "." + verID + ".claimset.json",
+			ClaimSetPath: unit.ID + "/sidecars/" + verID + ".claimset.json",
 		},
 	}
-	if err := uc.Audit.Append(ev); err != nil {
+	// persist via repo (persistence-only), then append the audit event
+	intent := domain.Intent{Op: domain.IntentSetClaims, UnitID: unit.ID, VersionID: verID, ClaimSet: &cs, SidecarHash: ch, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
 		return ports.SetClaimsResponse{}, err
 	}
 
@@ -15,6 +15,9 @@ type SetMeaning struct {
 	Repo  ports.UnitRepository
 	Audit ports.AuditLog
 	Clock ports.Clock
+
+	// v0.6: optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
 }
 
 func (uc SetMeaning) SetMeaning(in ports.SetMeaningRequest) (ports.SetMeaningResponse, error) {
@@ -75,23 +78,17 @@ func (uc SetMeaning) SetMeaning(in ports.SetMeaningRequest) (ports.SetMeaningRes
 		return ports.SetMeaningResponse{}, err
 	}
 
-	// persist via repo (persistence-only)
-	if err := uc.Repo.SaveMeaning(unit.ID, verID, m, mh); err != nil {
-		return ports.SetMeaningResponse{}, err
-	}
-
-	// append audit event
 	ev := domain.AuditEvent{
 		Schema:    "digiemu.audit.v1",
 		ID:        domain.NewID("evt"),
-		Type:      "MEANING_SET",
+		Type:      domain.AuditMeaningSet,
 		AtUnix:    uc.Clock.NowUnix(),
 		ActorID:   in.ActorID,
 		UnitID:    unit.ID,
 		VersionID: verID,
 		Data: domain.MeaningSetData{
 			MeaningHash:   mh,
-			MeaningPath:   unit.ID + "." + verID + ".meaning.json",
+			MeaningPath:   unit.ID + "/sidecars/" + verID + ".meaning.json",
 			SchemaVersion: m.SchemaVersion,
 			InlinePreview: &struct {
 				Title   string `json:"title,omitempty"`
@@ -99,7 +96,9 @@ func (uc SetMeaning) SetMeaning(in ports.SetMeaningRequest) (ports.SetMeaningRes
 			}{Title: m.Title, Purpose: m.Purpose},
 		},
 	}
-	if err := uc.Audit.Append(ev); err != nil {
+	// persist via repo (persistence-only), then append the audit event
+	intent := domain.Intent{Op: domain.IntentSetMeaning, UnitID: unit.ID, VersionID: verID, Meaning: &m, SidecarHash: mh, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
 		return ports.SetMeaningResponse{}, err
 	}
 
@@ -12,6 +12,9 @@ type SetUncertainty struct {
 	Repo  ports.UnitRepository
 	Audit ports.AuditLog
 	Clock ports.Clock
+
+	// v0.6: optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
 }
 
 func (uc SetUncertainty) SetUncertainty(in ports.SetUncertaintyRequest) (ports.SetUncertaintyResponse, error) {
@@ -73,14 +76,10 @@ func (uc SetUncertainty) SetUncertainty(in ports.SetUncertaintyRequest) (ports.S
 		return ports.SetUncertaintyResponse{}, err
 	}
 
-	if err := uc.Repo.SaveUncertainty(unit.ID, verID, u, uh); err != nil {
-		return ports.SetUncertaintyResponse{}, err
-	}
-
 	ev := domain.AuditEvent{
 		Schema:    "digiemu.audit.v1",
 		ID:        domain.NewID("evt"),
-		Type:      "UNCERTAINTY_SET",
+		Type:      domain.AuditUncertaintySet,
 		AtUnix:    uc.Clock.NowUnix(),
 		ActorID:   in.ActorID,
 		UnitID:    unit.ID,
@@ -89,10 +88,11 @@ func (uc SetUncertainty) SetUncertainty(in ports.SetUncertaintyRequest) (ports.S
 			UnitID:          unit.ID,
 			VersionID:       verID,
 			UncertaintyHash: uh,
-			UncertaintyPath: unit.ID + "." + verID + ".uncertainty.json",
+			UncertaintyPath: unit.ID + "/sidecars/" + verID + ".uncertainty.json",
 		},
 	}
-	if err := uc.Audit.Append(ev); err != nil {
+	intent := domain.Intent{Op: domain.IntentSetUncertainty, UnitID: unit.ID, VersionID: verID, Uncertainty: &u, SidecarHash: uh, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
 		return ports.SetUncertaintyResponse{}, err
 	}
 
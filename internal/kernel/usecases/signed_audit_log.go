@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// SignedAuditLog is an optional layer around ports.AuditLog that signs every
+// event with the acting actor's Ed25519 key before it is appended (and
+// chained) by the inner log.
+type SignedAuditLog struct {
+	Inner  ports.AuditLog
+	Signer ports.AuditSigner
+
+	// Required rejects events whose actor has no usable key.
+	Required bool
+}
+
+func (l SignedAuditLog) Append(ev domain.AuditEvent) error {
+	if l.Inner == nil {
+		return domain.ErrAuditNotConfigured
+	}
+	if l.Signer == nil {
+		if l.Required {
+			return domain.ErrSigningKeyMissing
+		}
+		return l.Inner.Append(ev)
+	}
+	signed, ok, err := l.Signer.SignAuditEvent(ev)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if l.Required {
+			return domain.ErrSigningKeyMissing
+		}
+		return l.Inner.Append(ev)
+	}
+	return l.Inner.Append(signed)
+}
@@ -16,15 +16,26 @@ import (
 // It avoids relying on json.Marshal map iteration order.
 // Format (one record per line):
 //
+//	TENANT|<tenantID>   (v0.6: only for units of a named tenant)
 //	UNIT|<unitID>|<key>|<title>|<desc>|<headVersionID>
+//	REV|<revision>|<title>|<desc>|<actor>|<atUnix>   (v0.6: only past revision 1)
 //	VER|<id>|<label>|<prev>|<contentHash>|<actor>|<createdAtUnix>
 //	AUD|<id>|<type>|<atUnix>|<actor>|<unit>|<ver>|<dataCanonical>
-func snapshotCanonicalLines(u ports.UnitDTO, vs []ports.VersionDTO) []string {
-	lines := make([]string, 0, 1+len(vs))
+func snapshotCanonicalLines(u ports.UnitDTO, revs []ports.UnitRevisionInfo, vs []ports.VersionDTO) []string {
+	lines := make([]string, 0, 2+len(revs)+len(vs))
+	if u.TenantID != domain.DefaultTenant {
+		lines = append(lines, "TENANT|"+u.TenantID)
+	}
 	lines = append(lines, fmt.Sprintf(
 		"UNIT|%s|%s|%s|%s|%s",
 		u.ID, u.Key, u.Title, u.Description, u.HeadVersionID,
 	))
+	for _, r := range revs {
+		lines = append(lines, fmt.Sprintf(
+			"REV|%d|%s|%s|%s|%d",
+			r.Revision, r.Title, r.Description, r.ActorID, r.AtUnix,
+		))
+	}
 	for _, v := range vs {
 		lines = append(lines, fmt.Sprintf(
 			"VER|%s|%s|%s|%s|%s|%d",
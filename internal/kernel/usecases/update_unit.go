@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// UpdateUnit changes the title and/or description of a unit as a new
+// metadata revision and records a unit.updated event holding the metadata
+// before and after. Earlier revisions stay queryable (ListUnitRevisions).
+type UpdateUnit struct {
+	Repo  ports.UnitRepository // must also implement ports.UnitMetadataRepository
+	Audit ports.AuditLog
+	Clock ports.Clock
+
+	// optional write-ahead journal (see RecoverIntents)
+	Journal ports.IntentJournal
+}
+
+func (uc UpdateUnit) UpdateUnit(in ports.UpdateUnitRequest) (ports.UpdateUnitResponse, error) {
+	if uc.Repo == nil {
+		return ports.UpdateUnitResponse{}, domain.ErrUnitNotFound
+	}
+	if uc.Audit == nil {
+		return ports.UpdateUnitResponse{}, domain.ErrAuditNotConfigured
+	}
+	if uc.Clock == nil {
+		return ports.UpdateUnitResponse{}, domain.ErrClockNotConfigured
+	}
+	if _, err := unitMetadataRepo(uc.Repo); err != nil {
+		return ports.UpdateUnitResponse{}, err
+	}
+
+	unit, err := findUnit(uc.Repo, in.UnitKey)
+	if err != nil {
+		return ports.UpdateUnitResponse{}, err
+	}
+	cur := unit.Revision()
+	if in.BaseRevision != 0 && in.BaseRevision != cur {
+		return ports.UpdateUnitResponse{}, fmt.Errorf("%w: base %d, current %d", domain.ErrUnitRevisionConflict, in.BaseRevision, cur)
+	}
+
+	before := domain.UnitMetadata{Title: unit.Title, Description: unit.Description}
+	after := before
+	if in.Title != nil {
+		after.Title = strings.TrimSpace(*in.Title)
+		if len(after.Title) < 3 {
+			return ports.UpdateUnitResponse{}, domain.ErrInvalidUnitTitle
+		}
+	}
+	if in.Description != nil {
+		after.Description = strings.TrimSpace(*in.Description)
+	}
+	if after == before {
+		return ports.UpdateUnitResponse{}, domain.ErrNothingToUpdate
+	}
+
+	rev := domain.UnitRevision{
+		Revision:    cur + 1,
+		Title:       after.Title,
+		Description: after.Description,
+		AtUnix:      uc.Clock.NowUnix(),
+		ActorID:     actorOrUnknown(in.ActorID),
+	}
+	ev := domain.AuditEvent{
+		Schema:  "digiemu.audit.v1",
+		ID:      domain.NewID("evt"),
+		Type:    domain.AuditUnitUpdated,
+		AtUnix:  rev.AtUnix,
+		ActorID: rev.ActorID,
+		UnitID:  unit.ID,
+		Data:    domain.UnitUpdatedData{Revision: rev.Revision, Before: before, After: after},
+	}
+	intent := domain.Intent{Op: domain.IntentUpdateUnit, UnitID: unit.ID, UnitRevision: &rev, Event: ev}
+	if err := runIntent(uc.Journal, uc.Repo, uc.Audit, intent); err != nil {
+		return ports.UpdateUnitResponse{}, err
+	}
+	return ports.UpdateUnitResponse{UnitID: unit.ID, UnitKey: unit.Key, Revision: unitRevisionInfo(rev)}, nil
+}
+
+// unitMetadataRepo returns repo as a ports.UnitMetadataRepository.
+func unitMetadataRepo(repo ports.UnitRepository) (ports.UnitMetadataRepository, error) {
+	mr, ok := repo.(ports.UnitMetadataRepository)
+	if !ok {
+		return nil, fmt.Errorf("repository does not store unit revisions")
+	}
+	return mr, nil
+}
+
+func unitRevisionInfo(r domain.UnitRevision) ports.UnitRevisionInfo {
+	return ports.UnitRevisionInfo{
+		Revision:    r.Revision,
+		Title:       r.Title,
+		Description: r.Description,
+		AtUnix:      r.AtUnix,
+		ActorID:     r.ActorID,
+	}
+}
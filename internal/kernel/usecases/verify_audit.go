@@ -1,6 +1,7 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
 
 	"digiemu-core/internal/kernel/domain"
@@ -10,12 +11,28 @@ import (
 // VerifyAudit verifies that each Unit and Version has a corresponding audit event.
 // It detects:
 // - missing unit.created for units
-// - missing version.created for versions
+// - missing version.created (or version.reverted / version.merged) for versions
 // - duplicates (multiple events for same unit/version)
 // - optional content hash mismatch (StrictHash)
+// - events about unknown units/versions/relations
+// - prevVersionId and head mismatches, moved tags
+// - events dated before their unit's preceding event
+// - optional hash chain breaks (Chain)
+// - optional unsigned / badly signed / unknown-key events (Signatures)
+// - optional unknown event types and malformed payloads (Payloads)
+// - missing, altered or unlinked sealed segments of a rotated log (Segments)
 type VerifyAudit struct {
 	Repo  ports.UnitRepository
 	Audit ports.AuditLogReader
+
+	// v0.6: optional; lets chain verification detect truncation of the log tail
+	ChainHead ports.AuditChainHeadReader
+
+	// v0.6: required only if Signatures=true
+	Keys ports.ActorKeyRegistry
+
+	// v0.6: optional; checks the sealed segments of a rotated log
+	Segments ports.AuditSegmentChecker
 }
 
 func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAuditResponse, error) {
@@ -48,6 +65,7 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 	expectedUnitCreated := make(map[string]struct{}, len(units)) // unitID -> exists
 	expectedVersions := make(map[string]domain.Version)          // versionID -> version
 	versionToUnit := make(map[string]string)                     // versionID -> unitID
+	versionsByUnit := make(map[string][]domain.Version, len(units))
 
 	totalVersions := 0
 	for _, u := range units {
@@ -58,12 +76,37 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 			return ports.VerifyAuditResponse{}, err
 		}
 		totalVersions += len(vs)
+		versionsByUnit[u.ID] = vs
 		for _, v := range vs {
 			expectedVersions[v.ID] = v
 			versionToUnit[v.ID] = u.ID
 		}
 	}
 
+	// v0.6: sealed segments first; with one missing the log cannot be read
+	segmentFindings := []ports.AuditSegmentFinding{}
+	sealedSegments := 0
+	if uc.Segments != nil {
+		var err error
+		if segmentFindings, sealedSegments, err = uc.Segments.CheckAuditSegments(); err != nil {
+			return ports.VerifyAuditResponse{}, err
+		}
+		for _, f := range segmentFindings {
+			if f.Kind == ports.SegmentMissing {
+				return ports.VerifyAuditResponse{
+					TotalUnits:      len(units),
+					TotalVersions:   totalVersions,
+					Missing:         []ports.MissingAudit{},
+					Duplicates:      []ports.DuplicateAudit{},
+					HashMismatches:  []ports.HashMismatch{},
+					SegmentsChecked: true,
+					SealedSegments:  sealedSegments,
+					SegmentFindings: segmentFindings,
+				}, nil
+			}
+		}
+	}
+
 	// Track found events and duplicates
 	foundUnitCreated := make(map[string]int)    // unitID -> count
 	foundVersionCreated := make(map[string]int) // versionID -> count
@@ -75,79 +118,93 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 	foundUncertaintyEvent := make(map[string]int)
 	foundUncertaintyHash := make(map[string]string)
 
-	// Scan audit log
+	// Scan audit log; v0.6: payloads are read through the event registry
+	payloadIssues := []ports.PayloadIssue{}
+	var pos int64
 	if err := uc.Audit.Scan(func(ev domain.AuditEvent) error {
-		switch ev.Type {
-		case "unit.created":
+		pos++
+		payload, perr := domain.DecodeAuditPayload(ev)
+		if perr != nil && in.Payloads {
+			_, unitInScope := expectedUnitCreated[ev.UnitID]
+			_, versionInScope := expectedVersions[ev.VersionID]
+			if in.UnitKey == "" || unitInScope || versionInScope {
+				payloadIssues = append(payloadIssues, payloadIssue(pos, ev, perr))
+			}
+		}
+		switch domain.CanonicalAuditEventType(ev.Type) {
+		case domain.AuditUnitCreated:
 			if ev.UnitID != "" {
 				if _, ok := expectedUnitCreated[ev.UnitID]; ok {
 					foundUnitCreated[ev.UnitID]++
 				}
 			}
-		case "version.created":
+		case domain.AuditVersionCreated:
+			if ev.VersionID != "" {
+				if _, ok := expectedVersions[ev.VersionID]; ok {
+					foundVersionCreated[ev.VersionID]++
+					if d, ok := payload.(domain.VersionCreatedData); ok && d.ContentHash != "" {
+						foundVersionHash[ev.VersionID] = d.ContentHash
+					}
+				}
+			}
+		case domain.AuditVersionReverted:
+			// creates the version and sets the sidecars it carried over
 			if ev.VersionID != "" {
 				if _, ok := expectedVersions[ev.VersionID]; ok {
 					foundVersionCreated[ev.VersionID]++
-					// Try extract content hash if present (support both map and struct forms)
-					switch d := ev.Data.(type) {
-					case map[string]any:
-						if h, ok := d["contentHash"].(string); ok && h != "" {
-							foundVersionHash[ev.VersionID] = h
-						}
-					case domain.VersionCreatedData:
-						if d.ContentHash != "" {
-							foundVersionHash[ev.VersionID] = d.ContentHash
-						}
+					d, _ := payload.(domain.VersionRevertedData)
+					if d.ContentHash != "" {
+						foundVersionHash[ev.VersionID] = d.ContentHash
+					}
+					if d.MeaningHash != "" {
+						foundMeaningEvent[ev.VersionID]++
+						foundMeaningHash[ev.VersionID] = d.MeaningHash
+					}
+					if d.ClaimSetHash != "" {
+						foundClaimEvent[ev.VersionID]++
+						foundClaimHash[ev.VersionID] = d.ClaimSetHash
+					}
+					if d.UncertaintyHash != "" {
+						foundUncertaintyEvent[ev.VersionID]++
+						foundUncertaintyHash[ev.VersionID] = d.UncertaintyHash
 					}
 				}
 			}
-		case "MEANING_SET":
+		case domain.AuditVersionMerged:
+			if ev.VersionID != "" {
+				if _, ok := expectedVersions[ev.VersionID]; ok {
+					foundVersionCreated[ev.VersionID]++
+					if d, ok := payload.(domain.VersionMergedData); ok && d.ContentHash != "" {
+						foundVersionHash[ev.VersionID] = d.ContentHash
+					}
+				}
+			}
+		case domain.AuditMeaningSet:
 			if ev.VersionID != "" {
 				if _, ok := expectedVersions[ev.VersionID]; ok {
 					foundMeaningEvent[ev.VersionID]++
-					switch d := ev.Data.(type) {
-					case map[string]any:
-						if h, ok := d["meaning_hash"].(string); ok && h != "" {
-							foundMeaningHash[ev.VersionID] = h
-						}
-					case domain.MeaningSetData:
-						if d.MeaningHash != "" {
-							foundMeaningHash[ev.VersionID] = d.MeaningHash
-						}
+					if d, ok := payload.(domain.MeaningSetData); ok && d.MeaningHash != "" {
+						foundMeaningHash[ev.VersionID] = d.MeaningHash
 					}
 				}
 			}
-		case "CLAIM_SET":
+		case domain.AuditClaimSet:
 			if ev.VersionID != "" {
 				if _, ok := expectedVersions[ev.VersionID]; ok {
 					foundClaimEvent[ev.VersionID]++
-					switch d := ev.Data.(type) {
-					case map[string]any:
-						if h, ok := d["claimset_hash"].(string); ok && h != "" {
-							foundClaimHash[ev.VersionID] = h
-						}
-					case domain.ClaimSetData:
-						if d.ClaimSetHash != "" {
-							foundClaimHash[ev.VersionID] = d.ClaimSetHash
-						}
+					if d, ok := payload.(domain.ClaimSetData); ok && d.ClaimSetHash != "" {
+						foundClaimHash[ev.VersionID] = d.ClaimSetHash
 					}
 				}
 			}
-		case "CLAIM_RELATION_SET":
-			// presence is noted but handled later when claimset exists
-		case "UNCERTAINTY_SET":
+		case domain.AuditClaimRelationSet:
+			// checked against the stored claimset by verifyAuditLineage
+		case domain.AuditUncertaintySet:
 			if ev.VersionID != "" {
 				if _, ok := expectedVersions[ev.VersionID]; ok {
 					foundUncertaintyEvent[ev.VersionID]++
-					switch d := ev.Data.(type) {
-					case map[string]any:
-						if h, ok := d["uncertainty_hash"].(string); ok && h != "" {
-							foundUncertaintyHash[ev.VersionID] = h
-						}
-					case domain.UncertaintySetData:
-						if d.UncertaintyHash != "" {
-							foundUncertaintyHash[ev.VersionID] = d.UncertaintyHash
-						}
+					if d, ok := payload.(domain.UncertaintySetData); ok && d.UncertaintyHash != "" {
+						foundUncertaintyHash[ev.VersionID] = d.UncertaintyHash
 					}
 				}
 			}
@@ -165,17 +222,26 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 		Duplicates:     []ports.DuplicateAudit{},
 		HashMismatches: []ports.HashMismatch{},
 	}
+	if in.Payloads {
+		out.PayloadsChecked = true
+		out.PayloadIssues = payloadIssues
+	}
+	if uc.Segments != nil {
+		out.SegmentsChecked = true
+		out.SealedSegments = sealedSegments
+		out.SegmentFindings = segmentFindings
+	}
 
 	// Missing or duplicate unit.created
 	for unitID := range expectedUnitCreated {
 		n := foundUnitCreated[unitID]
 		if n == 0 {
 			out.Missing = append(out.Missing, ports.MissingAudit{
-				UnitID: unitID, VersionID: "", EventType: "unit.created",
+				UnitID: unitID, VersionID: "", EventType: domain.AuditUnitCreated,
 			})
 		} else if n > 1 {
 			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{
-				EventType: "unit.created", TargetID: unitID,
+				EventType: domain.AuditUnitCreated, TargetID: unitID,
 			})
 		}
 	}
@@ -185,11 +251,11 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 		n := foundVersionCreated[verID]
 		if n == 0 {
 			out.Missing = append(out.Missing, ports.MissingAudit{
-				UnitID: versionToUnit[verID], VersionID: verID, EventType: "version.created",
+				UnitID: versionToUnit[verID], VersionID: verID, EventType: domain.AuditVersionCreated,
 			})
 		} else if n > 1 {
 			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{
-				EventType: "version.created", TargetID: verID,
+				EventType: domain.AuditVersionCreated, TargetID: verID,
 			})
 		}
 	}
@@ -202,11 +268,11 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 		n := foundMeaningEvent[verID]
 		if n == 0 {
 			out.Missing = append(out.Missing, ports.MissingAudit{
-				UnitID: versionToUnit[verID], VersionID: verID, EventType: "MEANING_SET",
+				UnitID: versionToUnit[verID], VersionID: verID, EventType: domain.AuditMeaningSet,
 			})
 		} else if n > 1 {
 			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{
-				EventType: "MEANING_SET", TargetID: verID,
+				EventType: domain.AuditMeaningSet, TargetID: verID,
 			})
 		}
 		// check event-level meaning_hash matches recorded version meaning_hash
@@ -245,10 +311,10 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 		n := foundClaimEvent[verID]
 		if n == 0 {
 			out.Missing = append(out.Missing, ports.MissingAudit{
-				UnitID: versionToUnit[verID], VersionID: verID, EventType: "CLAIM_SET",
+				UnitID: versionToUnit[verID], VersionID: verID, EventType: domain.AuditClaimSet,
 			})
 		} else if n > 1 {
-			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{EventType: "CLAIM_SET", TargetID: verID})
+			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{EventType: domain.AuditClaimSet, TargetID: verID})
 		}
 		eh, ok := foundClaimHash[verID]
 		if !ok || eh == "" || eh != v.ClaimSetHash {
@@ -285,10 +351,10 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 		n := foundUncertaintyEvent[verID]
 		if n == 0 {
 			out.Missing = append(out.Missing, ports.MissingAudit{
-				UnitID: versionToUnit[verID], VersionID: verID, EventType: "UNCERTAINTY_SET",
+				UnitID: versionToUnit[verID], VersionID: verID, EventType: domain.AuditUncertaintySet,
 			})
 		} else if n > 1 {
-			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{EventType: "UNCERTAINTY_SET", TargetID: verID})
+			out.Duplicates = append(out.Duplicates, ports.DuplicateAudit{EventType: domain.AuditUncertaintySet, TargetID: verID})
 		}
 		eh, ok := foundUncertaintyHash[verID]
 		if !ok || eh == "" || eh != v.UncertaintyHash {
@@ -334,6 +400,61 @@ func (uc VerifyAudit) VerifyAudit(in ports.VerifyAuditRequest) (ports.VerifyAudi
 		}
 	}
 
-	out.Ok = len(out.Missing) == 0 && len(out.Duplicates) == 0 && len(out.HashMismatches) == 0
+	lr, err := verifyAuditLineage(uc.Audit, uc.Repo, units, versionsByUnit, in.UnitKey != "")
+	if err != nil {
+		return ports.VerifyAuditResponse{}, err
+	}
+	out.Missing = append(out.Missing, lr.missing...)
+	out.OrphanEvents = lr.orphans
+	out.LineageMismatches = lr.lineage
+	out.HeadMismatches = lr.heads
+	out.TimeOrderViolations = lr.timeOrder
+	out.TagMismatches = lr.tags
+	out.RevisionMismatches = lr.revisions
+
+	if in.Chain {
+		cr, err := verifyAuditChain(uc.Audit, uc.ChainHead)
+		if err != nil {
+			return ports.VerifyAuditResponse{}, err
+		}
+		out.ChainChecked = true
+		out.ChainLength = cr.length
+		out.LegacyEvents = cr.legacy
+		out.ChainBreaks = cr.breaks
+		if out.ChainBreaks == nil {
+			out.ChainBreaks = []ports.ChainBreak{}
+		}
+	}
+
+	if in.Signatures {
+		if uc.Keys == nil {
+			return ports.VerifyAuditResponse{}, fmt.Errorf("key registry not configured")
+		}
+		signed, findings, err := verifyAuditSignatures(uc.Audit, uc.Keys)
+		if err != nil {
+			return ports.VerifyAuditResponse{}, err
+		}
+		out.SignaturesChecked = true
+		out.SignedEvents = signed
+		out.SignatureFindings = findings
+	}
+
+	out.Ok = len(out.Missing) == 0 && len(out.Duplicates) == 0 && len(out.HashMismatches) == 0 &&
+		len(out.OrphanEvents) == 0 && len(out.LineageMismatches) == 0 && len(out.HeadMismatches) == 0 && len(out.TagMismatches) == 0 &&
+		len(out.RevisionMismatches) == 0 &&
+		len(out.TimeOrderViolations) == 0 && len(out.ChainBreaks) == 0 && len(out.SignatureFindings) == 0 &&
+		len(out.PayloadIssues) == 0 && len(out.SegmentFindings) == 0
 	return out, nil
 }
+
+// payloadIssue turns a domain.DecodeAuditPayload error into a finding.
+func payloadIssue(pos int64, ev domain.AuditEvent, err error) ports.PayloadIssue {
+	kind := ports.PayloadMalformed
+	if errors.Is(err, domain.ErrUnknownAuditEventType) {
+		kind = ports.PayloadUnknownType
+	}
+	return ports.PayloadIssue{
+		Kind: kind, Position: pos, EventID: ev.ID, EventType: ev.Type,
+		UnitID: ev.UnitID, VersionID: ev.VersionID, Detail: err.Error(),
+	}
+}
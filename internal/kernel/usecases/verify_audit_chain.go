@@ -0,0 +1,157 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+type chainResult struct {
+	length int64
+	legacy int64
+	breaks []ports.ChainBreak
+}
+
+// verifyAuditChain walks the log in append order and reports every place
+// where the hash chain breaks. Unchained events before the first chained
+// event are legacy events; the genesis event must account for them.
+func verifyAuditChain(r ports.AuditLogReader, headReader ports.AuditChainHeadReader) (chainResult, error) {
+	var (
+		res        chainResult
+		pos        int64
+		started    bool
+		prev       domain.AuditChainHead
+		legacyHash string
+		seen       = map[int64]bool{}
+		gaps       []ports.ChainBreak
+		gapMissing [][2]int64 // [from,to] per gap, same index as gaps
+	)
+
+	if err := r.Scan(func(ev domain.AuditEvent) error {
+		pos++
+		if !ev.IsChained() {
+			if !started {
+				res.legacy++
+				h, err := domain.FoldLegacyHash(legacyHash, ev)
+				if err != nil {
+					return err
+				}
+				legacyHash = h
+				return nil
+			}
+			res.breaks = append(res.breaks, ports.ChainBreak{
+				Kind: ports.ChainBreakUnchained, Position: pos, EventID: ev.ID,
+				Detail: "event without seq/hash after chain start",
+			})
+			return nil
+		}
+
+		res.length++
+		seen[ev.Seq] = true
+
+		if h, err := domain.ComputeAuditEventHash(ev); err != nil || h != ev.Hash {
+			res.breaks = append(res.breaks, ports.ChainBreak{
+				Kind: ports.ChainBreakEdited, Position: pos, Seq: ev.Seq, EventID: ev.ID,
+				Detail: fmt.Sprintf("content hash %s does not match stored hash %s", h, ev.Hash),
+			})
+		}
+
+		if !started {
+			started = true
+			if ev.Seq != 1 || ev.PrevHash != "" {
+				res.breaks = append(res.breaks, ports.ChainBreak{
+					Kind: ports.ChainBreakTruncated, Position: pos, Seq: ev.Seq, EventID: ev.ID,
+					Detail: "chain does not start at seq 1",
+				})
+			}
+			if res.legacy > 0 || ev.Type == domain.AuditChainGenesisType {
+				if d := checkGenesis(ev, res.legacy, legacyHash); d != "" {
+					res.breaks = append(res.breaks, ports.ChainBreak{
+						Kind: ports.ChainBreakEdited, Position: pos, Seq: ev.Seq, EventID: ev.ID, Detail: d,
+					})
+				}
+			}
+			prev = domain.AuditChainHead{Seq: ev.Seq, Hash: ev.Hash, EventID: ev.ID}
+			return nil
+		}
+
+		switch {
+		case ev.Seq <= prev.Seq:
+			res.breaks = append(res.breaks, ports.ChainBreak{
+				Kind: ports.ChainBreakReorder, Position: pos, Seq: ev.Seq, EventID: ev.ID,
+				Detail: fmt.Sprintf("seq %d after seq %d", ev.Seq, prev.Seq),
+			})
+			// keep prev: the following event should still link to the highest seq
+			return nil
+		case ev.Seq > prev.Seq+1:
+			gaps = append(gaps, ports.ChainBreak{
+				Kind: ports.ChainBreakGap, Position: pos, Seq: ev.Seq, EventID: ev.ID,
+				Detail: fmt.Sprintf("missing seq %d..%d", prev.Seq+1, ev.Seq-1),
+			})
+			gapMissing = append(gapMissing, [2]int64{prev.Seq + 1, ev.Seq - 1})
+		case ev.PrevHash != prev.Hash:
+			res.breaks = append(res.breaks, ports.ChainBreak{
+				Kind: ports.ChainBreakEdited, Position: pos, Seq: ev.Seq, EventID: ev.ID,
+				Detail: fmt.Sprintf("prevHash %s does not match hash of seq %d", ev.PrevHash, prev.Seq),
+			})
+		}
+		prev = domain.AuditChainHead{Seq: ev.Seq, Hash: ev.Hash, EventID: ev.ID}
+		return nil
+	}); err != nil {
+		return chainResult{}, err
+	}
+
+	// A gap whose missing events all show up later is a reorder, already reported.
+	for i, g := range gaps {
+		all := true
+		for s := gapMissing[i][0]; s <= gapMissing[i][1]; s++ {
+			if !seen[s] {
+				all = false
+				break
+			}
+		}
+		if !all {
+			res.breaks = append(res.breaks, g)
+		}
+	}
+
+	if headReader != nil {
+		h, ok, err := headReader.ReadChainHead()
+		if err != nil {
+			return chainResult{}, err
+		}
+		if ok {
+			switch {
+			case h.Seq > prev.Seq:
+				res.breaks = append(res.breaks, ports.ChainBreak{
+					Kind: ports.ChainBreakTruncated, Seq: h.Seq, EventID: h.EventID,
+					Detail: fmt.Sprintf("log ends at seq %d but head records seq %d", prev.Seq, h.Seq),
+				})
+			case h.Seq == prev.Seq && h.Hash != prev.Hash:
+				res.breaks = append(res.breaks, ports.ChainBreak{
+					Kind: ports.ChainBreakEdited, Seq: h.Seq, EventID: prev.EventID,
+					Detail: "last event hash does not match recorded head",
+				})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// checkGenesis returns "" if ev is a genesis event matching the legacy prefix.
+func checkGenesis(ev domain.AuditEvent, legacy int64, legacyHash string) string {
+	if ev.Type != domain.AuditChainGenesisType {
+		return fmt.Sprintf("%d legacy events but chain starts without genesis", legacy)
+	}
+	p, _ := domain.DecodeAuditPayload(ev)
+	g, ok := p.(domain.AuditChainGenesisData)
+	if !ok {
+		return "genesis payload unreadable"
+	}
+	if g.LegacyEvents != legacy || g.LegacyHash != legacyHash {
+		return fmt.Sprintf("genesis records %d legacy events (%s), log has %d (%s)", g.LegacyEvents, g.LegacyHash, legacy, legacyHash)
+	}
+	return ""
+}
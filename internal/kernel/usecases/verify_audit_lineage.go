@@ -0,0 +1,352 @@
+package usecases
+
+import (
+	"sort"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// lineageResult is the outcome of verifyAuditLineage.
+type lineageResult struct {
+	orphans   []ports.OrphanEvent
+	lineage   []ports.LineageMismatch
+	heads     []ports.HeadMismatch
+	timeOrder []ports.TimeOrderViolation
+	missing   []ports.MissingAudit // v0.6: branch.created / branch.deleted / TAG_CREATED / unit.updated
+	tags      []ports.TagMismatch
+	revisions []ports.UnitRevisionMismatch
+}
+
+// v0.6: event types that describe repo state and are checked against it.
+var stateEventTypes = map[string]bool{
+	domain.AuditUnitCreated:      true,
+	domain.AuditUnitUpdated:      true,
+	domain.AuditVersionCreated:   true,
+	domain.AuditVersionReverted:  true,
+	domain.AuditVersionMerged:    true,
+	domain.AuditBranchCreated:    true,
+	domain.AuditBranchDeleted:    true,
+	domain.AuditTagCreated:       true,
+	domain.AuditMeaningSet:       true,
+	domain.AuditClaimSet:         true,
+	domain.AuditClaimRelationSet: true,
+	domain.AuditUncertaintySet:   true,
+}
+
+// verifyAuditLineage checks the events against the repo beyond their mere
+// presence: events about unknown units, versions or claim relations
+// (orphans), version.created, version.reverted and version.merged events
+// whose parents differ from the stored version, units whose head is not
+// their last main version, branch heads with a successor on their branch,
+// branches whose last branch.created / branch.deleted event disagrees with
+// the repo, tags without their TAG_CREATED event or with one naming another
+// version, metadata revisions without their unit.updated event or with
+// events recording other metadata, and events dated earlier than the
+// preceding event of the same unit.
+//
+// versions holds each unit's versions in repo order. If scoped is set (only
+// some units are verified), events about other units are ignored instead of
+// being reported as orphans.
+func verifyAuditLineage(audit ports.AuditLogReader, repo ports.UnitRepository, units []domain.Unit, versions map[string][]domain.Version, scoped bool) (lineageResult, error) {
+	out := lineageResult{
+		orphans:   []ports.OrphanEvent{},
+		lineage:   []ports.LineageMismatch{},
+		heads:     []ports.HeadMismatch{},
+		timeOrder: []ports.TimeOrderViolation{},
+		missing:   []ports.MissingAudit{},
+		tags:      []ports.TagMismatch{},
+		revisions: []ports.UnitRevisionMismatch{},
+	}
+
+	knownUnits := make(map[string]bool, len(units))
+	owner := map[string]string{}        // versionID -> unitID
+	byID := map[string]domain.Version{} // versionID -> version
+	for _, u := range units {
+		knownUnits[u.ID] = true
+		for _, v := range versions[u.ID] {
+			owner[v.ID] = u.ID
+			byID[v.ID] = v
+		}
+	}
+
+	type lastEvent struct {
+		id     string
+		atUnix int64
+	}
+	last := map[string]lastEvent{}             // unitID -> preceding event
+	lineageSeen := map[string]bool{}           // versionID -> version.created checked
+	claimSets := map[string]*domain.ClaimSet{} // versionID -> stored claimset (nil if none)
+	branchLive := map[[2]string]bool{}         // (unitID, branch) -> last event was branch.created
+	type tagEvent struct{ id, versionID string }
+	tagEvents := map[[2]string][]tagEvent{} // (unitID, tag) -> TAG_CREATED events
+	type revisionEvent struct {
+		id       string
+		revision int
+		meta     domain.UnitMetadata
+		hasDesc  bool // unit.created carries no description
+	}
+	revisionEvents := map[string][]revisionEvent{} // unitID -> unit.created / unit.updated events
+
+	var pos int64
+	err := audit.Scan(func(ev domain.AuditEvent) error {
+		pos++
+		typ := domain.CanonicalAuditEventType(ev.Type)
+		if !stateEventTypes[typ] {
+			return nil
+		}
+		orphan := func(detail string) {
+			out.orphans = append(out.orphans, ports.OrphanEvent{
+				Position: pos, EventID: ev.ID, EventType: ev.Type,
+				UnitID: ev.UnitID, VersionID: ev.VersionID, Detail: detail,
+			})
+		}
+
+		unitID := ev.UnitID
+		if unitID == "" {
+			unitID = owner[ev.VersionID]
+		}
+		if unitID != "" && !knownUnits[unitID] {
+			if !scoped {
+				orphan("unit not found")
+			}
+			return nil
+		}
+		if ev.VersionID != "" {
+			vOwner, ok := owner[ev.VersionID]
+			switch {
+			case !ok && unitID == "":
+				if !scoped {
+					orphan("version not found")
+				}
+				return nil
+			case !ok:
+				orphan("version not found")
+				return nil
+			case vOwner != unitID:
+				orphan("version belongs to unit " + vOwner)
+				return nil
+			}
+		}
+		if unitID == "" {
+			return nil // nothing to relate the event to
+		}
+
+		switch typ {
+		case domain.AuditVersionCreated, domain.AuditVersionReverted, domain.AuditVersionMerged:
+			if prev, mergeParent, ok := versionCreatedParents(ev); ok && !lineageSeen[ev.VersionID] {
+				lineageSeen[ev.VersionID] = true
+				if v := byID[ev.VersionID]; prev != v.PrevVersionID || mergeParent != v.MergeParentID {
+					out.lineage = append(out.lineage, ports.LineageMismatch{
+						UnitID: unitID, VersionID: v.ID,
+						RepoPrevVersionID: v.PrevVersionID, EventPrevVersionID: prev,
+						RepoMergeParentID: v.MergeParentID, EventMergeParentID: mergeParent,
+					})
+				}
+			}
+		case domain.AuditBranchCreated, domain.AuditBranchDeleted:
+			if name, ok := branchOf(ev); ok {
+				branchLive[[2]string{unitID, name}] = typ == domain.AuditBranchCreated
+			}
+		case domain.AuditUnitCreated:
+			if d, ok := payloadOf[domain.UnitCreatedData](ev); ok && d.Title != "" {
+				// events before v0.6 carry no description; an empty one is
+				// indistinguishable from those and not compared
+				revisionEvents[unitID] = append(revisionEvents[unitID], revisionEvent{id: ev.ID, revision: 1, meta: domain.UnitMetadata{Title: d.Title, Description: d.Description}, hasDesc: d.Description != ""})
+			}
+		case domain.AuditUnitUpdated:
+			if d, ok := payloadOf[domain.UnitUpdatedData](ev); ok {
+				revisionEvents[unitID] = append(revisionEvents[unitID], revisionEvent{id: ev.ID, revision: d.Revision, meta: d.After, hasDesc: true})
+			}
+		case domain.AuditTagCreated:
+			if d, ok := payloadOf[domain.TagCreatedData](ev); ok && d.Tag != "" {
+				k := [2]string{unitID, d.Tag}
+				tagEvents[k] = append(tagEvents[k], tagEvent{id: ev.ID, versionID: ev.VersionID})
+			}
+		case domain.AuditClaimRelationSet:
+			if ev.VersionID == "" {
+				orphan("relation names no version")
+				return nil
+			}
+			cs, ok := claimSets[ev.VersionID]
+			if !ok {
+				loaded, found, err := repo.LoadClaimSet(unitID, ev.VersionID)
+				if err != nil {
+					return err
+				}
+				if found {
+					cs = &loaded
+				}
+				claimSets[ev.VersionID] = cs
+			}
+			if cs == nil {
+				orphan("no claimset stored for version")
+				return nil
+			}
+			if rel, ok := claimRelationOf(ev); !ok || !hasRelation(*cs, rel) {
+				orphan("relation not in stored claimset")
+				return nil
+			}
+		}
+
+		if prev, ok := last[unitID]; ok && ev.AtUnix < prev.atUnix {
+			out.timeOrder = append(out.timeOrder, ports.TimeOrderViolation{
+				Position: pos, EventID: ev.ID, EventType: ev.Type, UnitID: unitID,
+				AtUnix: ev.AtUnix, PrevEventID: prev.id, PrevAtUnix: prev.atUnix,
+			})
+		}
+		last[unitID] = lastEvent{id: ev.ID, atUnix: ev.AtUnix}
+		return nil
+	})
+	if err != nil {
+		return lineageResult{}, err
+	}
+
+	for _, u := range units {
+		lastID := ""
+		for _, v := range versions[u.ID] {
+			if v.Branch == "" {
+				lastID = v.ID
+			}
+		}
+		if u.HeadVersionID != lastID {
+			out.heads = append(out.heads, ports.HeadMismatch{UnitID: u.ID, HeadVersionID: u.HeadVersionID, LastVersionID: lastID})
+		}
+
+		// v0.6: a branch head is its base or a version of the branch, and
+		// no version of the branch builds on it
+		for _, b := range u.Branches {
+			if _, ok := byID[b.HeadVersionID]; !ok || owner[b.HeadVersionID] != u.ID {
+				out.heads = append(out.heads, ports.HeadMismatch{UnitID: u.ID, HeadVersionID: b.HeadVersionID, Branch: b.Name})
+				continue
+			}
+			for _, v := range versions[u.ID] {
+				if v.Branch == b.Name && v.PrevVersionID == b.HeadVersionID {
+					out.heads = append(out.heads, ports.HeadMismatch{UnitID: u.ID, HeadVersionID: b.HeadVersionID, LastVersionID: v.ID, Branch: b.Name})
+					break
+				}
+			}
+		}
+
+		// v0.6: branches in the repo were created last, branches gone from
+		// it were deleted last
+		stored := map[string]bool{}
+		for _, b := range u.Branches {
+			stored[b.Name] = true
+			if !branchLive[[2]string{u.ID, b.Name}] {
+				out.missing = append(out.missing, ports.MissingAudit{UnitID: u.ID, EventType: domain.AuditBranchCreated, Branch: b.Name})
+			}
+		}
+		var gone []string
+		for k, live := range branchLive {
+			if k[0] == u.ID && live && !stored[k[1]] {
+				gone = append(gone, k[1])
+			}
+		}
+		sort.Strings(gone)
+		for _, name := range gone {
+			out.missing = append(out.missing, ports.MissingAudit{UnitID: u.ID, EventType: domain.AuditBranchDeleted, Branch: name})
+		}
+
+		// v0.6: every tag has its TAG_CREATED event, and every TAG_CREATED
+		// event names the version the tag still points to
+		tagged := map[string]string{}
+		for _, t := range u.Tags {
+			tagged[t.Name] = t.VersionID
+			if len(tagEvents[[2]string{u.ID, t.Name}]) == 0 {
+				out.missing = append(out.missing, ports.MissingAudit{UnitID: u.ID, VersionID: t.VersionID, EventType: domain.AuditTagCreated, Tag: t.Name})
+			}
+		}
+		var names []string
+		for k := range tagEvents {
+			if k[0] == u.ID {
+				names = append(names, k[1])
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, te := range tagEvents[[2]string{u.ID, name}] {
+				if te.versionID != tagged[name] {
+					out.tags = append(out.tags, ports.TagMismatch{UnitID: u.ID, Tag: name, RepoVersionID: tagged[name], EventVersionID: te.versionID, EventID: te.id})
+				}
+			}
+		}
+
+		// v0.6: every metadata revision past the first has its unit.updated
+		// event, and every unit.created / unit.updated event records the
+		// metadata the repo holds for its revision
+		recorded := map[int]bool{}
+		for _, re := range revisionEvents[u.ID] {
+			recorded[re.revision] = true
+			r, ok := u.FindRevision(re.revision)
+			if ok && r.Title == re.meta.Title && (!re.hasDesc || r.Description == re.meta.Description) {
+				continue
+			}
+			m := ports.UnitRevisionMismatch{UnitID: u.ID, Revision: re.revision, RepoTitle: r.Title, EventTitle: re.meta.Title, EventID: re.id}
+			if re.hasDesc {
+				m.RepoDescription, m.EventDescription = r.Description, re.meta.Description
+			}
+			out.revisions = append(out.revisions, m)
+		}
+		for _, r := range u.History() {
+			if r.Revision > 1 && !recorded[r.Revision] {
+				out.missing = append(out.missing, ports.MissingAudit{UnitID: u.ID, EventType: domain.AuditUnitUpdated, Revision: r.Revision})
+			}
+		}
+	}
+	return out, nil
+}
+
+// versionCreatedParents returns the prevVersionId (and mergeParentId) of a
+// version.created, version.reverted or version.merged event; ok is false if
+// the event carries no payload to compare.
+func versionCreatedParents(ev domain.AuditEvent) (prev, mergeParent string, ok bool) {
+	p, _ := domain.DecodeAuditPayload(ev)
+	switch d := p.(type) {
+	case domain.VersionCreatedData:
+		return d.PrevVersionID, "", true
+	case domain.VersionRevertedData:
+		return d.PrevVersionID, "", true
+	case domain.VersionMergedData:
+		return d.PrevVersionID, d.MergeParentID, true
+	}
+	return "", "", false
+}
+
+// payloadOf returns the payload of ev if it decodes as T.
+func payloadOf[T any](ev domain.AuditEvent) (T, bool) {
+	p, _ := domain.DecodeAuditPayload(ev)
+	d, ok := p.(T)
+	return d, ok
+}
+
+// branchOf returns the branch named by a branch.created or branch.deleted
+// event.
+func branchOf(ev domain.AuditEvent) (string, bool) {
+	p, _ := domain.DecodeAuditPayload(ev)
+	switch d := p.(type) {
+	case domain.BranchCreatedData:
+		return d.Branch, d.Branch != ""
+	case domain.BranchDeletedData:
+		return d.Branch, d.Branch != ""
+	}
+	return "", false
+}
+
+func claimRelationOf(ev domain.AuditEvent) (domain.ClaimRelation, bool) {
+	p, _ := domain.DecodeAuditPayload(ev)
+	d, ok := p.(domain.ClaimRelationSetData)
+	if !ok {
+		return domain.ClaimRelation{}, false
+	}
+	return domain.ClaimRelation{Type: domain.RelationType(d.Type), FromClaimID: d.FromClaimID, ToClaimID: d.ToClaimID}, true
+}
+
+func hasRelation(cs domain.ClaimSet, rel domain.ClaimRelation) bool {
+	for _, r := range cs.Relations {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package usecases
+
+import (
+	"fmt"
+
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// VerifierVersion is reported in every verification report; bump it when
+// the checks or the report format change.
+const VerifierVersion = "0.6.0"
+
+// VerifyAuditReport runs VerifyAudit and flattens the response into a
+// ports.VerifyAuditReport with one severity-tagged finding per entry.
+type VerifyAuditReport struct {
+	Verify ports.VerifyAuditUsecase
+
+	// optional
+	Fingerprint ports.DataDirFingerprinter
+	Clock       ports.Clock
+	TenantID    string // v0.6: recorded in the report
+}
+
+func (uc VerifyAuditReport) VerifyAuditReport(in ports.VerifyAuditRequest) (ports.VerifyAuditReport, error) {
+	if uc.Verify == nil {
+		return ports.VerifyAuditReport{}, fmt.Errorf("verifier not configured")
+	}
+
+	// fingerprint first: it names the input, even if writes race the check
+	fp := ""
+	if uc.Fingerprint != nil {
+		var err error
+		if fp, err = uc.Fingerprint.Fingerprint(); err != nil {
+			return ports.VerifyAuditReport{}, err
+		}
+	}
+	res, err := uc.Verify.VerifyAudit(in)
+	if err != nil {
+		return ports.VerifyAuditReport{}, err
+	}
+
+	out := BuildVerifyAuditReport(in, res)
+	out.Fingerprint = fp
+	out.TenantID = uc.TenantID
+	if uc.Clock != nil {
+		out.GeneratedAtUnix = uc.Clock.NowUnix()
+	}
+	return out, nil
+}
+
+// BuildVerifyAuditReport converts a VerifyAuditResponse. Findings keep the
+// order of the response lists.
+func BuildVerifyAuditReport(in ports.VerifyAuditRequest, res ports.VerifyAuditResponse) ports.VerifyAuditReport {
+	out := ports.VerifyAuditReport{
+		Schema:          ports.VerifyAuditReportSchema,
+		VerifierVersion: VerifierVersion,
+		Request: ports.VerifyAuditReportRequest{
+			UnitKey:    in.UnitKey,
+			StrictHash: in.StrictHash,
+			Chain:      in.Chain,
+			Signatures: in.Signatures,
+			Payloads:   in.Payloads,
+		},
+		Ok: res.Ok,
+		Summary: ports.VerifyAuditSummary{
+			Units:             res.TotalUnits,
+			Versions:          res.TotalVersions,
+			ChainChecked:      res.ChainChecked,
+			ChainLength:       res.ChainLength,
+			LegacyEvents:      res.LegacyEvents,
+			SignaturesChecked: res.SignaturesChecked,
+			SignedEvents:      res.SignedEvents,
+			PayloadsChecked:   res.PayloadsChecked,
+			SegmentsChecked:   res.SegmentsChecked,
+			SealedSegments:    res.SealedSegments,
+		},
+		Findings: []ports.VerifyFinding{},
+	}
+	add := func(f ports.VerifyFinding) {
+		if f.Severity == ports.SeverityWarning {
+			out.Summary.Warnings++
+		} else {
+			out.Summary.Errors++
+		}
+		out.Findings = append(out.Findings, f)
+	}
+
+	for _, m := range res.Missing {
+		f := ports.VerifyFinding{Category: ports.FindingMissing, Kind: m.EventType, Severity: ports.SeverityError, UnitID: m.UnitID, VersionID: m.VersionID}
+		if m.Branch != "" {
+			f.Detail = "branch " + m.Branch
+		}
+		if m.Tag != "" {
+			f.Detail = "tag " + m.Tag
+		}
+		if m.Revision != 0 {
+			f.Detail = fmt.Sprintf("revision %d", m.Revision)
+		}
+		add(f)
+	}
+	for _, d := range res.Duplicates {
+		f := ports.VerifyFinding{Category: ports.FindingDuplicate, Kind: d.EventType, Severity: ports.SeverityError}
+		if d.EventType == domain.AuditUnitCreated {
+			f.UnitID = d.TargetID
+		} else {
+			f.VersionID = d.TargetID
+		}
+		add(f)
+	}
+	for _, h := range res.HashMismatches {
+		add(ports.VerifyFinding{Category: ports.FindingHash, Severity: ports.SeverityError, UnitID: h.UnitID, VersionID: h.VersionID, Expected: h.ExpectedHash, Actual: h.EventHash})
+	}
+	for _, o := range res.OrphanEvents {
+		add(ports.VerifyFinding{Category: ports.FindingOrphan, Kind: o.EventType, Severity: ports.SeverityError, UnitID: o.UnitID, VersionID: o.VersionID, EventID: o.EventID, Position: o.Position, Detail: o.Detail})
+	}
+	for _, l := range res.LineageMismatches {
+		f := ports.VerifyFinding{Category: ports.FindingLineage, Severity: ports.SeverityError, UnitID: l.UnitID, VersionID: l.VersionID, Expected: l.RepoPrevVersionID, Actual: l.EventPrevVersionID}
+		if l.RepoMergeParentID != l.EventMergeParentID {
+			f.Detail = fmt.Sprintf("merge parent: repo %q, event %q", l.RepoMergeParentID, l.EventMergeParentID)
+		}
+		add(f)
+	}
+	for _, h := range res.HeadMismatches {
+		f := ports.VerifyFinding{Category: ports.FindingHead, Severity: ports.SeverityError, UnitID: h.UnitID, Expected: h.LastVersionID, Actual: h.HeadVersionID}
+		if h.Branch != "" {
+			f.Detail = "branch " + h.Branch
+		}
+		add(f)
+	}
+	for _, t := range res.TagMismatches {
+		add(ports.VerifyFinding{Category: ports.FindingTag, Severity: ports.SeverityError, UnitID: t.UnitID, EventID: t.EventID, Expected: t.RepoVersionID, Actual: t.EventVersionID, Detail: "tag " + t.Tag})
+	}
+	for _, r := range res.RevisionMismatches {
+		f := ports.VerifyFinding{Category: ports.FindingRevision, Severity: ports.SeverityError, UnitID: r.UnitID, EventID: r.EventID, Expected: r.RepoTitle, Actual: r.EventTitle, Detail: fmt.Sprintf("revision %d", r.Revision)}
+		if r.RepoDescription != r.EventDescription {
+			f.Detail += fmt.Sprintf(": description repo %q, event %q", r.RepoDescription, r.EventDescription)
+		}
+		add(f)
+	}
+	for _, t := range res.TimeOrderViolations {
+		// clock skew between writers produces these as well
+		add(ports.VerifyFinding{
+			Category: ports.FindingTimeOrder, Kind: t.EventType, Severity: ports.SeverityWarning, UnitID: t.UnitID, EventID: t.EventID, Position: t.Position,
+			Detail: fmt.Sprintf("at %d, preceding event %s at %d", t.AtUnix, t.PrevEventID, t.PrevAtUnix),
+		})
+	}
+	for _, c := range res.ChainBreaks {
+		add(ports.VerifyFinding{Category: ports.FindingChain, Kind: c.Kind, Severity: ports.SeverityError, EventID: c.EventID, Position: c.Position, Seq: c.Seq, Detail: c.Detail})
+	}
+	for _, s := range res.SignatureFindings {
+		sev := ports.SeverityError
+		if s.Kind == ports.SignatureUnsigned {
+			sev = ports.SeverityWarning // events written before the actor had a key
+		}
+		add(ports.VerifyFinding{Category: ports.FindingSignature, Kind: s.Kind, Severity: sev, EventID: s.EventID, Position: s.Position, Detail: fmt.Sprintf("type=%s actor=%s keyId=%s", s.EventType, s.ActorID, s.KeyID)})
+	}
+	for _, p := range res.PayloadIssues {
+		sev := ports.SeverityError
+		if p.Kind == ports.PayloadUnknownType {
+			sev = ports.SeverityWarning // e.g. written by a newer version
+		}
+		add(ports.VerifyFinding{Category: ports.FindingPayload, Kind: p.Kind, Severity: sev, UnitID: p.UnitID, VersionID: p.VersionID, EventID: p.EventID, Position: p.Position, Detail: p.Detail})
+	}
+	for _, s := range res.SegmentFindings {
+		add(ports.VerifyFinding{Category: ports.FindingSegment, Kind: s.Kind, Severity: ports.SeverityError, Detail: fmt.Sprintf("%s: %s", s.File, s.Detail)})
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"digiemu-core/internal/kernel/domain"
+	"digiemu-core/internal/kernel/ports"
+)
+
+// verifyAuditSignatures checks every event signature against the registry.
+// The chain genesis is written by the log itself and is exempt.
+func verifyAuditSignatures(r ports.AuditLogReader, keys ports.ActorKeyRegistry) (int64, []ports.SignatureFinding, error) {
+	var (
+		pos      int64
+		signed   int64
+		findings = []ports.SignatureFinding{}
+		cache    = map[string]*domain.ActorKey{}
+	)
+
+	err := r.Scan(func(ev domain.AuditEvent) error {
+		pos++
+		if ev.Type == domain.AuditChainGenesisType {
+			return nil
+		}
+		f := ports.SignatureFinding{
+			Position: pos, EventID: ev.ID, EventType: ev.Type, ActorID: ev.ActorID, KeyID: ev.KeyID,
+		}
+		if ev.Sig == "" || ev.KeyID == "" {
+			f.Kind = ports.SignatureUnsigned
+			findings = append(findings, f)
+			return nil
+		}
+
+		k, seen := cache[ev.KeyID]
+		if !seen {
+			found, ok, err := keys.FindKey(ev.KeyID)
+			if err != nil {
+				return err
+			}
+			if ok {
+				k = &found
+			}
+			cache[ev.KeyID] = k
+		}
+		if k == nil {
+			f.Kind = ports.SignatureUnknownKey
+			findings = append(findings, f)
+			return nil
+		}
+
+		pub, err := domain.DecodePublicKey(k.PublicKey)
+		if err != nil || !domain.VerifyAuditEventSignature(ev, pub) {
+			f.Kind = ports.SignatureBad
+			findings = append(findings, f)
+			return nil
+		}
+		if k.ActorID != ev.ActorID {
+			f.Kind = ports.SignatureActorMismatch
+			findings = append(findings, f)
+			return nil
+		}
+		if k.RevokedAt(ev.AtUnix) {
+			f.Kind = ports.SignatureRevokedKey
+			findings = append(findings, f)
+			return nil
+		}
+		signed++
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return signed, findings, nil
+}
@@ -0,0 +1,199 @@
+// Package server wires the HTTP API to a data dir. `digiemu serve` and
+// cmd/api both run it, so they serve the same routes with the same
+// tenants, policy, journal and audit log.
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"digiemu-core/internal/httpapi"
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+	mem "digiemu-core/internal/kernel/adapters/memory"
+	"digiemu-core/internal/kernel/ports"
+	"digiemu-core/internal/kernel/usecases"
+)
+
+// Options configure New.
+type Options struct {
+	Addr string
+	Data string
+
+	// optional; keys file with API keys and HS256 secrets. Without one only
+	// EdDSA JWTs of the actor key registry authenticate writes.
+	AuthFile string
+
+	// optional; accept only JWTs with this iss / aud
+	JWTIssuer   string
+	JWTAudience string
+
+	// optional; key file (`digiemu key generate --out`) whose actor's events
+	// are signed, e.g. the "system" actor of recoveries and tree heads
+	SigningKey string
+}
+
+// New returns the HTTP server for o. Writes need an API key or a JWT
+// (HS256 with a secret of the auth file, or EdDSA with a key of the actor
+// key registry); reads of the default tenant stay open.
+func New(o Options) (*http.Server, error) {
+	keys := httpapi.AuthFile{Schema: httpapi.AuthFileSchema}
+	if o.AuthFile != "" {
+		var err error
+		if keys, err = httpapi.LoadAuthFile(o.AuthFile); err != nil {
+			return nil, err
+		}
+	}
+	var signing *fsrepo.KeyFile
+	if o.SigningKey != "" {
+		kf, err := fsrepo.ReadKeyFile(o.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+		signing = &kf
+	}
+	auth, err := keys.Authenticator(httpapi.JWTAuth{Keys: fsrepo.NewKeyRegistry(o.Data), Issuer: o.JWTIssuer, Audience: o.JWTAudience, Clock: mem.RealClock{}})
+	if err != nil {
+		return nil, err
+	}
+
+	// one API per tenant, selected by the X-Digiemu-Tenant header or the
+	// caller's credentials
+	return &http.Server{
+		Addr:         o.Addr,
+		Handler:      httpapi.NewTenantRouter(auth, TenantAPIs(o.Data, signing)),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}, nil
+}
+
+// TenantAPIs opens the API of each tenant once; tenants share nothing, each
+// has its own repo, audit log and journal. A failed open is not kept, so
+// the next request of the tenant tries again. signing is optional (see
+// OpenAuditLog).
+func TenantAPIs(data string, signing *fsrepo.KeyFile) httpapi.TenantAPIs {
+	var (
+		mu   sync.Mutex
+		apis = map[string]httpapi.API{}
+	)
+	return func(tenant string) (httpapi.API, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if api, ok := apis[tenant]; ok {
+			return api, nil
+		}
+		p, err := fsrepo.OpenTenant(data, tenant)
+		if err != nil {
+			return httpapi.API{}, err
+		}
+		api, err := NewAPI(p, tenant, signing)
+		if err != nil {
+			return httpapi.API{}, err
+		}
+		apis[tenant] = api
+		return api, nil
+	}
+}
+
+// NewAPI wires the HTTP API to the data dir of a tenant. It fails if the
+// tenant's unfinished operations cannot be recovered; the server then
+// answers that tenant's requests with an error and keeps serving the others.
+func NewAPI(data, tenant string, signing *fsrepo.KeyFile) (httpapi.API, error) {
+	repo := fsrepo.NewUnitRepo(data)
+	// one shared log: appends are serialized to keep the hash chain linear
+	audit := OpenAuditLog(data, signing)
+	journal, err := RecoverJournal(data, repo, audit)
+	if err != nil {
+		return httpapi.API{}, fmt.Errorf("tenant %s: %w", tenant, err)
+	}
+
+	// writes pass the role check of <data>/policy.json first
+	guard := PolicyGuard(data, repo, audit)
+	guard.Units = usecases.CreateUnit{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Vers = usecases.CreateVersion{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Revert = usecases.RevertVersion{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Branches = usecases.CreateBranch{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.DelBranch = usecases.DeleteBranch{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Merge = usecases.MergeBranch{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Tags = usecases.CreateTag{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.UnitUpdate = usecases.UpdateUnit{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Meaning = usecases.SetMeaning{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Claims = usecases.SetClaims{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+	guard.Uncertainty = usecases.SetUncertainty{Repo: repo, Audit: audit, Clock: mem.RealClock{}, Journal: journal}
+
+	return httpapi.API{
+		Units:       guard,
+		Vers:        guard,
+		Revert:      guard,
+		Branches:    guard,
+		DelBranch:   guard,
+		Merge:       guard,
+		Tags:        guard,
+		UnitUpdate:  guard,
+		Meaning:     guard,
+		Claims:      guard,
+		Uncertainty: guard,
+		Repo:        repo,
+		Verify: usecases.VerifyAuditReport{
+			Verify: usecases.VerifyAudit{
+				Repo:      repo,
+				Audit:     fsrepo.NewAuditReader(data),
+				ChainHead: fsrepo.NewAuditChainHead(data),
+				Keys:      fsrepo.NewKeyRegistry(data),
+				Segments:  fsrepo.NewAuditSegmentChecker(data),
+			},
+			Fingerprint: fsrepo.NewDataDirFingerprint(data),
+			Clock:       mem.RealClock{},
+			TenantID:    tenant,
+		},
+		Stream:     usecases.FollowAudit{Audit: fsrepo.NewAuditReader(data)},
+		Query:      usecases.QueryAudit{Audit: fsrepo.NewAuditReader(data), Repo: repo},
+		Diff:       usecases.DiffVersions{Repo: repo},
+		BranchList: usecases.ListBranches{Repo: repo},
+		TagList:    usecases.ListTags{Repo: repo},
+		History:    usecases.ListUnitRevisions{Repo: repo, Audit: fsrepo.NewAuditByUnitReader(data)},
+		TenantID:   tenant,
+	}, nil
+}
+
+// PolicyGuard checks writes against <data>/policy.json; without the file
+// every write is allowed. The caller fills in the usecases it guards.
+func PolicyGuard(data string, repo ports.UnitRepository, audit ports.AuditLog) usecases.PolicyGuard {
+	return usecases.PolicyGuard{Policy: fsrepo.NewPolicyStore(data), Repo: repo, Audit: audit, Clock: mem.RealClock{}}
+}
+
+// RecoverJournal returns the intent journal of the data dir after
+// recovering operations a previous run left unfinished. Recovery actions
+// are audited and reported on stderr.
+func RecoverJournal(data string, repo ports.UnitRepository, audit ports.AuditLog) (ports.IntentJournal, error) {
+	journal := fsrepo.NewIntentJournal(data)
+	uc := usecases.RecoverIntents{
+		Repo:    repo,
+		Audit:   audit,
+		Reader:  fsrepo.NewAuditReader(data),
+		Journal: journal,
+		Clock:   mem.RealClock{},
+	}
+	out, err := uc.RecoverIntents()
+	if err != nil {
+		return nil, fmt.Errorf("recover intents: %w", err)
+	}
+	for _, r := range out.Recovered {
+		fmt.Fprintf(os.Stderr, "RECOVERED: %s intent=%s op=%s eventId=%s (%s)\n", r.Action, r.IntentID, r.Op, r.EventID, r.Reason)
+	}
+	return journal, nil
+}
+
+// OpenAuditLog returns the data dir audit log. With a signing key, the
+// events of its actor are signed while the key is registered in the data
+// dir; private keys never live in the data dir itself.
+func OpenAuditLog(data string, signing *fsrepo.KeyFile) ports.AuditLog {
+	l := usecases.SignedAuditLog{Inner: fsrepo.NewAuditLog(data)}
+	if signing != nil {
+		l.Signer = fsrepo.KeySigner{Key: *signing, Keys: fsrepo.NewKeyRegistry(data)}
+	}
+	return l
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"digiemu-core/internal/httpapi"
+	fsrepo "digiemu-core/internal/kernel/adapters/fs"
+)
+
+func TestNew_ServesTenantsWithAuthAndPolicy(t *testing.T) {
+	dir := t.TempDir()
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	af := `{"schema":"` + httpapi.AuthFileSchema + `","apiKeys":[{"actorId":"alice","keySha256":"` + httpapi.APIKeyHash("alice-key") + `"}]}`
+	if err := os.WriteFile(authFile, []byte(af), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "policy.json"), []byte(`{"schema":"digiemu.policy.v1","grants":[{"actor":"alice","role":"reader","prefix":""}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsrepo.CreateTenant(dir, "acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := New(Options{Data: dir, AuthFile: authFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, key, tenant string, want int) {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(`{"key":"alpha","title":"Alpha"}`))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		if tenant != "" {
+			req.Header.Set(httpapi.TenantHeader, tenant)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Fatalf("%s %s: expected %d, got %d", method, path, want, res.StatusCode)
+		}
+	}
+
+	do("POST", "/v1/units", "", "", http.StatusUnauthorized)
+	do("POST", "/v1/units", "alice-key", "", http.StatusForbidden) // a reader by policy.json
+	do("GET", "/v1/audit/events", "", "", http.StatusOK)
+	do("GET", "/v1/audit/events", "", "acme", http.StatusUnauthorized)
+}
+
+func TestNew_RejectsAnUnreadableSigningKey(t *testing.T) {
+	if _, err := New(Options{Data: t.TempDir(), SigningKey: filepath.Join(t.TempDir(), "missing.key")}); err == nil {
+		t.Fatal("expected an error for a missing signing key file")
+	}
+}